@@ -245,6 +245,20 @@ func (c APIClient) FinishCommit(repoName string, commitID string) error {
 	return grpcutil.ScrubGRPC(err)
 }
 
+// AnnotateCommit adds 'annotations' to a commit's annotations, overwriting
+// the values of any keys that are already set. Annotations can be modified
+// after a commit has been finished.
+func (c APIClient) AnnotateCommit(repoName string, commitID string, annotations map[string]string) error {
+	_, err := c.PfsAPIClient.AnnotateCommit(
+		c.Ctx(),
+		&pfs.AnnotateCommitRequest{
+			Commit:      NewCommit(repoName, commitID),
+			Annotations: annotations,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
 // InspectCommit returns info about a specific Commit.
 func (c APIClient) InspectCommit(repoName string, commitID string) (*pfs.CommitInfo, error) {
 	return c.inspectCommit(repoName, commitID, pfs.CommitState_STARTED)
@@ -301,10 +315,17 @@ func (c APIClient) ListCommit(repoName string, to string, from string, number ui
 // `reverse` lists the commits from oldest to newest, rather than newest to oldest
 // all commits that match the aforementioned criteria are passed to f.
 func (c APIClient) ListCommitF(repoName string, to string, from string, number uint64, reverse bool, f func(*pfs.CommitInfo) error) error {
+	return c.ListCommitByAnnotationsF(repoName, to, from, number, reverse, nil, f)
+}
+
+// ListCommitByAnnotationsF is like ListCommitF, except that only commits that
+// have all of the given annotations (with matching values) are passed to f.
+func (c APIClient) ListCommitByAnnotationsF(repoName string, to string, from string, number uint64, reverse bool, annotations map[string]string, f func(*pfs.CommitInfo) error) error {
 	req := &pfs.ListCommitRequest{
-		Repo:    NewRepo(repoName),
-		Number:  number,
-		Reverse: reverse,
+		Repo:        NewRepo(repoName),
+		Number:      number,
+		Reverse:     reverse,
+		Annotations: annotations,
 	}
 	if from != "" {
 		req.From = NewCommit(repoName, from)
@@ -355,6 +376,25 @@ func (c APIClient) CreateBranch(repoName string, branch string, commit string, p
 	return grpcutil.ScrubGRPC(err)
 }
 
+// CreateBranchTrigger creates a branch that follows the branch named in
+// 'trigger' whenever the trigger fires. 'commit' is the branch's initial head
+// and may be empty.
+func (c APIClient) CreateBranchTrigger(repoName string, branch string, commit string, trigger *pfs.Trigger) error {
+	var head *pfs.Commit
+	if commit != "" {
+		head = NewCommit(repoName, commit)
+	}
+	_, err := c.PfsAPIClient.CreateBranch(
+		c.Ctx(),
+		&pfs.CreateBranchRequest{
+			Branch:  NewBranch(repoName, branch),
+			Head:    head,
+			Trigger: trigger,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
 // InspectBranch returns information on a specific PFS branch
 func (c APIClient) InspectBranch(repoName string, branch string) (*pfs.BranchInfo, error) {
 	branchInfo, err := c.PfsAPIClient.InspectBranch(
@@ -400,6 +440,56 @@ func (c APIClient) DeleteBranch(repoName string, branch string, force bool) erro
 	return grpcutil.ScrubGRPC(err)
 }
 
+// SetBranchRetention sets the retention policy of a branch, which determines
+// which of its commits are deleted when it's pruned. A nil 'retention'
+// removes the branch's policy.
+func (c APIClient) SetBranchRetention(repoName string, branch string, retention *pfs.RetentionPolicy) error {
+	_, err := c.PfsAPIClient.SetBranchRetention(
+		c.Ctx(),
+		&pfs.SetBranchRetentionRequest{
+			Branch:    NewBranch(repoName, branch),
+			Retention: retention,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// PruneBranch deletes the commits on a branch that fall outside of its
+// retention policy and returns them. If dryRun is set, the commits are
+// returned but not deleted.
+func (c APIClient) PruneBranch(repoName string, branch string, dryRun bool) ([]*pfs.CommitInfo, error) {
+	commitInfos, err := c.PfsAPIClient.PruneBranch(
+		c.Ctx(),
+		&pfs.PruneBranchRequest{
+			Branch: NewBranch(repoName, branch),
+			DryRun: dryRun,
+		},
+	)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return commitInfos.CommitInfo, nil
+}
+
+// MergeBranch applies the changes made on branch 'from' since its common
+// ancestor with branch 'into' to 'into', in a new commit. Paths that were
+// changed differently on both branches are resolved according to 'strategy',
+// and are returned in the response's conflicts.
+func (c APIClient) MergeBranch(repoName string, from string, into string, strategy pfs.MergeStrategy) (*pfs.MergeBranchResponse, error) {
+	response, err := c.PfsAPIClient.MergeBranch(
+		c.Ctx(),
+		&pfs.MergeBranchRequest{
+			From:     NewBranch(repoName, from),
+			Into:     NewBranch(repoName, into),
+			Strategy: strategy,
+		},
+	)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return response, nil
+}
+
 // DeleteCommit deletes a commit.
 func (c APIClient) DeleteCommit(repoName string, commitID string) error {
 	_, err := c.PfsAPIClient.DeleteCommit(
@@ -573,7 +663,13 @@ func (c APIClient) SubscribeCommitF(repo, branch string, prov *pfs.CommitProvena
 
 // PutObjectAsync puts a value into the object store asynchronously.
 func (c APIClient) PutObjectAsync(tags []*pfs.Tag) (*PutObjectWriteCloserAsync, error) {
-	w, err := c.newPutObjectWriteCloserAsync(tags)
+	return c.PutObjectAsyncCompressed(tags, pfs.Compression_UNCOMPRESSED)
+}
+
+// PutObjectAsyncCompressed is the same as PutObjectAsync except that the
+// value is compressed with 'compression' before it's stored.
+func (c APIClient) PutObjectAsyncCompressed(tags []*pfs.Tag, compression pfs.Compression) (*PutObjectWriteCloserAsync, error) {
+	w, err := c.newPutObjectWriteCloserAsync(tags, compression)
 	if err != nil {
 		return nil, grpcutil.ScrubGRPC(err)
 	}
@@ -582,8 +678,15 @@ func (c APIClient) PutObjectAsync(tags []*pfs.Tag) (*PutObjectWriteCloserAsync,
 
 // PutObject puts a value into the object store and tags it with 0 or more tags.
 func (c APIClient) PutObject(_r io.Reader, tags ...string) (object *pfs.Object, _ int64, retErr error) {
+	return c.PutObjectCompressed(_r, pfs.Compression_UNCOMPRESSED, tags...)
+}
+
+// PutObjectCompressed is the same as PutObject except that the value is
+// compressed with 'compression' before it's stored. The returned size is the
+// size of the value before it's compressed.
+func (c APIClient) PutObjectCompressed(_r io.Reader, compression pfs.Compression, tags ...string) (object *pfs.Object, _ int64, retErr error) {
 	r := grpcutil.ReaderWrapper{_r}
-	w, err := c.newPutObjectWriteCloser(tags...)
+	w, err := c.newPutObjectWriteCloser(compression, tags...)
 	if err != nil {
 		return nil, 0, grpcutil.ScrubGRPC(err)
 	}
@@ -609,8 +712,14 @@ func (c APIClient) PutObject(_r io.Reader, tags ...string) (object *pfs.Object,
 // into several smaller objects.  This is primarily useful if you'd like to
 // be able to resume upload.
 func (c APIClient) PutObjectSplit(_r io.Reader) (objects []*pfs.Object, _ int64, retErr error) {
+	return c.PutObjectSplitCompressed(_r, pfs.Compression_UNCOMPRESSED)
+}
+
+// PutObjectSplitCompressed is the same as PutObjectSplit except that the
+// objects are compressed with 'compression' before they're stored.
+func (c APIClient) PutObjectSplitCompressed(_r io.Reader, compression pfs.Compression) (objects []*pfs.Object, _ int64, retErr error) {
 	r := grpcutil.ReaderWrapper{_r}
-	w, err := c.newPutObjectSplitWriteCloser()
+	w, err := c.newPutObjectSplitWriteCloser(compression)
 	if err != nil {
 		return nil, 0, grpcutil.ScrubGRPC(err)
 	}
@@ -935,6 +1044,25 @@ type PutFileClient interface {
 	// overwrite the entire file, specify an index of 0.
 	PutFileOverwrite(repoName string, commitID string, path string, reader io.Reader, overwriteIndex int64) (_ int, retErr error)
 
+	// PutFileOverwriteMode is like PutFileOverwrite, except that it also
+	// records mode as the permission bits of the file.
+	PutFileOverwriteMode(repoName string, commitID string, path string, reader io.Reader, overwriteIndex int64, mode uint32) (_ int, retErr error)
+
+	// PutFileVerified is like PutFile (or PutFileOverwrite with an index of 0,
+	// if overwrite is set), except that the write fails if the SHA-256 digest
+	// of the data in reader doesn't match expectedSHA256. If mode isn't 0,
+	// it's recorded as the permission bits of the file.
+	PutFileVerified(repoName string, commitID string, path string, reader io.Reader, overwrite bool, mode uint32, expectedSHA256 []byte) (_ int, retErr error)
+
+	// PutFileMode is like PutFile (or PutFileOverwrite with an index of 0, if
+	// overwrite is set), except that it also records mode as the permission
+	// bits of the file.
+	PutFileMode(repoName string, commitID string, path string, reader io.Reader, overwrite bool, mode uint32) (_ int, retErr error)
+
+	// PutSymlink creates a symlink at path that points to target, replacing
+	// any file that's already there.
+	PutSymlink(repoName string, commitID string, path string, target string) error
+
 	// PutFileSplit writes a file to PFS from a reader.
 	// delimiter is used to tell PFS how to break the input into blocks.
 	PutFileSplit(repoName string, commitID string, path string, delimiter pfs.Delimiter, targetFileDatums int64, targetFileBytes int64, headerRecords int64, overwrite bool, reader io.Reader) (_ int, retErr error)
@@ -1003,10 +1131,17 @@ func (c *putFileClient) PutFile(repoName string, commitID string, path string, r
 // object starting from which you'd like to overwrite.  If you want to
 // overwrite the entire file, specify an index of 0.
 func (c *putFileClient) PutFileOverwrite(repoName string, commitID string, path string, reader io.Reader, overwriteIndex int64) (_ int, retErr error) {
+	return c.PutFileOverwriteMode(repoName, commitID, path, reader, overwriteIndex, 0)
+}
+
+// PutFileOverwriteMode is like PutFileOverwrite, except that it also records
+// mode as the permission bits of the file.
+func (c *putFileClient) PutFileOverwriteMode(repoName string, commitID string, path string, reader io.Reader, overwriteIndex int64, mode uint32) (_ int, retErr error) {
 	writer, err := c.newPutFileWriteCloser(repoName, commitID, path, pfs.Delimiter_NONE, 0, 0, 0, &pfs.OverwriteIndex{Index: overwriteIndex})
 	if err != nil {
 		return 0, grpcutil.ScrubGRPC(err)
 	}
+	writer.request.Mode = mode
 	defer func() {
 		if err := writer.Close(); err != nil && retErr == nil {
 			retErr = err
@@ -1016,6 +1151,63 @@ func (c *putFileClient) PutFileOverwrite(repoName string, commitID string, path
 	return int(written), grpcutil.ScrubGRPC(err)
 }
 
+// PutFileVerified is like PutFile (or PutFileOverwrite with an index of 0, if
+// overwrite is set), except that the write fails if the SHA-256 digest of the
+// data in reader doesn't match expectedSHA256. If mode isn't 0, it's recorded
+// as the permission bits of the file.
+func (c *putFileClient) PutFileVerified(repoName string, commitID string, path string, reader io.Reader, overwrite bool, mode uint32, expectedSHA256 []byte) (_ int, retErr error) {
+	var overwriteIndex *pfs.OverwriteIndex
+	if overwrite {
+		overwriteIndex = &pfs.OverwriteIndex{}
+	}
+	writer, err := c.newPutFileWriteCloser(repoName, commitID, path, pfs.Delimiter_NONE, 0, 0, 0, overwriteIndex)
+	if err != nil {
+		return 0, grpcutil.ScrubGRPC(err)
+	}
+	writer.request.ExpectedSha256 = expectedSHA256
+	writer.request.Mode = mode
+	defer func() {
+		if err := writer.Close(); err != nil && retErr == nil {
+			retErr = err
+		}
+	}()
+	written, err := io.Copy(writer, reader)
+	return int(written), grpcutil.ScrubGRPC(err)
+}
+
+// PutFileMode is like PutFile (or PutFileOverwrite with an index of 0, if
+// overwrite is set), except that it also records mode as the permission bits
+// of the file.
+func (c *putFileClient) PutFileMode(repoName string, commitID string, path string, reader io.Reader, overwrite bool, mode uint32) (_ int, retErr error) {
+	var overwriteIndex *pfs.OverwriteIndex
+	if overwrite {
+		overwriteIndex = &pfs.OverwriteIndex{}
+	}
+	writer, err := c.newPutFileWriteCloser(repoName, commitID, path, pfs.Delimiter_NONE, 0, 0, 0, overwriteIndex)
+	if err != nil {
+		return 0, grpcutil.ScrubGRPC(err)
+	}
+	writer.request.Mode = mode
+	defer func() {
+		if err := writer.Close(); err != nil && retErr == nil {
+			retErr = err
+		}
+	}()
+	written, err := io.Copy(writer, reader)
+	return int(written), grpcutil.ScrubGRPC(err)
+}
+
+// PutSymlink creates a symlink at path that points to target, replacing any
+// file that's already there.
+func (c *putFileClient) PutSymlink(repoName string, commitID string, path string, target string) error {
+	writer, err := c.newPutFileWriteCloser(repoName, commitID, path, pfs.Delimiter_NONE, 0, 0, 0, nil)
+	if err != nil {
+		return grpcutil.ScrubGRPC(err)
+	}
+	writer.request.SymlinkTarget = target
+	return writer.Close()
+}
+
 //PutFileSplit writes a file to PFS from a reader
 // delimiter is used to tell PFS how to break the input into blocks
 func (c *putFileClient) PutFileSplit(repoName string, commitID string, path string, delimiter pfs.Delimiter, targetFileDatums int64, targetFileBytes int64, headerRecords int64, overwrite bool, reader io.Reader) (_ int, retErr error) {
@@ -1114,6 +1306,49 @@ func (c APIClient) PutFileOverwrite(repoName string, commitID string, path strin
 	return pfc.PutFileOverwrite(repoName, commitID, path, reader, overwriteIndex)
 }
 
+// PutFileOverwriteMode is like PutFileOverwrite, except that it also records
+// mode as the permission bits of the file.
+func (c APIClient) PutFileOverwriteMode(repoName string, commitID string, path string, reader io.Reader, overwriteIndex int64, mode uint32) (_ int, retErr error) {
+	pfc, err := c.newOneoffPutFileClient()
+	if err != nil {
+		return 0, err
+	}
+	return pfc.PutFileOverwriteMode(repoName, commitID, path, reader, overwriteIndex, mode)
+}
+
+// PutFileVerified is like PutFile (or PutFileOverwrite with an index of 0, if
+// overwrite is set), except that the write fails if the SHA-256 digest of the
+// data in reader doesn't match expectedSHA256. If mode isn't 0, it's recorded
+// as the permission bits of the file.
+func (c APIClient) PutFileVerified(repoName string, commitID string, path string, reader io.Reader, overwrite bool, mode uint32, expectedSHA256 []byte) (_ int, retErr error) {
+	pfc, err := c.newOneoffPutFileClient()
+	if err != nil {
+		return 0, err
+	}
+	return pfc.PutFileVerified(repoName, commitID, path, reader, overwrite, mode, expectedSHA256)
+}
+
+// PutFileMode is like PutFile (or PutFileOverwrite with an index of 0, if
+// overwrite is set), except that it also records mode as the permission bits
+// of the file.
+func (c APIClient) PutFileMode(repoName string, commitID string, path string, reader io.Reader, overwrite bool, mode uint32) (_ int, retErr error) {
+	pfc, err := c.newOneoffPutFileClient()
+	if err != nil {
+		return 0, err
+	}
+	return pfc.PutFileMode(repoName, commitID, path, reader, overwrite, mode)
+}
+
+// PutSymlink creates a symlink at path that points to target, replacing any
+// file that's already there.
+func (c APIClient) PutSymlink(repoName string, commitID string, path string, target string) error {
+	pfc, err := c.newOneoffPutFileClient()
+	if err != nil {
+		return err
+	}
+	return pfc.PutSymlink(repoName, commitID, path, target)
+}
+
 //PutFileSplit writes a file to PFS from a reader
 // delimiter is used to tell PFS how to break the input into blocks
 func (c APIClient) PutFileSplit(repoName string, commitID string, path string, delimiter pfs.Delimiter, targetFileDatums int64, targetFileBytes int64, headerRecords int64, overwrite bool, reader io.Reader) (_ int, retErr error) {
@@ -1217,14 +1452,22 @@ func (c APIClient) getFile(repoName string, commitID string, path string, offset
 
 // InspectFile returns info about a specific file.
 func (c APIClient) InspectFile(repoName string, commitID string, path string) (*pfs.FileInfo, error) {
-	return c.inspectFile(repoName, commitID, path)
+	return c.inspectFile(repoName, commitID, path, false)
+}
+
+// InspectFileStoredSize is the same as InspectFile except that it also sets
+// the returned FileInfo's StoredSizeBytes, which takes an object lookup for
+// each object that the file's contents are stored in.
+func (c APIClient) InspectFileStoredSize(repoName string, commitID string, path string) (*pfs.FileInfo, error) {
+	return c.inspectFile(repoName, commitID, path, true)
 }
 
-func (c APIClient) inspectFile(repoName string, commitID string, path string) (*pfs.FileInfo, error) {
+func (c APIClient) inspectFile(repoName string, commitID string, path string, storedSize bool) (*pfs.FileInfo, error) {
 	fileInfo, err := c.PfsAPIClient.InspectFile(
 		c.Ctx(),
 		&pfs.InspectFileRequest{
-			File: NewFile(repoName, commitID, path),
+			File:       NewFile(repoName, commitID, path),
+			StoredSize: storedSize,
 		},
 	)
 	if err != nil {
@@ -1342,6 +1585,38 @@ func (c APIClient) GlobFileF(repoName string, commitID string, pattern string, f
 	}
 }
 
+// GrepFile calls f with every line matching the regular expression 'regex'
+// in the files matching the glob 'pattern'. The search runs inside pachd, so
+// only matching lines are sent to the client.
+// If f returns ErrBreak, the search stops and GrepFile returns nil.
+func (c APIClient) GrepFile(repoName string, commitID string, pattern string, regex string, f func(match *pfs.GrepMatch) error) error {
+	gc, err := c.PfsAPIClient.GrepFile(
+		c.Ctx(),
+		&pfs.GrepFileRequest{
+			Commit:  NewCommit(repoName, commitID),
+			Pattern: pattern,
+			Regex:   regex,
+		},
+	)
+	if err != nil {
+		return grpcutil.ScrubGRPC(err)
+	}
+	for {
+		match, err := gc.Recv()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return grpcutil.ScrubGRPC(err)
+		}
+		if err := f(match); err != nil {
+			if err == errutil.ErrBreak {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
 // DiffFile returns the difference between 2 paths, old path may be omitted in
 // which case the parent of the new path will be used. DiffFile return 2 values
 // (unless it returns an error) the first value is files present under new
@@ -1424,7 +1699,19 @@ type putFileWriteCloser struct {
 // prevent the completion of fsck. Errors that do prevent completion will be
 // returned from the function.
 func (c APIClient) Fsck(fix bool, cb func(*pfs.FsckResponse) error) error {
-	fsckClient, err := c.PfsAPIClient.Fsck(c.Ctx(), &pfs.FsckRequest{Fix: fix})
+	return c.fsck(&pfs.FsckRequest{Fix: fix}, cb)
+}
+
+// FsckVerifyChecksums is like Fsck, except that it also reads the contents of
+// every file that has a recorded checksum, and reports the files whose
+// contents don't match it. This reads all of the data in pfs, so it can be
+// slow.
+func (c APIClient) FsckVerifyChecksums(fix bool, cb func(*pfs.FsckResponse) error) error {
+	return c.fsck(&pfs.FsckRequest{Fix: fix, VerifyChecksums: true}, cb)
+}
+
+func (c APIClient) fsck(request *pfs.FsckRequest, cb func(*pfs.FsckResponse) error) error {
+	fsckClient, err := c.PfsAPIClient.Fsck(c.Ctx(), request)
 	if err != nil {
 		return grpcutil.ScrubGRPC(err)
 	}
@@ -1538,7 +1825,7 @@ type putObjectWriteCloser struct {
 	object  *pfs.Object
 }
 
-func (c APIClient) newPutObjectWriteCloser(tags ...string) (*putObjectWriteCloser, error) {
+func (c APIClient) newPutObjectWriteCloser(compression pfs.Compression, tags ...string) (*putObjectWriteCloser, error) {
 	client, err := c.ObjectAPIClient.PutObject(c.Ctx())
 	if err != nil {
 		return nil, grpcutil.ScrubGRPC(err)
@@ -1549,7 +1836,8 @@ func (c APIClient) newPutObjectWriteCloser(tags ...string) (*putObjectWriteClose
 	}
 	return &putObjectWriteCloser{
 		request: &pfs.PutObjectRequest{
-			Tags: _tags,
+			Tags:        _tags,
+			Compression: compression,
 		},
 		client: client,
 	}, nil
@@ -1580,7 +1868,7 @@ type PutObjectWriteCloserAsync struct {
 	object    *pfs.Object
 }
 
-func (c APIClient) newPutObjectWriteCloserAsync(tags []*pfs.Tag) (*PutObjectWriteCloserAsync, error) {
+func (c APIClient) newPutObjectWriteCloserAsync(tags []*pfs.Tag, compression pfs.Compression) (*PutObjectWriteCloserAsync, error) {
 	client, err := c.ObjectAPIClient.PutObject(c.Ctx())
 	if err != nil {
 		return nil, grpcutil.ScrubGRPC(err)
@@ -1588,7 +1876,8 @@ func (c APIClient) newPutObjectWriteCloserAsync(tags []*pfs.Tag) (*PutObjectWrit
 	w := &PutObjectWriteCloserAsync{
 		client: client,
 		request: &pfs.PutObjectRequest{
-			Tags: tags,
+			Tags:        tags,
+			Compression: compression,
 		},
 		buf:       grpcutil.GetBuffer()[:0],
 		writeChan: make(chan []byte, 5),
@@ -1675,13 +1964,13 @@ type putObjectSplitWriteCloser struct {
 	objects []*pfs.Object
 }
 
-func (c APIClient) newPutObjectSplitWriteCloser() (*putObjectSplitWriteCloser, error) {
+func (c APIClient) newPutObjectSplitWriteCloser(compression pfs.Compression) (*putObjectSplitWriteCloser, error) {
 	client, err := c.ObjectAPIClient.PutObjectSplit(c.Ctx())
 	if err != nil {
 		return nil, grpcutil.ScrubGRPC(err)
 	}
 	return &putObjectSplitWriteCloser{
-		request: &pfs.PutObjectRequest{},
+		request: &pfs.PutObjectRequest{Compression: compression},
 		client:  client,
 	}, nil
 }
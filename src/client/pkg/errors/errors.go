@@ -25,6 +25,8 @@ var (
 	// WithStack annotates err with a stack trace at the point WithStack was called.
 	// If err is nil, WithStack returns nil.
 	WithStack = errors.WithStack
+	// Cause returns the underlying cause of the error, if possible.
+	Cause = errors.Cause
 )
 
 // Callers returns an errors.StackTrace for the place at which it's called.
@@ -1,16 +1,19 @@
 package client
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"io"
 	"time"
 
 	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
 	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
 	"github.com/pachyderm/pachyderm/src/client/pps"
 	"github.com/pachyderm/pachyderm/src/server/pkg/errutil"
 
+	"github.com/gogo/protobuf/jsonpb"
 	"github.com/gogo/protobuf/types"
 )
 
@@ -136,6 +139,15 @@ func NewJoinInput(input ...*pps.Input) *pps.Input {
 	}
 }
 
+// NewGroupInput returns an input which groups the datums of other inputs.
+// That means that all of the datums which match on `groupBy` will be seen by
+// the job / pipeline together, as a single datum.
+func NewGroupInput(input ...*pps.Input) *pps.Input {
+	return &pps.Input{
+		Group: input,
+	}
+}
+
 // NewUnionInput returns an input which is the union of other inputs. That
 // means that all datums from any of the inputs will be seen individually by
 // the job / pipeline.
@@ -173,6 +185,20 @@ func NewCronInputOpts(name string, repo string, spec string, overwrite bool) *pp
 	}
 }
 
+// NewObjectStoreInput returns an input which ingests the objects under 'url'
+// (e.g. "s3://bucket/prefix/") into a repo, polling the bucket for new,
+// changed and deleted objects. The objects will be exposed to jobs as
+// `/pfs/<name>/<path relative to the prefix>`. The input's Secret must be set
+// to a secret holding the credentials for the bucket.
+func NewObjectStoreInput(name string, url string) *pps.Input {
+	return &pps.Input{
+		ObjectStore: &pps.ObjectStoreInput{
+			Name: name,
+			URL:  url,
+		},
+	}
+}
+
 // NewJobInput creates a pps.JobInput.
 func NewJobInput(repoName string, commitID string, glob string) *pps.JobInput {
 	return &pps.JobInput{
@@ -381,14 +407,25 @@ func (c APIClient) RestartDatum(jobID string, datumFilter []string) error {
 
 // ListDatum returns info about all datums in a Job
 func (c APIClient) ListDatum(jobID string, pageSize int64, page int64) (*pps.ListDatumResponse, error) {
-	client, err := c.PpsAPIClient.ListDatumStream(
-		c.Ctx(),
-		&pps.ListDatumRequest{
-			Job:      NewJob(jobID),
-			PageSize: pageSize,
-			Page:     page,
-		},
-	)
+	return c.listDatum(&pps.ListDatumRequest{
+		Job:      NewJob(jobID),
+		PageSize: pageSize,
+		Page:     page,
+	})
+}
+
+// ListDatumInput returns info about the datums that a pipeline with 'input'
+// would process, without creating the pipeline.
+func (c APIClient) ListDatumInput(input *pps.Input, pageSize int64, page int64) (*pps.ListDatumResponse, error) {
+	return c.listDatum(&pps.ListDatumRequest{
+		Input:    input,
+		PageSize: pageSize,
+		Page:     page,
+	})
+}
+
+func (c APIClient) listDatum(request *pps.ListDatumRequest) (*pps.ListDatumResponse, error) {
+	client, err := c.PpsAPIClient.ListDatumStream(c.Ctx(), request)
 	if err != nil {
 		return nil, grpcutil.ScrubGRPC(err)
 	}
@@ -404,6 +441,7 @@ func (c APIClient) ListDatum(jobID string, pageSize int64, page int64) (*pps.Lis
 		if first {
 			resp.TotalPages = r.TotalPages
 			resp.Page = r.Page
+			resp.TotalDatums = r.TotalDatums
 			first = false
 		}
 		resp.DatumInfos = append(resp.DatumInfos, r.DatumInfo)
@@ -413,14 +451,25 @@ func (c APIClient) ListDatum(jobID string, pageSize int64, page int64) (*pps.Lis
 
 // ListDatumF returns info about all datums in a Job, calling f with each datum info.
 func (c APIClient) ListDatumF(jobID string, pageSize int64, page int64, f func(di *pps.DatumInfo) error) error {
-	client, err := c.PpsAPIClient.ListDatumStream(
-		c.Ctx(),
-		&pps.ListDatumRequest{
-			Job:      NewJob(jobID),
-			PageSize: pageSize,
-			Page:     page,
-		},
-	)
+	return c.listDatumF(&pps.ListDatumRequest{
+		Job:      NewJob(jobID),
+		PageSize: pageSize,
+		Page:     page,
+	}, f)
+}
+
+// ListDatumInputF returns info about the datums that a pipeline with 'input'
+// would process, calling f with each datum info.
+func (c APIClient) ListDatumInputF(input *pps.Input, pageSize int64, page int64, f func(di *pps.DatumInfo) error) error {
+	return c.listDatumF(&pps.ListDatumRequest{
+		Input:    input,
+		PageSize: pageSize,
+		Page:     page,
+	}, f)
+}
+
+func (c APIClient) listDatumF(request *pps.ListDatumRequest, f func(di *pps.DatumInfo) error) error {
+	client, err := c.PpsAPIClient.ListDatumStream(c.Ctx(), request)
 	if err != nil {
 		return grpcutil.ScrubGRPC(err)
 	}
@@ -440,6 +489,56 @@ func (c APIClient) ListDatumF(jobID string, pageSize int64, page int64, f func(d
 	}
 }
 
+// ListDeadLetters returns the datums that 'pipeline' has dead-lettered and
+// that haven't been resubmitted since, read from the pipeline's dead letter
+// repo. The pipeline must have been created with dead_letter set.
+func (c APIClient) ListDeadLetters(pipeline string) ([]*pps.DeadLetterDatum, error) {
+	repo := pps.DeadLetterRepo(pipeline)
+	fileInfos, err := c.ListFile(repo, "master", "/")
+	if err != nil {
+		return nil, err
+	}
+	var result []*pps.DeadLetterDatum
+	for _, fileInfo := range fileInfos {
+		if fileInfo.FileType != pfs.FileType_FILE {
+			continue
+		}
+		buf := &bytes.Buffer{}
+		if err := c.GetFile(repo, "master", fileInfo.File.Path, 0, 0, buf); err != nil {
+			return nil, err
+		}
+		deadLetter := &pps.DeadLetterDatum{}
+		if err := jsonpb.Unmarshal(buf, deadLetter); err != nil {
+			return nil, errors.Wrapf(err, "malformed dead-lettered datum %s", fileInfo.File.Path)
+		}
+		result = append(result, deadLetter)
+	}
+	return result, nil
+}
+
+// ResubmitDeadLetters clears the records in 'pipeline's dead letter repo and
+// starts a new job for 'pipeline'. Jobs skip the datums that have a record, so
+// the new job retries exactly the datums that were dead-lettered (along with
+// any input that no job has processed yet).
+func (c APIClient) ResubmitDeadLetters(pipeline string) error {
+	repo := pps.DeadLetterRepo(pipeline)
+	if err := func() (retErr error) {
+		commit, err := c.StartCommit(repo, "master")
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := c.FinishCommit(repo, commit.ID); err != nil && retErr == nil {
+				retErr = err
+			}
+		}()
+		return c.DeleteFile(repo, commit.ID, "/")
+	}(); err != nil {
+		return err
+	}
+	return c.RunPipeline(pipeline, nil, "")
+}
+
 // InspectDatum returns info about a single datum
 func (c APIClient) InspectDatum(jobID string, datumID string) (*pps.DatumInfo, error) {
 	datumInfo, err := c.PpsAPIClient.InspectDatum(
@@ -585,6 +684,38 @@ func (c APIClient) InspectPipeline(pipelineName string) (*pps.PipelineInfo, erro
 	return pipelineInfo, grpcutil.ScrubGRPC(err)
 }
 
+// InspectPipelineUsage returns the resources used by the jobs of pipeline
+// 'pipelineName' (including those of its previous versions) that started at
+// or after 'since'. If 'since' is the zero time, all of the pipeline's jobs
+// are counted.
+func (c APIClient) InspectPipelineUsage(pipelineName string, since time.Time) (*pps.PipelineUsage, error) {
+	request := &pps.InspectPipelineUsageRequest{
+		Pipeline: NewPipeline(pipelineName),
+	}
+	if !since.IsZero() {
+		var err error
+		if request.Since, err = types.TimestampProto(since); err != nil {
+			return nil, err
+		}
+	}
+	usage, err := c.PpsAPIClient.InspectPipelineUsage(c.Ctx(), request)
+	return usage, grpcutil.ScrubGRPC(err)
+}
+
+// ValidatePipeline checks the pipeline specs in 'requests' (in order, as if
+// they were created in order) without creating them, and returns every problem
+// found in them.
+func (c APIClient) ValidatePipeline(requests ...*pps.CreatePipelineRequest) ([]*pps.PipelineProblem, error) {
+	response, err := c.PpsAPIClient.ValidatePipeline(
+		c.Ctx(),
+		&pps.ValidatePipelineRequest{Pipelines: requests},
+	)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return response.Problems, nil
+}
+
 // ListPipeline returns info about all pipelines.
 func (c APIClient) ListPipeline() ([]*pps.PipelineInfo, error) {
 	pipelineInfos, err := c.PpsAPIClient.ListPipeline(
@@ -673,6 +804,21 @@ func (c APIClient) RunPipeline(name string, provenance []*pfs.CommitProvenance,
 	return grpcutil.ScrubGRPC(err)
 }
 
+// RollbackPipeline re-applies version 'version' of a pipeline's spec (or the
+// version before the current one, if 'version' is 0) as a new version of the
+// pipeline. If 'reprocess' is true, the pipeline reprocesses all of its input.
+func (c APIClient) RollbackPipeline(name string, version uint64, reprocess bool) error {
+	_, err := c.PpsAPIClient.RollbackPipeline(
+		c.Ctx(),
+		&pps.RollbackPipelineRequest{
+			Pipeline:  NewPipeline(name),
+			Version:   version,
+			Reprocess: reprocess,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
 // RunCron runs a pipeline. It can be passed a list of commit provenance.
 // This will trigger a new job provenant on those commits, effectively running the pipeline on the data in those commits.
 func (c APIClient) RunCron(name string) error {
@@ -727,6 +873,55 @@ func (c APIClient) ListSecret() ([]*pps.SecretInfo, error) {
 	return secretInfos.SecretInfo, grpcutil.ScrubGRPC(err)
 }
 
+// CreateNotifier registers a webhook that's sent job and pipeline events.
+// If pipeline is "", the notifier is sent events for every pipeline. If
+// events is empty, it's sent every kind of event. If secret is set, each
+// request is signed with it.
+func (c APIClient) CreateNotifier(name string, url string, pipeline string, events []pps.NotifierEvent, secret string, update bool) error {
+	notifier := &pps.Notifier{
+		Name:   name,
+		URL:    url,
+		Events: events,
+		Secret: secret,
+	}
+	if pipeline != "" {
+		notifier.Pipeline = NewPipeline(pipeline)
+	}
+	_, err := c.PpsAPIClient.CreateNotifier(
+		c.Ctx(),
+		&pps.CreateNotifierRequest{
+			Notifier: notifier,
+			Update:   update,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// ListNotifier returns the notifiers for pipeline, or every notifier if
+// pipeline is "". Notifier secrets aren't returned.
+func (c APIClient) ListNotifier(pipeline string) ([]*pps.Notifier, error) {
+	request := &pps.ListNotifierRequest{}
+	if pipeline != "" {
+		request.Pipeline = NewPipeline(pipeline)
+	}
+	notifierInfos, err := c.PpsAPIClient.ListNotifier(c.Ctx(), request)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return notifierInfos.Notifiers, nil
+}
+
+// DeleteNotifier deletes a notifier.
+func (c APIClient) DeleteNotifier(name string) error {
+	_, err := c.PpsAPIClient.DeleteNotifier(
+		c.Ctx(),
+		&pps.DeleteNotifierRequest{
+			Name: name,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
 // CreatePipelineService creates a new pipeline service.
 func (c APIClient) CreatePipelineService(
 	name string,
@@ -1,9 +1,14 @@
 package pps
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"sort"
 	"strings"
+	"time"
 
+	"github.com/gogo/protobuf/types"
 	"github.com/pachyderm/pachyderm/src/client/pfs"
 
 	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
@@ -23,6 +28,10 @@ func VisitInput(input *Input, f func(*Input)) {
 		for _, input := range input.Join {
 			VisitInput(input, f)
 		}
+	case input.Group != nil:
+		for _, input := range input.Group {
+			VisitInput(input, f)
+		}
 	case input.Union != nil:
 		for _, input := range input.Union {
 			VisitInput(input, f)
@@ -46,6 +55,10 @@ func InputName(input *Input) string {
 		if len(input.Join) > 0 {
 			return InputName(input.Join[0])
 		}
+	case input.Group != nil:
+		if len(input.Group) > 0 {
+			return InputName(input.Group[0])
+		}
 	case input.Union != nil:
 		if len(input.Union) > 0 {
 			return InputName(input.Union[0])
@@ -65,6 +78,8 @@ func SortInput(input *Input) {
 			SortInputs(input.Cross)
 		case input.Join != nil:
 			SortInputs(input.Join)
+		case input.Group != nil:
+			SortInputs(input.Group)
 		case input.Union != nil:
 			SortInputs(input.Union)
 		}
@@ -93,6 +108,18 @@ func InputBranches(input *Input) []*pfs.Branch {
 				Name: input.Git.Branch,
 			})
 		}
+		if input.Webhook != nil {
+			result = append(result, &pfs.Branch{
+				Repo: &pfs.Repo{Name: input.Webhook.Repo},
+				Name: "master",
+			})
+		}
+		if input.ObjectStore != nil {
+			result = append(result, &pfs.Branch{
+				Repo: &pfs.Repo{Name: input.ObjectStore.Repo},
+				Name: "master",
+			})
+		}
 	})
 	return result
 }
@@ -130,3 +157,79 @@ func ValidateGitCloneURL(url string) error {
 
 	return nil
 }
+
+// DeadLetterRepo returns the name of the repo in which a pipeline with
+// 'dead_letter' set records the datums that failed all of their tries.
+func DeadLetterRepo(pipelineName string) string {
+	return pipelineName + "_failed"
+}
+
+// NotificationSignature returns the X-Pachyderm-Signature header that's sent
+// with a notification whose request body is 'body', by a notifier whose
+// secret is 'secret'. Receivers can compare it (using hmac.Equal) to the
+// header, to check that a notification came from Pachyderm.
+func NotificationSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// MergeProcessStats merges 'y' into 'x'. Times and byte counts are added, the
+// peak memory is the larger of the two, and the average memory is weighted by
+// each side's process time.
+func MergeProcessStats(x, y *ProcessStats) error {
+	xProcessTime, err := durationFromProto(x.ProcessTime)
+	if err != nil {
+		return err
+	}
+	yProcessTime, err := durationFromProto(y.ProcessTime)
+	if err != nil {
+		return err
+	}
+	if total := xProcessTime + yProcessTime; total > 0 {
+		x.AverageMemoryBytes = uint64((float64(x.AverageMemoryBytes)*float64(xProcessTime) +
+			float64(y.AverageMemoryBytes)*float64(yProcessTime)) / float64(total))
+	}
+	if y.PeakMemoryBytes > x.PeakMemoryBytes {
+		x.PeakMemoryBytes = y.PeakMemoryBytes
+	}
+	if x.DownloadTime, err = plusDuration(x.DownloadTime, y.DownloadTime); err != nil {
+		return err
+	}
+	if x.ProcessTime, err = plusDuration(x.ProcessTime, y.ProcessTime); err != nil {
+		return err
+	}
+	if x.UploadTime, err = plusDuration(x.UploadTime, y.UploadTime); err != nil {
+		return err
+	}
+	if x.CpuTime, err = plusDuration(x.CpuTime, y.CpuTime); err != nil {
+		return err
+	}
+	if x.GpuTime, err = plusDuration(x.GpuTime, y.GpuTime); err != nil {
+		return err
+	}
+	x.DownloadBytes += y.DownloadBytes
+	x.UploadBytes += y.UploadBytes
+	x.ReadBytes += y.ReadBytes
+	x.WriteBytes += y.WriteBytes
+	return nil
+}
+
+func durationFromProto(d *types.Duration) (time.Duration, error) {
+	if d == nil {
+		return 0, nil
+	}
+	return types.DurationFromProto(d)
+}
+
+func plusDuration(x *types.Duration, y *types.Duration) (*types.Duration, error) {
+	xd, err := durationFromProto(x)
+	if err != nil {
+		return nil, err
+	}
+	yd, err := durationFromProto(y)
+	if err != nil {
+		return nil, err
+	}
+	return types.DurationProto(xd + yd), nil
+}
@@ -0,0 +1,44 @@
+package pps
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+)
+
+func TestMergeProcessStats(t *testing.T) {
+	x := &ProcessStats{
+		ProcessTime:        types.DurationProto(time.Second),
+		CpuTime:            types.DurationProto(time.Second),
+		PeakMemoryBytes:    300,
+		AverageMemoryBytes: 100,
+		ReadBytes:          1,
+		WriteBytes:         2,
+	}
+	require.NoError(t, MergeProcessStats(x, &ProcessStats{
+		ProcessTime:        types.DurationProto(3 * time.Second),
+		CpuTime:            types.DurationProto(2 * time.Second),
+		GpuTime:            types.DurationProto(6 * time.Second),
+		PeakMemoryBytes:    400,
+		AverageMemoryBytes: 200,
+		ReadBytes:          3,
+		WriteBytes:         4,
+	}))
+	require.Equal(t, types.DurationProto(4*time.Second), x.ProcessTime)
+	require.Equal(t, types.DurationProto(3*time.Second), x.CpuTime)
+	require.Equal(t, types.DurationProto(6*time.Second), x.GpuTime)
+	require.Equal(t, uint64(400), x.PeakMemoryBytes)
+	// 1s at 100 bytes and 3s at 200 bytes
+	require.Equal(t, uint64(175), x.AverageMemoryBytes)
+	require.Equal(t, uint64(4), x.ReadBytes)
+	require.Equal(t, uint64(6), x.WriteBytes)
+
+	// Merging into empty stats (e.g. a job's, before any datums are processed)
+	// keeps the other side's averages
+	empty := &ProcessStats{}
+	require.NoError(t, MergeProcessStats(empty, x))
+	require.Equal(t, x, empty)
+}
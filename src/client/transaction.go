@@ -341,12 +341,24 @@ func (c *pfsBuilderClient) SubscribeCommit(ctx context.Context, req *pfs.Subscri
 func (c *pfsBuilderClient) BuildCommit(ctx context.Context, req *pfs.BuildCommitRequest, opts ...grpc.CallOption) (*pfs.Commit, error) {
 	return nil, unsupportedError("BuildCommit")
 }
+func (c *pfsBuilderClient) AnnotateCommit(ctx context.Context, req *pfs.AnnotateCommitRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	return nil, unsupportedError("AnnotateCommit")
+}
 func (c *pfsBuilderClient) InspectBranch(ctx context.Context, req *pfs.InspectBranchRequest, opts ...grpc.CallOption) (*pfs.BranchInfo, error) {
 	return nil, unsupportedError("InspectBranch")
 }
 func (c *pfsBuilderClient) ListBranch(ctx context.Context, req *pfs.ListBranchRequest, opts ...grpc.CallOption) (*pfs.BranchInfos, error) {
 	return nil, unsupportedError("ListBranch")
 }
+func (c *pfsBuilderClient) SetBranchRetention(ctx context.Context, req *pfs.SetBranchRetentionRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	return nil, unsupportedError("SetBranchRetention")
+}
+func (c *pfsBuilderClient) PruneBranch(ctx context.Context, req *pfs.PruneBranchRequest, opts ...grpc.CallOption) (*pfs.CommitInfos, error) {
+	return nil, unsupportedError("PruneBranch")
+}
+func (c *pfsBuilderClient) MergeBranch(ctx context.Context, req *pfs.MergeBranchRequest, opts ...grpc.CallOption) (*pfs.MergeBranchResponse, error) {
+	return nil, unsupportedError("MergeBranch")
+}
 func (c *pfsBuilderClient) PutFile(ctx context.Context, opts ...grpc.CallOption) (pfs.API_PutFileClient, error) {
 	return nil, unsupportedError("PutFile")
 }
@@ -374,6 +386,9 @@ func (c *pfsBuilderClient) GlobFile(ctx context.Context, req *pfs.GlobFileReques
 func (c *pfsBuilderClient) GlobFileStream(ctx context.Context, req *pfs.GlobFileRequest, opts ...grpc.CallOption) (pfs.API_GlobFileStreamClient, error) {
 	return nil, unsupportedError("GlobFileStream")
 }
+func (c *pfsBuilderClient) GrepFile(ctx context.Context, req *pfs.GrepFileRequest, opts ...grpc.CallOption) (pfs.API_GrepFileClient, error) {
+	return nil, unsupportedError("GrepFile")
+}
 func (c *pfsBuilderClient) DiffFile(ctx context.Context, req *pfs.DiffFileRequest, opts ...grpc.CallOption) (*pfs.DiffFileResponse, error) {
 	return nil, unsupportedError("DiffFile")
 }
@@ -532,6 +547,24 @@ func (c *ppsBuilderClient) ActivateAuth(ctx context.Context, req *pps.ActivateAu
 func (c *ppsBuilderClient) RunCron(ctx context.Context, req *pps.RunCronRequest, opts ...grpc.CallOption) (*types.Empty, error) {
 	return nil, unsupportedError("RunCron")
 }
+func (c *ppsBuilderClient) InspectPipelineUsage(ctx context.Context, req *pps.InspectPipelineUsageRequest, opts ...grpc.CallOption) (*pps.PipelineUsage, error) {
+	return nil, unsupportedError("InspectPipelineUsage")
+}
+func (c *ppsBuilderClient) ValidatePipeline(ctx context.Context, req *pps.ValidatePipelineRequest, opts ...grpc.CallOption) (*pps.ValidatePipelineResponse, error) {
+	return nil, unsupportedError("ValidatePipeline")
+}
+func (c *ppsBuilderClient) RollbackPipeline(ctx context.Context, req *pps.RollbackPipelineRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	return nil, unsupportedError("RollbackPipeline")
+}
+func (c *ppsBuilderClient) CreateNotifier(ctx context.Context, req *pps.CreateNotifierRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	return nil, unsupportedError("CreateNotifier")
+}
+func (c *ppsBuilderClient) ListNotifier(ctx context.Context, req *pps.ListNotifierRequest, opts ...grpc.CallOption) (*pps.NotifierInfos, error) {
+	return nil, unsupportedError("ListNotifier")
+}
+func (c *ppsBuilderClient) DeleteNotifier(ctx context.Context, req *pps.DeleteNotifierRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	return nil, unsupportedError("DeleteNotifier")
+}
 func (c *ppsBuilderClient) CreateSecret(ctx context.Context, req *pps.CreateSecretRequest, opts ...grpc.CallOption) (*types.Empty, error) {
 	return nil, unsupportedError("CreateSecret")
 }
@@ -293,6 +293,66 @@ func TestExtractRestoreHeadlessBranches(t *testing.T) {
 	require.Equal(t, "headless", bis[0].Branch.Name)
 }
 
+func TestExtractRestoreRepoSettings(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	c := tu.GetPachClient(t)
+	require.NoError(t, c.DeleteAll())
+
+	dataRepo := tu.UniqueString("TestExtractRestoreRepoSettings_data")
+	_, err := c.PfsAPIClient.CreateRepo(c.Ctx(), &pfs.CreateRepoRequest{
+		Repo:        client.NewRepo(dataRepo),
+		Compression: pfs.Compression_GZIP,
+		Quota:       &pfs.Quota{SizeBytes: 10 * MB, FileCount: 100},
+	})
+	require.NoError(t, err)
+
+	ops, err := c.ExtractAll(false)
+	require.NoError(t, err)
+	require.NoError(t, c.DeleteAll())
+	require.NoError(t, c.Restore(ops))
+
+	ri, err := c.InspectRepo(dataRepo)
+	require.NoError(t, err)
+	require.Equal(t, pfs.Compression_GZIP, ri.Compression)
+	require.NotNil(t, ri.Quota)
+	require.Equal(t, uint64(10*MB), ri.Quota.SizeBytes)
+	require.Equal(t, uint64(100), ri.Quota.FileCount)
+}
+
+func TestExtractRestoreBranchSettings(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	c := tu.GetPachClient(t)
+	require.NoError(t, c.DeleteAll())
+
+	dataRepo := tu.UniqueString("TestExtractRestoreBranchSettings_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	_, err := c.PutFile(dataRepo, "master", "file", strings.NewReader("foo"))
+	require.NoError(t, err)
+	require.NoError(t, c.SetBranchRetention(dataRepo, "master", &pfs.RetentionPolicy{KeepLast: 3}))
+	require.NoError(t, c.CreateBranchTrigger(dataRepo, "batched", "", &pfs.Trigger{Branch: "master", Commits: 10}))
+
+	ops, err := c.ExtractAll(false)
+	require.NoError(t, err)
+	require.NoError(t, c.DeleteAll())
+	require.NoError(t, c.Restore(ops))
+
+	bi, err := c.InspectBranch(dataRepo, "master")
+	require.NoError(t, err)
+	require.NotNil(t, bi.Retention)
+	require.Equal(t, int64(3), bi.Retention.KeepLast)
+	bi, err = c.InspectBranch(dataRepo, "batched")
+	require.NoError(t, err)
+	require.NotNil(t, bi.Trigger)
+	require.Equal(t, "master", bi.Trigger.Branch)
+	require.Equal(t, int64(10), bi.Trigger.Commits)
+}
+
 func TestExtractVersion(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")
@@ -161,6 +161,8 @@ func (a *apiServer) Extract(request *admin.ExtractRequest, extractServer admin.A
 				Repo: &pfs.CreateRepoRequest{
 					Repo:        ri.Repo,
 					Description: ri.Description,
+					Compression: ri.Compression,
+					Quota:       ri.Quota,
 				}},
 			}); err != nil {
 				return err
@@ -171,13 +173,14 @@ func (a *apiServer) Extract(request *admin.ExtractRequest, extractServer admin.A
 				ci.ParentCommit = client.NewCommit(ci.Commit.Repo.Name, "")
 			}
 			return writeOp(&admin.Op{Op1_10: &admin.Op1_10{Commit: &pfs.BuildCommitRequest{
-				Parent:     ci.ParentCommit,
-				Tree:       ci.Tree,
-				ID:         ci.Commit.ID,
-				Trees:      ci.Trees,
-				Datums:     ci.Datums,
-				SizeBytes:  ci.SizeBytes,
-				Provenance: ci.Provenance,
+				Parent:      ci.ParentCommit,
+				Tree:        ci.Tree,
+				ID:          ci.Commit.ID,
+				Trees:       ci.Trees,
+				Datums:      ci.Datums,
+				SizeBytes:   ci.SizeBytes,
+				Provenance:  ci.Provenance,
+				Annotations: ci.Annotations,
 			}}})
 		}); err != nil {
 			return err
@@ -197,6 +200,8 @@ func (a *apiServer) Extract(request *admin.ExtractRequest, extractServer admin.A
 					Head:       bi.Head,
 					Branch:     bi.Branch,
 					Provenance: bi.DirectProvenance,
+					Retention:  bi.Retention,
+					Trigger:    bi.Trigger,
 				},
 			}}); err != nil {
 				return err
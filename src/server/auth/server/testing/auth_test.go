@@ -1985,6 +1985,50 @@ func TestListDatum(t *testing.T) {
 	}, files)
 }
 
+// TestListDatumInput tests that you must have READER access to every repo in
+// an input to list the datums it would produce, including the repos of inputs
+// that aren't PFS inputs
+func TestListDatumInput(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	deleteAll(t)
+	defer deleteAll(t)
+	alice, bob := tu.UniqueString("alice"), tu.UniqueString("bob")
+	aliceClient, bobClient := getPachClient(t, alice), getPachClient(t, bob)
+
+	// alice creates two repos
+	repoA := tu.UniqueString(t.Name())
+	require.NoError(t, aliceClient.CreateRepo(repoA))
+	_, err := aliceClient.PutFile(repoA, "master", "/file", strings.NewReader("test"))
+	require.NoError(t, err)
+	repoB := tu.UniqueString(t.Name())
+	require.NoError(t, aliceClient.CreateRepo(repoB))
+
+	// bob can't list the datums of an input that reads repoA
+	input := client.NewPFSInput(repoA, "/*")
+	_, err = bobClient.ListDatumInput(input, 0 /*pageSize*/, 0 /*page*/)
+	require.YesError(t, err)
+	require.True(t, auth.IsErrNotAuthorized(err), err.Error())
+
+	// or of a cron input whose repo is repoB
+	cronInput := &pps.Input{Cron: &pps.CronInput{Name: "tick", Repo: repoB, Spec: "@every 1h"}}
+	_, err = bobClient.ListDatumInput(cronInput, 0 /*pageSize*/, 0 /*page*/)
+	require.YesError(t, err)
+	require.True(t, auth.IsErrNotAuthorized(err), err.Error())
+
+	// alice adds bob to repoA, and now bob can list its datums
+	_, err = aliceClient.SetScope(aliceClient.Ctx(), &auth.SetScopeRequest{
+		Username: bob,
+		Scope:    auth.Scope_READER,
+		Repo:     repoA,
+	})
+	require.NoError(t, err)
+	resp, err := bobClient.ListDatumInput(input, 0 /*pageSize*/, 0 /*page*/)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), resp.TotalDatums)
+}
+
 // TestListJob tests that you must have READER access to a pipeline's output
 // repo to call ListJob on that pipeline, but a blank ListJob always succeeds
 // (but doesn't return a given job if you don't have access to the job's output
@@ -798,12 +798,54 @@ This resets the cluster to its initial state.`,
 	}
 	subcommands = append(subcommands, cmdutil.CreateAlias(resumeDocs, "resume"))
 
+	resubmitDocs := &cobra.Command{
+		Short: "Retry the failed parts of a Pachyderm resource.",
+		Long:  "Retry the failed parts of a Pachyderm resource.",
+	}
+	subcommands = append(subcommands, cmdutil.CreateAlias(resubmitDocs, "resubmit"))
+
+	rollbackDocs := &cobra.Command{
+		Short: "Return a Pachyderm resource to a previous version.",
+		Long:  "Return a Pachyderm resource to a previous version.",
+	}
+	subcommands = append(subcommands, cmdutil.CreateAlias(rollbackDocs, "rollback"))
+
 	runDocs := &cobra.Command{
 		Short: "Manually run a Pachyderm resource.",
 		Long:  "Manually run a Pachyderm resource.",
 	}
 	subcommands = append(subcommands, cmdutil.CreateAlias(runDocs, "run"))
 
+	lintDocs := &cobra.Command{
+		Short: "Check a Pachyderm resource for problems without creating it.",
+		Long:  "Check a Pachyderm resource for problems without creating it.",
+	}
+	subcommands = append(subcommands, cmdutil.CreateAlias(lintDocs, "lint"))
+
+	annotateDocs := &cobra.Command{
+		Short: "Add or remove annotations on a Pachyderm resource.",
+		Long:  "Add or remove annotations on a Pachyderm resource.",
+	}
+	subcommands = append(subcommands, cmdutil.CreateAlias(annotateDocs, "annotate"))
+
+	pruneDocs := &cobra.Command{
+		Short: "Delete the parts of a Pachyderm resource that are no longer retained.",
+		Long:  "Delete the parts of a Pachyderm resource that are no longer retained.",
+	}
+	subcommands = append(subcommands, cmdutil.CreateAlias(pruneDocs, "prune"))
+
+	mergeDocs := &cobra.Command{
+		Short: "Combine the changes made to two Pachyderm resources.",
+		Long:  "Combine the changes made to two Pachyderm resources.",
+	}
+	subcommands = append(subcommands, cmdutil.CreateAlias(mergeDocs, "merge"))
+
+	grepDocs := &cobra.Command{
+		Short: "Search the contents of a Pachyderm resource.",
+		Long:  "Search the contents of a Pachyderm resource.",
+	}
+	subcommands = append(subcommands, cmdutil.CreateAlias(grepDocs, "grep"))
+
 	editDocs := &cobra.Command{
 		Short: "Edit the value of an existing Pachyderm resource.",
 		Long:  "Edit the value of an existing Pachyderm resource.",
@@ -856,6 +898,7 @@ func applyRootUsageFunc(rootCmd *cobra.Command) {
 			"tag":
 			// These are ignored - they will show up in the help topics section
 		case
+			"annotate",
 			"copy",
 			"create",
 			"delete",
@@ -865,10 +908,16 @@ func applyRootUsageFunc(rootCmd *cobra.Command) {
 			"flush",
 			"get",
 			"glob",
+			"grep",
 			"inspect",
+			"lint",
 			"list",
+			"merge",
+			"prune",
 			"put",
 			"restart",
+			"resubmit",
+			"rollback",
 			"start",
 			"stop",
 			"subscribe",
@@ -682,7 +682,7 @@ func doFullMode(config interface{}) (retErr error) {
 		return http.ListenAndServe(fmt.Sprintf(":%v", env.HTTPPort), httpServer)
 	})
 	go waitForError("Githook Server", errChan, requireNoncriticalServers, func() error {
-		return githook.RunGitHookServer(address, etcdAddress, path.Join(env.EtcdPrefix, env.PPSEtcdPrefix))
+		return githook.RunGitHookServer(address, etcdAddress, path.Join(env.EtcdPrefix, env.PPSEtcdPrefix), env.GetKubeClient(), env.Namespace)
 	})
 	go waitForError("S3 Server", errChan, requireNoncriticalServers, func() error {
 		server, err := s3.Server(env.S3GatewayPort, s3.NewMasterDriver(), s3.NewLocalClientFactory(env.PeerPort))
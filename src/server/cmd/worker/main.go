@@ -71,7 +71,7 @@ func getPipelineInfo(pachClient *client.APIClient, env *serviceenv.ServiceEnv) (
 func do(config interface{}) error {
 	// must run InstallJaegerTracer before InitWithKube/pach client initialization
 	tracing.InstallJaegerTracerFromEnv()
-	env := serviceenv.InitServiceEnv(serviceenv.NewConfiguration(config))
+	env := serviceenv.InitWithKube(serviceenv.NewConfiguration(config))
 
 	// Construct a client that connects to the sidecar.
 	pachClient := env.GetPachClient(context.Background())
@@ -82,7 +82,16 @@ func do(config interface{}) error {
 
 	// Construct worker API server.
 	workerRcName := ppsutil.PipelineRcName(pipelineInfo.Pipeline.Name, pipelineInfo.Version)
-	apiServer, err := worker.NewAPIServer(pachClient, env.GetEtcdClient(), env.PPSEtcdPrefix, pipelineInfo, env.PodName, env.Namespace, env.StorageRoot)
+	var userImageID string
+	if pipelineInfo.DatumCache {
+		// The datum cache is keyed on the digest of the user image, which is
+		// only known once the image has been pulled
+		userImageID, err = worker.UserImageID(env.GetKubeClient(), env.Namespace, env.PodName)
+		if err != nil {
+			log.Warnf("disabling the datum cache: %v", err)
+		}
+	}
+	apiServer, err := worker.NewAPIServer(pachClient, env.GetEtcdClient(), env.PPSEtcdPrefix, pipelineInfo, env.PodName, env.Namespace, env.StorageRoot, userImageID)
 	if err != nil {
 		return err
 	}
@@ -1384,6 +1384,263 @@ func TestEgressFailure(t *testing.T) {
 	require.True(t, strings.Contains(jobInfo.Reason, "egress"))
 }
 
+func TestIncrementalEgress(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	c := tu.GetPachClient(t)
+	require.NoError(t, c.DeleteAll())
+
+	dataRepo := tu.UniqueString("TestIncrementalEgress_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	_, err := c.PutFile(dataRepo, "master", "a", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	_, err = c.PutFile(dataRepo, "master", "b", strings.NewReader("bar\n"))
+	require.NoError(t, err)
+
+	// The local object store is in the worker's filesystem, so only the
+	// pipeline's record of what it egressed can be checked here
+	pipeline := tu.UniqueString("pipeline")
+	_, err = c.PpsAPIClient.CreatePipeline(
+		context.Background(),
+		&pps.CreatePipelineRequest{
+			Pipeline: client.NewPipeline(pipeline),
+			Transform: &pps.Transform{
+				Cmd:   []string{"bash"},
+				Stdin: []string{fmt.Sprintf("cp /pfs/%s/* /pfs/out/", dataRepo)},
+			},
+			Input: client.NewPFSInput(dataRepo, "/*"),
+			Egress: &pps.Egress{
+				URL:         fmt.Sprintf("local://tmp/%s/", pipeline),
+				Incremental: true,
+			},
+		})
+	require.NoError(t, err)
+
+	checkEgressed := func() {
+		commitIter, err := c.FlushCommit([]*pfs.Commit{client.NewCommit(dataRepo, "master")}, []*pfs.Repo{client.NewRepo(pipeline)})
+		require.NoError(t, err)
+		commitInfos := collectCommitInfos(t, commitIter)
+		require.Equal(t, 1, len(commitInfos))
+		jobInfos, err := c.ListJob(pipeline, nil, commitInfos[0].Commit, -1, true)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(jobInfos))
+		// The job's output commit is finished before it's egressed
+		jobInfo, err := c.PpsAPIClient.InspectJob(context.Background(), &pps.InspectJobRequest{
+			Job:        jobInfos[0].Job,
+			BlockState: true,
+		})
+		require.NoError(t, err)
+		require.Equal(t, pps.JobState_JOB_SUCCESS, jobInfo.State)
+		pipelineInfo, err := c.InspectPipeline(pipeline)
+		require.NoError(t, err)
+		require.NotNil(t, pipelineInfo.LastEgressCommit)
+		require.Equal(t, commitInfos[0].Commit.ID, pipelineInfo.LastEgressCommit.ID)
+	}
+	checkEgressed()
+
+	// The second job only egresses its diff: a changed file and a deletion
+	commit, err := c.StartCommit(dataRepo, "master")
+	require.NoError(t, err)
+	require.NoError(t, c.DeleteFile(dataRepo, commit.ID, "a"))
+	_, err = c.PutFile(dataRepo, commit.ID, "b", strings.NewReader("baz\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
+	checkEgressed()
+}
+
+func TestPipelineUsage(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	c := tu.GetPachClient(t)
+	require.NoError(t, c.DeleteAll())
+
+	dataRepo := tu.UniqueString("TestPipelineUsage_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	for i := 0; i < 3; i++ {
+		_, err := c.PutFile(dataRepo, "master", fmt.Sprintf("file-%d", i), strings.NewReader("foo\n"))
+		require.NoError(t, err)
+	}
+	pipeline := tu.UniqueString("pipeline")
+	require.NoError(t, c.CreatePipeline(
+		pipeline,
+		"",
+		[]string{"bash"},
+		[]string{fmt.Sprintf("cp /pfs/%s/* /pfs/out/", dataRepo)},
+		nil,
+		client.NewPFSInput(dataRepo, "/*"),
+		"",
+		false,
+	))
+	commitIter, err := c.FlushCommit([]*pfs.Commit{client.NewCommit(dataRepo, "master")}, []*pfs.Repo{client.NewRepo(pipeline)})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(collectCommitInfos(t, commitIter)))
+
+	usage, err := c.InspectPipelineUsage(pipeline, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), usage.Jobs)
+	require.Equal(t, int64(3), usage.DataProcessed)
+	require.NotNil(t, usage.Stats.CpuTime)
+	require.True(t, usage.Stats.PeakMemoryBytes > 0)
+	require.True(t, usage.Stats.AverageMemoryBytes > 0)
+	require.Nil(t, usage.Stats.GpuTime)
+
+	// Jobs that started before 'since' aren't counted
+	usage, err = c.InspectPipelineUsage(pipeline, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, int64(0), usage.Jobs)
+
+	_, err = c.InspectPipelineUsage(tu.UniqueString("no-such-pipeline"), time.Time{})
+	require.YesError(t, err)
+}
+
+func TestValidatePipeline(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	c := tu.GetPachClient(t)
+	require.NoError(t, c.DeleteAll())
+
+	dataRepo := tu.UniqueString("TestValidatePipeline_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	_, err := c.PutFile(dataRepo, "master", "a.txt", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	_, err = c.PutFile(dataRepo, "master", "b.txt", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+
+	request := func(name string, input *pps.Input) *pps.CreatePipelineRequest {
+		return &pps.CreatePipelineRequest{
+			Pipeline: client.NewPipeline(name),
+			Transform: &pps.Transform{
+				Cmd: []string{"bash"},
+			},
+			Input: input,
+		}
+	}
+	// messages returns the messages of 'problems', prefixed with "error: " or
+	// "warning: "
+	messages := func(problems []*pps.PipelineProblem) []string {
+		var result []string
+		for _, problem := range problems {
+			severity := "error: "
+			if problem.Warning {
+				severity = "warning: "
+			}
+			result = append(result, severity+problem.Message)
+		}
+		return result
+	}
+
+	// A valid pipeline has no problems, and isn't created
+	problems, err := c.ValidatePipeline(request("first", client.NewPFSInput(dataRepo, "/*")))
+	require.NoError(t, err)
+	require.Equal(t, 0, len(problems))
+	_, err = c.InspectPipeline("first")
+	require.YesError(t, err)
+
+	// Every problem with the spec is reported, not just the first
+	invalid := request("first", client.NewPFSInput(dataRepo, "/*"))
+	invalid.MaxConcurrentJobs = -1
+	invalid.Egress = &pps.Egress{Incremental: true}
+	invalid.ParallelismSpec = &pps.ParallelismSpec{Coefficient: -1}
+	invalid.CacheSize = "lots"
+	problems, err = c.ValidatePipeline(invalid)
+	require.NoError(t, err)
+	require.Equal(t, 4, len(problems))
+	require.Matches(t, "max_concurrent_jobs", problems[0].Message)
+	require.Matches(t, "egress", problems[1].Message)
+	require.Matches(t, "Coefficient", problems[2].Message)
+	require.Matches(t, "cacheSize", problems[3].Message)
+
+	// Missing repos are errors, and globs that match nothing are warnings
+	problems, err = c.ValidatePipeline(request("first", client.NewCrossInput(
+		client.NewPFSInput(dataRepo, "/*.csv"),
+		client.NewPFSInput("no-such-repo", "/*"),
+	)))
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		fmt.Sprintf("warning: glob \"/*.csv\" matches no files in %s@master", dataRepo),
+		"error: input repo \"no-such-repo\" does not exist",
+	}, messages(problems))
+
+	// Later pipelines may read from earlier ones
+	problems, err = c.ValidatePipeline(
+		request("first", client.NewPFSInput(dataRepo, "/*")),
+		request("second", client.NewPFSInput("first", "/*")),
+	)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(problems))
+
+	// Joins whose keys never match are warnings
+	left := client.NewPFSInput(dataRepo, "/(*).txt")
+	left.Pfs.Name = "left"
+	left.Pfs.JoinOn = "$1"
+	right := client.NewPFSInput(dataRepo, "/(*).txt")
+	right.Pfs.Name = "right"
+	right.Pfs.JoinOn = "x$1"
+	problems, err = c.ValidatePipeline(request("first", client.NewJoinInput(left, right)))
+	require.NoError(t, err)
+	require.Equal(t, 1, len(problems))
+	require.True(t, problems[0].Warning)
+	require.Matches(t, "produces no datums", problems[0].Message)
+
+	// Resources and pod patches that CreatePipeline would accept, but that
+	// couldn't be applied
+	badResources := request("first", client.NewPFSInput(dataRepo, "/*"))
+	badResources.ResourceRequests = &pps.ResourceSpec{Memory: "lots"}
+	badResources.PodPatch = `[{"op": "replace", "path": "/no/such/path", "value": 1}]`
+	problems, err = c.ValidatePipeline(badResources)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(problems))
+	require.Matches(t, "invalid resource_requests", problems[0].Message)
+	require.Matches(t, "invalid pod_spec or pod_patch", problems[1].Message)
+
+	// Pipelines that would read from each other are errors, including when the
+	// cycle runs through an existing pipeline
+	require.NoError(t, c.CreatePipeline(
+		"first",
+		"",
+		[]string{"bash"},
+		[]string{fmt.Sprintf("cp /pfs/%s/* /pfs/out/", dataRepo)},
+		nil,
+		client.NewPFSInput(dataRepo, "/*"),
+		"",
+		false,
+	))
+	commitIter, err := c.FlushCommit([]*pfs.Commit{client.NewCommit(dataRepo, "master")}, []*pfs.Repo{client.NewRepo("first")})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(collectCommitInfos(t, commitIter)))
+	update := request("first", client.NewPFSInput("second", "/*"))
+	update.Update = true
+	problems, err = c.ValidatePipeline(
+		request("second", client.NewPFSInput("first", "/*")),
+		update,
+	)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"error: pipeline is part of a cycle: first -> second -> first",
+		"error: pipeline is part of a cycle: first -> second -> first",
+	}, messages(problems))
+
+	// Existing pipelines can only be updated, and missing ones can only be
+	// created
+	missing := request("missing", client.NewPFSInput(dataRepo, "/*"))
+	missing.Update = true
+	problems, err = c.ValidatePipeline(
+		request("first", client.NewPFSInput(dataRepo, "/*")),
+		missing,
+	)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"error: pipeline \"first\" already exists; set 'update' to update it",
+		"error: pipeline \"missing\" does not exist, so it can't be updated",
+	}, messages(problems))
+}
+
 func TestLazyPipelinePropagation(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")
@@ -2931,6 +3188,67 @@ func TestUpdatePipeline(t *testing.T) {
 	require.Equal(t, "buzz\n", buffer.String())
 }
 
+func TestRollbackPipeline(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	c := tu.GetPachClient(t)
+	require.NoError(t, c.DeleteAll())
+	dataRepo := tu.UniqueString("TestRollbackPipeline_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	pipeline := tu.UniqueString("TestRollbackPipeline")
+	createPipeline := func(output string) {
+		require.NoError(t, c.CreatePipeline(
+			pipeline,
+			"",
+			[]string{"bash"},
+			[]string{fmt.Sprintf("echo %s >/pfs/out/file", output)},
+			nil,
+			client.NewPFSInput(dataRepo, "/*"),
+			"",
+			true,
+		))
+	}
+	checkOutput := func(expected string) {
+		commitIter, err := c.FlushCommit([]*pfs.Commit{client.NewCommit(dataRepo, "master")}, []*pfs.Repo{client.NewRepo(pipeline)})
+		require.NoError(t, err)
+		collectCommitInfos(t, commitIter)
+		var buf bytes.Buffer
+		require.NoError(t, c.GetFile(pipeline, "master", "file", 0, 0, &buf))
+		require.Equal(t, expected+"\n", buf.String())
+	}
+	_, err := c.PutFile(dataRepo, "master", "file", strings.NewReader("1"))
+	require.NoError(t, err)
+	createPipeline("foo")
+	checkOutput("foo")
+	createPipeline("bar")
+	checkOutput("bar")
+	createPipeline("baz")
+	checkOutput("baz")
+
+	// A pipeline can't be rolled back to its current version or one that
+	// doesn't exist
+	require.YesError(t, c.RollbackPipeline(pipeline, 3, false))
+	require.YesError(t, c.RollbackPipeline(pipeline, 10, false))
+
+	// By default, a pipeline is rolled back to the version before its current
+	// one, which becomes a new version
+	require.NoError(t, c.RollbackPipeline(pipeline, 0, true))
+	checkOutput("bar")
+	pipelineInfo, err := c.InspectPipeline(pipeline)
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), pipelineInfo.Version)
+
+	// It can also be rolled back to a specific version
+	require.NoError(t, c.RollbackPipeline(pipeline, 1, true))
+	checkOutput("foo")
+	pipelineInfo, err = c.InspectPipeline(pipeline)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), pipelineInfo.Version)
+	require.Equal(t, []string{"echo foo >/pfs/out/file"}, pipelineInfo.Transform.Stdin)
+}
+
 func TestUpdatePipelineWithInProgressCommitsAndStats(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")
@@ -3865,6 +4183,52 @@ func TestPipelineThatSymlinks(t *testing.T) {
 	}
 }
 
+func TestPipelineOutputModes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	c := tu.GetPachClient(t)
+	require.NoError(t, c.DeleteAll())
+
+	dataRepo := tu.UniqueString("TestPipelineOutputModes_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	_, err := c.PutFile(dataRepo, "master", "file", strings.NewReader("foo"))
+	require.NoError(t, err)
+
+	pipelineName := tu.UniqueString("pipeline")
+	require.NoError(t, c.CreatePipeline(
+		pipelineName,
+		"",
+		[]string{"bash"},
+		[]string{
+			"echo 'echo foo' > /pfs/out/script",
+			"chmod 0750 /pfs/out/script",
+			"echo foo > /tmp/external",
+			"chmod 0600 /tmp/external",
+			// The target's mode is recorded for links outside of /pfs
+			"ln -s /tmp/external /pfs/out/external",
+		},
+		&pps.ParallelismSpec{
+			Constant: 1,
+		},
+		client.NewPFSInput(dataRepo, "/"),
+		"",
+		false,
+	))
+
+	commitInfoIter, err := c.FlushCommit([]*pfs.Commit{client.NewCommit(dataRepo, "master")}, nil)
+	require.NoError(t, err)
+	commitInfos := collectCommitInfos(t, commitInfoIter)
+	require.Equal(t, 1, len(commitInfos))
+	for path, mode := range map[string]uint32{"script": 0750, "external": 0600} {
+		fileInfo, err := c.InspectFile(pipelineName, commitInfos[0].Commit.ID, path)
+		require.NoError(t, err)
+		require.Equal(t, pfs.FileType_FILE, fileInfo.FileType)
+		require.Equal(t, mode, fileInfo.Mode)
+	}
+}
+
 // TestChainedPipelines tracks https://github.com/pachyderm/pachyderm/issues/797
 func TestChainedPipelines(t *testing.T) {
 	if testing.Short() {
@@ -5243,6 +5607,73 @@ func TestJoinInput(t *testing.T) {
 	}
 }
 
+func TestOuterJoinInputDirs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	c := tu.GetPachClient(t)
+	require.NoError(t, c.DeleteAll())
+
+	files := map[string][]string{
+		"left":  {"a", "b"},
+		"right": {"a"},
+		"other": {"c"},
+	}
+	repos := make(map[string]string)
+	var commits []*pfs.Commit
+	for name, names := range files {
+		repo := tu.UniqueString("TestOuterJoinInputDirs_" + name)
+		repos[name] = repo
+		require.NoError(t, c.CreateRepo(repo))
+		commit, err := c.StartCommit(repo, "master")
+		require.NoError(t, err)
+		commits = append(commits, commit)
+		for _, n := range names {
+			_, err = c.PutFile(repo, commit.ID, n+".txt", strings.NewReader(n))
+			require.NoError(t, err)
+		}
+		require.NoError(t, c.FinishCommit(repo, commit.ID))
+	}
+
+	// Each datum records which input directories it sees. The right side of
+	// the outer join gets an empty directory when it has no file for a key,
+	// but the other side of the union doesn't.
+	left := client.NewPFSInputOpts("left", repos["left"], "", "/(*).txt", "$1", false)
+	left.Pfs.OuterJoin = true
+	right := client.NewPFSInputOpts("right", repos["right"], "", "/(*).txt", "$1", false)
+	pipeline := tu.UniqueString("TestOuterJoinInputDirs")
+	require.NoError(t, c.CreatePipeline(
+		pipeline,
+		"",
+		[]string{"bash"},
+		[]string{
+			"key=$(cat /pfs/left/* /pfs/other/* 2>/dev/null)",
+			"for d in left right other; do if [ -d /pfs/$d ]; then echo $d; fi; done > /pfs/out/$key",
+		},
+		nil,
+		client.NewUnionInput(
+			client.NewJoinInput(left, right),
+			client.NewPFSInputOpts("other", repos["other"], "", "/*", "", false),
+		),
+		"",
+		false,
+	))
+
+	commitInfos, err := c.FlushCommitAll(commits, []*pfs.Repo{client.NewRepo(pipeline)})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(commitInfos))
+	for key, expected := range map[string]string{
+		"a": "left\nright\n",
+		"b": "left\nright\n",
+		"c": "other\n",
+	} {
+		var buf bytes.Buffer
+		require.NoError(t, c.GetFile(pipeline, commitInfos[0].Commit.ID, key, 0, 0, &buf))
+		require.Equal(t, expected, buf.String())
+	}
+}
+
 func TestUnionRegression4688(t *testing.T) {
 	c := tu.GetPachClient(t)
 	require.NoError(t, c.DeleteAll())
@@ -6078,7 +6509,7 @@ func TestPipelineWithStatsFailedDatums(t *testing.T) {
 	require.Equal(t, pps.DatumState_FAILED, datum.State)
 }
 
-func TestPipelineWithStatsPaginated(t *testing.T) {
+func TestListDatumInput(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")
 	}
@@ -6086,43 +6517,165 @@ func TestPipelineWithStatsPaginated(t *testing.T) {
 	c := tu.GetPachClient(t)
 	require.NoError(t, c.DeleteAll())
 
-	dataRepo := tu.UniqueString("TestPipelineWithStatsPaginated_data")
-	require.NoError(t, c.CreateRepo(dataRepo))
+	repoA := tu.UniqueString("TestListDatumInput_A")
+	require.NoError(t, c.CreateRepo(repoA))
+	repoB := tu.UniqueString("TestListDatumInput_B")
+	require.NoError(t, c.CreateRepo(repoB))
+	for i := 0; i < 3; i++ {
+		_, err := c.PutFile(repoA, "master", fmt.Sprintf("file-%d", i), strings.NewReader("foo\n"))
+		require.NoError(t, err)
+	}
+	for i := 0; i < 2; i++ {
+		_, err := c.PutFile(repoB, "master", fmt.Sprintf("file-%d", i), strings.NewReader("bar\n"))
+		require.NoError(t, err)
+	}
 
-	numPages := int64(2)
-	pageSize := int64(10)
-	numFiles := int(numPages * pageSize)
-	commit1, err := c.StartCommit(dataRepo, "master")
+	// The datums of a bare input can be listed without creating a pipeline
+	resp, err := c.ListDatumInput(client.NewPFSInput(repoA, "/*"), 0, 0)
 	require.NoError(t, err)
-	for i := 0; i < numFiles; i++ {
-		_, err = c.PutFile(dataRepo, commit1.ID, fmt.Sprintf("file-%d", i), strings.NewReader(strings.Repeat("foo\n", 100)))
+	require.Equal(t, 3, len(resp.DatumInfos))
+	for _, datumInfo := range resp.DatumInfos {
+		require.Equal(t, pps.DatumState_STARTING, datumInfo.State)
+		require.Equal(t, 1, len(datumInfo.Data))
 	}
-	require.NoError(t, c.FinishCommit(dataRepo, commit1.ID))
 
-	pipeline := tu.UniqueString("pipeline")
-	_, err = c.PpsAPIClient.CreatePipeline(context.Background(),
-		&pps.CreatePipelineRequest{
-			Pipeline: client.NewPipeline(pipeline),
-			Transform: &pps.Transform{
-				Cmd: []string{"bash"},
-				Stdin: []string{
-					fmt.Sprintf("if [ -f /pfs/%s/file-5 ]; then exit 1; fi", dataRepo),
-					fmt.Sprintf("cp /pfs/%s/* /pfs/out/", dataRepo),
-				},
-			},
-			Input:       client.NewPFSInput(dataRepo, "/*"),
-			EnableStats: true,
-			ParallelismSpec: &pps.ParallelismSpec{
-				Constant: 4,
-			},
-		})
+	// Cross inputs are expanded the same way a pipeline's workers would
+	resp, err = c.ListDatumInput(client.NewCrossInput(
+		client.NewPFSInput(repoA, "/*"),
+		client.NewPFSInput(repoB, "/*"),
+	), 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, 6, len(resp.DatumInfos))
+	require.Equal(t, int64(6), resp.TotalDatums)
+	require.Equal(t, 2, len(resp.DatumInfos[0].Data))
 
-	commitIter, err := c.FlushCommit([]*pfs.Commit{commit1}, nil)
+	// Pagination works as it does for jobs, and the total counts every page
+	resp, err = c.ListDatumInput(client.NewCrossInput(
+		client.NewPFSInput(repoA, "/*"),
+		client.NewPFSInput(repoB, "/*"),
+	), 4, 1)
 	require.NoError(t, err)
-	commitInfos := collectCommitInfos(t, commitIter)
-	require.Equal(t, 2, len(commitInfos))
+	require.Equal(t, 2, len(resp.DatumInfos))
+	require.Equal(t, int64(2), resp.TotalPages)
+	require.Equal(t, int64(6), resp.TotalDatums)
 
-	var jobs []*pps.JobInfo
+	// No pipelines or jobs were created
+	pipelineInfos, err := c.ListPipeline()
+	require.NoError(t, err)
+	require.Equal(t, 0, len(pipelineInfos))
+}
+
+func TestPipelineInputTrigger(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	c := tu.GetPachClient(t)
+	require.NoError(t, c.DeleteAll())
+
+	dataRepo := tu.UniqueString("TestPipelineInputTrigger_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	pipeline := tu.UniqueString("TestPipelineInputTrigger")
+	input := client.NewPFSInputOpts("in", dataRepo, "trigger", "/", "", false)
+	input.Pfs.Trigger = &pfs.Trigger{Branch: "master", Commits: 2}
+	require.NoError(t, c.CreatePipeline(
+		pipeline,
+		"",
+		[]string{"bash"},
+		[]string{"cp /pfs/in/* /pfs/out/"},
+		nil,
+		input,
+		"",
+		false,
+	))
+
+	// The first commit doesn't fire the trigger
+	commit1, err := c.StartCommit(dataRepo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(dataRepo, commit1.ID, "file1", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(dataRepo, commit1.ID))
+	branchInfo, err := c.InspectBranch(dataRepo, "trigger")
+	require.NoError(t, err)
+	require.Nil(t, branchInfo.Head)
+
+	// The second one does, and the job sees both commits' files
+	commit2, err := c.StartCommit(dataRepo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(dataRepo, commit2.ID, "file2", strings.NewReader("bar\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(dataRepo, commit2.ID))
+	commitIter, err := c.FlushCommit([]*pfs.Commit{client.NewCommit(dataRepo, "trigger")}, []*pfs.Repo{client.NewRepo(pipeline)})
+	require.NoError(t, err)
+	commitInfos := collectCommitInfos(t, commitIter)
+	require.Equal(t, 1, len(commitInfos))
+	fileInfos, err := c.ListFile(pipeline, commitInfos[0].Commit.ID, "")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(fileInfos))
+
+	// Creating the pipeline again fails, and leaves the input's trigger as it
+	// was
+	input.Pfs.Trigger = &pfs.Trigger{Branch: "master", Commits: 5}
+	require.YesError(t, c.CreatePipeline(
+		pipeline,
+		"",
+		[]string{"bash"},
+		[]string{"cp /pfs/in/* /pfs/out/"},
+		nil,
+		input,
+		"",
+		false,
+	))
+	branchInfo, err = c.InspectBranch(dataRepo, "trigger")
+	require.NoError(t, err)
+	require.Equal(t, int64(2), branchInfo.Trigger.Commits)
+}
+
+func TestPipelineWithStatsPaginated(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	c := tu.GetPachClient(t)
+	require.NoError(t, c.DeleteAll())
+
+	dataRepo := tu.UniqueString("TestPipelineWithStatsPaginated_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+
+	numPages := int64(2)
+	pageSize := int64(10)
+	numFiles := int(numPages * pageSize)
+	commit1, err := c.StartCommit(dataRepo, "master")
+	require.NoError(t, err)
+	for i := 0; i < numFiles; i++ {
+		_, err = c.PutFile(dataRepo, commit1.ID, fmt.Sprintf("file-%d", i), strings.NewReader(strings.Repeat("foo\n", 100)))
+	}
+	require.NoError(t, c.FinishCommit(dataRepo, commit1.ID))
+
+	pipeline := tu.UniqueString("pipeline")
+	_, err = c.PpsAPIClient.CreatePipeline(context.Background(),
+		&pps.CreatePipelineRequest{
+			Pipeline: client.NewPipeline(pipeline),
+			Transform: &pps.Transform{
+				Cmd: []string{"bash"},
+				Stdin: []string{
+					fmt.Sprintf("if [ -f /pfs/%s/file-5 ]; then exit 1; fi", dataRepo),
+					fmt.Sprintf("cp /pfs/%s/* /pfs/out/", dataRepo),
+				},
+			},
+			Input:       client.NewPFSInput(dataRepo, "/*"),
+			EnableStats: true,
+			ParallelismSpec: &pps.ParallelismSpec{
+				Constant: 4,
+			},
+		})
+
+	commitIter, err := c.FlushCommit([]*pfs.Commit{commit1}, nil)
+	require.NoError(t, err)
+	commitInfos := collectCommitInfos(t, commitIter)
+	require.Equal(t, 2, len(commitInfos))
+
+	var jobs []*pps.JobInfo
 	require.NoError(t, backoff.Retry(func() error {
 		jobs, err = c.ListJob(pipeline, nil, nil, -1, true)
 		require.NoError(t, err)
@@ -7947,6 +8500,182 @@ func TestPipelineWithGitInputCustomName(t *testing.T) {
 	require.Equal(t, "9047fbfc251e7412ef3300868f743f2c24852539", strings.TrimSpace(buf.String()))
 }
 
+func TestPipelineWithWebhookInput(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	c := tu.GetPachClient(t)
+	require.NoError(t, c.DeleteAll())
+
+	require.NoError(t, c.CreateSecret([]byte(`{
+		"kind": "Secret",
+		"apiVersion": "v1",
+		"metadata": {
+			"name": "webhook-secret"
+		},
+		"stringData": {
+			"secret": "s3cr3t"
+		}
+	}`)))
+	pipeline := tu.UniqueString("webhook_pipeline")
+	require.NoError(t, c.CreatePipeline(
+		pipeline,
+		"",
+		[]string{"bash"},
+		[]string{"cp /pfs/events/* /pfs/out/"},
+		nil,
+		&pps.Input{
+			Webhook: &pps.WebhookInput{
+				Name:   "events",
+				Secret: "webhook-secret",
+			},
+		},
+		"",
+		false,
+	))
+	// The input repo is created with the pipeline
+	inputRepo := pipeline + "_events"
+	_, err := c.InspectRepo(inputRepo)
+	require.NoError(t, err)
+
+	// Requests without the right secret are rejected
+	require.Equal(t, http.StatusUnauthorized, postWebhook(t, pipeline, "events", "", "foo"))
+	require.Equal(t, http.StatusUnauthorized, postWebhook(t, pipeline, "events", "wrong", "foo"))
+	require.Equal(t, http.StatusNotFound, postWebhook(t, pipeline, "nope", "s3cr3t", "foo"))
+	commits, err := c.ListCommit(inputRepo, "", "", 0)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(commits))
+
+	// Each accepted request is committed to the input repo, and processed as a
+	// new datum
+	for i, payload := range []string{"foo", "bar"} {
+		require.Equal(t, http.StatusOK, postWebhook(t, pipeline, "events", "s3cr3t", payload))
+		commitIter, err := c.FlushCommit([]*pfs.Commit{client.NewCommit(inputRepo, "master")}, []*pfs.Repo{client.NewRepo(pipeline)})
+		require.NoError(t, err)
+		commitInfos := collectCommitInfos(t, commitIter)
+		require.Equal(t, 1, len(commitInfos))
+		files, err := c.ListFile(pipeline, commitInfos[0].Commit.ID, "")
+		require.NoError(t, err)
+		require.Equal(t, i+1, len(files))
+		var buf bytes.Buffer
+		require.NoError(t, c.GetFile(pipeline, commitInfos[0].Commit.ID, files[i].File.Path, 0, 0, &buf))
+		require.Equal(t, payload, buf.String())
+	}
+
+	pipelineInfo, err := c.InspectPipeline(pipeline)
+	require.NoError(t, err)
+	require.NotEqual(t, "", pipelineInfo.WebhookURL)
+
+	// Updating the pipeline keeps using its input repo
+	require.NoError(t, c.CreatePipeline(
+		pipeline,
+		"",
+		[]string{"bash"},
+		[]string{"cp /pfs/events/* /pfs/out/"},
+		nil,
+		pipelineInfo.Input,
+		"",
+		true,
+	))
+
+	// Other pipelines can't write payloads to repos that they didn't create,
+	// whether they name the repo or it already exists
+	dataRepo := tu.UniqueString("TestPipelineWithWebhookInput_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	other := tu.UniqueString("webhook_pipeline")
+	createOther := func(input *pps.WebhookInput) error {
+		return c.CreatePipeline(
+			other,
+			"",
+			[]string{"bash"},
+			[]string{"cp /pfs/events/* /pfs/out/"},
+			nil,
+			&pps.Input{Webhook: input},
+			"",
+			false,
+		)
+	}
+	require.YesError(t, createOther(&pps.WebhookInput{Name: "events", Repo: dataRepo}))
+	require.NoError(t, c.CreateRepo(other+"_events"))
+	require.YesError(t, createOther(&pps.WebhookInput{Name: "events"}))
+}
+
+func TestPipelineWithObjectStoreInput(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	c := tu.GetPachClient(t)
+	require.NoError(t, c.DeleteAll())
+
+	pipeline := tu.UniqueString("object_store_pipeline")
+	createPipeline := func(input *pps.Input) error {
+		return c.CreatePipeline(
+			pipeline,
+			"",
+			[]string{"bash"},
+			[]string{"cp /pfs/data/* /pfs/out/"},
+			nil,
+			input,
+			"",
+			false,
+		)
+	}
+	require.NoError(t, c.CreateSecret([]byte(`{
+		"kind": "Secret",
+		"apiVersion": "v1",
+		"metadata": {
+			"name": "object-store-creds"
+		},
+		"stringData": {
+			"amazon-region": "us-east-1",
+			"amazon-id": "id",
+			"amazon-secret": "secret"
+		}
+	}`)))
+	newInput := func(name, url string) *pps.Input {
+		input := client.NewObjectStoreInput(name, url)
+		input.ObjectStore.Secret = "object-store-creds"
+		return input
+	}
+	require.YesError(t, createPipeline(newInput("data", "")))
+	require.YesError(t, createPipeline(newInput("out", "s3://bucket/data/")))
+	input := newInput("data", "s3://bucket/data/")
+	input.ObjectStore.Interval = types.DurationProto(time.Millisecond)
+	require.YesError(t, createPipeline(input))
+	// Inputs must bring their own credentials, and can't read pachd's disk
+	require.YesError(t, createPipeline(client.NewObjectStoreInput("data", "s3://bucket/data/")))
+	require.YesError(t, createPipeline(newInput("data", "local://tmp/data/")))
+	// Objects can only be written to a repo that the pipeline creates
+	dataRepo := tu.UniqueString("TestPipelineWithObjectStoreInput_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	input = newInput("data", "s3://bucket/data/")
+	input.ObjectStore.Repo = dataRepo
+	require.YesError(t, createPipeline(input))
+	require.NoError(t, c.CreateRepo(pipeline+"_data"))
+	require.YesError(t, createPipeline(newInput("data", "s3://bucket/data/")))
+	require.NoError(t, c.DeleteRepo(pipeline+"_data", false))
+
+	require.NoError(t, createPipeline(newInput("data", fmt.Sprintf("s3://bucket/%s/", pipeline))))
+	// The input repo is created with the pipeline
+	_, err := c.InspectRepo(pipeline + "_data")
+	require.NoError(t, err)
+	pipelineInfo, err := c.InspectPipeline(pipeline)
+	require.NoError(t, err)
+	objectStore := pipelineInfo.Input.ObjectStore
+	require.Equal(t, pipeline+"_data", objectStore.Repo)
+	require.Equal(t, "/*", objectStore.Glob)
+	interval, err := types.DurationFromProto(objectStore.Interval)
+	require.NoError(t, err)
+	require.Equal(t, time.Minute, interval)
+
+	// The input repo is deleted with the pipeline
+	require.NoError(t, c.DeletePipeline(pipeline, false))
+	_, err = c.InspectRepo(pipeline + "_data")
+	require.YesError(t, err)
+}
+
 func TestPipelineWithGitInputMultiPipelineSeparateInputs(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")
@@ -9464,6 +10193,245 @@ func TestDatumTries(t *testing.T) {
 	require.Equal(t, tries, observedTries)
 }
 
+func TestDeadLetter(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	c := tu.GetPachClient(t)
+	require.NoError(t, c.DeleteAll())
+
+	dataRepo := tu.UniqueString("TestDeadLetter_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	commit, err := c.StartCommit(dataRepo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(dataRepo, commit.ID, "file1", strings.NewReader("good"))
+	require.NoError(t, err)
+	_, err = c.PutFile(dataRepo, commit.ID, "file2", strings.NewReader("bad"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
+
+	// The pipeline fails on any file that contains "bad"
+	pipeline := tu.UniqueString("TestDeadLetter")
+	_, err = c.PpsAPIClient.CreatePipeline(
+		context.Background(),
+		&pps.CreatePipelineRequest{
+			Pipeline: client.NewPipeline(pipeline),
+			Transform: &pps.Transform{
+				Cmd: []string{"bash"},
+				Stdin: []string{
+					fmt.Sprintf("if grep -q bad /pfs/%s/*; then echo 'cannot process bad'; exit 3; fi", dataRepo),
+					fmt.Sprintf("cp /pfs/%s/* /pfs/out/", dataRepo),
+				},
+			},
+			Input:      client.NewPFSInput(dataRepo, "/*"),
+			DatumTries: 2,
+			DeadLetter: true,
+		})
+	require.NoError(t, err)
+
+	// The job succeeds, but the bad datum ends up in the dead letter repo
+	jobInfos, err := c.FlushJobAll([]*pfs.Commit{client.NewCommit(dataRepo, "master")}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(jobInfos))
+	require.Equal(t, pps.JobState_JOB_SUCCESS, jobInfos[0].State)
+	require.Equal(t, int64(1), jobInfos[0].DataFailed)
+	fileInfos, err := c.ListFile(pipeline, "master", "")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(fileInfos))
+	deadLetters, err := c.ListDeadLetters(pipeline)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(deadLetters))
+	require.Equal(t, int64(3), deadLetters[0].ExitCode)
+	require.True(t, strings.Contains(deadLetters[0].Output, "cannot process bad"))
+	require.Equal(t, jobInfos[0].Job.ID, deadLetters[0].DatumInfo.Datum.Job.ID)
+	firstJob := jobInfos[0].Job.ID
+
+	// Later jobs skip the dead-lettered datum, and keep its record
+	_, err = c.PutFile(dataRepo, "master", "file3", strings.NewReader("good"))
+	require.NoError(t, err)
+	jobInfos, err = c.FlushJobAll([]*pfs.Commit{client.NewCommit(dataRepo, "master")}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(jobInfos))
+	require.Equal(t, pps.JobState_JOB_SUCCESS, jobInfos[0].State)
+	require.Equal(t, int64(1), jobInfos[0].DataProcessed)
+	require.Equal(t, int64(2), jobInfos[0].DataSkipped)
+	require.Equal(t, int64(0), jobInfos[0].DataFailed)
+	deadLetters, err = c.ListDeadLetters(pipeline)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(deadLetters))
+	require.Equal(t, firstJob, deadLetters[0].DatumInfo.Datum.Job.ID)
+
+	// Resubmitting clears the record and retries exactly the dead-lettered
+	// datum, which is dead-lettered again because it still fails
+	require.NoError(t, c.ResubmitDeadLetters(pipeline))
+	var resubmitJob *pps.JobInfo
+	require.NoError(t, backoff.Retry(func() error {
+		jobInfos, err := c.ListJob(pipeline, nil, nil, -1, true)
+		if err != nil {
+			return err
+		}
+		if len(jobInfos) != 3 || !ppsutil.IsTerminal(jobInfos[0].State) {
+			return errors.Errorf("expected the resubmitted job to finish")
+		}
+		resubmitJob = jobInfos[0]
+		return nil
+	}, backoff.NewTestingBackOff()))
+	require.Equal(t, pps.JobState_JOB_SUCCESS, resubmitJob.State)
+	require.Equal(t, int64(0), resubmitJob.DataProcessed)
+	require.Equal(t, int64(2), resubmitJob.DataSkipped)
+	require.Equal(t, int64(1), resubmitJob.DataFailed)
+	deadLetters, err = c.ListDeadLetters(pipeline)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(deadLetters))
+	require.Equal(t, resubmitJob.Job.ID, deadLetters[0].DatumInfo.Datum.Job.ID)
+}
+
+func TestDeletePipelineDeletesNotifiers(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	c := tu.GetPachClient(t)
+	require.NoError(t, c.DeleteAll())
+
+	dataRepo := tu.UniqueString("TestDeletePipelineDeletesNotifiers_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	pipeline := tu.UniqueString("TestDeletePipelineDeletesNotifiers")
+	require.NoError(t, c.CreatePipeline(
+		pipeline,
+		"",
+		[]string{"bash"},
+		[]string{fmt.Sprintf("cp /pfs/%s/* /pfs/out/", dataRepo)},
+		nil,
+		client.NewPFSInput(dataRepo, "/*"),
+		"",
+		false,
+	))
+	require.NoError(t, c.CreateNotifier("pipeline-notifier", "https://hooks.example.com/pipeline", pipeline, nil, "", false))
+	require.NoError(t, c.CreateNotifier("global-notifier", "https://hooks.example.com/global", "", nil, "", false))
+
+	// Notifiers can't send requests inside the cluster
+	require.YesError(t, c.CreateNotifier("internal-notifier", "http://pachd:650/", pipeline, nil, "", false))
+
+	// Deleting the pipeline deletes its notifiers, but not the others
+	require.NoError(t, c.DeletePipeline(pipeline, false))
+	notifiers, err := c.ListNotifier("")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(notifiers))
+	require.Equal(t, "global-notifier", notifiers[0].Name)
+}
+
+func TestDeadLetterRepoAlreadyExists(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	c := tu.GetPachClient(t)
+	require.NoError(t, c.DeleteAll())
+
+	dataRepo := tu.UniqueString("TestDeadLetterRepoAlreadyExists_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	pipeline := tu.UniqueString("TestDeadLetterRepoAlreadyExists")
+	require.NoError(t, c.CreateRepo(pps.DeadLetterRepo(pipeline)))
+
+	// A pipeline can't take over a repo it didn't create as its dead letter repo
+	request := &pps.CreatePipelineRequest{
+		Pipeline: client.NewPipeline(pipeline),
+		Transform: &pps.Transform{
+			Cmd:   []string{"bash"},
+			Stdin: []string{fmt.Sprintf("cp /pfs/%s/* /pfs/out/", dataRepo)},
+		},
+		Input:      client.NewPFSInput(dataRepo, "/*"),
+		DeadLetter: true,
+	}
+	_, err := c.PpsAPIClient.CreatePipeline(context.Background(), request)
+	require.YesError(t, err)
+	require.Matches(t, "didn't create it", err.Error())
+	request.Update = true
+	_, err = c.PpsAPIClient.CreatePipeline(context.Background(), request)
+	require.YesError(t, err)
+
+	// Once the repo is gone, the pipeline creates its own, and keeps it when
+	// it's updated
+	require.NoError(t, c.DeleteRepo(pps.DeadLetterRepo(pipeline), false))
+	request.Update = false
+	_, err = c.PpsAPIClient.CreatePipeline(context.Background(), request)
+	require.NoError(t, err)
+	request.Update = true
+	_, err = c.PpsAPIClient.CreatePipeline(context.Background(), request)
+	require.NoError(t, err)
+}
+
+func TestMaxConcurrentJobs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	c := tu.GetPachClient(t)
+	require.NoError(t, c.DeleteAll())
+
+	createPipeline := func(pipeline, repo, cmd string, maxConcurrentJobs int64) {
+		require.NoError(t, c.CreateRepo(repo))
+		_, err := c.PpsAPIClient.CreatePipeline(
+			context.Background(),
+			&pps.CreatePipelineRequest{
+				Pipeline: client.NewPipeline(pipeline),
+				Transform: &pps.Transform{
+					Cmd:   []string{"bash"},
+					Stdin: []string{cmd, fmt.Sprintf("cp /pfs/%s/* /pfs/out/", repo)},
+				},
+				Input:             client.NewPFSInput(repo, "/*"),
+				MaxConcurrentJobs: maxConcurrentJobs,
+			})
+		require.NoError(t, err)
+	}
+	otherRepo := tu.UniqueString("TestMaxConcurrentJobs_other_data")
+	other := tu.UniqueString("TestMaxConcurrentJobs_other")
+	createPipeline(other, otherRepo, "sleep 20", 0)
+	limitedRepo := tu.UniqueString("TestMaxConcurrentJobs_limited_data")
+	limited := tu.UniqueString("TestMaxConcurrentJobs_limited")
+	createPipeline(limited, limitedRepo, "sleep 10", 1)
+	waitForStates := func(pipeline string, states ...pps.JobState) {
+		require.NoError(t, backoff.Retry(func() error {
+			jobInfos, err := c.ListJob(pipeline, nil, nil, -1, true)
+			if err != nil {
+				return err
+			}
+			if len(jobInfos) != len(states) {
+				return errors.Errorf("expected %d jobs, got %d", len(states), len(jobInfos))
+			}
+			// ListJob returns the newest job first
+			for i, state := range states {
+				if jobInfos[len(jobInfos)-1-i].State != state {
+					return errors.Errorf("expected job %d to be %v, got %v", i, state, jobInfos[len(jobInfos)-1-i].State)
+				}
+			}
+			return nil
+		}, backoff.NewTestingBackOff()))
+	}
+
+	// Another pipeline's running job doesn't hold up the limited pipeline's
+	_, err := c.PutFile(otherRepo, "master", "file", strings.NewReader("foo"))
+	require.NoError(t, err)
+	waitForStates(other, pps.JobState_JOB_RUNNING)
+	_, err = c.PutFile(limitedRepo, "master", "file1", strings.NewReader("foo"))
+	require.NoError(t, err)
+	waitForStates(limited, pps.JobState_JOB_RUNNING)
+
+	// But while the limited pipeline's job runs, its next job has to wait
+	_, err = c.PutFile(limitedRepo, "master", "file2", strings.NewReader("foo"))
+	require.NoError(t, err)
+	waitForStates(limited, pps.JobState_JOB_RUNNING, pps.JobState_JOB_QUEUED)
+
+	// Once the first job finishes, the next one runs
+	jobInfos, err := c.FlushJobAll([]*pfs.Commit{client.NewCommit(limitedRepo, "master")}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(jobInfos))
+	require.Equal(t, pps.JobState_JOB_SUCCESS, jobInfos[0].State)
+	waitForStates(limited, pps.JobState_JOB_SUCCESS, pps.JobState_JOB_SUCCESS)
+}
+
 func TestInspectJob(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")
@@ -11257,6 +12225,96 @@ func TestKeepRepo(t *testing.T) {
 	require.NoError(t, c.DeletePipeline(pipeline, false))
 }
 
+func TestDatumCache(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	c := tu.GetPachClient(t)
+	require.NoError(t, c.DeleteAll())
+
+	dataRepo := tu.UniqueString("TestDatumCache_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	commit, err := c.StartCommit(dataRepo, "master")
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		_, err = c.PutFile(dataRepo, commit.ID, fmt.Sprintf("file%d", i), strings.NewReader(fmt.Sprintf("%d", i)))
+		require.NoError(t, err)
+	}
+	require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
+
+	createPipeline := func(pipeline string, stdin string) {
+		_, err := c.PpsAPIClient.CreatePipeline(
+			context.Background(),
+			&pps.CreatePipelineRequest{
+				Pipeline: client.NewPipeline(pipeline),
+				Transform: &pps.Transform{
+					Cmd:   []string{"bash"},
+					Stdin: []string{stdin},
+				},
+				Input:      client.NewPFSInput(dataRepo, "/*"),
+				DatumCache: true,
+			})
+		require.NoError(t, err)
+	}
+	flushJob := func(pipeline string) *pps.JobInfo {
+		jis, err := c.FlushJobAll([]*pfs.Commit{commit}, []string{pipeline})
+		require.NoError(t, err)
+		require.Equal(t, 1, len(jis))
+		require.Equal(t, pps.JobState_JOB_SUCCESS, jis[0].State)
+		return jis[0]
+	}
+	stdin := fmt.Sprintf("cp /pfs/%s/* /pfs/out/", dataRepo)
+
+	first := tu.UniqueString("TestDatumCache_first")
+	createPipeline(first, stdin)
+	jobInfo := flushJob(first)
+	require.Equal(t, int64(3), jobInfo.DataProcessed)
+	require.Equal(t, int64(0), jobInfo.DataSkipped)
+
+	// A second pipeline with the same transform reuses the first one's outputs
+	second := tu.UniqueString("TestDatumCache_second")
+	createPipeline(second, stdin)
+	jobInfo = flushJob(second)
+	require.Equal(t, int64(0), jobInfo.DataProcessed)
+	require.Equal(t, int64(3), jobInfo.DataSkipped)
+	for i := 0; i < 3; i++ {
+		var buf bytes.Buffer
+		require.NoError(t, c.GetFile(second, "master", fmt.Sprintf("file%d", i), 0, 0, &buf))
+		require.Equal(t, fmt.Sprintf("%d", i), buf.String())
+	}
+
+	// As does a re-created pipeline
+	require.NoError(t, c.DeletePipeline(first, false))
+	createPipeline(first, stdin)
+	jobInfo = flushJob(first)
+	require.Equal(t, int64(3), jobInfo.DataSkipped)
+
+	// But a pipeline with a different transform doesn't
+	third := tu.UniqueString("TestDatumCache_third")
+	createPipeline(third, stdin+" && touch /pfs/out/extra")
+	jobInfo = flushJob(third)
+	require.Equal(t, int64(3), jobInfo.DataProcessed)
+	require.Equal(t, int64(0), jobInfo.DataSkipped)
+
+	// Pipelines with secrets can't use the cache, as the secrets' contents
+	// aren't part of the cache key
+	_, err = c.PpsAPIClient.CreatePipeline(
+		context.Background(),
+		&pps.CreatePipelineRequest{
+			Pipeline: client.NewPipeline(tu.UniqueString("TestDatumCache_secret")),
+			Transform: &pps.Transform{
+				Cmd:     []string{"bash"},
+				Stdin:   []string{stdin},
+				Secrets: []*pps.SecretMount{{Name: "secret", MountPath: "/secret"}},
+			},
+			Input:      client.NewPFSInput(dataRepo, "/*"),
+			DatumCache: true,
+		})
+	require.YesError(t, err)
+	require.Matches(t, "secrets", err.Error())
+}
+
 func getObjectCountForRepo(t testing.TB, c *client.APIClient, repo string) int {
 	pipelineInfos, err := c.ListPipeline()
 	require.NoError(t, err)
@@ -11407,6 +12465,24 @@ func simulateGitPush(t *testing.T, pathToPayload string) {
 	require.Equal(t, 200, resp.StatusCode)
 }
 
+// postWebhook POSTs 'payload' to the webhook input 'input' of 'pipeline',
+// presenting 'secret' if it's set, and returns the response's status code.
+func postWebhook(t *testing.T, pipeline string, input string, secret string, payload string) int {
+	req, err := http.NewRequest(
+		"POST",
+		fmt.Sprintf("http://127.0.0.1:%v/v1/handle/webhook/%v/%v", githook.GitHookPort+30000, pipeline, input),
+		strings.NewReader(payload),
+	)
+	require.NoError(t, err)
+	if secret != "" {
+		req.Header.Set(githook.WebhookSecretHeader, secret)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	return resp.StatusCode
+}
+
 func pachdDeployment(t testing.TB) *apps.Deployment {
 	k := tu.GetKubeClient(t)
 	result, err := k.AppsV1().Deployments(v1.NamespaceDefault).Get("pachd", metav1.GetOptions{})
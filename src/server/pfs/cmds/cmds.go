@@ -2,6 +2,9 @@ package cmds
 
 import (
 	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -12,9 +15,12 @@ import (
 	"path/filepath"
 	"strings"
 	gosync "sync"
+	"time"
 
 	prompt "github.com/c-bata/go-prompt"
+	units "github.com/docker/go-units"
 	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/types"
 	"github.com/pachyderm/pachyderm/src/client"
 	"github.com/pachyderm/pachyderm/src/client/limit"
 	pfsclient "github.com/pachyderm/pachyderm/src/client/pfs"
@@ -69,11 +75,22 @@ or type (e.g. csv, binary, images, etc).`,
 	commands = append(commands, cmdutil.CreateDocsAlias(repoDocs, "repo", " repo$"))
 
 	var description string
+	var compression string
+	var sizeQuota string
+	var fileQuota uint64
 	createRepo := &cobra.Command{
 		Use:   "{{alias}} <repo>",
 		Short: "Create a new repo.",
 		Long:  "Create a new repo.",
 		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			repoCompression, err := parseCompression(compression)
+			if err != nil {
+				return err
+			}
+			quota := &pfsclient.Quota{FileCount: fileQuota}
+			if quota.SizeBytes, err = parseSizeQuota(sizeQuota); err != nil {
+				return err
+			}
 			c, err := client.NewOnUserMachine("user")
 			if err != nil {
 				return err
@@ -86,6 +103,8 @@ or type (e.g. csv, binary, images, etc).`,
 					&pfsclient.CreateRepoRequest{
 						Repo:        client.NewRepo(args[0]),
 						Description: description,
+						Compression: repoCompression,
+						Quota:       quota,
 					},
 				)
 				return err
@@ -94,26 +113,69 @@ or type (e.g. csv, binary, images, etc).`,
 		}),
 	}
 	createRepo.Flags().StringVarP(&description, "description", "d", "", "A description of the repo.")
+	createRepo.Flags().StringVar(&compression, "compression", "none", "How to compress the contents of files put in the repo, one of none, gzip or zstd.")
+	createRepo.Flags().StringVar(&sizeQuota, "quota", "0", "The maximum size of the repo's data, e.g. 10GB. 0 means no limit.")
+	createRepo.Flags().Uint64Var(&fileQuota, "file-quota", 0, "The maximum number of files in the repo. 0 means no limit.")
 	commands = append(commands, cmdutil.CreateAlias(createRepo, "create repo"))
 
 	updateRepo := &cobra.Command{
 		Use:   "{{alias}} <repo>",
 		Short: "Update a repo.",
-		Long:  "Update a repo.",
-		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+		Long: `Update a repo. Changing a repo's compression only affects files put in the repo afterwards.
+
+A repo's quota limits the size of, and optionally the number of files in, each of
+its commits. Writes that would exceed it fail, as do pipeline jobs that would
+write too much to their output repo.`,
+		Example: `
+# Limit the data in repo "foo" to 10GB
+$ {{alias}} foo --quota 10GB
+
+# Limit repo "foo" to 1000 files, and remove its limit on size
+$ {{alias}} foo --file-quota 1000 --quota 0`,
+		Run: cmdutil.RunCmdFixedArgs(1, func(cmd *cobra.Command, args []string) error {
 			c, err := client.NewOnUserMachine("user")
 			if err != nil {
 				return err
 			}
 			defer c.Close()
 
+			// Keep the repo's current compression and quota unless new ones
+			// were passed
+			repoInfo, err := c.InspectRepo(args[0])
+			if err != nil {
+				return err
+			}
+			repoCompression := repoInfo.Compression
+			if cmd.Flags().Changed("compression") {
+				if repoCompression, err = parseCompression(compression); err != nil {
+					return err
+				}
+			}
+			quota := &pfsclient.Quota{}
+			if repoInfo.Quota != nil {
+				quota = repoInfo.Quota
+			}
+			if cmd.Flags().Changed("quota") {
+				if quota.SizeBytes, err = parseSizeQuota(sizeQuota); err != nil {
+					return err
+				}
+			}
+			if cmd.Flags().Changed("file-quota") {
+				quota.FileCount = fileQuota
+			}
+
 			err = txncmds.WithActiveTransaction(c, func(c *client.APIClient) error {
 				_, err = c.PfsAPIClient.CreateRepo(
 					c.Ctx(),
 					&pfsclient.CreateRepoRequest{
 						Repo:        client.NewRepo(args[0]),
 						Description: description,
+						Compression: repoCompression,
+						Quota:       quota,
 						Update:      true,
+						// 'none' has to be explicit, or the repo keeps its
+						// current compression
+						ClearCompression: repoCompression == pfsclient.Compression_UNCOMPRESSED,
 					},
 				)
 				return err
@@ -122,6 +184,9 @@ or type (e.g. csv, binary, images, etc).`,
 		}),
 	}
 	updateRepo.Flags().StringVarP(&description, "description", "d", "", "A description of the repo.")
+	updateRepo.Flags().StringVar(&compression, "compression", "none", "How to compress the contents of files put in the repo from now on, one of none, gzip or zstd.")
+	updateRepo.Flags().StringVar(&sizeQuota, "quota", "0", "The maximum size of the repo's data, e.g. 10GB. 0 removes the limit.")
+	updateRepo.Flags().Uint64Var(&fileQuota, "file-quota", 0, "The maximum number of files in the repo. 0 removes the limit.")
 	shell.RegisterCompletionFunc(updateRepo, shell.RepoCompletion)
 	commands = append(commands, cmdutil.CreateAlias(updateRepo, "update repo"))
 
@@ -249,6 +314,7 @@ Commits can be created with another commit as a parent.`,
 	commands = append(commands, cmdutil.CreateDocsAlias(commitDocs, "commit", " commit$"))
 
 	var parent string
+	var annotations cmdutil.RepeatedStringArg
 	startCommit := &cobra.Command{
 		Use:   "{{alias}} <repo>@<branch-or-commit>",
 		Short: "Start a new commit.",
@@ -263,12 +329,19 @@ $ {{alias}} test@master
 $ {{alias}} test@patch -p master
 
 # Start a commit with XXX as the parent in repo "test", not on any branch
-$ {{alias}} test -p XXX`,
+$ {{alias}} test -p XXX
+
+# Start a commit in repo "test" on branch "master", annotated with an experiment ID
+$ {{alias}} test@master --annotation experiment=42`,
 		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
 			branch, err := cmdutil.ParseBranch(args[0])
 			if err != nil {
 				return err
 			}
+			commitAnnotations, err := cmdutil.ParseKeyValues(annotations)
+			if err != nil {
+				return err
+			}
 			c, err := client.NewOnUserMachine("user")
 			if err != nil {
 				return err
@@ -284,6 +357,7 @@ $ {{alias}} test -p XXX`,
 						Branch:      branch.Name,
 						Parent:      client.NewCommit(branch.Repo.Name, parent),
 						Description: description,
+						Annotations: commitAnnotations,
 					},
 				)
 				return err
@@ -298,6 +372,7 @@ $ {{alias}} test -p XXX`,
 	startCommit.MarkFlagCustom("parent", "__pachctl_get_commit $(__parse_repo ${nouns[0]})")
 	startCommit.Flags().StringVarP(&description, "message", "m", "", "A description of this commit's contents")
 	startCommit.Flags().StringVar(&description, "description", "", "A description of this commit's contents (synonym for --message)")
+	startCommit.Flags().Var(&annotations, "annotation", "An annotation of the form key=value to attach to this commit (may be repeated)")
 	shell.RegisterCompletionFunc(startCommit, shell.BranchCompletion)
 	commands = append(commands, cmdutil.CreateAlias(startCommit, "start commit"))
 
@@ -310,6 +385,10 @@ $ {{alias}} test -p XXX`,
 			if err != nil {
 				return err
 			}
+			commitAnnotations, err := cmdutil.ParseKeyValues(annotations)
+			if err != nil {
+				return err
+			}
 			c, err := client.NewOnUserMachine("user")
 			if err != nil {
 				return err
@@ -322,6 +401,7 @@ $ {{alias}} test -p XXX`,
 					&pfsclient.FinishCommitRequest{
 						Commit:      commit,
 						Description: description,
+						Annotations: commitAnnotations,
 					},
 				)
 				return err
@@ -331,6 +411,7 @@ $ {{alias}} test -p XXX`,
 	}
 	finishCommit.Flags().StringVarP(&description, "message", "m", "", "A description of this commit's contents (overwrites any existing commit description)")
 	finishCommit.Flags().StringVar(&description, "description", "", "A description of this commit's contents (synonym for --message)")
+	finishCommit.Flags().Var(&annotations, "annotation", "An annotation of the form key=value to add to this commit, overwriting any existing value for key (may be repeated)")
 	shell.RegisterCompletionFunc(finishCommit, shell.BranchCompletion)
 	commands = append(commands, cmdutil.CreateAlias(finishCommit, "finish commit"))
 
@@ -388,7 +469,10 @@ $ {{alias}} foo@master
 $ {{alias}} foo@master -n 20
 
 # return commits in repo "foo" since commit XXX
-$ {{alias}} foo@master --from XXX`,
+$ {{alias}} foo@master --from XXX
+
+# return commits in repo "foo" annotated with experiment=42
+$ {{alias}} foo --annotation experiment=42`,
 		Run: cmdutil.RunFixedArgs(1, func(args []string) (retErr error) {
 			c, err := client.NewOnUserMachine("user")
 			if err != nil {
@@ -400,14 +484,18 @@ $ {{alias}} foo@master --from XXX`,
 			if err != nil {
 				return err
 			}
+			selector, err := cmdutil.ParseKeyValues(annotations)
+			if err != nil {
+				return err
+			}
 
 			if raw {
-				return c.ListCommitF(branch.Repo.Name, branch.Name, from, uint64(number), false, func(ci *pfsclient.CommitInfo) error {
+				return c.ListCommitByAnnotationsF(branch.Repo.Name, branch.Name, from, uint64(number), false, selector, func(ci *pfsclient.CommitInfo) error {
 					return marshaller.Marshal(os.Stdout, ci)
 				})
 			}
 			writer := tabwriter.NewWriter(os.Stdout, pretty.CommitHeader)
-			if err := c.ListCommitF(branch.Repo.Name, branch.Name, from, uint64(number), false, func(ci *pfsclient.CommitInfo) error {
+			if err := c.ListCommitByAnnotationsF(branch.Repo.Name, branch.Name, from, uint64(number), false, selector, func(ci *pfsclient.CommitInfo) error {
 				pretty.PrintCommitInfo(writer, ci, fullTimestamps)
 				return nil
 			}); err != nil {
@@ -418,6 +506,7 @@ $ {{alias}} foo@master --from XXX`,
 	}
 	listCommit.Flags().StringVarP(&from, "from", "f", "", "list all commits since this commit")
 	listCommit.Flags().IntVarP(&number, "number", "n", 0, "list only this many commits; if set to zero, list all commits")
+	listCommit.Flags().Var(&annotations, "annotation", "list only commits with this annotation, of the form key=value (may be repeated, in which case commits must match all annotations)")
 	listCommit.MarkFlagCustom("from", "__pachctl_get_commit $(__parse_repo ${nouns[0]})")
 	listCommit.Flags().AddFlagSet(rawFlags)
 	listCommit.Flags().AddFlagSet(fullTimestampsFlags)
@@ -579,6 +668,50 @@ $ {{alias}} test@master --new`,
 	shell.RegisterCompletionFunc(deleteCommit, shell.BranchCompletion)
 	commands = append(commands, cmdutil.CreateAlias(deleteCommit, "delete commit"))
 
+	var deleteKeys cmdutil.RepeatedStringArg
+	annotateCommit := &cobra.Command{
+		Use:   "{{alias}} <repo>@<branch-or-commit> [<key>=<value> ...]",
+		Short: "Set or remove annotations on a commit.",
+		Long:  "Set or remove annotations on a commit. Setting an annotation overwrites any existing value for its key. Unlike the rest of a commit, annotations may be changed after the commit has been finished.",
+		Example: `
+# annotate the head of branch "master" in repo "test"
+$ {{alias}} test@master experiment=42 dataset=v3
+
+# remove the "experiment" annotation from commit XXX in repo "test"
+$ {{alias}} test@XXX --delete experiment`,
+		Run: cmdutil.RunMinimumArgs(1, func(args []string) error {
+			commit, err := cmdutil.ParseCommit(args[0])
+			if err != nil {
+				return err
+			}
+			commitAnnotations, err := cmdutil.ParseKeyValues(args[1:])
+			if err != nil {
+				return err
+			}
+			if len(commitAnnotations) == 0 && len(deleteKeys) == 0 {
+				return errors.Errorf("at least one annotation or --delete key must be given")
+			}
+			c, err := client.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+
+			_, err = c.PfsAPIClient.AnnotateCommit(
+				c.Ctx(),
+				&pfsclient.AnnotateCommitRequest{
+					Commit:      commit,
+					Annotations: commitAnnotations,
+					DeleteKeys:  deleteKeys,
+				},
+			)
+			return grpcutil.ScrubGRPC(err)
+		}),
+	}
+	annotateCommit.Flags().Var(&deleteKeys, "delete", "An annotation key to remove from the commit (may be repeated)")
+	shell.RegisterCompletionFunc(annotateCommit, shell.BranchCompletion)
+	commands = append(commands, cmdutil.CreateAlias(annotateCommit, "annotate commit"))
+
 	branchDocs := &cobra.Command{
 		Short: "Docs for branches.",
 		Long: `A branch in Pachyderm is an alias for a Commit ID.
@@ -593,10 +726,31 @@ Any pachctl command that can take a Commit ID, can take a branch name instead.`,
 
 	var branchProvenance cmdutil.RepeatedStringArg
 	var head string
+	var trigger pfsclient.Trigger
+	var triggerBranches cmdutil.RepeatedStringArg
+	var removeTrigger bool
 	createBranch := &cobra.Command{
 		Use:   "{{alias}} <repo>@<branch-or-commit>",
 		Short: "Create a new branch, or update an existing branch, on a repo.",
-		Long:  "Create a new branch, or update an existing branch, on a repo, starting a commit on the branch will also create it, so there's often no need to call this.",
+		Long: `Create a new branch, or update an existing branch, on a repo, starting a commit on the branch will also create it, so there's often no need to call this.
+
+A branch created with --trigger follows another branch in the same repo, but
+only moves to that branch's head when the trigger's conditions are met. If
+several conditions are given the trigger fires when any of them is met, or
+when all of them are with --trigger-all. Updating a branch without --trigger
+keeps its existing trigger; --remove-trigger removes it.`,
+		Example: `
+# create branch "master" in repo "foo" that moves to the head of branch
+# "staging" once 100 commits or 1 GB of data have been added to it
+$ {{alias}} foo@master --trigger staging --trigger-commits 100 --trigger-size 1G
+
+# create branch "master" in repo "foo" that moves to the head of branch
+# "staging" at most once an hour
+$ {{alias}} foo@master --trigger staging --trigger-cron '@every 1h'
+
+# create branch "master" in repo "foo" that moves to the head of branch
+# "staging" once both "images" and "labels" have moved since it last did
+$ {{alias}} foo@master --trigger staging --trigger-branches images --trigger-branches labels --trigger-all`,
 		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
 			branch, err := cmdutil.ParseBranch(args[0])
 			if err != nil {
@@ -606,6 +760,13 @@ Any pachctl command that can take a Commit ID, can take a branch name instead.`,
 			if err != nil {
 				return err
 			}
+			trigger.Branches = triggerBranches
+			if trigger.Branch == "" && (trigger.All || trigger.CronSpec != "" || trigger.Size_ != "" || trigger.Commits != 0 || len(trigger.Branches) > 0) {
+				return errors.New("--trigger-all, --trigger-cron, --trigger-size, --trigger-commits and --trigger-branches require --trigger")
+			}
+			if removeTrigger && trigger.Branch != "" {
+				return errors.New("--remove-trigger cannot be used with --trigger")
+			}
 			c, err := client.NewOnUserMachine("user")
 			if err != nil {
 				return err
@@ -613,6 +774,15 @@ Any pachctl command that can take a Commit ID, can take a branch name instead.`,
 			defer c.Close()
 
 			return txncmds.WithActiveTransaction(c, func(c *client.APIClient) error {
+				if trigger.Branch != "" {
+					if len(provenance) > 0 {
+						return errors.New("--trigger cannot be used with --provenance")
+					}
+					return c.CreateBranchTrigger(branch.Repo.Name, branch.Name, head, &trigger)
+				}
+				if removeTrigger {
+					return c.CreateBranchTrigger(branch.Repo.Name, branch.Name, head, &pfsclient.Trigger{})
+				}
 				return c.CreateBranch(branch.Repo.Name, branch.Name, head, provenance)
 			})
 		}),
@@ -621,6 +791,13 @@ Any pachctl command that can take a Commit ID, can take a branch name instead.`,
 	createBranch.MarkFlagCustom("provenance", "__pachctl_get_repo_commit")
 	createBranch.Flags().StringVarP(&head, "head", "", "", "The head of the newly created branch.")
 	createBranch.MarkFlagCustom("head", "__pachctl_get_commit $(__parse_repo ${nouns[0]})")
+	createBranch.Flags().StringVarP(&trigger.Branch, "trigger", "t", "", "The branch (in the same repo) that this branch follows when its trigger fires.")
+	createBranch.Flags().BoolVar(&trigger.All, "trigger-all", false, "Only fire the trigger when all of its conditions are met.")
+	createBranch.Flags().StringVar(&trigger.CronSpec, "trigger-cron", "", "Fire the trigger if this cron schedule has ticked since the branch last moved.")
+	createBranch.Flags().StringVar(&trigger.Size_, "trigger-size", "", "Fire the trigger once this much data (e.g. 100M, 1G) has been added to the followed branch.")
+	createBranch.Flags().Int64Var(&trigger.Commits, "trigger-commits", 0, "Fire the trigger after this many commits on the followed branch.")
+	createBranch.Flags().Var(&triggerBranches, "trigger-branches", "Fire the trigger once all of these branches (in the same repo) have moved since the branch last moved.")
+	createBranch.Flags().BoolVar(&removeTrigger, "remove-trigger", false, "Remove the branch's trigger.")
 	commands = append(commands, cmdutil.CreateAlias(createBranch, "create branch"))
 
 	inspectBranch := &cobra.Command{
@@ -714,6 +891,175 @@ Any pachctl command that can take a Commit ID, can take a branch name instead.`,
 	shell.RegisterCompletionFunc(deleteBranch, shell.BranchCompletion)
 	commands = append(commands, cmdutil.CreateAlias(deleteBranch, "delete branch"))
 
+	var keepLast int64
+	var keepFor time.Duration
+	updateBranch := &cobra.Command{
+		Use:   "{{alias}} <repo>@<branch>",
+		Short: "Update the retention policy of a branch.",
+		Long: `Update the retention policy of a branch.
+
+Commits on the branch that are older than both --keep-last and --keep-for are
+deleted periodically (see 'pachctl prune branch'), along with the older
+pipeline output derived from them. The head of a branch, and commits that a
+pipeline's latest output was computed from, are always kept. Calling
+'update branch' with neither flag set removes the branch's retention policy.`,
+		Example: `
+# keep only the 100 most recent commits on branch "master" in repo "foo"
+$ {{alias}} foo@master --keep-last 100
+
+# keep the commits from the last week on branch "master" in repo "foo", and
+# at least 10 commits however old they are
+$ {{alias}} foo@master --keep-for 168h --keep-last 10`,
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			branch, err := cmdutil.ParseBranch(args[0])
+			if err != nil {
+				return err
+			}
+			var retention *pfsclient.RetentionPolicy
+			if keepLast != 0 || keepFor != 0 {
+				retention = &pfsclient.RetentionPolicy{KeepLast: keepLast}
+				if keepFor != 0 {
+					retention.KeepFor = types.DurationProto(keepFor)
+				}
+			}
+			c, err := client.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			return c.SetBranchRetention(branch.Repo.Name, branch.Name, retention)
+		}),
+	}
+	updateBranch.Flags().Int64Var(&keepLast, "keep-last", 0, "Keep at least this many of the most recent commits on the branch.")
+	updateBranch.Flags().DurationVar(&keepFor, "keep-for", 0, "Keep commits that were finished less than this long ago.")
+	shell.RegisterCompletionFunc(updateBranch, shell.BranchCompletion)
+	commands = append(commands, cmdutil.CreateAlias(updateBranch, "update branch"))
+
+	var dryRun bool
+	pruneBranch := &cobra.Command{
+		Use:   "{{alias}} <repo>@<branch>",
+		Short: "Delete the commits on a branch that fall outside of its retention policy.",
+		Long: `Delete the commits on a branch that fall outside of its retention policy.
+
+Branches with a retention policy are also pruned periodically by pachd. Pruning
+only deletes commits; run 'pachctl garbage-collect' to reclaim their storage.`,
+		Example: `
+# list the commits that would be pruned from branch "master" in repo "foo"
+$ {{alias}} foo@master --dry-run`,
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			branch, err := cmdutil.ParseBranch(args[0])
+			if err != nil {
+				return err
+			}
+			c, err := client.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			commitInfos, err := c.PruneBranch(branch.Repo.Name, branch.Name, dryRun)
+			if err != nil {
+				return err
+			}
+			if raw {
+				for _, commitInfo := range commitInfos {
+					if err := marshaller.Marshal(os.Stdout, commitInfo); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+			writer := tabwriter.NewWriter(os.Stdout, pretty.CommitHeader)
+			for _, commitInfo := range commitInfos {
+				pretty.PrintCommitInfo(writer, commitInfo, fullTimestamps)
+			}
+			return writer.Flush()
+		}),
+	}
+	pruneBranch.Flags().BoolVar(&dryRun, "dry-run", false, "Print the commits that would be pruned, without deleting them.")
+	pruneBranch.Flags().AddFlagSet(rawFlags)
+	pruneBranch.Flags().AddFlagSet(fullTimestampsFlags)
+	shell.RegisterCompletionFunc(pruneBranch, shell.BranchCompletion)
+	commands = append(commands, cmdutil.CreateAlias(pruneBranch, "prune branch"))
+
+	var strategy string
+	mergeBranch := &cobra.Command{
+		Use:   "{{alias}} <repo>@<from-branch> <repo>@<into-branch>",
+		Short: "Merge one branch of a repo into another.",
+		Long: `Merge one branch of a repo into another.
+
+The changes made on <from-branch> since its common ancestor with <into-branch>
+are applied to <into-branch> in a new commit. A path that was changed
+differently on both branches is a conflict, which is resolved according to
+--strategy:
+  fail:   don't merge, and print the conflicts (default)
+  ours:   keep the version on <into-branch>
+  theirs: take the version on <from-branch>`,
+		Example: `
+# merge branch "labels-a" into branch "master" of repo "foo"
+$ {{alias}} foo@labels-a foo@master
+
+# merge, resolving conflicts in favor of "labels-b"
+$ {{alias}} foo@labels-b foo@master --strategy theirs`,
+		Run: cmdutil.RunFixedArgs(2, func(args []string) error {
+			from, err := cmdutil.ParseBranch(args[0])
+			if err != nil {
+				return err
+			}
+			into, err := cmdutil.ParseBranch(args[1])
+			if err != nil {
+				return err
+			}
+			mergeStrategy, ok := pfsclient.MergeStrategy_value[strings.ToUpper(strategy)]
+			if !ok {
+				return errors.Errorf("unrecognized merge strategy \"%s\", must be one of fail, ours or theirs", strategy)
+			}
+			c, err := client.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+
+			response, err := c.PfsAPIClient.MergeBranch(
+				c.Ctx(),
+				&pfsclient.MergeBranchRequest{
+					From:        from,
+					Into:        into,
+					Strategy:    pfsclient.MergeStrategy(mergeStrategy),
+					Description: description,
+				},
+			)
+			if err != nil {
+				return grpcutil.ScrubGRPC(err)
+			}
+			if raw {
+				return marshaller.Marshal(os.Stdout, response)
+			}
+			if len(response.Conflicts) > 0 {
+				writer := tabwriter.NewWriter(os.Stdout, pretty.MergeConflictHeader)
+				for _, conflict := range response.Conflicts {
+					pretty.PrintMergeConflict(writer, conflict)
+				}
+				if err := writer.Flush(); err != nil {
+					return err
+				}
+			}
+			if response.Commit == nil {
+				if len(response.Conflicts) > 0 && pfsclient.MergeStrategy(mergeStrategy) == pfsclient.MergeStrategy_FAIL {
+					return errors.Errorf("merge failed because of %d conflicting paths", len(response.Conflicts))
+				}
+				fmt.Fprintln(os.Stderr, "nothing to merge")
+				return nil
+			}
+			fmt.Println(response.Commit.ID)
+			return nil
+		}),
+	}
+	mergeBranch.Flags().StringVar(&strategy, "strategy", "fail", "How to resolve conflicts, one of fail, ours or theirs.")
+	mergeBranch.Flags().StringVarP(&description, "message", "m", "", "A description of the merge commit's contents.")
+	mergeBranch.Flags().AddFlagSet(rawFlags)
+	shell.RegisterCompletionFunc(mergeBranch, shell.BranchCompletion)
+	commands = append(commands, cmdutil.CreateAlias(mergeBranch, "merge branch"))
+
 	fileDocs := &cobra.Command{
 		Short: "Docs for files.",
 		Long: `Files are the lowest level data objects in Pachyderm.
@@ -735,10 +1081,11 @@ from commits with 'get file'.`,
 	var putFileCommit bool
 	var overwrite bool
 	var compress bool
+	var expectedSHA256 string
 	putFile := &cobra.Command{
 		Use:   "{{alias}} <repo>@<branch-or-commit>[:<path/to/file>]",
 		Short: "Put a file into the filesystem.",
-		Long:  "Put a file into the filesystem.  This command supports a number of ways to insert data into PFS.",
+		Long:  "Put a file into the filesystem.  This command supports a number of ways to insert data into PFS.  The permission bits of local files are recorded, and symlinks found with -r are put as symlinks rather than as the files they point to.",
 		Example: `
 # Put data from stdin as repo/branch/path:
 $ echo "data" | {{alias}} repo@branch:/path
@@ -777,12 +1124,26 @@ $ {{alias}} repo@branch -i file
 # Put several files or URLs that are listed at URL.
 # NOTE this URL can reference local files, so it could cause you to put sensitive
 # files into your Pachyderm cluster.
-$ {{alias}} repo@branch -i http://host/path`,
+$ {{alias}} repo@branch -i http://host/path
+
+# Put a file from the local filesystem as repo/branch/path, failing if its
+# contents don't match a known SHA-256 checksum:
+$ {{alias}} repo@branch:/path -f file --sha256 9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08`,
 		Run: cmdutil.RunFixedArgs(1, func(args []string) (retErr error) {
 			file, err := cmdutil.ParseFile(args[0])
 			if err != nil {
 				return err
 			}
+			var expectedSum []byte
+			if expectedSHA256 != "" {
+				if recursive || split != "" || inputFile != "" || len(filePaths) != 1 {
+					return errors.Errorf("--sha256 can only be used to put a single file, without --recursive, --split or --input-file")
+				}
+				expectedSum, err = hex.DecodeString(expectedSHA256)
+				if err != nil || len(expectedSum) != sha256.Size {
+					return errors.Errorf("invalid --sha256 %q, it must be %d hex-encoded bytes", expectedSHA256, sha256.Size)
+				}
+			}
 			opts := []client.Option{client.WithMaxConcurrentStreams(parallelism)}
 			if compress {
 				opts = append(opts, client.WithGZIPCompression())
@@ -860,19 +1221,19 @@ $ {{alias}} repo@branch -i http://host/path`,
 						return errors.Errorf("must specify filename when reading data from stdin")
 					}
 					eg.Go(func() error {
-						return putFileHelper(c, pfc, file.Commit.Repo.Name, file.Commit.ID, joinPaths("", source), source, recursive, overwrite, limiter, split, targetFileDatums, targetFileBytes, headerRecords, filesPut)
+						return putFileHelper(c, pfc, file.Commit.Repo.Name, file.Commit.ID, joinPaths("", source), source, recursive, overwrite, limiter, split, targetFileDatums, targetFileBytes, headerRecords, expectedSum, filesPut)
 					})
 				} else if len(sources) == 1 {
 					// We have a single source and the user has specified a path,
 					// we use the path and ignore source (in terms of naming the file).
 					eg.Go(func() error {
-						return putFileHelper(c, pfc, file.Commit.Repo.Name, file.Commit.ID, file.Path, source, recursive, overwrite, limiter, split, targetFileDatums, targetFileBytes, headerRecords, filesPut)
+						return putFileHelper(c, pfc, file.Commit.Repo.Name, file.Commit.ID, file.Path, source, recursive, overwrite, limiter, split, targetFileDatums, targetFileBytes, headerRecords, expectedSum, filesPut)
 					})
 				} else {
 					// We have multiple sources and the user has specified a path,
 					// we use that path as a prefix for the filepaths.
 					eg.Go(func() error {
-						return putFileHelper(c, pfc, file.Commit.Repo.Name, file.Commit.ID, joinPaths(file.Path, source), source, recursive, overwrite, limiter, split, targetFileDatums, targetFileBytes, headerRecords, filesPut)
+						return putFileHelper(c, pfc, file.Commit.Repo.Name, file.Commit.ID, joinPaths(file.Path, source), source, recursive, overwrite, limiter, split, targetFileDatums, targetFileBytes, headerRecords, expectedSum, filesPut)
 					})
 				}
 			}
@@ -884,12 +1245,13 @@ $ {{alias}} repo@branch -i http://host/path`,
 	putFile.Flags().BoolVarP(&recursive, "recursive", "r", false, "Recursively put the files in a directory.")
 	putFile.Flags().BoolVarP(&compress, "compress", "", false, "Compress data during upload.")
 	putFile.Flags().IntVarP(&parallelism, "parallelism", "p", DefaultParallelism, "The maximum number of files that can be uploaded in parallel.")
-	putFile.Flags().StringVar(&split, "split", "", "Split the input file into smaller files, subject to the constraints of --target-file-datums and --target-file-bytes. Permissible values are `line`, `json`, `sql` and `csv`.")
+	putFile.Flags().StringVar(&split, "split", "", "Split the input file into smaller files, subject to the constraints of --target-file-datums and --target-file-bytes. Permissible values are `line`, `json`, `sql`, `csv`, `avro` and `protobuf-delimited`. Avro files are split between their data blocks, so a file may hold more records than --target-file-datums.")
 	putFile.Flags().UintVar(&targetFileDatums, "target-file-datums", 0, "The upper bound of the number of datums that each file contains, the last file will contain fewer if the datums don't divide evenly; needs to be used with --split.")
 	putFile.Flags().UintVar(&targetFileBytes, "target-file-bytes", 0, "The target upper bound of the number of bytes that each file contains; needs to be used with --split.")
-	putFile.Flags().UintVar(&headerRecords, "header-records", 0, "the number of records that will be converted to a PFS 'header', and prepended to future retrievals of any subset of data from PFS; needs to be used with --split=(json|line|csv)")
+	putFile.Flags().UintVar(&headerRecords, "header-records", 0, "the number of records that will be converted to a PFS 'header', and prepended to future retrievals of any subset of data from PFS; needs to be used with --split=(json|line|csv|protobuf-delimited)")
 	putFile.Flags().BoolVarP(&putFileCommit, "commit", "c", false, "DEPRECATED: Put file(s) in a new commit.")
 	putFile.Flags().BoolVarP(&overwrite, "overwrite", "o", false, "Overwrite the existing content of the file, either from previous commits or previous calls to 'put file' within this commit.")
+	putFile.Flags().StringVar(&expectedSHA256, "sha256", "", "The hex-encoded SHA-256 checksum of the data being put; the write fails if the data doesn't match it.")
 	shell.RegisterCompletionFunc(putFile,
 		func(flag, text string, maxCompletions int64) ([]prompt.Suggest, shell.CacheFunc) {
 			if flag == "-f" || flag == "--file" || flag == "-i" || flag == "input-file" {
@@ -934,6 +1296,7 @@ $ {{alias}} repo@branch -i http://host/path`,
 	commands = append(commands, cmdutil.CreateAlias(copyFile, "copy file"))
 
 	var outputPath string
+	var verify bool
 	getFile := &cobra.Command{
 		Use:   "{{alias}} <repo>@<branch-or-commit>:<path/in/pfs>",
 		Short: "Return the contents of a file.",
@@ -948,7 +1311,11 @@ $ {{alias}} foo@master^:XXX
 
 # get file "XXX" in the grandparent of the current head of branch "master"
 # in repo "foo"
-$ {{alias}} foo@master^2:XXX`,
+$ {{alias}} foo@master^2:XXX
+
+# get file "XXX" on branch "master" in repo "foo", and check that its
+# contents match the checksum pachyderm recorded when it was written
+$ {{alias}} foo@master:XXX --verify`,
 		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
 			file, err := cmdutil.ParseFile(args[0])
 			if err != nil {
@@ -959,6 +1326,9 @@ $ {{alias}} foo@master^2:XXX`,
 				return err
 			}
 			defer c.Close()
+			if recursive && verify {
+				return errors.Errorf("cannot set --verify with --recursive")
+			}
 			if recursive {
 				if outputPath == "" {
 					return errors.Errorf("an output path needs to be specified when using the --recursive flag")
@@ -978,10 +1348,14 @@ $ {{alias}} foo@master^2:XXX`,
 				defer f.Close()
 				w = f
 			}
+			if verify {
+				return getFileVerified(c, file, w)
+			}
 			return c.GetFile(file.Commit.Repo.Name, file.Commit.ID, file.Path, 0, 0, w)
 		}),
 	}
 	getFile.Flags().BoolVarP(&recursive, "recursive", "r", false, "Recursively download a directory.")
+	getFile.Flags().BoolVar(&verify, "verify", false, "Check that the file's contents match the SHA-256 checksum recorded when it was written, and fail if they don't.")
 	getFile.Flags().StringVarP(&outputPath, "output", "o", "", "The path where data will be downloaded.")
 	getFile.Flags().IntVarP(&parallelism, "parallelism", "p", DefaultParallelism, "The maximum number of files that can be downloaded in parallel")
 	shell.RegisterCompletionFunc(getFile, shell.FileCompletion)
@@ -1001,7 +1375,7 @@ $ {{alias}} foo@master^2:XXX`,
 				return err
 			}
 			defer c.Close()
-			fileInfo, err := c.InspectFile(file.Commit.Repo.Name, file.Commit.ID, file.Path)
+			fileInfo, err := c.InspectFileStoredSize(file.Commit.Repo.Name, file.Commit.ID, file.Path)
 			if err != nil {
 				return err
 			}
@@ -1128,6 +1502,40 @@ $ {{alias}} "foo@master:data/*"`,
 	shell.RegisterCompletionFunc(globFile, shell.FileCompletion)
 	commands = append(commands, cmdutil.CreateAlias(globFile, "glob file"))
 
+	grepFile := &cobra.Command{
+		Use:   "{{alias}} <repo>@<branch-or-commit>:<pattern> <regex>",
+		Short: "Search the files that match a glob pattern in a commit for lines matching a regex.",
+		Long:  "Search the files that match a glob pattern in a commit for lines matching a regular expression. The search runs inside pachd, so only matching lines are downloaded. Each match is printed as <path>:<line number>:<line>. Regular expressions use RE2 syntax, documented [here](https://golang.org/s/re2syntax).",
+		Example: `
+# Print every line containing "error" in the files under "logs" in repo "foo"
+# on branch "master".
+$ {{alias}} "foo@master:logs/*" error
+
+# Print every line that starts with a date in any file in repo "foo".
+$ {{alias}} "foo@master:**" '^[0-9]{4}-[0-9]{2}-[0-9]{2}'`,
+		Run: cmdutil.RunFixedArgs(2, func(args []string) error {
+			file, err := cmdutil.ParseFile(args[0])
+			if err != nil {
+				return err
+			}
+			c, err := client.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			return c.GrepFile(file.Commit.Repo.Name, file.Commit.ID, file.Path, args[1], func(match *pfsclient.GrepMatch) error {
+				if raw {
+					return marshaller.Marshal(os.Stdout, match)
+				}
+				_, err := fmt.Printf("%s:%d:%s\n", match.File.Path, match.LineNumber, match.Line)
+				return err
+			})
+		}),
+	}
+	grepFile.Flags().AddFlagSet(rawFlags)
+	shell.RegisterCompletionFunc(grepFile, shell.FileCompletion)
+	commands = append(commands, cmdutil.CreateAlias(grepFile, "grep file"))
+
 	var shallow bool
 	var nameOnly bool
 	var diffCmdArg string
@@ -1300,6 +1708,7 @@ Tags are a low-level resource and should not be accessed directly by most users.
 	commands = append(commands, cmdutil.CreateAlias(getTag, "get tag"))
 
 	var fix bool
+	var verifyChecksums bool
 	fsck := &cobra.Command{
 		Use:   "{{alias}}",
 		Short: "Run a file system consistency check on pfs.",
@@ -1311,7 +1720,11 @@ Tags are a low-level resource and should not be accessed directly by most users.
 			}
 			defer c.Close()
 			errors := false
-			if err = c.Fsck(fix, func(resp *pfsclient.FsckResponse) error {
+			fsck := c.Fsck
+			if verifyChecksums {
+				fsck = c.FsckVerifyChecksums
+			}
+			if err = fsck(fix, func(resp *pfsclient.FsckResponse) error {
 				if resp.Error != "" {
 					errors = true
 					fmt.Printf("Error: %s\n", resp.Error)
@@ -1329,6 +1742,7 @@ Tags are a low-level resource and should not be accessed directly by most users.
 		}),
 	}
 	fsck.Flags().BoolVarP(&fix, "fix", "f", false, "Attempt to fix as many issues as possible.")
+	fsck.Flags().BoolVar(&verifyChecksums, "verify-checksums", false, "Also read every file that has a recorded checksum and check that its contents still match it. This reads all of the data in pfs.")
 	commands = append(commands, cmdutil.CreateAlias(fsck, "fsck"))
 
 	// Add the mount commands (which aren't available on Windows, so they're in
@@ -1338,10 +1752,36 @@ Tags are a low-level resource and should not be accessed directly by most users.
 	return commands
 }
 
+// getFileVerified writes the contents of 'file' to 'w', and then returns an
+// error if they don't match the file's recorded SHA-256 checksum.
+func getFileVerified(c *client.APIClient, file *pfsclient.File, w io.Writer) error {
+	fileInfo, err := c.InspectFile(file.Commit.Repo.Name, file.Commit.ID, file.Path)
+	if err != nil {
+		return err
+	}
+	if fileInfo.FileType != pfsclient.FileType_FILE {
+		return errors.Errorf("cannot verify %s, it's not a regular file", file.Path)
+	}
+	if fileInfo.Sha256 == nil {
+		return errors.Errorf("cannot verify %s, it has no recorded checksum", file.Path)
+	}
+	// Read from the same commit that was inspected, in case the branch moves
+	commit := fileInfo.File.Commit
+	hash := sha256.New()
+	if err := c.GetFile(commit.Repo.Name, commit.ID, file.Path, 0, 0, io.MultiWriter(w, hash)); err != nil {
+		return err
+	}
+	if actual := hash.Sum(nil); !bytes.Equal(actual, fileInfo.Sha256) {
+		return errors.Errorf("checksum mismatch for %s: expected sha256 %x, got %x", file.Path, fileInfo.Sha256, actual)
+	}
+	return nil
+}
+
 func putFileHelper(c *client.APIClient, pfc client.PutFileClient,
 	repo, commit, path, source string, recursive, overwrite bool, // destination
 	limiter limit.ConcurrencyLimiter,
 	split string, targetFileDatums, targetFileBytes, headerRecords uint, // split
+	expectedSHA256 []byte, // verification
 	filesPut *gosync.Map) (retErr error) {
 	// Resolve the path, then trim any prefixed '../' to avoid sending bad paths
 	// to the server
@@ -1355,14 +1795,24 @@ func putFileHelper(c *client.APIClient, pfc client.PutFileClient,
 			"some files may already have been put and should be cleaned up with "+
 			"'delete file' or 'delete commit'", path)
 	}
-	putFile := func(reader io.ReadSeeker) error {
+	// 'mode' holds the permission bits of local files, which are recorded in
+	// PFS (0 for data read from stdin)
+	putFile := func(reader io.ReadSeeker, mode uint32) error {
+		if expectedSHA256 != nil {
+			_, err := pfc.PutFileVerified(repo, commit, path, reader, overwrite, mode, expectedSHA256)
+			return err
+		}
 		if split == "" {
 			pipe, err := isPipe(reader)
 			if err != nil {
 				return err
 			}
 			if overwrite && !pipe {
-				return sync.PushFile(c, pfc, client.NewFile(repo, commit, path), reader)
+				return sync.PushFileMode(c, pfc, client.NewFile(repo, commit, path), reader, mode)
+			}
+			if mode != 0 {
+				_, err := pfc.PutFileMode(repo, commit, path, reader, overwrite, mode)
+				return err
 			}
 			if overwrite {
 				_, err = pfc.PutFileOverwrite(repo, commit, path, reader, 0)
@@ -1382,9 +1832,13 @@ func putFileHelper(c *client.APIClient, pfc client.PutFileClient,
 			delimiter = pfsclient.Delimiter_SQL
 		case "csv":
 			delimiter = pfsclient.Delimiter_CSV
+		case "avro":
+			delimiter = pfsclient.Delimiter_AVRO
+		case "protobuf-delimited":
+			delimiter = pfsclient.Delimiter_PROTOBUF_DELIMITED
 		default:
 			return errors.Errorf("unrecognized delimiter '%s'; only accepts one of "+
-				"{json,line,sql,csv}", split)
+				"{json,line,sql,csv,avro,protobuf-delimited}", split)
 		}
 		_, err := pfc.PutFileSplit(repo, commit, path, delimiter, int64(targetFileDatums), int64(targetFileBytes), int64(headerRecords), overwrite, reader)
 		return err
@@ -1398,10 +1852,13 @@ func putFileHelper(c *client.APIClient, pfc client.PutFileClient,
 		defer limiter.Release()
 		stdin := progress.Stdin()
 		defer stdin.Finish()
-		return putFile(stdin)
+		return putFile(stdin, 0)
 	}
 	// try parsing the filename as a url, if it is one do a PutFileURL
 	if url, err := url.Parse(source); err == nil && url.Scheme != "" {
+		if expectedSHA256 != nil {
+			return errors.Errorf("cannot set an expected checksum when putting a URL")
+		}
 		limiter.Acquire()
 		defer limiter.Release()
 		return pfc.PutFileURL(repo, commit, path, url.String(), recursive, overwrite)
@@ -1417,13 +1874,31 @@ func putFileHelper(c *client.APIClient, pfc client.PutFileClient,
 				return nil
 			}
 			childDest := filepath.Join(path, strings.TrimPrefix(filePath, source))
+			if info.Mode()&os.ModeSymlink != 0 {
+				// Symlinks are put as symlinks, rather than as the file they point to
+				target, err := os.Readlink(filePath)
+				if err != nil {
+					return err
+				}
+				if _, ok := filesPut.LoadOrStore(childDest, nil); ok {
+					return errors.Errorf("multiple files put with the path %s, aborting, "+
+						"some files may already have been put and should be cleaned up with "+
+						"'delete file' or 'delete commit'", childDest)
+				}
+				eg.Go(func() error {
+					limiter.Acquire()
+					defer limiter.Release()
+					return pfc.PutSymlink(repo, commit, childDest, target)
+				})
+				return nil
+			}
 			eg.Go(func() error {
 				// don't do a second recursive 'put file', just put the one file at
 				// filePath into childDest, and then this walk loop will go on to the
 				// next one
 				return putFileHelper(c, pfc, repo, commit, childDest, filePath, false,
 					overwrite, limiter, split, targetFileDatums, targetFileBytes,
-					headerRecords, filesPut)
+					headerRecords, nil, filesPut)
 			})
 			return nil
 		}); err != nil {
@@ -1433,6 +1908,10 @@ func putFileHelper(c *client.APIClient, pfc client.PutFileClient,
 	}
 	limiter.Acquire()
 	defer limiter.Release()
+	info, err := os.Stat(source)
+	if err != nil {
+		return err
+	}
 	f, err := progress.Open(source)
 	if err != nil {
 		return err
@@ -1442,7 +1921,35 @@ func putFileHelper(c *client.APIClient, pfc client.PutFileClient,
 			retErr = err
 		}
 	}()
-	return putFile(f)
+	return putFile(f, uint32(info.Mode().Perm()))
+}
+
+// parseCompression parses the value of a --compression flag.
+func parseCompression(compression string) (pfsclient.Compression, error) {
+	if strings.ToLower(compression) == "none" {
+		return pfsclient.Compression_UNCOMPRESSED, nil
+	}
+	c, ok := pfsclient.Compression_value[strings.ToUpper(compression)]
+	if !ok || c == int32(pfsclient.Compression_UNCOMPRESSED) {
+		return 0, errors.Errorf("unrecognized compression \"%s\", must be one of none, gzip or zstd", compression)
+	}
+	return pfsclient.Compression(c), nil
+}
+
+// parseSizeQuota parses a human-readable size quota, e.g. "10GB". "0" means
+// no limit.
+func parseSizeQuota(quota string) (uint64, error) {
+	if quota == "" || quota == "0" {
+		return 0, nil
+	}
+	size, err := units.RAMInBytes(quota)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid quota \"%s\"", quota)
+	}
+	if size < 0 {
+		return 0, errors.Errorf("invalid quota \"%s\", must not be negative", quota)
+	}
+	return uint64(size), nil
 }
 
 func joinPaths(prefix, filePath string) string {
@@ -1,9 +1,18 @@
 package cmds
 
 import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	gosync "sync"
 	"testing"
 
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/limit"
 	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	"github.com/pachyderm/pachyderm/src/server/pkg/testpachd"
 	tu "github.com/pachyderm/pachyderm/src/server/pkg/testutil"
 )
 
@@ -68,3 +77,47 @@ func TestPutFileSplit(t *testing.T) {
 		"repo", tu.UniqueString("TestPutFileSplit-repo"),
 	).Run())
 }
+
+// overwriteRecorder is a PutFileClient that records the overwrite index of
+// each PutFileOverwriteMode call, which is how sync.PushFile puts files.
+type overwriteRecorder struct {
+	client.PutFileClient
+	overwriteIndexes []int64
+}
+
+func (r *overwriteRecorder) PutFileOverwriteMode(repoName string, commitID string, path string, reader io.Reader, overwriteIndex int64, mode uint32) (int, error) {
+	r.overwriteIndexes = append(r.overwriteIndexes, overwriteIndex)
+	return r.PutFileClient.PutFileOverwriteMode(repoName, commitID, path, reader, overwriteIndex, mode)
+}
+
+func TestPutFileOverwriteLocalFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestPutFileOverwriteLocalFile")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	source := filepath.Join(dir, "file")
+
+	require.NoError(t, testpachd.WithRealEnv(func(env *testpachd.RealEnv) error {
+		c := env.PachClient
+		require.NoError(t, c.CreateRepo("repo"))
+		pfc := &overwriteRecorder{PutFileClient: c}
+		putFile := func(content string) {
+			require.NoError(t, ioutil.WriteFile(source, []byte(content), 0600))
+			require.NoError(t, os.Chmod(source, 0750))
+			require.NoError(t, putFileHelper(c, pfc, "repo", "master", "file", source, false, true,
+				limit.New(1), "", 0, 0, 0, nil, &gosync.Map{}))
+		}
+
+		// 'put file -o' of a local file only uploads what changed (through
+		// sync.PushFile), and still records the file's mode
+		putFile("foo")
+		putFile("bar")
+		require.Equal(t, []int64{0, 0}, pfc.overwriteIndexes)
+		var buf bytes.Buffer
+		require.NoError(t, c.GetFile("repo", "master", "file", 0, 0, &buf))
+		require.Equal(t, "bar", buf.String())
+		fileInfo, err := c.InspectFile("repo", "master", "file")
+		require.NoError(t, err)
+		require.Equal(t, uint32(0750), fileInfo.Mode)
+		return nil
+	}))
+}
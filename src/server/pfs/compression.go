@@ -0,0 +1,64 @@
+package pfs
+
+import (
+	"compress/gzip"
+	"io"
+
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// NewCompressor returns a writer that compresses what's written to it with
+// 'compression' and writes the result to 'w'. The returned writer must be
+// closed to flush the compressed data, closing it doesn't close 'w'.
+func NewCompressor(w io.Writer, compression pfs.Compression) (io.WriteCloser, error) {
+	switch compression {
+	case pfs.Compression_GZIP:
+		return gzip.NewWriterLevel(w, gzip.BestSpeed)
+	case pfs.Compression_ZSTD:
+		return zstd.NewWriter(w)
+	default:
+		return nil, errors.Errorf("unrecognized compression %s", compression)
+	}
+}
+
+// NewDecompressor returns a reader that decompresses the data in 'r', which
+// was compressed with 'compression'. Closing the returned reader closes 'r'.
+func NewDecompressor(r io.ReadCloser, compression pfs.Compression) (io.ReadCloser, error) {
+	switch compression {
+	case pfs.Compression_GZIP:
+		gzipR, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return &decompressor{Reader: gzipR, closeFns: []func() error{gzipR.Close, r.Close}}, nil
+	case pfs.Compression_ZSTD:
+		zstdR, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return &decompressor{Reader: zstdR, closeFns: []func() error{
+			func() error { zstdR.Close(); return nil },
+			r.Close,
+		}}, nil
+	default:
+		return nil, errors.Errorf("unrecognized compression %s", compression)
+	}
+}
+
+type decompressor struct {
+	io.Reader
+	closeFns []func() error
+}
+
+func (d *decompressor) Close() error {
+	var retErr error
+	for _, closeFn := range d.closeFns {
+		if err := closeFn(); err != nil && retErr == nil {
+			retErr = err
+		}
+	}
+	return retErr
+}
@@ -19,6 +19,7 @@ import (
 const (
 	modeFile = fuse.S_IFREG | 0444 // everyone can read, no one can do anything else
 	modeDir  = fuse.S_IFDIR | 0555 // everyone can read and execute, no one can do anything else (execute permission is required to list a dir)
+	modeLink = fuse.S_IFLNK | 0777 // symlinks' permissions are ignored
 )
 
 // Mount pfs to mountPoint, opts may be left nil.
@@ -108,6 +109,24 @@ func (fs *filesystem) OpenDir(name string, context *fuse.Context) ([]fuse.DirEnt
 	return result, fuse.OK
 }
 
+func (fs *filesystem) Readlink(name string, context *fuse.Context) (string, fuse.Status) {
+	_, f, err := fs.parsePath(name)
+	if err != nil {
+		return "", toStatus(err)
+	}
+	if f == nil {
+		return "", fuse.EINVAL
+	}
+	fi, err := fs.c.InspectFile(f.Commit.Repo.Name, f.Commit.ID, f.Path)
+	if err != nil {
+		return "", toStatus(err)
+	}
+	if fi.FileType != pfs.FileType_SYMLINK {
+		return "", fuse.EINVAL
+	}
+	return fi.SymlinkTarget, fuse.OK
+}
+
 func (fs *filesystem) Open(name string, flags uint32, context *fuse.Context) (nodefs.File, fuse.Status) {
 	f := int(flags)
 	writeFlags := os.O_WRONLY | os.O_RDWR
@@ -203,9 +222,12 @@ func repoDirEntry(ri *pfs.RepoInfo) fuse.DirEntry {
 func fileMode(fi *pfs.FileInfo) uint32 {
 	switch fi.FileType {
 	case pfs.FileType_FILE:
-		return modeFile
+		// files are read-only, but keep any execute bits they were put with
+		return modeFile | (fi.Mode & 0111)
 	case pfs.FileType_DIR:
 		return modeDir
+	case pfs.FileType_SYMLINK:
+		return modeLink
 	default:
 		return 0
 	}
@@ -64,6 +64,26 @@ func TestChunkSize(t *testing.T) {
 	})
 }
 
+func TestSymlinkAndMode(t *testing.T) {
+	c := server.GetPachClient(t, server.GetBasicConfig())
+	require.NoError(t, c.CreateRepo("repo"))
+	_, err := c.PutFileMode("repo", "master", "script", strings.NewReader("#!/bin/sh\n"), false, 0755)
+	require.NoError(t, err)
+	require.NoError(t, c.PutSymlink("repo", "master", "link", "script"))
+	mount(t, c, nil, func(mountPoint string) {
+		info, err := os.Stat(filepath.Join(mountPoint, "repo", "script"))
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0555), info.Mode().Perm())
+
+		target, err := os.Readlink(filepath.Join(mountPoint, "repo", "link"))
+		require.NoError(t, err)
+		require.Equal(t, "script", target)
+		data, err := ioutil.ReadFile(filepath.Join(mountPoint, "repo", "link"))
+		require.NoError(t, err)
+		require.Equal(t, "#!/bin/sh\n", string(data))
+	})
+}
+
 func TestLargeFile(t *testing.T) {
 	c := server.GetPachClient(t, server.GetBasicConfig())
 	require.NoError(t, c.CreateRepo("repo"))
@@ -62,6 +62,23 @@ type ErrOutputCommitNotFinished struct {
 	Commit *pfs.Commit
 }
 
+// ErrChecksumMismatch represents an error where a file's contents don't match
+// its expected SHA-256 digest
+type ErrChecksumMismatch struct {
+	File     *pfs.File
+	Expected []byte
+	Actual   []byte
+}
+
+// ErrQuotaExceeded represents an error where a write would take a repo over
+// its quota. Unit is what the quota limits, i.e. "bytes" or "files".
+type ErrQuotaExceeded struct {
+	Repo  *pfs.Repo
+	Unit  string
+	Limit uint64
+	Usage uint64
+}
+
 func (e ErrFileNotFound) Error() string {
 	return fmt.Sprintf("file %v not found in repo %v at commit %v", e.File.Path, e.File.Commit.Repo.Name, e.File.Commit.ID)
 }
@@ -103,11 +120,46 @@ func (e ErrOutputCommitNotFinished) Error() string {
 	return fmt.Sprintf("output commit %v not finished", e.Commit.ID)
 }
 
+func (e ErrChecksumMismatch) Error() string {
+	return fmt.Sprintf("checksum mismatch for file %v in repo %v at commit %v: expected sha256 %x, got %x",
+		e.File.Path, e.File.Commit.Repo.Name, e.File.Commit.ID, e.Expected, e.Actual)
+}
+
+func (e ErrQuotaExceeded) Error() string {
+	return fmt.Sprintf("quota exceeded for repo %v: the write would take it to %d %s, but its quota is %d %s",
+		e.Repo.Name, e.Usage, e.Unit, e.Limit, e.Unit)
+}
+
+// CheckQuota returns ErrQuotaExceeded if a commit in 'repo' containing
+// 'sizeBytes' bytes in 'fileCount' files would exceed 'quota' (which may be
+// nil, meaning the repo has no quota).
+func CheckQuota(repo *pfs.Repo, quota *pfs.Quota, sizeBytes, fileCount uint64) error {
+	if quota == nil {
+		return nil
+	}
+	if quota.SizeBytes != 0 && sizeBytes > quota.SizeBytes {
+		return ErrQuotaExceeded{Repo: repo, Unit: "bytes", Limit: quota.SizeBytes, Usage: sizeBytes}
+	}
+	if quota.FileCount != 0 && fileCount > quota.FileCount {
+		return ErrQuotaExceeded{Repo: repo, Unit: "files", Limit: quota.FileCount, Usage: fileCount}
+	}
+	return nil
+}
+
 // ByteRangeSize returns byteRange.Upper - byteRange.Lower.
 func ByteRangeSize(byteRange *pfs.ByteRange) uint64 {
 	return byteRange.Upper - byteRange.Lower
 }
 
+// BlockRefSize returns the size of the data referenced by blockRef once it's
+// decompressed.
+func BlockRefSize(blockRef *pfs.BlockRef) uint64 {
+	if blockRef.Compression != pfs.Compression_UNCOMPRESSED {
+		return blockRef.SizeBytes
+	}
+	return ByteRangeSize(blockRef.Range)
+}
+
 var (
 	commitNotFoundRe          = regexp.MustCompile("commit [^ ]+ not found in repo [^ ]+")
 	commitDeletedRe           = regexp.MustCompile("commit [^ ]+/[^ ]+ was deleted")
@@ -117,6 +169,8 @@ var (
 	fileNotFoundRe            = regexp.MustCompile(`file .+ not found`)
 	hasNoHeadRe               = regexp.MustCompile(`the branch .+ has no head \(create one with 'start commit'\)`)
 	outputCommitNotFinishedRe = regexp.MustCompile("output commit .+ not finished")
+	checksumMismatchRe        = regexp.MustCompile("checksum mismatch for file .+: expected sha256 [0-9a-f]*, got [0-9a-f]*")
+	quotaExceededRe           = regexp.MustCompile("quota exceeded for repo [^ ]+: the write would take it to [0-9]+ [a-z]+, but its quota is [0-9]+ [a-z]+")
 )
 
 // IsCommitNotFoundErr returns true if 'err' has an error message that matches
@@ -190,3 +244,21 @@ func IsOutputCommitNotFinishedErr(err error) bool {
 	}
 	return outputCommitNotFinishedRe.MatchString(err.Error())
 }
+
+// IsChecksumMismatchErr returns true if the err is due to a file's contents
+// not matching their expected SHA-256 digest
+func IsChecksumMismatchErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	return checksumMismatchRe.MatchString(err.Error())
+}
+
+// IsQuotaExceededErr returns true if the err is due to a write that would take
+// a repo over its quota
+func IsQuotaExceededErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	return quotaExceededRe.MatchString(err.Error())
+}
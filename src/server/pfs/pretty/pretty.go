@@ -27,6 +27,8 @@ const (
 	FileHeaderWithCommit = "COMMIT\tNAME\tTYPE\tCOMMITTED\tSIZE\t\n"
 	// DiffFileHeader is the header for files produced by diff file.
 	DiffFileHeader = "OP\t" + FileHeader
+	// MergeConflictHeader is the header for conflicts produced by merge branch.
+	MergeConflictHeader = "PATH\tOURS\tTHEIRS\t\n"
 )
 
 // PrintRepoInfo pretty-prints repo info.
@@ -66,7 +68,11 @@ func PrintDetailedRepoInfo(repoInfo *PrintableRepoInfo) error {
 Description: {{.Description}}{{end}}{{if .FullTimestamps}}
 Created: {{.Created}}{{else}}
 Created: {{prettyAgo .Created}}{{end}}
-Size of HEAD on master: {{prettySize .SizeBytes}}{{if .AuthInfo}}
+Size of HEAD on master: {{prettySize .SizeBytes}}{{if .Compression}}
+Compression: {{.Compression.String}}{{end}}{{if .Quota.GetSizeBytes}}
+Quota: {{prettySize .Quota.SizeBytes}}{{end}}{{if .Quota.GetFileCount}}
+Files in HEAD on master: {{.FileCount}}
+File quota: {{.Quota.FileCount}}{{end}}{{if .AuthInfo}}
 Access level: {{ .AuthInfo.AccessLevel.String }}{{end}}
 `)
 	if err != nil {
@@ -95,7 +101,9 @@ func PrintDetailedBranchInfo(branchInfo *pfs.BranchInfo) error {
 	template, err := template.New("BranchInfo").Funcs(funcMap).Parse(
 		`Name: {{.Branch.Repo.Name}}@{{.Branch.Name}}{{if .Head}}
 Head Commit: {{ .Head.Repo.Name}}@{{.Head.ID}} {{end}}{{if .Provenance}}
-Provenance: {{range .Provenance}} {{.Repo.Name}}@{{.Name}} {{end}} {{end}}
+Provenance: {{range .Provenance}} {{.Repo.Name}}@{{.Name}} {{end}} {{end}}{{if .Retention}}
+Retention:{{if .Retention.KeepLast}} keep last {{.Retention.KeepLast}} commits{{end}}{{if .Retention.KeepFor}} keep commits for {{prettyDuration .Retention.KeepFor}}{{end}}{{end}}{{if .Trigger}}
+Trigger: follows {{.Trigger.Branch}}{{if .Trigger.CronSpec}}, cron {{.Trigger.CronSpec}}{{end}}{{if .Trigger.Size_}}, size {{.Trigger.Size_}}{{end}}{{if .Trigger.Commits}}, commits {{.Trigger.Commits}}{{end}}{{if .Trigger.Branches}}, branches{{range .Trigger.Branches}} {{.}}{{end}}{{end}}{{if .Trigger.All}} (all){{end}}{{end}}
 `)
 	if err != nil {
 		return err
@@ -162,7 +170,8 @@ func PrintDetailedCommitInfo(commitInfo *PrintableCommitInfo) error {
 	template, err := template.New("CommitInfo").Funcs(funcMap).Parse(
 		`Commit: {{.Commit.Repo.Name}}@{{.Commit.ID}}{{if .Branch}}
 Original Branch: {{.Branch.Name}}{{end}}{{if .Description}}
-Description: {{.Description}}{{end}}{{if .ParentCommit}}
+Description: {{.Description}}{{end}}{{if .Annotations}}
+Annotations: {{range $key, $value := .Annotations}} {{$key}}={{$value}} {{end}}{{end}}{{if .ParentCommit}}
 Parent: {{.ParentCommit.ID}}{{end}}{{if .FullTimestamps}}
 Started: {{.Started}}{{else}}
 Started: {{prettyAgo .Started}}{{end}}{{if .Finished}}{{if .FullTimestamps}}
@@ -189,11 +198,7 @@ func PrintFileInfo(w io.Writer, fileInfo *pfs.FileInfo, fullTimestamps, withComm
 		fmt.Fprintf(w, "%s\t", fileInfo.File.Commit.ID)
 	}
 	fmt.Fprintf(w, "%s\t", fileInfo.File.Path)
-	if fileInfo.FileType == pfs.FileType_FILE {
-		fmt.Fprint(w, "file\t")
-	} else {
-		fmt.Fprint(w, "dir\t")
-	}
+	fmt.Fprintf(w, "%s\t", fileType(fileInfo.FileType))
 	if withCommit {
 		if fileInfo.Committed == nil {
 			fmt.Fprintf(w, "-\t")
@@ -217,12 +222,31 @@ func PrintDiffFileInfo(w io.Writer, added bool, fileInfo *pfs.FileInfo, fullTime
 	PrintFileInfo(w, fileInfo, fullTimestamps, false)
 }
 
+// PrintMergeConflict pretty-prints a conflict from merge branch. Each side is
+// shown as the size of its version of the file, or "deleted".
+func PrintMergeConflict(w io.Writer, conflict *pfs.MergeConflict) {
+	fmt.Fprintf(w, "%s\t", conflict.Path)
+	for _, fileInfo := range []*pfs.FileInfo{conflict.Ours, conflict.Theirs} {
+		if fileInfo == nil {
+			fmt.Fprint(w, color.RedString("deleted\t"))
+		} else {
+			fmt.Fprintf(w, "%s\t", units.BytesSize(float64(fileInfo.SizeBytes)))
+		}
+	}
+	fmt.Fprintln(w)
+}
+
 // PrintDetailedFileInfo pretty-prints detailed file info.
 func PrintDetailedFileInfo(fileInfo *pfs.FileInfo) error {
 	template, err := template.New("FileInfo").Funcs(funcMap).Parse(
 		`Path: {{.File.Path}}
 Type: {{fileType .FileType}}
-Size: {{prettySize .SizeBytes}}
+Size: {{prettySize .SizeBytes}}{{if and .StoredSizeBytes (ne .StoredSizeBytes .SizeBytes)}}
+Stored size: {{prettySize .StoredSizeBytes}}{{end}}{{if .Sha256}}
+SHA-256: {{printf "%x" .Sha256}}{{end}}{{if .Md5}}
+MD5: {{printf "%x" .Md5}}{{end}}{{if .Mode}}
+Mode: {{printf "%#o" .Mode}}{{end}}{{if .SymlinkTarget}}
+Target: {{.SymlinkTarget}}{{end}}
 Children: {{range .Children}} {{.}} {{end}}
 `)
 	if err != nil {
@@ -232,16 +256,21 @@ Children: {{range .Children}} {{.}} {{end}}
 }
 
 func fileType(fileType pfs.FileType) string {
-	if fileType == pfs.FileType_FILE {
+	switch fileType {
+	case pfs.FileType_FILE:
 		return "file"
+	case pfs.FileType_SYMLINK:
+		return "symlink"
+	default:
+		return "dir"
 	}
-	return "dir"
 }
 
 var funcMap = template.FuncMap{
-	"prettyAgo":  pretty.Ago,
-	"prettySize": pretty.Size,
-	"fileType":   fileType,
+	"prettyAgo":      pretty.Ago,
+	"prettySize":     pretty.Size,
+	"prettyDuration": pretty.Duration,
+	"fileType":       fileType,
 }
 
 // CompactPrintBranch renders 'b' as a compact string, e.g.
@@ -15,6 +15,15 @@ import (
 	"github.com/pachyderm/s2"
 )
 
+// etag returns the ETag of a file, which is the MD5 digest of its contents
+// (like S3) if PFS has it, and the PFS hash of the file otherwise.
+func etag(fileInfo *pfsClient.FileInfo) string {
+	if fileInfo.Md5 != nil {
+		return fmt.Sprintf("%x", fileInfo.Md5)
+	}
+	return fmt.Sprintf("%x", fileInfo.Hash)
+}
+
 func newContents(fileInfo *pfsClient.FileInfo) (s2.Contents, error) {
 	t, err := types.TimestampFromProto(fileInfo.Committed)
 	if err != nil {
@@ -24,7 +33,7 @@ func newContents(fileInfo *pfsClient.FileInfo) (s2.Contents, error) {
 	return s2.Contents{
 		Key:          fileInfo.File.Path,
 		LastModified: t,
-		ETag:         fmt.Sprintf("%x", fileInfo.Hash),
+		ETag:         etag(fileInfo),
 		Size:         fileInfo.SizeBytes,
 		StorageClass: globalStorageClass,
 		Owner:        defaultUser,
@@ -1,7 +1,6 @@
 package s3
 
 import (
-	"fmt"
 	"io"
 	"net/http"
 	"path"
@@ -263,10 +262,11 @@ func (c *controller) CompleteMultipart(r *http.Request, bucketName, key, uploadI
 			return nil, err
 		}
 
-		// Only verify the ETag when it's of the same length as PFS file
-		// hashes. This is because s3 clients will generally use md5 for
-		// ETags, and would otherwise fail.
-		expectedETag := fmt.Sprintf("%x", fileInfo.Hash)
+		// Only verify the ETag when it's of the same length as the one we
+		// returned for the part. This is because s3 clients will generally
+		// use md5 for ETags, and would otherwise fail if the part has no
+		// md5 digest in PFS.
+		expectedETag := etag(fileInfo)
 		if len(part.ETag) == len(expectedETag) && part.ETag != expectedETag {
 			return nil, s2.InvalidPartError(r)
 		}
@@ -298,7 +298,7 @@ func (c *controller) CompleteMultipart(r *http.Request, bucketName, key, uploadI
 
 	result := s2.CompleteMultipartResult{Location: globalLocation}
 	if fileInfo != nil {
-		result.ETag = fmt.Sprintf("%x", fileInfo.Hash)
+		result.ETag = etag(fileInfo)
 		result.Version = fileInfo.File.Commit.ID
 	}
 
@@ -348,7 +348,7 @@ func (c *controller) ListMultipartChunks(r *http.Request, bucketName, key, uploa
 
 		result.Parts = append(result.Parts, s2.Part{
 			PartNumber: partNumber,
-			ETag:       fmt.Sprintf("%x", fileInfo.Hash),
+			ETag:       etag(fileInfo),
 		})
 
 		return nil
@@ -392,7 +392,7 @@ func (c *controller) UploadMultipartChunk(r *http.Request, bucketName, key, uplo
 		return "", err
 	}
 
-	return fmt.Sprintf("%x", fileInfo.Hash), nil
+	return etag(fileInfo), nil
 }
 
 func (c *controller) DeleteMultipartChunk(r *http.Request, bucketName, key, uploadID string, partNumber int) error {
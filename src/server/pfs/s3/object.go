@@ -1,7 +1,6 @@
 package s3
 
 import (
-	"fmt"
 	"io"
 	"net/http"
 	"strings"
@@ -65,7 +64,7 @@ func (c *controller) GetObject(r *http.Request, bucketName, file, version string
 	result := s2.GetObjectResult{
 		ModTime:      modTime,
 		Content:      content,
-		ETag:         fmt.Sprintf("%x", fileInfo.Hash),
+		ETag:         etag(fileInfo),
 		Version:      bucket.Commit,
 		DeleteMarker: false,
 	}
@@ -111,7 +110,7 @@ func (c *controller) PutObject(r *http.Request, bucketName, file string, reader
 
 	result := s2.PutObjectResult{}
 	if fileInfo != nil {
-		result.ETag = fmt.Sprintf("%x", fileInfo.Hash)
+		result.ETag = etag(fileInfo)
 		result.Version = fileInfo.File.Commit.ID
 	}
 
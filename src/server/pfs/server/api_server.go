@@ -61,7 +61,7 @@ func (a *apiServer) CreateRepoInTransaction(
 	txnCtx *txnenv.TransactionContext,
 	request *pfs.CreateRepoRequest,
 ) error {
-	return a.driver.createRepo(txnCtx, request.Repo, request.Description, request.Update)
+	return a.driver.createRepo(txnCtx, request.Repo, request.Description, request.Compression, request.ClearCompression, request.Quota, request.Update)
 }
 
 // CreateRepo implements the protobuf pfs.CreateRepo RPC
@@ -145,7 +145,7 @@ func (a *apiServer) Fsck(request *pfs.FsckRequest, fsckServer pfs.API_FsckServer
 	defer func(start time.Time) {
 		a.Log(request, fmt.Sprintf("stream containing %d messages", sent), retErr, time.Since(start))
 	}(time.Now())
-	if err := a.driver.fsck(a.env.GetPachClient(fsckServer.Context()), request.Fix, func(resp *pfs.FsckResponse) error {
+	if err := a.driver.fsck(a.env.GetPachClient(fsckServer.Context()), request.Fix, request.VerifyChecksums, func(resp *pfs.FsckResponse) error {
 		sent++
 		return fsckServer.Send(resp)
 	}); err != nil {
@@ -172,12 +172,12 @@ func (a *apiServer) StartCommitInTransaction(
 		var commit *pfs.Commit
 		err := metrics.ReportRequest(func() error {
 			var err error
-			commit, err = a.driver.startCommitNewStorageLayer(txnCtx, id, request.Parent, request.Branch, request.Provenance, request.Description)
+			commit, err = a.driver.startCommitNewStorageLayer(txnCtx, id, request.Parent, request.Branch, request.Provenance, request.Description, request.Annotations)
 			return err
 		})
 		return commit, err
 	}
-	return a.driver.startCommit(txnCtx, id, request.Parent, request.Branch, request.Provenance, request.Description)
+	return a.driver.startCommit(txnCtx, id, request.Parent, request.Branch, request.Provenance, request.Description, request.Annotations)
 }
 
 // StartCommit implements the protobuf pfs.StartCommit RPC
@@ -201,13 +201,24 @@ func (a *apiServer) BuildCommit(ctx context.Context, request *pfs.BuildCommitReq
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
 
-	commit, err := a.driver.buildCommit(ctx, request.ID, request.Parent, request.Branch, request.Provenance, request.Tree, request.Trees, request.Datums, request.SizeBytes)
+	commit, err := a.driver.buildCommit(ctx, request.ID, request.Parent, request.Branch, request.Provenance, request.Tree, request.Trees, request.Datums, request.SizeBytes, request.Annotations)
 	if err != nil {
 		return nil, err
 	}
 	return commit, nil
 }
 
+// AnnotateCommit implements the protobuf pfs.AnnotateCommit RPC
+func (a *apiServer) AnnotateCommit(ctx context.Context, request *pfs.AnnotateCommitRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	if err := a.driver.annotateCommit(ctx, request.Commit, request.Annotations, request.DeleteKeys); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
 // FinishCommitInTransaction is identical to FinishCommit except that it can run
 // inside an existing etcd STM transaction.  This is not an RPC.
 func (a *apiServer) FinishCommitInTransaction(
@@ -216,13 +227,13 @@ func (a *apiServer) FinishCommitInTransaction(
 ) error {
 	if a.env.NewStorageLayer {
 		return metrics.ReportRequest(func() error {
-			return a.driver.finishCommitNewStorageLayer(txnCtx, request.Commit, request.Description)
+			return a.driver.finishCommitNewStorageLayer(txnCtx, request.Commit, request.Description, request.Annotations)
 		})
 	}
 	if request.Trees != nil {
-		return a.driver.finishOutputCommit(txnCtx, request.Commit, request.Trees, request.Datums, request.SizeBytes)
+		return a.driver.finishOutputCommit(txnCtx, request.Commit, request.Trees, request.Datums, request.SizeBytes, request.FileCount, request.Annotations)
 	}
-	return a.driver.finishCommit(txnCtx, request.Commit, request.Tree, request.Empty, request.Description)
+	return a.driver.finishCommit(txnCtx, request.Commit, request.Tree, request.Empty, request.Description, request.Annotations)
 }
 
 // FinishCommit implements the protobuf pfs.FinishCommit RPC
@@ -251,7 +262,7 @@ func (a *apiServer) ListCommit(ctx context.Context, request *pfs.ListCommitReque
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
 
-	commitInfos, err := a.driver.listCommit(a.env.GetPachClient(ctx), request.Repo, request.To, request.From, request.Number, request.Reverse)
+	commitInfos, err := a.driver.listCommit(a.env.GetPachClient(ctx), request.Repo, request.To, request.From, request.Number, request.Reverse, request.Annotations)
 	if err != nil {
 		return nil, err
 	}
@@ -267,7 +278,7 @@ func (a *apiServer) ListCommitStream(request *pfs.ListCommitRequest, respServer
 	defer func(start time.Time) {
 		a.Log(request, fmt.Sprintf("stream containing %d commits", sent), retErr, time.Since(start))
 	}(time.Now())
-	return a.driver.listCommitF(a.env.GetPachClient(respServer.Context()), request.Repo, request.To, request.From, request.Number, request.Reverse, func(ci *pfs.CommitInfo) error {
+	return a.driver.listCommitF(a.env.GetPachClient(respServer.Context()), request.Repo, request.To, request.From, request.Number, request.Reverse, request.Annotations, func(ci *pfs.CommitInfo) error {
 		sent++
 		return respServer.Send(ci)
 	})
@@ -279,7 +290,13 @@ func (a *apiServer) CreateBranchInTransaction(
 	txnCtx *txnenv.TransactionContext,
 	request *pfs.CreateBranchRequest,
 ) error {
-	return a.driver.createBranch(txnCtx, request.Branch, request.Head, request.Provenance)
+	if err := a.driver.createBranch(txnCtx, request.Branch, request.Head, request.Provenance, request.Trigger); err != nil {
+		return err
+	}
+	if request.Retention != nil {
+		return a.driver.setBranchRetention(txnCtx, request.Branch, request.Retention)
+	}
+	return nil
 }
 
 // CreateBranch implements the protobuf pfs.CreateBranch RPC
@@ -345,6 +362,39 @@ func (a *apiServer) DeleteBranch(ctx context.Context, request *pfs.DeleteBranchR
 	return &types.Empty{}, nil
 }
 
+// SetBranchRetention implements the protobuf pfs.SetBranchRetention RPC
+func (a *apiServer) SetBranchRetention(ctx context.Context, request *pfs.SetBranchRetentionRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	if err := a.txnEnv.WithWriteContext(ctx, func(txnCtx *txnenv.TransactionContext) error {
+		return a.driver.setBranchRetention(txnCtx, request.Branch, request.Retention)
+	}); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+// MergeBranch implements the protobuf pfs.MergeBranch RPC
+func (a *apiServer) MergeBranch(ctx context.Context, request *pfs.MergeBranchRequest) (response *pfs.MergeBranchResponse, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	return a.driver.mergeBranch(a.env.GetPachClient(ctx), request.From, request.Into, request.Strategy, request.Description)
+}
+
+// PruneBranch implements the protobuf pfs.PruneBranch RPC
+func (a *apiServer) PruneBranch(ctx context.Context, request *pfs.PruneBranchRequest) (response *pfs.CommitInfos, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+
+	commitInfos, err := a.driver.pruneBranch(a.env.GetPachClient(ctx), request.Branch, request.DryRun)
+	if err != nil {
+		return nil, err
+	}
+	return &pfs.CommitInfos{CommitInfo: commitInfos}, nil
+}
+
 // DeleteCommitInTransaction is identical to DeleteCommit except that it can run
 // inside an existing etcd STM transaction.  This is not an RPC.
 func (a *apiServer) DeleteCommitInTransaction(
@@ -380,7 +430,7 @@ func (a *apiServer) SubscribeCommit(request *pfs.SubscribeCommitRequest, stream
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, nil, retErr, time.Since(start)) }(time.Now())
 
-	return a.driver.subscribeCommit(a.env.GetPachClient(stream.Context()), request.Repo, request.Branch, request.Prov, request.From, request.State, stream.Send)
+	return a.driver.subscribeCommit(a.env.GetPachClient(stream.Context()), request.Repo, request.Branch, request.Prov, request.From, request.State, request.Annotations, stream.Send)
 }
 
 // PutFile implements the protobuf pfs.PutFile RPC
@@ -446,7 +496,17 @@ func (a *apiServer) InspectFile(ctx context.Context, request *pfs.InspectFileReq
 		}
 	}(time.Now())
 
-	return a.driver.inspectFile(a.env.GetPachClient(ctx), request.File)
+	pachClient := a.env.GetPachClient(ctx)
+	fileInfo, err := a.driver.inspectFile(pachClient, request.File)
+	if err != nil {
+		return nil, err
+	}
+	if request.StoredSize {
+		if err := a.driver.setStoredSize(pachClient, fileInfo); err != nil {
+			return nil, err
+		}
+	}
+	return fileInfo, nil
 }
 
 // ListFile implements the protobuf pfs.ListFile RPC
@@ -536,6 +596,19 @@ func (a *apiServer) GlobFileStream(request *pfs.GlobFileRequest, respServer pfs.
 	})
 }
 
+// GrepFile implements the protobuf pfs.GrepFile RPC
+func (a *apiServer) GrepFile(request *pfs.GrepFileRequest, respServer pfs.API_GrepFileServer) (retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	var sent int
+	defer func(start time.Time) {
+		a.Log(request, fmt.Sprintf("response stream with %d matches", sent), retErr, time.Since(start))
+	}(time.Now())
+	return a.driver.grepFile(a.env.GetPachClient(respServer.Context()), request.Commit, request.Pattern, request.Regex, func(match *pfs.GrepMatch) error {
+		sent++
+		return respServer.Send(match)
+	})
+}
+
 // DiffFile implements the protobuf pfs.DiffFile RPC
 func (a *apiServer) DiffFile(ctx context.Context, request *pfs.DiffFileRequest) (response *pfs.DiffFileResponse, retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
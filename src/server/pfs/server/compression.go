@@ -0,0 +1,42 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+
+	pfsclient "github.com/pachyderm/pachyderm/src/client/pfs"
+	pfsserver "github.com/pachyderm/pachyderm/src/server/pfs"
+)
+
+// decompress returns the decompressed contents of 'data'.
+func decompress(data []byte, compression pfsclient.Compression) (_ []byte, retErr error) {
+	r, err := pfsserver.NewDecompressor(ioutil.NopCloser(bytes.NewReader(data)), compression)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := r.Close(); err != nil && retErr == nil {
+			retErr = err
+		}
+	}()
+	return ioutil.ReadAll(r)
+}
+
+// countWriter counts the bytes written through it.
+type countWriter struct {
+	w    io.Writer
+	size int64
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// limitReadCloser limits the number of bytes read from a ReadCloser.
+type limitReadCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -4,6 +4,9 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/binary"
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
@@ -15,6 +18,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -30,7 +34,10 @@ import (
 	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
 	pfsserver "github.com/pachyderm/pachyderm/src/server/pfs"
 	"github.com/pachyderm/pachyderm/src/server/pkg/ancestry"
+	"github.com/pachyderm/pachyderm/src/server/pkg/avro"
+	"github.com/pachyderm/pachyderm/src/server/pkg/backoff"
 	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	"github.com/pachyderm/pachyderm/src/server/pkg/dlock"
 	"github.com/pachyderm/pachyderm/src/server/pkg/errutil"
 	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
 	"github.com/pachyderm/pachyderm/src/server/pkg/obj"
@@ -194,9 +201,56 @@ func newDriver(
 		go d.master(env, objClient, db)
 		go d.compactionWorker()
 	}
+	if env.PFSRetentionInterval != "" {
+		interval, err := time.ParseDuration(env.PFSRetentionInterval)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not parse retention interval")
+		}
+		if interval > 0 {
+			go d.lockedLoop(retentionLockPath, interval, "enforcing branch retention policies", d.enforceRetentionPolicies)
+		}
+	}
+	if env.PFSTriggerInterval != "" {
+		interval, err := time.ParseDuration(env.PFSTriggerInterval)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not parse trigger interval")
+		}
+		if interval > 0 {
+			go d.lockedLoop(triggerLockPath, interval, "evaluating cron triggers", d.evaluateCronTriggers)
+		}
+	}
 	return d, nil
 }
 
+// lockedLoop calls 'f' once per 'interval' in the one pachd that holds the
+// dlock at 'lockPath'. If 'f' fails, the lock is released and the loop is
+// retried with backoff; 'desc' describes 'f' in the logged error.
+func (d *driver) lockedLoop(lockPath string, interval time.Duration, desc string, f func(ctx context.Context) error) {
+	lock := dlock.NewDLock(d.etcdClient, path.Join(d.prefix, lockPath))
+	backoff.RetryNotify(func() error {
+		ctx, err := lock.Lock(context.Background())
+		if err != nil {
+			return err
+		}
+		defer lock.Unlock(ctx)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := f(ctx); err != nil {
+				return err
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}, backoff.NewInfiniteBackOff(), func(err error, d time.Duration) error {
+		logrus.Errorf("error %s: %v; retrying in %v", desc, err, d)
+		return nil
+	})
+}
+
 // checkIsAuthorizedInTransaction is identicalto checkIsAuthorized except that
 // it performs reads consistent with the latest state of the STM transaction.
 func (d *driver) checkIsAuthorizedInTransaction(txnCtx *txnenv.TransactionContext, r *pfs.Repo, s auth.Scope) error {
@@ -244,11 +298,14 @@ func now() *types.Timestamp {
 	return t
 }
 
-func (d *driver) createRepo(txnCtx *txnenv.TransactionContext, repo *pfs.Repo, description string, update bool) error {
+func (d *driver) createRepo(txnCtx *txnenv.TransactionContext, repo *pfs.Repo, description string, compression pfs.Compression, clearCompression bool, quota *pfs.Quota, update bool) error {
 	// Validate arguments
 	if repo == nil {
 		return errors.New("repo cannot be nil")
 	}
+	if _, ok := pfs.Compression_name[int32(compression)]; !ok {
+		return errors.Errorf("unrecognized compression %d", compression)
+	}
 
 	// Check that the user is logged in (user doesn't need any access level to
 	// create a repo, but they must be authenticated if auth is active)
@@ -273,8 +330,9 @@ func (d *driver) createRepo(txnCtx *txnenv.TransactionContext, repo *pfs.Repo, d
 	} else if err == nil && !update {
 		return errors.Errorf("cannot create \"%s\" as it already exists", repo.Name)
 	}
+	exists := err == nil
 	created := now()
-	if err == nil {
+	if exists {
 		created = existingRepoInfo.Created
 	}
 
@@ -295,10 +353,36 @@ func (d *driver) createRepo(txnCtx *txnenv.TransactionContext, repo *pfs.Repo, d
 		}
 	}
 
+	// An empty quota doesn't limit anything, so it removes the repo's quota
+	removeQuota := quota != nil && quota.SizeBytes == 0 && quota.FileCount == 0
+	if removeQuota {
+		quota = nil
+	}
 	repoInfo := &pfs.RepoInfo{
 		Repo:        repo,
 		Created:     created,
 		Description: description,
+		Compression: compression,
+		Quota:       quota,
+	}
+	if exists {
+		// Updating a repo doesn't change its contents, or settings that the
+		// request leaves unset
+		repoInfo.SizeBytes = existingRepoInfo.SizeBytes
+		repoInfo.Branches = existingRepoInfo.Branches
+		if compression == pfs.Compression_UNCOMPRESSED && !clearCompression {
+			repoInfo.Compression = existingRepoInfo.Compression
+		}
+		if quota == nil && !removeQuota {
+			repoInfo.Quota = existingRepoInfo.Quota
+		}
+	}
+	if repoInfo.Quota.GetFileCount() != 0 {
+		if existingRepoInfo.Quota.GetFileCount() != 0 {
+			repoInfo.FileCount = existingRepoInfo.FileCount
+		} else if repoInfo.FileCount, err = d.headFileCount(txnCtx, repo); err != nil {
+			return err
+		}
 	}
 	// Only Put the new repoInfo if something has changed.  This
 	// optimization is impactful because pps will frequently update the
@@ -567,7 +651,7 @@ func (e ErrProvenanceOfSubvenance) Error() string {
 // 3. Commit provenance is transitive
 // 4. Commit provenance and commit subvenance are dual relations
 // If fix is true it will attempt to fix as many of these issues as it can.
-func (d *driver) fsck(pachClient *client.APIClient, fix bool, cb func(*pfs.FsckResponse) error) error {
+func (d *driver) fsck(pachClient *client.APIClient, fix, verifyChecksums bool, cb func(*pfs.FsckResponse) error) error {
 	ctx := pachClient.Ctx()
 	repos := d.repos.ReadOnly(ctx)
 	key := path.Join
@@ -901,6 +985,11 @@ func (d *driver) fsck(pachClient *client.APIClient, fix bool, cb func(*pfs.FsckR
 			}
 		}
 	}
+	if verifyChecksums {
+		if err := d.fsckChecksums(pachClient, commitInfos, onError); err != nil {
+			return err
+		}
+	}
 	if fix {
 		_, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
 			for _, ci := range newCommitInfos {
@@ -918,6 +1007,51 @@ func (d *driver) fsck(pachClient *client.APIClient, fix bool, cb func(*pfs.FsckR
 	return nil
 }
 
+// fsckChecksums reads the contents of every file in the finished commits in
+// 'commitInfos' that has a recorded SHA-256 digest, and reports the files
+// whose contents no longer match it. Content that's shared by several files is
+// only read once.
+func (d *driver) fsckChecksums(pachClient *client.APIClient, commitInfos map[string]*pfs.CommitInfo, onError func(error) error) error {
+	verified := make(map[string]bool)
+	for _, ci := range commitInfos {
+		if ci.Finished == nil {
+			continue
+		}
+		if err := d.walkFile(pachClient, client.NewFile(ci.Commit.Repo.Name, ci.Commit.ID, "/"), func(fi *pfs.FileInfo) error {
+			if fi.FileType != pfs.FileType_FILE || fi.Sha256 == nil {
+				return nil
+			}
+			key := fmt.Sprintf("%x/%x", fi.Hash, fi.Sha256)
+			if verified[key] {
+				return nil
+			}
+			verified[key] = true
+			r, err := d.getFile(pachClient, fi.File, 0, 0)
+			if err != nil {
+				return err
+			}
+			hash := sha256.New()
+			if _, err := io.Copy(hash, r); err != nil {
+				return err
+			}
+			if actual := hash.Sum(nil); !bytes.Equal(actual, fi.Sha256) {
+				return onError(pfsserver.ErrChecksumMismatch{
+					File:     fi.File,
+					Expected: fi.Sha256,
+					Actual:   actual,
+				})
+			}
+			return nil
+		}); err != nil {
+			if err := onError(errors.Wrapf(err, "could not verify the checksums in commit %s@%s",
+				ci.Commit.Repo.Name, ci.Commit.ID)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (d *driver) listRepo(pachClient *client.APIClient, includeAuth bool) (*pfs.ListRepoResponse, error) {
 	ctx := pachClient.Ctx()
 	repos := d.repos.ReadOnly(ctx)
@@ -1112,17 +1246,17 @@ func (d *driver) deleteRepo(txnCtx *txnenv.TransactionContext, repo *pfs.Repo, f
 
 // ID can be passed in for transactions, which need to ensure the ID doesn't
 // change after the commit ID has been reported to a client.
-func (d *driver) startCommit(txnCtx *txnenv.TransactionContext, ID string, parent *pfs.Commit, branch string, provenance []*pfs.CommitProvenance, description string) (*pfs.Commit, error) {
-	return d.makeCommit(txnCtx, ID, parent, branch, provenance, nil, nil, nil, nil, nil, description, 0)
+func (d *driver) startCommit(txnCtx *txnenv.TransactionContext, ID string, parent *pfs.Commit, branch string, provenance []*pfs.CommitProvenance, description string, annotations map[string]string) (*pfs.Commit, error) {
+	return d.makeCommit(txnCtx, ID, parent, branch, provenance, nil, nil, nil, nil, nil, description, annotations, 0)
 }
 
 func (d *driver) buildCommit(ctx context.Context, ID string, parent *pfs.Commit,
 	branch string, provenance []*pfs.CommitProvenance,
-	tree *pfs.Object, trees []*pfs.Object, datums *pfs.Object, sizeBytes uint64) (*pfs.Commit, error) {
+	tree *pfs.Object, trees []*pfs.Object, datums *pfs.Object, sizeBytes uint64, annotations map[string]string) (*pfs.Commit, error) {
 	commit := &pfs.Commit{}
 	err := d.txnEnv.WithWriteContext(ctx, func(txnCtx *txnenv.TransactionContext) error {
 		var err error
-		commit, err = d.makeCommit(txnCtx, ID, parent, branch, provenance, tree, trees, datums, nil, nil, "", sizeBytes)
+		commit, err = d.makeCommit(txnCtx, ID, parent, branch, provenance, tree, trees, datums, nil, nil, "", annotations, sizeBytes)
 		return err
 	})
 	return commit, err
@@ -1152,12 +1286,16 @@ func (d *driver) makeCommit(
 	recordFiles []string,
 	records []*pfs.PutFileRecords,
 	description string,
+	annotations map[string]string,
 	sizeBytes uint64,
 ) (*pfs.Commit, error) {
 	// Validate arguments:
 	if parent == nil {
 		return nil, errors.Errorf("parent cannot be nil")
 	}
+	if err := validateAnnotations(annotations); err != nil {
+		return nil, err
+	}
 
 	// Check that caller is authorized
 	if err := d.checkIsAuthorizedInTransaction(txnCtx, parent.Repo, auth.Scope_WRITER); err != nil {
@@ -1177,6 +1315,7 @@ func (d *driver) makeCommit(
 		Origin:      &pfs.CommitOrigin{Kind: pfs.OriginKind_USER},
 		Started:     now(),
 		Description: description,
+		Annotations: annotations,
 	}
 	if branch != "" {
 		if err := ancestry.ValidateName(branch); err != nil {
@@ -1264,12 +1403,13 @@ func (d *driver) makeCommit(
 	// 2. Finish 'newCommit' (if treeRef != nil or records != nil); see
 	//    "FinishCommit case" above)
 	if treeRef != nil || treesRefs != nil || records != nil {
+		var tree hashtree.HashTree
 		if records != nil {
 			parentTree, err := d.getTreeForCommit(txnCtx, parent)
 			if err != nil {
 				return nil, err
 			}
-			tree, err := parentTree.Copy()
+			tree, err = parentTree.Copy()
 			if err != nil {
 				return nil, err
 			}
@@ -1294,12 +1434,16 @@ func (d *driver) makeCommit(
 		newCommitInfo.Trees = treesRefs
 		newCommitInfo.Datums = datumsRef
 		newCommitInfo.SizeBytes = sizeBytes
+		if err := d.checkQuota(txnCtx, newCommitInfo, tree); err != nil {
+			return nil, err
+		}
 		newCommitInfo.Finished = now()
 
 		// If we're updating the master branch, also update the repo size (see
 		// "Update repoInfo" below)
 		if branch == "master" {
 			repoInfo.SizeBytes = newCommitInfo.SizeBytes
+			repoInfo.FileCount = newCommitInfo.FileCount
 		}
 	} else {
 		if err := d.openCommits.ReadWrite(txnCtx.Stm).Put(newCommit.ID, newCommit); err != nil {
@@ -1407,7 +1551,7 @@ func (d *driver) makeCommit(
 	return newCommit, nil
 }
 
-func (d *driver) finishCommit(txnCtx *txnenv.TransactionContext, commit *pfs.Commit, tree *pfs.Object, empty bool, description string) (retErr error) {
+func (d *driver) finishCommit(txnCtx *txnenv.TransactionContext, commit *pfs.Commit, tree *pfs.Object, empty bool, description string, annotations map[string]string) (retErr error) {
 	// Validate arguments
 	if commit == nil {
 		return errors.New("commit cannot be nil")
@@ -1415,6 +1559,9 @@ func (d *driver) finishCommit(txnCtx *txnenv.TransactionContext, commit *pfs.Com
 	if commit.Repo == nil {
 		return errors.New("commit repo cannot be nil")
 	}
+	if err := validateAnnotations(annotations); err != nil {
+		return err
+	}
 
 	if err := d.checkIsAuthorizedInTransaction(txnCtx, commit.Repo, auth.Scope_WRITER); err != nil {
 		return err
@@ -1429,6 +1576,7 @@ func (d *driver) finishCommit(txnCtx *txnenv.TransactionContext, commit *pfs.Com
 	if description != "" {
 		commitInfo.Description = description
 	}
+	mergeAnnotations(commitInfo, annotations, nil)
 
 	var parentTree, finishedTree hashtree.HashTree
 	if !empty {
@@ -1480,15 +1628,44 @@ func (d *driver) finishCommit(txnCtx *txnenv.TransactionContext, commit *pfs.Com
 		}
 
 		commitInfo.SizeBytes = uint64(finishedTree.FSSize())
+		if err := d.checkQuota(txnCtx, commitInfo, finishedTree); err != nil {
+			return err
+		}
 	}
 	commitInfo.Finished = now()
 	if err := d.updateProvenanceProgress(txnCtx, !empty, commitInfo); err != nil {
 		return err
 	}
-	return d.writeFinishedCommit(txnCtx.Stm, commit, commitInfo)
+	if err := d.writeFinishedCommit(txnCtx.Stm, commit, commitInfo); err != nil {
+		return err
+	}
+	return d.triggerFinishedCommit(txnCtx, commitInfo)
 }
 
-func (d *driver) finishOutputCommit(txnCtx *txnenv.TransactionContext, commit *pfs.Commit, trees []*pfs.Object, datums *pfs.Object, size uint64) (retErr error) {
+// triggerFinishedCommit evaluates the triggers that follow the branch of the
+// just-finished 'commitInfo'. Commits that are no longer the head of their
+// branch are skipped; they're counted when the branch's head is finished.
+func (d *driver) triggerFinishedCommit(txnCtx *txnenv.TransactionContext, commitInfo *pfs.CommitInfo) error {
+	if commitInfo.Branch == nil {
+		return nil
+	}
+	branchInfo := &pfs.BranchInfo{}
+	if err := d.branches(commitInfo.Branch.Repo.Name).ReadWrite(txnCtx.Stm).Get(commitInfo.Branch.Name, branchInfo); err != nil {
+		if col.IsErrNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if branchInfo.Head == nil || branchInfo.Head.ID != commitInfo.Commit.ID {
+		return nil
+	}
+	return d.triggerCommit(txnCtx, commitInfo.Branch)
+}
+
+func (d *driver) finishOutputCommit(txnCtx *txnenv.TransactionContext, commit *pfs.Commit, trees []*pfs.Object, datums *pfs.Object, size uint64, fileCount uint64, annotations map[string]string) (retErr error) {
+	if err := validateAnnotations(annotations); err != nil {
+		return err
+	}
 	if err := d.checkIsAuthorizedInTransaction(txnCtx, commit.Repo, auth.Scope_WRITER); err != nil {
 		return err
 	}
@@ -1502,11 +1679,50 @@ func (d *driver) finishOutputCommit(txnCtx *txnenv.TransactionContext, commit *p
 	commitInfo.Trees = trees
 	commitInfo.Datums = datums
 	commitInfo.SizeBytes = size
+	commitInfo.FileCount = fileCount
+	if err := d.checkQuota(txnCtx, commitInfo, nil); err != nil {
+		return err
+	}
 	commitInfo.Finished = now()
+	mergeAnnotations(commitInfo, annotations, nil)
 	if err := d.updateProvenanceProgress(txnCtx, true, commitInfo); err != nil {
 		return err
 	}
-	return d.writeFinishedCommit(txnCtx.Stm, commit, commitInfo)
+	if err := d.writeFinishedCommit(txnCtx.Stm, commit, commitInfo); err != nil {
+		return err
+	}
+	return d.triggerFinishedCommit(txnCtx, commitInfo)
+}
+
+// annotateCommit adds 'annotations' to the annotations of 'userCommit' and
+// removes the keys in 'deleteKeys'. Unlike the rest of a commit's metadata,
+// annotations may be modified after the commit has been finished.
+func (d *driver) annotateCommit(ctx context.Context, userCommit *pfs.Commit, annotations map[string]string, deleteKeys []string) error {
+	// Validate arguments
+	if userCommit == nil {
+		return errors.New("commit cannot be nil")
+	}
+	if userCommit.Repo == nil {
+		return errors.New("commit repo cannot be nil")
+	}
+	if err := validateAnnotations(annotations); err != nil {
+		return err
+	}
+	return d.txnEnv.WithWriteContext(ctx, func(txnCtx *txnenv.TransactionContext) error {
+		if err := d.checkIsAuthorizedInTransaction(txnCtx, userCommit.Repo, auth.Scope_WRITER); err != nil {
+			return err
+		}
+		commit := proto.Clone(userCommit).(*pfs.Commit)
+		commitInfo, err := d.resolveCommit(txnCtx.Stm, commit)
+		if err != nil {
+			return err
+		}
+		commits := d.commits(commit.Repo.Name).ReadWrite(txnCtx.Stm)
+		return commits.Update(commitInfo.Commit.ID, commitInfo, func() error {
+			mergeAnnotations(commitInfo, annotations, deleteKeys)
+			return nil
+		})
+	})
 }
 
 func (d *driver) updateProvenanceProgress(txnCtx *txnenv.TransactionContext, success bool, ci *pfs.CommitInfo) error {
@@ -1557,6 +1773,7 @@ func (d *driver) writeFinishedCommit(stm col.STM, commit *pfs.Commit, commitInfo
 			// had shared its head commit with master, and then we created a new commit on that branch
 			if branchInfo.Head != nil && branchInfo.Head.ID == commit.ID {
 				repoInfo.SizeBytes = commitInfo.SizeBytes
+				repoInfo.FileCount = commitInfo.FileCount
 				if err := repos.Put(commit.Repo.Name, repoInfo); err != nil {
 					return err
 				}
@@ -1566,6 +1783,145 @@ func (d *driver) writeFinishedCommit(stm col.STM, commit *pfs.Commit, commitInfo
 	return nil
 }
 
+// checkQuota returns ErrQuotaExceeded if 'commitInfo' would take its repo over
+// its quota. The commit's files are in 'tree', or if 'tree' is nil, in
+// commitInfo.Tree or commitInfo.Trees. If the quota limits the number of
+// files, checkQuota also sets commitInfo.FileCount.
+func (d *driver) checkQuota(txnCtx *txnenv.TransactionContext, commitInfo *pfs.CommitInfo, tree hashtree.HashTree) error {
+	repoInfo := &pfs.RepoInfo{}
+	if err := d.repos.ReadWrite(txnCtx.Stm).Get(commitInfo.Commit.Repo.Name, repoInfo); err != nil {
+		return err
+	}
+	if repoInfo.Quota == nil {
+		return nil
+	}
+	if repoInfo.Quota.FileCount != 0 {
+		var err error
+		commitInfo.FileCount, err = d.countFiles(txnCtx, commitInfo, tree)
+		if err != nil {
+			return err
+		}
+	}
+	return pfsserver.CheckQuota(commitInfo.Commit.Repo, repoInfo.Quota, commitInfo.SizeBytes, commitInfo.FileCount)
+}
+
+// countFiles returns the number of files in a finished commit, whose files are
+// in 'tree', or if 'tree' is nil, in commitInfo.Tree or commitInfo.Trees. If
+// the commit's parent has a known count, countFiles only visits the files that
+// differ from the parent's (Diff skips subtrees whose hashes match), so that
+// finishing a commit doesn't walk every file in the repo.
+func (d *driver) countFiles(txnCtx *txnenv.TransactionContext, commitInfo *pfs.CommitInfo, tree hashtree.HashTree) (_ uint64, retErr error) {
+	if commitInfo.FileCount != 0 {
+		// Already counted, e.g. by the worker that merged an output commit
+		return commitInfo.FileCount, nil
+	}
+	var count uint64
+	countFile := func(_ string, node *hashtree.NodeProto) error {
+		if node.FileNode != nil {
+			count++
+		}
+		return nil
+	}
+	if tree == nil && commitInfo.Tree != nil {
+		var err error
+		tree, err = hashtree.GetHashTreeObject(txnCtx.Client, d.storageRoot, commitInfo.Tree)
+		if err != nil {
+			return 0, err
+		}
+		defer destroyHashtree(tree)
+	}
+	if tree != nil {
+		parentCount, ok, err := d.parentFileCount(txnCtx, commitInfo)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			err := tree.Walk("/", countFile)
+			return count, err
+		}
+		parentTree, err := d.getTreeForCommit(txnCtx, commitInfo.ParentCommit)
+		if err != nil {
+			return 0, err
+		}
+		count = parentCount
+		if err := tree.Diff(parentTree, "/", "/", -1, func(_ string, node *hashtree.NodeProto, new bool) error {
+			if node.FileNode != nil {
+				if new {
+					count++
+				} else {
+					count--
+				}
+			}
+			return nil
+		}); err != nil {
+			return 0, err
+		}
+		return count, nil
+	}
+	if commitInfo.Trees == nil {
+		return 0, nil
+	}
+	rs, err := d.getTrees(txnCtx.Client, commitInfo, "/")
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		for _, r := range rs {
+			if err := r.Close(); err != nil && retErr == nil {
+				retErr = err
+			}
+		}
+	}()
+	err = hashtree.Walk(rs, "/", countFile)
+	return count, err
+}
+
+// parentFileCount returns the number of files in the parent of 'commitInfo',
+// and whether it's known without walking the parent's files. It's known if the
+// parent was counted when it was finished (because its repo had a file-count
+// quota), or if the parent has no files at all.
+func (d *driver) parentFileCount(txnCtx *txnenv.TransactionContext, commitInfo *pfs.CommitInfo) (uint64, bool, error) {
+	if commitInfo.ParentCommit == nil {
+		return 0, true, nil
+	}
+	parentInfo := &pfs.CommitInfo{}
+	if err := d.commits(commitInfo.ParentCommit.Repo.Name).ReadWrite(txnCtx.Stm).Get(commitInfo.ParentCommit.ID, parentInfo); err != nil {
+		return 0, false, err
+	}
+	switch {
+	case parentInfo.Finished == nil || parentInfo.Trees != nil:
+		// getTreeForCommit can't read the parent's files
+		return 0, false, nil
+	case parentInfo.Tree == nil:
+		return 0, true, nil
+	default:
+		return parentInfo.FileCount, parentInfo.FileCount != 0, nil
+	}
+}
+
+// headFileCount returns the number of files in the last finished commit on
+// 'repo's master branch, which is what RepoInfo.FileCount tracks.
+func (d *driver) headFileCount(txnCtx *txnenv.TransactionContext, repo *pfs.Repo) (uint64, error) {
+	branchInfo := &pfs.BranchInfo{}
+	if err := d.branches(repo.Name).ReadWrite(txnCtx.Stm).Get("master", branchInfo); err != nil {
+		if col.IsErrNotFound(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	for commit := branchInfo.Head; commit != nil; {
+		commitInfo := &pfs.CommitInfo{}
+		if err := d.commits(repo.Name).ReadWrite(txnCtx.Stm).Get(commit.ID, commitInfo); err != nil {
+			return 0, err
+		}
+		if commitInfo.Finished != nil {
+			return d.countFiles(txnCtx, commitInfo, nil)
+		}
+		commit = commitInfo.ParentCommit
+	}
+	return 0, nil
+}
+
 // propagateCommits selectively starts commits in or downstream of 'branches' in
 // order to restore the invariant that branch provenance matches HEAD commit
 // provenance:
@@ -1925,9 +2281,9 @@ func (d *driver) resolveCommit(stm col.STM, userCommit *pfs.Commit) (*pfs.Commit
 }
 
 func (d *driver) listCommit(pachClient *client.APIClient, repo *pfs.Repo,
-	to *pfs.Commit, from *pfs.Commit, number uint64, reverse bool) ([]*pfs.CommitInfo, error) {
+	to *pfs.Commit, from *pfs.Commit, number uint64, reverse bool, annotations map[string]string) ([]*pfs.CommitInfo, error) {
 	var result []*pfs.CommitInfo
-	if err := d.listCommitF(pachClient, repo, to, from, number, reverse, func(ci *pfs.CommitInfo) error {
+	if err := d.listCommitF(pachClient, repo, to, from, number, reverse, annotations, func(ci *pfs.CommitInfo) error {
 		result = append(result, ci)
 		return nil
 	}); err != nil {
@@ -1937,7 +2293,7 @@ func (d *driver) listCommit(pachClient *client.APIClient, repo *pfs.Repo,
 }
 
 func (d *driver) listCommitF(pachClient *client.APIClient, repo *pfs.Repo,
-	to *pfs.Commit, from *pfs.Commit, number uint64, reverse bool, f func(*pfs.CommitInfo) error) error {
+	to *pfs.Commit, from *pfs.Commit, number uint64, reverse bool, annotations map[string]string, f func(*pfs.CommitInfo) error) error {
 	// Validate arguments
 	if repo == nil {
 		return errors.New("repo cannot be nil")
@@ -2025,6 +2381,9 @@ func (d *driver) listCommitF(pachClient *client.APIClient, repo *pfs.Repo,
 				}
 				lastRev = createRev
 			}
+			if !hasAnnotations(ci, annotations) {
+				return nil
+			}
 			cis = append(cis, proto.Clone(ci).(*pfs.CommitInfo))
 			return nil
 		}); err != nil {
@@ -2044,13 +2403,16 @@ func (d *driver) listCommitF(pachClient *client.APIClient, repo *pfs.Repo,
 			if err := commits.Get(cursor.ID, &commitInfo); err != nil {
 				return err
 			}
+			cursor = commitInfo.ParentCommit
+			if !hasAnnotations(&commitInfo, annotations) {
+				continue
+			}
 			if err := f(&commitInfo); err != nil {
 				if err == errutil.ErrBreak {
 					return nil
 				}
 				return err
 			}
-			cursor = commitInfo.ParentCommit
 			number--
 		}
 	}
@@ -2058,7 +2420,7 @@ func (d *driver) listCommitF(pachClient *client.APIClient, repo *pfs.Repo,
 }
 
 func (d *driver) subscribeCommit(pachClient *client.APIClient, repo *pfs.Repo, branch string, prov *pfs.CommitProvenance,
-	from *pfs.Commit, state pfs.CommitState, f func(*pfs.CommitInfo) error) error {
+	from *pfs.Commit, state pfs.CommitState, annotations map[string]string, f func(*pfs.CommitInfo) error) error {
 	// Validate arguments
 	if repo == nil {
 		return errors.New("repo cannot be nil")
@@ -2106,6 +2468,10 @@ func (d *driver) subscribeCommit(pachClient *client.APIClient, repo *pfs.Repo, b
 				}
 			}
 
+			if !hasAnnotations(commitInfo, annotations) {
+				continue
+			}
+
 			if commitInfo.Branch != nil {
 				// if branch is provided, make sure the commit was created on that branch
 				if branch != "" && commitInfo.Branch.Name != branch {
@@ -2219,6 +2585,14 @@ func (d *driver) deleteCommit(txnCtx *txnenv.TransactionContext, userCommit *pfs
 	if err := d.checkIsAuthorizedInTransaction(txnCtx, userCommit.Repo, auth.Scope_WRITER); err != nil {
 		return err
 	}
+	return d.deleteCommitUnchecked(txnCtx, userCommit)
+}
+
+// deleteCommitUnchecked deletes 'userCommit' and all of its downstream
+// commits. Unlike deleteCommit it doesn't check that the caller is authorized
+// to do so, it's used by retention policy enforcement, which runs on behalf of
+// whoever set the policy.
+func (d *driver) deleteCommitUnchecked(txnCtx *txnenv.TransactionContext, userCommit *pfs.Commit) error {
 	// Main txn: Delete all downstream commits, and update subvenance of upstream commits
 	// TODO update branches inside this txn, by storing a repo's branches in its
 	// RepoInfo or its HEAD commit
@@ -2471,9 +2845,11 @@ func (d *driver) deleteCommit(txnCtx *txnenv.TransactionContext, userCommit *pfs
 						return err
 					}
 					repoInfo.SizeBytes = headCommitInfo.SizeBytes
+					repoInfo.FileCount = headCommitInfo.FileCount
 				} else {
 					// No HEAD commit, set the repo size to 0
 					repoInfo.SizeBytes = 0
+					repoInfo.FileCount = 0
 				}
 
 				if err := repos.Put(repo, repoInfo); err != nil {
@@ -2529,7 +2905,7 @@ func (d *driver) resolveCommitProvenance(stm col.STM, userCommitProvenance *pfs.
 //
 // This invariant is assumed to hold for all branches upstream of 'branch', but not
 // for 'branch' itself once 'b.Provenance' has been set.
-func (d *driver) createBranch(txnCtx *txnenv.TransactionContext, branch *pfs.Branch, commit *pfs.Commit, provenance []*pfs.Branch) error {
+func (d *driver) createBranch(txnCtx *txnenv.TransactionContext, branch *pfs.Branch, commit *pfs.Commit, provenance []*pfs.Branch, trigger *pfs.Trigger) error {
 	// Validate arguments
 	if branch == nil {
 		return errors.New("branch cannot be nil")
@@ -2546,6 +2922,17 @@ func (d *driver) createBranch(txnCtx *txnenv.TransactionContext, branch *pfs.Bra
 	if err := ancestry.ValidateName(branch.Name); err != nil {
 		return err
 	}
+	// An empty trigger removes the branch's existing trigger
+	removeTrigger := trigger != nil && proto.Equal(trigger, &pfs.Trigger{})
+	if trigger != nil && !removeTrigger {
+		// A branch with provenance is moved by its upstream branches
+		if len(provenance) > 0 {
+			return errors.Errorf("branch %s@%s cannot have both provenance and a trigger", branch.Repo.Name, branch.Name)
+		}
+		if err := d.validateTrigger(txnCtx, branch, trigger); err != nil {
+			return err
+		}
+	}
 	// The request must do exactly one of:
 	// 1) updating 'branch's provenance (commit is nil OR commit == branch)
 	// 2) re-pointing 'branch' at a new commit
@@ -2596,6 +2983,17 @@ func (d *driver) createBranch(txnCtx *txnenv.TransactionContext, branch *pfs.Bra
 		branchInfo.Name = branch.Name // set in case 'branch' is new
 		branchInfo.Branch = branch
 		branchInfo.Head = commit
+		// Requests that don't mention a trigger keep the existing one
+		if removeTrigger {
+			branchInfo.Trigger = nil
+			branchInfo.LastTriggered = nil
+			branchInfo.TriggerHeads = nil
+		} else if trigger != nil {
+			branchInfo.Trigger = trigger
+		}
+		if branchInfo.Trigger != nil && len(provenance) > 0 {
+			return errors.Errorf("branch %s@%s cannot have both provenance and a trigger", branch.Repo.Name, branch.Name)
+		}
 		branchInfo.DirectProvenance = nil
 		for _, provBranch := range provenance {
 			if provBranch.Repo.Name == branch.Repo.Name && provBranch.Name == branch.Name {
@@ -2617,6 +3015,7 @@ func (d *driver) createBranch(txnCtx *txnenv.TransactionContext, branch *pfs.Bra
 				return err
 			}
 			repoInfo.SizeBytes = ci.SizeBytes
+			repoInfo.FileCount = ci.FileCount
 		}
 		return nil
 	}); err != nil {
@@ -2689,7 +3088,17 @@ func (d *driver) createBranch(txnCtx *txnenv.TransactionContext, branch *pfs.Bra
 	// propagate the head commit to 'branch'. This may also modify 'branch', by
 	// creating a new HEAD commit if 'branch's provenance was changed and its
 	// current HEAD commit has old provenance
-	return txnCtx.PropagateCommit(branch, false)
+	if err := txnCtx.PropagateCommit(branch, false); err != nil {
+		return err
+	}
+
+	// A new trigger may already be satisfied by the branch it follows
+	if trigger != nil && !removeTrigger {
+		return d.evaluateTriggers(txnCtx, branch.Repo.Name, func(bi *pfs.BranchInfo) bool {
+			return bi.Branch.Name == branch.Name
+		})
+	}
+	return nil
 }
 
 func (d *driver) inspectBranch(txnCtx *txnenv.TransactionContext, branch *pfs.Branch) (*pfs.BranchInfo, error) {
@@ -2812,75 +3221,614 @@ func (d *driver) deleteBranch(txnCtx *txnenv.TransactionContext, branch *pfs.Bra
 	return nil
 }
 
-// scratchCommitPrefix returns an etcd prefix that's used to temporarily
-// store the state of a file in an open commit.  Once the commit is finished,
-// the scratch space is removed.
-func (d *driver) scratchCommitPrefix(commit *pfs.Commit) string {
-	return path.Join(commit.Repo.Name, commit.ID)
-}
-
-func (d *driver) checkFilePath(path string) error {
-	path = filepath.Clean(path)
-	if strings.HasPrefix(path, "../") {
-		return errors.Errorf("path (%s) invalid: traverses above root", path)
+func (d *driver) setBranchRetention(txnCtx *txnenv.TransactionContext, branch *pfs.Branch, retention *pfs.RetentionPolicy) error {
+	// Validate arguments
+	if branch == nil {
+		return errors.New("branch cannot be nil")
 	}
-	return nil
-}
-
-// scratchFilePrefix returns an etcd prefix that's used to temporarily
-// store the state of a file in an open commit.  Once the commit is finished,
-// the scratch space is removed.
-func (d *driver) scratchFilePrefix(file *pfs.File) (string, error) {
-	cleanedPath := path.Clean("/" + file.Path)
-	if err := d.checkFilePath(cleanedPath); err != nil {
-		return "", err
+	if branch.Repo == nil {
+		return errors.New("branch repo cannot be nil")
 	}
-	return path.Join(d.scratchCommitPrefix(file.Commit), cleanedPath), nil
-}
-
-func (d *driver) putFiles(pachClient *client.APIClient, s *putFileServer) error {
-	var files []*pfs.File
-	var putFilePaths []string
-	var putFileRecords []*pfs.PutFileRecords
-	var mu sync.Mutex
-	oneOff, repo, branch, err := d.forEachPutFile(pachClient, s, func(req *pfs.PutFileRequest, r io.Reader) error {
-		records, err := d.putFile(pachClient, req.File, req.Delimiter, req.TargetFileDatums,
-			req.TargetFileBytes, req.HeaderRecords, req.OverwriteIndex, r)
-		if err != nil {
-			return err
+	if retention != nil {
+		if retention.KeepLast < 0 {
+			return errors.Errorf("keep_last cannot be negative (got %d)", retention.KeepLast)
+		}
+		if retention.KeepFor != nil {
+			keepFor, err := types.DurationFromProto(retention.KeepFor)
+			if err != nil {
+				return err
+			}
+			if keepFor < 0 {
+				return errors.Errorf("keep_for cannot be negative (got %v)", keepFor)
+			}
 		}
-		mu.Lock()
-		defer mu.Unlock()
-		files = append(files, req.File)
-		putFilePaths = append(putFilePaths, req.File.Path)
-		putFileRecords = append(putFileRecords, records)
-		return nil
-	})
-	if err != nil {
-		return err
 	}
 
-	ctx := pachClient.Ctx()
-	if oneOff {
-		// oneOff puts only work on branches, so we know branch != "". We pass
-		// a commit with no ID, that ID will be filled in with the head of
-		// branch (if it exists).
-		return d.txnEnv.WithWriteContext(ctx, func(txnCtx *txnenv.TransactionContext) error {
-			_, err := d.makeCommit(txnCtx, "", client.NewCommit(repo, ""), branch, nil, nil, nil, nil, putFilePaths, putFileRecords, "", 0)
-			return err
-		})
+	if err := d.checkIsAuthorizedInTransaction(txnCtx, branch.Repo, auth.Scope_WRITER); err != nil {
+		return err
 	}
-	for i, file := range files {
-		if err := d.upsertPutFileRecords(pachClient, file, putFileRecords[i]); err != nil {
-			return err
+
+	branchInfo := &pfs.BranchInfo{}
+	if err := d.branches(branch.Repo.Name).ReadWrite(txnCtx.Stm).Update(branch.Name, branchInfo, func() error {
+		// Commits with provenance can't be deleted individually, so a policy on
+		// an output branch could never be enforced
+		if retention != nil && len(branchInfo.Provenance) > 0 {
+			return errors.Errorf("cannot set a retention policy on branch \"%s@%s\" because it has provenance", branch.Repo.Name, branch.Name)
+		}
+		branchInfo.Retention = retention
+		return nil
+	}); err != nil {
+		if col.IsErrNotFound(err) {
+			return ErrBranchInfoNotFound{Branch: branch}
 		}
+		return err
 	}
 	return nil
 }
 
-func (d *driver) putFile(pachClient *client.APIClient, file *pfs.File, delimiter pfs.Delimiter,
+// pruneBranch deletes the commits on 'branch' that fall outside of the
+// branch's retention policy, or just returns them if 'dryRun' is set.
+func (d *driver) pruneBranch(pachClient *client.APIClient, branch *pfs.Branch, dryRun bool) ([]*pfs.CommitInfo, error) {
+	// Validate arguments
+	if branch == nil {
+		return nil, errors.New("branch cannot be nil")
+	}
+	if branch.Repo == nil {
+		return nil, errors.New("branch repo cannot be nil")
+	}
+
+	scope := auth.Scope_WRITER
+	if dryRun {
+		scope = auth.Scope_READER
+	}
+	if err := d.checkIsAuthorized(pachClient, branch.Repo, scope); err != nil {
+		return nil, err
+	}
+	return d.enforceRetention(pachClient.Ctx(), branch, dryRun)
+}
+
+// enforceRetention implements pruneBranch without an authorization check. It's
+// also called by the retention loop, which runs without a user.
+func (d *driver) enforceRetention(ctx context.Context, branch *pfs.Branch, dryRun bool) ([]*pfs.CommitInfo, error) {
+	branches := d.branches(branch.Repo.Name).ReadOnly(ctx)
+	branchInfo := &pfs.BranchInfo{}
+	if err := branches.Get(branch.Name, branchInfo); err != nil {
+		if col.IsErrNotFound(err) {
+			return nil, ErrBranchInfoNotFound{Branch: branch}
+		}
+		return nil, err
+	}
+	policy := branchInfo.Retention
+	if policy == nil || (policy.KeepLast == 0 && policy.KeepFor == nil) {
+		return nil, nil
+	}
+	var cutoff time.Time
+	if policy.KeepFor != nil {
+		keepFor, err := types.DurationFromProto(policy.KeepFor)
+		if err != nil {
+			return nil, err
+		}
+		cutoff = time.Now().Add(-keepFor)
+	}
+
+	// The heads of other branches may be ancestors of this branch's head, and
+	// pruning them would move those branches
+	heads := make(map[string]bool)
+	otherBranch := &pfs.BranchInfo{}
+	if err := branches.List(otherBranch, col.DefaultOptions, func(string) error {
+		if otherBranch.Head != nil {
+			heads[otherBranch.Head.ID] = true
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	// Walk back from the head, collecting every commit that isn't protected.
+	// Only commits that could otherwise be pruned count towards keep_last.
+	var candidates []*pfs.CommitInfo
+	if err := d.txnEnv.WithReadContext(ctx, func(txnCtx *txnenv.TransactionContext) error {
+		commits := d.commits(branch.Repo.Name).ReadWrite(txnCtx.Stm)
+		var kept int64
+		for commit := branchInfo.Head; commit != nil; {
+			commitInfo := &pfs.CommitInfo{}
+			if err := commits.Get(commit.ID, commitInfo); err != nil {
+				return err
+			}
+			commit = commitInfo.ParentCommit
+			isHead := commitInfo.Commit.ID == branchInfo.Head.ID
+			if !isHead {
+				if heads[commitInfo.Commit.ID] {
+					continue
+				}
+				ok, err := d.prunable(txnCtx.Stm, commitInfo)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					continue
+				}
+			}
+			if isHead || kept < policy.KeepLast {
+				kept++
+				continue
+			}
+			if policy.KeepFor != nil {
+				finished, err := types.TimestampFromProto(commitInfo.Finished)
+				if err != nil {
+					return err
+				}
+				if finished.After(cutoff) {
+					continue
+				}
+			}
+			candidates = append(candidates, commitInfo)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return candidates, nil
+	}
+
+	var pruned []*pfs.CommitInfo
+	for _, commitInfo := range candidates {
+		deleted := false
+		if err := d.txnEnv.WithWriteContext(ctx, func(txnCtx *txnenv.TransactionContext) error {
+			// A pipeline may have processed the commit since it was read above
+			current := &pfs.CommitInfo{}
+			if err := d.commits(branch.Repo.Name).ReadWrite(txnCtx.Stm).Get(commitInfo.Commit.ID, current); err != nil {
+				return err
+			}
+			ok, err := d.prunable(txnCtx.Stm, current)
+			if err != nil || !ok {
+				return err
+			}
+			deleted = true
+			return d.deleteCommitUnchecked(txnCtx, current.Commit)
+		}); err != nil {
+			return pruned, errors.Wrapf(err, "error pruning commit %s@%s", commitInfo.Commit.Repo.Name, commitInfo.Commit.ID)
+		}
+		if deleted {
+			pruned = append(pruned, commitInfo)
+		}
+	}
+	return pruned, nil
+}
+
+// prunable returns true if a retention policy is allowed to delete the commit
+// described by 'commitInfo': it must be finished, have no provenance, and not
+// be the provenance of the current head of any output branch (deleting it
+// would delete that head). Older output commits derived from it are deleted
+// with it.
+func (d *driver) prunable(stm col.STM, commitInfo *pfs.CommitInfo) (bool, error) {
+	if commitInfo.Finished == nil || provenantOnInput(commitInfo.Provenance) {
+		return false, nil
+	}
+	checked := make(map[string]bool)
+	for _, subv := range commitInfo.Subvenance {
+		repo := subv.Upper.Repo.Name
+		if checked[repo] {
+			continue
+		}
+		checked[repo] = true
+		repoInfo := &pfs.RepoInfo{}
+		if err := d.repos.ReadWrite(stm).Get(repo, repoInfo); err != nil {
+			return false, err
+		}
+		for _, branch := range repoInfo.Branches {
+			branchInfo := &pfs.BranchInfo{}
+			if err := d.branches(repo).ReadWrite(stm).Get(branch.Name, branchInfo); err != nil {
+				return false, err
+			}
+			if branchInfo.Head == nil {
+				continue
+			}
+			headInfo := &pfs.CommitInfo{}
+			if err := d.commits(repo).ReadWrite(stm).Get(branchInfo.Head.ID, headInfo); err != nil {
+				return false, err
+			}
+			for _, prov := range headInfo.Provenance {
+				if prov.Commit.Repo.Name == commitInfo.Commit.Repo.Name && prov.Commit.ID == commitInfo.Commit.ID {
+					return false, nil
+				}
+			}
+		}
+	}
+	return true, nil
+}
+
+// mergeBranch applies the changes made on 'from' since its common ancestor
+// with 'into' to 'into', in a new commit. Paths that were changed differently
+// on both branches are resolved according to 'strategy'.
+func (d *driver) mergeBranch(pachClient *client.APIClient, from *pfs.Branch, into *pfs.Branch, strategy pfs.MergeStrategy, description string) (*pfs.MergeBranchResponse, error) {
+	// Validate arguments
+	if from == nil || into == nil {
+		return nil, errors.New("branch cannot be nil")
+	}
+	if from.Repo == nil || into.Repo == nil {
+		return nil, errors.New("branch repo cannot be nil")
+	}
+	if from.Repo.Name != into.Repo.Name {
+		return nil, errors.Errorf("cannot merge branches of different repos (\"%s\" and \"%s\")", from.Repo.Name, into.Repo.Name)
+	}
+	if from.Name == into.Name {
+		return nil, errors.Errorf("cannot merge branch \"%s\" into itself", from.Name)
+	}
+
+	if err := d.checkIsAuthorized(pachClient, into.Repo, auth.Scope_WRITER); err != nil {
+		return nil, err
+	}
+
+	// Compute the merge against the current heads of both branches
+	response := &pfs.MergeBranchResponse{}
+	var ours *pfs.Commit
+	var paths []string
+	var records []*pfs.PutFileRecords
+	if err := d.txnEnv.WithReadContext(pachClient.Ctx(), func(txnCtx *txnenv.TransactionContext) error {
+		theirsInfo, err := d.mergeHead(txnCtx, from)
+		if err != nil {
+			return err
+		}
+		oursInfo, err := d.mergeHead(txnCtx, into)
+		if err != nil {
+			return err
+		}
+		if theirsInfo == nil {
+			return nil // nothing to merge
+		}
+		var baseInfo *pfs.CommitInfo
+		if oursInfo != nil {
+			ours = oursInfo.Commit
+			baseInfo, err = d.mergeBase(txnCtx.Stm, oursInfo, theirsInfo)
+			if err != nil {
+				return err
+			}
+		}
+		var base *pfs.Commit
+		if baseInfo != nil {
+			base = baseInfo.Commit
+			response.Base = base
+		}
+
+		baseTree, err := d.getTreeForCommit(txnCtx, base)
+		if err != nil {
+			return err
+		}
+		oursTree, err := d.getTreeForCommit(txnCtx, ours)
+		if err != nil {
+			return err
+		}
+		theirsTree, err := d.getTreeForCommit(txnCtx, theirsInfo.Commit)
+		if err != nil {
+			return err
+		}
+		oursChanges, err := changedPaths(oursTree, baseTree)
+		if err != nil {
+			return err
+		}
+		theirsChanges, err := changedPaths(theirsTree, baseTree)
+		if err != nil {
+			return err
+		}
+
+		// Apply every change from 'theirs', unless 'ours' changed the same path
+		// differently
+		changed := make([]string, 0, len(theirsChanges))
+		for p := range theirsChanges {
+			changed = append(changed, p)
+		}
+		sort.Strings(changed)
+		for _, p := range changed {
+			theirsNode := theirsChanges[p]
+			if oursNode, ok := oursChanges[p]; ok {
+				if sameNode(oursNode, theirsNode) {
+					continue
+				}
+				conflict := &pfs.MergeConflict{Path: p}
+				if oursNode != nil {
+					conflict.Ours = nodeToFileInfo(oursInfo, p, oursNode, false)
+				}
+				if theirsNode != nil {
+					conflict.Theirs = nodeToFileInfo(theirsInfo, p, theirsNode, false)
+				}
+				response.Conflicts = append(response.Conflicts, conflict)
+				if strategy != pfs.MergeStrategy_THEIRS {
+					continue
+				}
+			}
+			record := &pfs.PutFileRecords{Tombstone: true}
+			if theirsNode != nil {
+				if theirsNode.FileNode.HasHeaderFooter {
+					return errors.Errorf("cannot merge \"%s\": merging files with a header or footer is not supported", p)
+				}
+				appendRecords(record, theirsNode)
+				record.Sha256 = theirsNode.FileNode.Sha256
+				record.Md5 = theirsNode.FileNode.Md5
+				record.Mode = theirsNode.FileNode.Mode
+				record.SymlinkTarget = theirsNode.FileNode.SymlinkTarget
+			}
+			paths = append(paths, p)
+			records = append(records, record)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if len(response.Conflicts) > 0 && strategy == pfs.MergeStrategy_FAIL {
+		return response, nil
+	}
+	if len(records) == 0 {
+		return response, nil
+	}
+
+	if description == "" {
+		description = fmt.Sprintf("Merge branch \"%s\" into \"%s\"", from.Name, into.Name)
+	}
+	if err := d.txnEnv.WithWriteContext(pachClient.Ctx(), func(txnCtx *txnenv.TransactionContext) error {
+		// Make sure that the merge was computed against the current head of
+		// 'into', otherwise the commits made in the meantime would be lost
+		branchInfo := &pfs.BranchInfo{}
+		if err := d.branches(into.Repo.Name).ReadWrite(txnCtx.Stm).Get(into.Name, branchInfo); err != nil {
+			return err
+		}
+		if !proto.Equal(branchInfo.Head, ours) {
+			return errors.Errorf("branch \"%s@%s\" was updated during the merge, retry it", into.Repo.Name, into.Name)
+		}
+		parent := client.NewCommit(into.Repo.Name, "")
+		if ours != nil {
+			parent.ID = ours.ID
+		}
+		commit, err := d.makeCommit(txnCtx, "", parent, into.Name, nil, nil, nil, nil, paths, records, description, nil, 0)
+		if err != nil {
+			return err
+		}
+		response.Commit = commit
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// mergeHead is a helper for mergeBranch that returns the CommitInfo of the
+// head of 'branch', or nil if the branch has no head.
+func (d *driver) mergeHead(txnCtx *txnenv.TransactionContext, branch *pfs.Branch) (*pfs.CommitInfo, error) {
+	branchInfo := &pfs.BranchInfo{}
+	if err := d.branches(branch.Repo.Name).ReadWrite(txnCtx.Stm).Get(branch.Name, branchInfo); err != nil {
+		if col.IsErrNotFound(err) {
+			return nil, ErrBranchInfoNotFound{Branch: branch}
+		}
+		return nil, err
+	}
+	if len(branchInfo.Provenance) > 0 {
+		return nil, errors.Errorf("cannot merge branch \"%s@%s\" because it has provenance", branch.Repo.Name, branch.Name)
+	}
+	if branchInfo.Head == nil {
+		return nil, nil
+	}
+	return d.resolveCommit(txnCtx.Stm, branchInfo.Head)
+}
+
+// mergeBase returns the most recent common ancestor of 'a' and 'b', or nil if
+// they don't have one.
+func (d *driver) mergeBase(stm col.STM, a *pfs.CommitInfo, b *pfs.CommitInfo) (*pfs.CommitInfo, error) {
+	commits := d.commits(a.Commit.Repo.Name).ReadWrite(stm)
+	ancestors := make(map[string]bool)
+	for commitInfo := a; ; {
+		ancestors[commitInfo.Commit.ID] = true
+		if commitInfo.ParentCommit == nil {
+			break
+		}
+		parentInfo := &pfs.CommitInfo{}
+		if err := commits.Get(commitInfo.ParentCommit.ID, parentInfo); err != nil {
+			return nil, err
+		}
+		commitInfo = parentInfo
+	}
+	for commitInfo := b; ; {
+		if ancestors[commitInfo.Commit.ID] {
+			return commitInfo, nil
+		}
+		if commitInfo.ParentCommit == nil {
+			return nil, nil
+		}
+		parentInfo := &pfs.CommitInfo{}
+		if err := commits.Get(commitInfo.ParentCommit.ID, parentInfo); err != nil {
+			return nil, err
+		}
+		commitInfo = parentInfo
+	}
+}
+
+// changedPaths returns the files that differ between 'tree' and 'base', mapped
+// to their node in 'tree' (or nil if they were deleted in 'tree')
+func changedPaths(tree hashtree.HashTree, base hashtree.HashTree) (map[string]*hashtree.NodeProto, error) {
+	changes := make(map[string]*hashtree.NodeProto)
+	if err := tree.Diff(base, "/", "/", -1, func(path string, node *hashtree.NodeProto, new bool) error {
+		if new {
+			changes[path] = node
+		} else if _, ok := changes[path]; !ok {
+			changes[path] = nil
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// sameNode returns true if 'a' and 'b' have the same content, or are both nil
+func sameNode(a *hashtree.NodeProto, b *hashtree.NodeProto) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return bytes.Equal(a.Hash, b.Hash)
+}
+
+// scratchCommitPrefix returns an etcd prefix that's used to temporarily
+// store the state of a file in an open commit.  Once the commit is finished,
+// the scratch space is removed.
+func (d *driver) scratchCommitPrefix(commit *pfs.Commit) string {
+	return path.Join(commit.Repo.Name, commit.ID)
+}
+
+func (d *driver) checkFilePath(path string) error {
+	path = filepath.Clean(path)
+	if strings.HasPrefix(path, "../") {
+		return errors.Errorf("path (%s) invalid: traverses above root", path)
+	}
+	return nil
+}
+
+// scratchFilePrefix returns an etcd prefix that's used to temporarily
+// store the state of a file in an open commit.  Once the commit is finished,
+// the scratch space is removed.
+func (d *driver) scratchFilePrefix(file *pfs.File) (string, error) {
+	cleanedPath := path.Clean("/" + file.Path)
+	if err := d.checkFilePath(cleanedPath); err != nil {
+		return "", err
+	}
+	return path.Join(d.scratchCommitPrefix(file.Commit), cleanedPath), nil
+}
+
+func (d *driver) putFiles(pachClient *client.APIClient, s *putFileServer) error {
+	var files []*pfs.File
+	var putFilePaths []string
+	var putFileRecords []*pfs.PutFileRecords
+	var mu sync.Mutex
+	oneOff, repo, branch, err := d.forEachPutFile(pachClient, s, func(req *pfs.PutFileRequest, r io.Reader) error {
+		records, err := d.putFile(pachClient, req.File, req.Delimiter, req.TargetFileDatums,
+			req.TargetFileBytes, req.HeaderRecords, req.OverwriteIndex, req.ExpectedSha256,
+			req.Mode, req.SymlinkTarget, r)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		files = append(files, req.File)
+		putFilePaths = append(putFilePaths, req.File.Path)
+		putFileRecords = append(putFileRecords, records)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx := pachClient.Ctx()
+	if oneOff {
+		// oneOff puts only work on branches, so we know branch != "". We pass
+		// a commit with no ID, that ID will be filled in with the head of
+		// branch (if it exists).
+		return d.txnEnv.WithWriteContext(ctx, func(txnCtx *txnenv.TransactionContext) error {
+			_, err := d.makeCommit(txnCtx, "", client.NewCommit(repo, ""), branch, nil, nil, nil, nil, putFilePaths, putFileRecords, "", nil, 0)
+			return err
+		})
+	}
+	for i, file := range files {
+		if err := d.upsertPutFileRecords(pachClient, file, putFileRecords[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// quotaReader returns ErrQuotaExceeded once the data read through it, added to
+// the size of the data it's being added to (see quotaBaseSize), exceeds the
+// repo's quota.
+type quotaReader struct {
+	r        io.Reader
+	repoInfo *pfs.RepoInfo
+	size     uint64
+}
+
+func (r *quotaReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.size += uint64(n)
+	if quotaErr := pfsserver.CheckQuota(r.repoInfo.Repo, r.repoInfo.Quota, r.size, 0); quotaErr != nil {
+		return n, quotaErr
+	}
+	return n, err
+}
+
+// quotaBaseSize returns the size of the data that a put to 'file' is added to.
+// That's the size of the commit that the put is applied on top of (the
+// parent of an open commit, or the head of a branch for a one-off put),
+// less any paths that are deleted or overwritten before the put is applied,
+// plus the data already put in the open commit. It's only an estimate, used
+// to stop a put early; checkQuota checks the finished commit exactly.
+func (d *driver) quotaBaseSize(pachClient *client.APIClient, file *pfs.File, overwrite bool) (uint64, error) {
+	commitInfo, err := d.inspectCommit(pachClient, file.Commit, pfs.CommitState_STARTED)
+	if err != nil {
+		if isNotFoundErr(err) || isNoHeadErr(err) {
+			return 0, nil // a one-off put to a branch with no head
+		}
+		return 0, err
+	}
+	var size uint64
+	var replaced []string
+	if overwrite {
+		replaced = append(replaced, path.Clean("/"+file.Path))
+	}
+	base := commitInfo.Commit
+	if commitInfo.Finished == nil {
+		base = commitInfo.ParentCommit
+		recordsCol := d.putFileRecords.ReadOnly(pachClient.Ctx())
+		records := &pfs.PutFileRecords{}
+		if err := recordsCol.ListPrefix(d.scratchCommitPrefix(commitInfo.Commit), records, col.DefaultOptions, func(key string) error {
+			if records.Tombstone {
+				replaced = append(replaced, path.Clean("/"+key))
+			}
+			for _, record := range records.Records {
+				size += uint64(record.SizeBytes)
+			}
+			return nil
+		}); err != nil {
+			return 0, err
+		}
+	}
+	if base == nil {
+		return size, nil
+	}
+	baseInfo, err := d.inspectCommit(pachClient, base, pfs.CommitState_STARTED)
+	if err != nil {
+		return 0, err
+	}
+	size += baseInfo.SizeBytes
+	// Only subtract each path once, and not at all if one of its parents
+	// is also replaced
+	sort.Slice(replaced, func(i, j int) bool { return len(replaced[i]) < len(replaced[j]) })
+	subtracted := make(map[string]bool)
+replacedPaths:
+	for _, p := range replaced {
+		for parent := p; ; parent = path.Dir(parent) {
+			if subtracted[parent] {
+				continue replacedPaths
+			}
+			if parent == "/" {
+				break
+			}
+		}
+		subtracted[p] = true
+		fileInfo, err := d.inspectFile(pachClient, client.NewFile(base.Repo.Name, base.ID, p))
+		if err != nil {
+			if pfsserver.IsFileNotFoundErr(err) {
+				continue
+			}
+			return 0, err
+		}
+		if fileInfo.SizeBytes > size {
+			return 0, nil
+		}
+		size -= fileInfo.SizeBytes
+	}
+	return size, nil
+}
+
+func (d *driver) putFile(pachClient *client.APIClient, file *pfs.File, delimiter pfs.Delimiter,
 	targetFileDatums, targetFileBytes, headerRecords int64, overwriteIndex *pfs.OverwriteIndex,
-	reader io.Reader) (*pfs.PutFileRecords, error) {
+	expectedSHA256 []byte, mode uint32, symlinkTarget string, reader io.Reader) (*pfs.PutFileRecords, error) {
 	if err := d.checkIsAuthorized(pachClient, file.Commit.Repo, auth.Scope_WRITER); err != nil {
 		return nil, err
 	}
@@ -2889,7 +3837,16 @@ func (d *driver) putFile(pachClient *client.APIClient, file *pfs.File, delimiter
 	if hasPutFileOptions && delimiter == pfs.Delimiter_NONE {
 		return nil, errors.Errorf("cannot set split options--targetFileBytes, targetFileDatums, or headerRecords--with delimiter == NONE, split disabled")
 	}
-	records := &pfs.PutFileRecords{}
+	if headerRecords != 0 && delimiter == pfs.Delimiter_AVRO {
+		return nil, errors.Errorf("cannot set headerRecords with delimiter == AVRO, the header of the avro file is used")
+	}
+	if (mode != 0 || symlinkTarget != "") && delimiter != pfs.Delimiter_NONE {
+		return nil, errors.Errorf("cannot set a mode or symlink target on files that are split")
+	}
+	if mode&^uint32(os.ModePerm) != 0 {
+		return nil, errors.Errorf("invalid mode %o, only permission bits may be set", mode)
+	}
+	records := &pfs.PutFileRecords{Mode: mode}
 	if overwriteIndex != nil && overwriteIndex.Index == 0 {
 		records.Tombstone = true
 	}
@@ -2899,11 +3856,45 @@ func (d *driver) putFile(pachClient *client.APIClient, file *pfs.File, delimiter
 	if err := hashtree.ValidatePath(file.Path); err != nil {
 		return nil, err
 	}
+	if symlinkTarget != "" {
+		// Symlinks have no contents, but 'reader' must still be drained, as
+		// forEachPutFile blocks until it is
+		n, err := io.Copy(ioutil.Discard, reader)
+		if err != nil {
+			return nil, err
+		}
+		if n > 0 {
+			return nil, errors.Errorf("cannot put data in symlink %q", file.Path)
+		}
+		records.Tombstone = true
+		records.SymlinkTarget = symlinkTarget
+		return records, nil
+	}
+
+	repoInfo := &pfs.RepoInfo{}
+	if err := d.repos.ReadOnly(pachClient.Ctx()).Get(file.Commit.Repo.Name, repoInfo); err != nil {
+		return nil, err
+	}
+	compression := repoInfo.Compression
+	if repoInfo.Quota.GetSizeBytes() != 0 {
+		// Fail as soon as the data put would take the repo over its quota,
+		// rather than storing all of it and failing in FinishCommit
+		size, err := d.quotaBaseSize(pachClient, file, records.Tombstone)
+		if err != nil {
+			return nil, err
+		}
+		reader = &quotaReader{r: reader, repoInfo: repoInfo, size: size}
+	}
+
+	// Compute the digests of everything that's put, so they can be checked
+	// against 'expectedSHA256' and recorded in the file (if it isn't split)
+	sha256Hash, md5Hash := sha256.New(), md5.New()
+	reader = io.TeeReader(reader, io.MultiWriter(sha256Hash, md5Hash))
 
 	if delimiter == pfs.Delimiter_NONE {
 		d.putObjectLimiter.Acquire()
 		defer d.putObjectLimiter.Release()
-		objects, size, err := pachClient.PutObjectSplit(reader)
+		objects, size, err := pachClient.PutObjectSplitCompressed(reader, compression)
 		if err != nil {
 			return nil, err
 		}
@@ -2929,6 +3920,8 @@ func (d *driver) putFile(pachClient *client.APIClient, file *pfs.File, delimiter
 
 			records.Records = append(records.Records, record)
 		}
+		records.Sha256 = sha256Hash.Sum(nil)
+		records.Md5 = md5Hash.Sum(nil)
 	} else {
 		var (
 			buffer        = &bytes.Buffer{}
@@ -2938,16 +3931,17 @@ func (d *driver) putFile(pachClient *client.APIClient, file *pfs.File, delimiter
 			// Note: this code generally distinguishes between nil header/footer (no
 			// header) and empty header/footer. To create a header-enabled directory
 			// with an empty header, allocate an empty slice & store it here
-			header    []byte
-			footer    []byte
-			EOF       = false
-			eg        errgroup.Group
-			bufioR    = bufio.NewReader(reader)
-			decoder   = json.NewDecoder(bufioR)
-			sqlReader = sql.NewPGDumpReader(bufioR)
-			csvReader = csv.NewReader(bufioR)
-			csvBuffer bytes.Buffer
-			csvWriter = csv.NewWriter(&csvBuffer)
+			header     []byte
+			footer     []byte
+			EOF        = false
+			eg         errgroup.Group
+			bufioR     = bufio.NewReader(reader)
+			decoder    = json.NewDecoder(bufioR)
+			sqlReader  = sql.NewPGDumpReader(bufioR)
+			avroReader = avro.NewContainerReader(bufioR)
+			csvReader  = csv.NewReader(bufioR)
+			csvBuffer  bytes.Buffer
+			csvWriter  = csv.NewWriter(&csvBuffer)
 			// indexToRecord serves as a de-facto slice of PutFileRecords. We can't
 			// use a real slice of PutFileRecords b/c indexToRecord has data appended
 			// to it by concurrent processes, and you can't append() to a slice
@@ -2962,6 +3956,7 @@ func (d *driver) putFile(pachClient *client.APIClient, file *pfs.File, delimiter
 			var err error
 			var value []byte
 			var csvRow []string // only used if delimiter == CSV
+			datums := int64(1)  // an AVRO block holds any number of records
 			switch delimiter {
 			case pfs.Delimiter_JSON:
 				var jsonValue json.RawMessage
@@ -2992,6 +3987,13 @@ func (d *driver) putFile(pachClient *client.APIClient, file *pfs.File, delimiter
 					}
 					value = csvBuffer.Bytes()
 				}
+			case pfs.Delimiter_AVRO:
+				value, datums, err = avroReader.ReadBlock()
+				if header == nil {
+					header = avroReader.Header
+				}
+			case pfs.Delimiter_PROTOBUF_DELIMITED:
+				value, err = readDelimitedMessage(bufioR)
 			default:
 				return nil, errors.Errorf("unrecognized delimiter %s", delimiter.String())
 			}
@@ -3004,7 +4006,7 @@ func (d *driver) putFile(pachClient *client.APIClient, file *pfs.File, delimiter
 			}
 			buffer.Write(value)
 			bytesWritten += int64(len(value))
-			datumsWritten++
+			datumsWritten += datums
 			var (
 				headerDone         = headerRecords == 0 || header != nil
 				headerReady        = !headerDone && datumsWritten >= headerRecords
@@ -3027,7 +4029,8 @@ func (d *driver) putFile(pachClient *client.APIClient, file *pfs.File, delimiter
 					eg.Go(func() error {
 						defer d.putObjectLimiter.Release()
 						defer d.memoryLimiter.Release(_bufferLen)
-						object, size, err := pachClient.PutObject(_buffer)
+						sha256Sum, md5Sum := sha256.Sum256(_buffer.Bytes()), md5.Sum(_buffer.Bytes())
+						object, size, err := pachClient.PutObjectCompressed(_buffer, compression)
 						if err != nil {
 							return err
 						}
@@ -3036,6 +4039,8 @@ func (d *driver) putFile(pachClient *client.APIClient, file *pfs.File, delimiter
 						indexToRecord[index] = &pfs.PutFileRecord{
 							SizeBytes:  size,
 							ObjectHash: object.Hash,
+							Sha256:     sha256Sum[:],
+							Md5:        md5Sum[:],
 						}
 						return nil
 					})
@@ -3063,7 +4068,7 @@ func (d *driver) putFile(pachClient *client.APIClient, file *pfs.File, delimiter
 				d.putObjectLimiter.Acquire()
 				eg.Go(func() error {
 					defer d.putObjectLimiter.Release()
-					object, size, err := pachClient.PutObject(bytes.NewReader(value))
+					object, size, err := pachClient.PutObjectCompressed(bytes.NewReader(value), compression)
 					if err != nil {
 						return err
 					}
@@ -3083,9 +4088,52 @@ func (d *driver) putFile(pachClient *client.APIClient, file *pfs.File, delimiter
 			return nil, err
 		}
 	}
+	if expectedSHA256 != nil {
+		if actual := sha256Hash.Sum(nil); !bytes.Equal(actual, expectedSHA256) {
+			return nil, pfsserver.ErrChecksumMismatch{
+				File:     file,
+				Expected: expectedSHA256,
+				Actual:   actual,
+			}
+		}
+	}
 	return records, nil
 }
 
+// maxDelimitedMessageSize is the largest message that a PROTOBUF_DELIMITED
+// split accepts, which is also protobuf's default limit.
+const maxDelimitedMessageSize = 64 * 1024 * 1024
+
+// readDelimitedMessage reads a protobuf message that's prefixed with its
+// length as a varint, and returns the message along with its prefix.
+func readDelimitedMessage(r *bufio.Reader) ([]byte, error) {
+	if _, err := r.Peek(1); err != nil {
+		return nil, err // io.EOF here means there are no more messages
+	}
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, errors.Wrapf(err, "error reading delimited protobuf message")
+	}
+	if size > maxDelimitedMessageSize {
+		return nil, errors.Errorf("delimited protobuf message is too large (%d bytes, the limit is %d)", size, maxDelimitedMessageSize)
+	}
+	// The size comes from the caller's data, so the message is read as it
+	// arrives rather than allocated up front
+	prefix := make([]byte, binary.MaxVarintLen64)
+	value := bytes.NewBuffer(prefix[:binary.PutUvarint(prefix, size)])
+	n, err := io.Copy(value, io.LimitReader(r, int64(size)))
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading delimited protobuf message")
+	}
+	if n < int64(size) {
+		return nil, errors.Wrapf(io.ErrUnexpectedEOF, "error reading delimited protobuf message")
+	}
+	return value.Bytes(), nil
+}
+
 func appendRecords(pfr *pfs.PutFileRecords, node *hashtree.NodeProto) {
 	for i, object := range node.FileNode.Objects {
 		// We only have the whole file size in src file, so mark the first object
@@ -3106,7 +4154,7 @@ func appendRecords(pfr *pfs.PutFileRecords, node *hashtree.NodeProto) {
 	for _, blockRef := range node.FileNode.BlockRefs {
 		pfr.Records = append(pfr.Records, &pfs.PutFileRecord{
 			BlockRef:  blockRef,
-			SizeBytes: int64(blockRef.Range.Upper - blockRef.Range.Lower),
+			SizeBytes: int64(pfsserver.BlockRefSize(blockRef)),
 		})
 	}
 }
@@ -3241,6 +4289,10 @@ func (d *driver) copyFile(pachClient *client.APIClient, src *pfs.File, dst *pfs.
 			return nil // parent dir will be copied as a PutFileRecord w/ Split==true
 		} else {
 			appendRecords(record, node)
+			record.Sha256 = node.FileNode.Sha256
+			record.Md5 = node.FileNode.Md5
+			record.Mode = node.FileNode.Mode
+			record.SymlinkTarget = node.FileNode.SymlinkTarget
 		}
 
 		// Either upsert 'record' to etcd (if 'dst' is in an open commit) or add it
@@ -3295,7 +4347,7 @@ func (d *driver) copyFile(pachClient *client.APIClient, src *pfs.File, dst *pfs.
 	// dst is finished => all PutFileRecords are in 'records'--put in a new commit
 	if !dstIsOpenCommit {
 		return d.txnEnv.WithWriteContext(pachClient.Ctx(), func(txnCtx *txnenv.TransactionContext) error {
-			_, err = d.makeCommit(txnCtx, "", client.NewCommit(dst.Commit.Repo.Name, ""), branch, nil, nil, nil, nil, paths, records, "", 0)
+			_, err = d.makeCommit(txnCtx, "", client.NewCommit(dst.Commit.Repo.Name, ""), branch, nil, nil, nil, nil, paths, records, "", nil, 0)
 			return err
 		})
 	}
@@ -3711,6 +4763,13 @@ func nodeToFileInfo(ci *pfs.CommitInfo, path string, node *hashtree.NodeProto, f
 	}
 	if node.FileNode != nil {
 		fileInfo.FileType = pfs.FileType_FILE
+		fileInfo.Sha256 = node.FileNode.Sha256
+		fileInfo.Md5 = node.FileNode.Md5
+		fileInfo.Mode = node.FileNode.Mode
+		if node.FileNode.SymlinkTarget != "" {
+			fileInfo.FileType = pfs.FileType_SYMLINK
+			fileInfo.SymlinkTarget = node.FileNode.SymlinkTarget
+		}
 		if full {
 			fileInfo.Objects = node.FileNode.Objects
 			fileInfo.BlockRefs = node.FileNode.BlockRefs
@@ -3808,7 +4867,11 @@ func (d *driver) inspectFile(pachClient *client.APIClient, file *pfs.File) (fi *
 		if err != nil {
 			return nil, pfsserver.ErrFileNotFound{file}
 		}
-		return nodeToFileInfoHeaderFooter(commitInfo, file.Path, node, tree, true)
+		fi, err := nodeToFileInfoHeaderFooter(commitInfo, file.Path, node, tree, true)
+		if err != nil {
+			return nil, err
+		}
+		return fi, nil
 	}
 	// Handle commits that use the newer hashtree format.
 	if commitInfo.Finished == nil {
@@ -3835,6 +4898,27 @@ func (d *driver) inspectFile(pachClient *client.APIClient, file *pfs.File) (fi *
 	return nodeToFileInfo(commitInfo, file.Path, node, true), nil
 }
 
+// setStoredSize sets fi.StoredSizeBytes to the number of bytes that the
+// contents of 'fi' take up in object storage. 'fi' must be a full FileInfo.
+func (d *driver) setStoredSize(pachClient *client.APIClient, fi *pfs.FileInfo) error {
+	if fi.FileType != pfs.FileType_FILE {
+		return nil
+	}
+	var storedSize uint64
+	for _, object := range fi.Objects {
+		objectInfo, err := pachClient.InspectObject(object.Hash)
+		if err != nil {
+			return err
+		}
+		storedSize += pfsserver.ByteRangeSize(objectInfo.BlockRef.Range)
+	}
+	for _, blockRef := range fi.BlockRefs {
+		storedSize += pfsserver.ByteRangeSize(blockRef.Range)
+	}
+	fi.StoredSizeBytes = storedSize
+	return nil
+}
+
 func (d *driver) listFile(pachClient *client.APIClient, file *pfs.File, full bool, history int64, f func(*pfs.FileInfo) error) (retErr error) {
 	// Validate arguments
 	if file == nil {
@@ -4078,6 +5162,62 @@ func (d *driver) globFile(pachClient *client.APIClient, commit *pfs.Commit, patt
 	})
 }
 
+// grepMaxLineSize is the longest line that grepFile will match against. Files
+// containing longer lines (typically binary files) are skipped.
+const grepMaxLineSize = 1024 * 1024
+
+// grepFile calls 'f' with every line that matches 'regex' in the files that
+// match the glob 'pattern' in 'commit'. Files are read in pachd through the
+// object API, so only the matching lines are sent back to the caller.
+func (d *driver) grepFile(pachClient *client.APIClient, commit *pfs.Commit, pattern string, regex string, f func(*pfs.GrepMatch) error) error {
+	re, err := regexp.Compile(regex)
+	if err != nil {
+		return errors.Wrapf(err, "invalid regex %q", regex)
+	}
+	// Collect the matching files before reading any of them, so that the
+	// hashtree isn't held open while file contents are streamed.
+	var files []*pfs.File
+	if err := d.globFile(pachClient, commit, pattern, func(fi *pfs.FileInfo) error {
+		if fi.FileType == pfs.FileType_FILE {
+			files = append(files, fi.File)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	for _, file := range files {
+		r, err := d.getFile(pachClient, file, 0, 0)
+		if err != nil {
+			return err
+		}
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), grepMaxLineSize)
+		var lineNumber int64
+		for scanner.Scan() {
+			lineNumber++
+			if !re.Match(scanner.Bytes()) {
+				continue
+			}
+			if err := f(&pfs.GrepMatch{
+				File:       file,
+				LineNumber: lineNumber,
+				Line:       scanner.Text(),
+			}); err != nil {
+				return err
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			if err == bufio.ErrTooLong {
+				logrus.Infof("grep: skipping %s@%s:%s, it has a line longer than %d bytes",
+					file.Commit.Repo.Name, file.Commit.ID, file.Path, grepMaxLineSize)
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
 func (d *driver) diffFile(pachClient *client.APIClient, newFile *pfs.File, oldFile *pfs.File, shallow bool) ([]*pfs.FileInfo, []*pfs.FileInfo, error) {
 	// Validate arguments
 	if newFile == nil {
@@ -4192,7 +5332,7 @@ func (d *driver) deleteFile(pachClient *client.APIClient, file *pfs.File) error
 			return pfsserver.ErrCommitFinished{file.Commit}
 		}
 		return d.txnEnv.WithWriteContext(pachClient.Ctx(), func(txnCtx *txnenv.TransactionContext) error {
-			_, err := d.makeCommit(txnCtx, "", client.NewCommit(file.Commit.Repo.Name, ""), branch, nil, nil, nil, nil, []string{file.Path}, []*pfs.PutFileRecords{&pfs.PutFileRecords{Tombstone: true}}, "", 0)
+			_, err := d.makeCommit(txnCtx, "", client.NewCommit(file.Commit.Repo.Name, ""), branch, nil, nil, nil, nil, []string{file.Path}, []*pfs.PutFileRecords{&pfs.PutFileRecords{Tombstone: true}}, "", nil, 0)
 			return err
 		})
 	}
@@ -4248,6 +5388,27 @@ func (d *driver) upsertPutFileRecords(
 			if newRecords.Tombstone {
 				existingRecords.Tombstone = true
 				existingRecords.Records = nil
+				existingRecords.Mode = 0
+				existingRecords.SymlinkTarget = ""
+			}
+			if newRecords.SymlinkTarget != "" {
+				// A symlink replaces any data put earlier in the commit
+				existingRecords.Records = nil
+				existingRecords.SymlinkTarget = newRecords.SymlinkTarget
+			} else if existingRecords.SymlinkTarget != "" && len(newRecords.Records) > 0 {
+				return errors.Errorf("cannot put data in symlink %q", file.Path)
+			}
+			if newRecords.Mode != 0 {
+				existingRecords.Mode = newRecords.Mode
+			}
+			// The digests only describe the file if they cover all of its
+			// data, so they're dropped once data is appended to it
+			if len(existingRecords.Records) == 0 {
+				existingRecords.Sha256 = newRecords.Sha256
+				existingRecords.Md5 = newRecords.Md5
+			} else if len(newRecords.Records) > 0 {
+				existingRecords.Sha256 = nil
+				existingRecords.Md5 = nil
 			}
 			existingRecords.Split = newRecords.Split
 			existingRecords.Records = append(existingRecords.Records, newRecords.Records...)
@@ -4269,9 +5430,20 @@ func (d *driver) applyWrite(key string, records *pfs.PutFileRecords, tree hashtr
 		}
 	}
 	if !records.Split {
+		if records.SymlinkTarget != "" {
+			return tree.PutSymlink(key, records.SymlinkTarget)
+		}
 		if len(records.Records) == 0 {
 			return nil
 		}
+		// The records' digests only describe the file if they're all of its
+		// contents, i.e. the file doesn't exist yet, or the records replace it
+		_, err := tree.Get(key)
+		if err != nil && hashtree.Code(err) != hashtree.PathNotFound {
+			return err
+		}
+		firstIndex := records.Records[0].OverwriteIndex
+		isNewFile := err != nil || records.Tombstone || (firstIndex != nil && firstIndex.Index == 0)
 		for _, record := range records.Records {
 			sizeMap[record.ObjectHash] = record.SizeBytes
 			if record.OverwriteIndex != nil {
@@ -4306,6 +5478,16 @@ func (d *driver) applyWrite(key string, records *pfs.PutFileRecords, tree hashtr
 				}
 			}
 		}
+		if isNewFile && records.Sha256 != nil {
+			if err := tree.SetFileChecksum(key, records.Sha256, records.Md5); err != nil {
+				return err
+			}
+		}
+		if records.Mode != 0 {
+			if err := tree.SetFileMode(key, records.Mode); err != nil {
+				return err
+			}
+		}
 	} else {
 		nodes, err := tree.ListAll(key)
 		if err != nil && hashtree.Code(err) != hashtree.PathNotFound {
@@ -4359,12 +5541,56 @@ func (d *driver) applyWrite(key string, records *pfs.PutFileRecords, tree hashtr
 						return err
 					}
 				}
+				if record.Sha256 != nil {
+					if err := tree.SetFileChecksum(path.Join(key, fmt.Sprintf(splitSuffixFmt, i+int(indexOffset))), record.Sha256, record.Md5); err != nil {
+						return err
+					}
+				}
 			}
 		}
 	}
 	return nil
 }
 
+// validateAnnotations checks that every annotation key is non-empty and can
+// be expressed on the command line as "key=value"
+func validateAnnotations(annotations map[string]string) error {
+	for k := range annotations {
+		if k == "" {
+			return errors.New("annotation keys cannot be empty")
+		}
+		if strings.Contains(k, "=") {
+			return errors.Errorf("annotation key %q cannot contain '='", k)
+		}
+	}
+	return nil
+}
+
+// mergeAnnotations adds 'annotations' to ci.Annotations (overwriting the
+// values of existing keys) and then removes 'deleteKeys'
+func mergeAnnotations(ci *pfs.CommitInfo, annotations map[string]string, deleteKeys []string) {
+	if len(annotations) > 0 && ci.Annotations == nil {
+		ci.Annotations = make(map[string]string)
+	}
+	for k, v := range annotations {
+		ci.Annotations[k] = v
+	}
+	for _, k := range deleteKeys {
+		delete(ci.Annotations, k)
+	}
+}
+
+// hasAnnotations returns true if 'ci' has every annotation in 'selector' (with
+// the same value). Every commit matches an empty selector.
+func hasAnnotations(ci *pfs.CommitInfo, selector map[string]string) bool {
+	for k, v := range selector {
+		if actual, ok := ci.Annotations[k]; !ok || actual != v {
+			return false
+		}
+	}
+	return true
+}
+
 func isNotFoundErr(err error) bool {
 	return err != nil && strings.Contains(err.Error(), "not found")
 }
@@ -4600,6 +5826,9 @@ func (d *driver) forEachPutFile(pachClient *client.APIClient, server pfs.API_Put
 						return false, "", "", err
 					}
 					if req.Recursive {
+						if req.ExpectedSha256 != nil {
+							return false, "", "", errors.New("cannot set an expected checksum for a recursive put")
+						}
 						path := strings.TrimPrefix(url.Object, "/")
 						if err := objClient.Walk(server.Context(), path, func(name string) error {
 							if strings.HasSuffix(name, "/") {
@@ -32,11 +32,14 @@ const (
 	storageTaskNamespace = "storage"
 )
 
-func (d *driver) startCommitNewStorageLayer(txnCtx *txnenv.TransactionContext, id string, parent *pfs.Commit, branch string, provenance []*pfs.CommitProvenance, description string) (*pfs.Commit, error) {
-	return d.startCommit(txnCtx, id, parent, branch, provenance, description)
+func (d *driver) startCommitNewStorageLayer(txnCtx *txnenv.TransactionContext, id string, parent *pfs.Commit, branch string, provenance []*pfs.CommitProvenance, description string, annotations map[string]string) (*pfs.Commit, error) {
+	return d.startCommit(txnCtx, id, parent, branch, provenance, description, annotations)
 }
 
-func (d *driver) finishCommitNewStorageLayer(txnCtx *txnenv.TransactionContext, commit *pfs.Commit, description string) (retErr error) {
+func (d *driver) finishCommitNewStorageLayer(txnCtx *txnenv.TransactionContext, commit *pfs.Commit, description string, annotations map[string]string) (retErr error) {
+	if err := validateAnnotations(annotations); err != nil {
+		return err
+	}
 	if err := d.checkIsAuthorizedInTransaction(txnCtx, commit.Repo, auth.Scope_WRITER); err != nil {
 		return err
 	}
@@ -50,6 +53,7 @@ func (d *driver) finishCommitNewStorageLayer(txnCtx *txnenv.TransactionContext,
 	if description != "" {
 		commitInfo.Description = description
 	}
+	mergeAnnotations(commitInfo, annotations, nil)
 	commitPath := path.Join(commit.Repo.Name, commit.ID)
 	// Clean up temporary filesets leftover from failed operations.
 	if err := d.storage.Delete(txnCtx.Client.Ctx(), path.Join(tmpPrefix, commitPath)); err != nil {
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/gogo/protobuf/proto"
+
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+)
+
+func delimited(t *testing.T, msg proto.Message) []byte {
+	data, err := proto.Marshal(msg)
+	require.NoError(t, err)
+	prefix := make([]byte, binary.MaxVarintLen64)
+	return append(prefix[:binary.PutUvarint(prefix, uint64(len(data)))], data...)
+}
+
+// TestReadDelimitedMessage reads messages one byte at a time through the
+// smallest bufio.Reader, so that their length prefixes span its refills.
+func TestReadDelimitedMessage(t *testing.T) {
+	var messages [][]byte
+	// Lengths with 1, 2 and 3 byte prefixes, including the empty message
+	for _, size := range []int{0, 1, 10, 127, 128, 300, 20000} {
+		messages = append(messages, delimited(t, &pfs.Repo{Name: strings.Repeat("x", size)}))
+	}
+	messages = append(messages, delimited(t, &pfs.Repo{}))
+	file := bytes.Join(messages, nil)
+
+	r := bufio.NewReaderSize(iotest.OneByteReader(bytes.NewReader(file)), 16)
+	for i, expected := range messages {
+		value, err := readDelimitedMessage(r)
+		require.NoError(t, err, "message %d", i)
+		require.Equal(t, expected, value, "message %d", i)
+	}
+	_, err := readDelimitedMessage(r)
+	require.Equal(t, io.EOF, err)
+
+	// Stopping between messages is the end of the file, but stopping part way
+	// through a length prefix or a message is an error
+	prefixLen := len(messages[0]) + len(messages[1]) + len(messages[2]) + len(messages[3])
+	for _, test := range []struct {
+		name string
+		n    int
+		err  error
+	}{
+		{"empty", 0, io.EOF},
+		{"between messages", prefixLen, io.EOF},
+		{"within a length prefix", prefixLen + 1, io.ErrUnexpectedEOF},
+		{"within a message", prefixLen + 3, io.ErrUnexpectedEOF},
+	} {
+		r := bufio.NewReaderSize(iotest.OneByteReader(bytes.NewReader(file[:test.n])), 16)
+		for {
+			_, err = readDelimitedMessage(r)
+			if err != nil {
+				break
+			}
+		}
+		require.Equal(t, test.err, errors.Cause(err), test.name)
+	}
+}
+
+// TestReadDelimitedMessageTooLarge checks that a length prefix above the limit
+// is rejected, and that one larger than the data that follows it is only read
+// as far as the data goes.
+func TestReadDelimitedMessageTooLarge(t *testing.T) {
+	prefix := make([]byte, binary.MaxVarintLen64)
+	for _, size := range []uint64{maxDelimitedMessageSize + 1, 1 << 63, math.MaxUint64} {
+		r := bufio.NewReader(bytes.NewReader(prefix[:binary.PutUvarint(prefix, size)]))
+		_, err := readDelimitedMessage(r)
+		require.YesError(t, err)
+		require.NotEqual(t, io.ErrUnexpectedEOF, errors.Cause(err))
+	}
+	file := append(prefix[:binary.PutUvarint(prefix, maxDelimitedMessageSize)], "short"...)
+	_, err := readDelimitedMessage(bufio.NewReader(bytes.NewReader(file)))
+	require.Equal(t, io.ErrUnexpectedEOF, errors.Cause(err))
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
 	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
 	"github.com/pachyderm/pachyderm/src/client/pkg/tracing"
+	pfsserver "github.com/pachyderm/pachyderm/src/server/pfs"
 	"github.com/pachyderm/pachyderm/src/server/pkg/backoff"
 	"github.com/pachyderm/pachyderm/src/server/pkg/log"
 	"github.com/pachyderm/pachyderm/src/server/pkg/obj"
@@ -243,8 +244,11 @@ func (s *objBlockAPIServer) PutObject(server pfsclient.ObjectAPI_PutObjectServer
 			), retErr, time.Since(start))
 	}(time.Now())
 	defer drainObjectServer(server)
-	var err error
-	object, err = s.putObject(server.Context(), putObjectReader, func(w io.Writer, r io.Reader) (int64, error) {
+	compression, err := putObjectReader.readCompression()
+	if err != nil {
+		return err
+	}
+	object, err = s.putObject(server.Context(), putObjectReader, compression, func(w io.Writer, r io.Reader) (int64, error) {
 		buf := grpcutil.GetBuffer()
 		defer grpcutil.PutBuffer(buf)
 		return io.CopyBuffer(w, r, buf)
@@ -277,9 +281,13 @@ func (s *objBlockAPIServer) PutObjectSplit(server pfsclient.ObjectAPI_PutObjectS
 	putObjectReader := &putObjectReader{
 		server: server,
 	}
+	compression, err := putObjectReader.readCompression()
+	if err != nil {
+		return err
+	}
 	var done bool
 	for !done {
-		object, err := s.putObject(server.Context(), putObjectReader, func(w io.Writer, r io.Reader) (int64, error) {
+		object, err := s.putObject(server.Context(), putObjectReader, compression, func(w io.Writer, r io.Reader) (int64, error) {
 			size, err := io.CopyN(w, r, pfsclient.ChunkSize)
 			if err == io.EOF {
 				done = true
@@ -295,11 +303,15 @@ func (s *objBlockAPIServer) PutObjectSplit(server pfsclient.ObjectAPI_PutObjectS
 	return server.SendAndClose(&pfsclient.Objects{Objects: objects})
 }
 
-func (s *objBlockAPIServer) putObject(ctx context.Context, dataReader io.Reader, f func(io.Writer, io.Reader) (int64, error)) (_ *pfsclient.Object, retErr error) {
+// putObject writes the data that 'f' copies from 'dataReader' to a new
+// block, compressed with 'compression'. The object's hash is computed from the
+// uncompressed data, so compressed and uncompressed copies of the same data
+// are deduplicated.
+func (s *objBlockAPIServer) putObject(ctx context.Context, dataReader io.Reader, compression pfsclient.Compression, f func(io.Writer, io.Reader) (int64, error)) (_ *pfsclient.Object, retErr error) {
 	hash := pfsclient.NewHash()
 	r := io.TeeReader(dataReader, hash)
 	block := &pfsclient.Block{Hash: uuid.NewWithoutDashes()}
-	var size int64
+	var size, storedSize int64
 	if err := func() (retErr error) {
 		w, err := s.objClient.Writer(ctx, s.blockPath(block))
 		if err != nil {
@@ -310,8 +322,24 @@ func (s *objBlockAPIServer) putObject(ctx context.Context, dataReader io.Reader,
 				retErr = err
 			}
 		}()
-		size, err = f(w, r)
-		return err
+		if compression == pfsclient.Compression_UNCOMPRESSED {
+			size, err = f(w, r)
+			storedSize = size
+			return err
+		}
+		cw := &countWriter{w: w}
+		compressor, err := pfsserver.NewCompressor(cw, compression)
+		if err != nil {
+			return err
+		}
+		if size, err = f(compressor, r); err != nil {
+			return err
+		}
+		if err := compressor.Close(); err != nil {
+			return err
+		}
+		storedSize = cw.size
+		return nil
 	}(); err != nil {
 		// We throw away the delete error state here because the original error is what should be communicated
 		// back and we do not know the cause of the original error. This is just an attempt to clean up
@@ -335,9 +363,13 @@ func (s *objBlockAPIServer) putObject(ctx context.Context, dataReader io.Reader,
 			Block: block,
 			Range: &pfsclient.ByteRange{
 				Lower: 0,
-				Upper: uint64(size),
+				Upper: uint64(storedSize),
 			},
 		}
+		if compression != pfsclient.Compression_UNCOMPRESSED {
+			blockRef.Compression = compression
+			blockRef.SizeBytes = uint64(size)
+		}
 		if err := s.writeProto(ctx, s.objectPath(object), blockRef); err != nil {
 			return nil, err
 		}
@@ -410,12 +442,11 @@ func (s *objBlockAPIServer) GetObject(request *pfsclient.Object, getObjectServer
 		logrus.Errorf("objectInfo.BlockRef.Range is nil; info: %+v; request: %v", objectInfo, request)
 		return nil
 	}
-	objectSize := objectInfo.BlockRef.Range.Upper - objectInfo.BlockRef.Range.Lower
+	objectSize := pfsserver.BlockRefSize(objectInfo.BlockRef)
 	if (objectSize) >= uint64(s.objectCacheBytes/maxCachedObjectDenom) {
 		// The object is a substantial portion of the available cache space so
 		// we bypass the cache and stream it directly out of the underlying store.
-		blockPath := s.blockPath(objectInfo.BlockRef.Block)
-		r, err := s.objClient.Reader(getObjectServer.Context(), blockPath, objectInfo.BlockRef.Range.Lower, objectSize)
+		r, err := s.blockRefReader(getObjectServer.Context(), objectInfo.BlockRef, 0, objectSize)
 		if err != nil {
 			return err
 		}
@@ -459,7 +490,7 @@ func (s *objBlockAPIServer) GetObjects(request *pfsclient.GetObjectsRequest, get
 			continue
 		}
 
-		objectSize := objectInfo.BlockRef.Range.Upper - objectInfo.BlockRef.Range.Lower
+		objectSize := pfsserver.BlockRefSize(objectInfo.BlockRef)
 		if offset > objectSize {
 			offset -= objectSize
 			continue
@@ -469,8 +500,7 @@ func (s *objBlockAPIServer) GetObjects(request *pfsclient.GetObjectsRequest, get
 			readSize = size
 		}
 		if request.TotalSize >= uint64(s.objectCacheBytes/maxCachedObjectDenom) {
-			blockPath := s.blockPath(objectInfo.BlockRef.Block)
-			r, err := s.objClient.Reader(getObjectsServer.Context(), blockPath, objectInfo.BlockRef.Range.Lower+offset, readSize)
+			r, err := s.blockRefReader(getObjectsServer.Context(), objectInfo.BlockRef, offset, readSize)
 			if err != nil {
 				return err
 			}
@@ -711,7 +741,7 @@ func (s *objBlockAPIServer) GetBlocks(request *pfsclient.GetBlocksRequest, getBl
 	offset := request.OffsetBytes
 	size := request.SizeBytes
 	for _, blockRef := range request.BlockRefs {
-		blockSize := blockRef.Range.Upper - blockRef.Range.Lower
+		blockSize := pfsserver.BlockRefSize(blockRef)
 		if offset > blockSize {
 			offset -= blockSize
 			continue
@@ -721,8 +751,7 @@ func (s *objBlockAPIServer) GetBlocks(request *pfsclient.GetBlocksRequest, getBl
 			readSize = size
 		}
 		if request.TotalSize >= uint64(s.objectCacheBytes/maxCachedObjectDenom) {
-			blockPath := s.blockPath(blockRef.Block)
-			r, err := s.objClient.Reader(getBlockServer.Context(), blockPath, blockRef.Range.Lower+offset, readSize)
+			r, err := s.blockRefReader(getBlockServer.Context(), blockRef, offset, readSize)
 			if err != nil {
 				return err
 			}
@@ -739,6 +768,12 @@ func (s *objBlockAPIServer) GetBlocks(request *pfsclient.GetBlocksRequest, getBl
 			if err := s.blockCache.Get(getBlockServer.Context(), key, sink); err != nil {
 				return err
 			}
+			if blockRef.Compression != pfsclient.Compression_UNCOMPRESSED {
+				var err error
+				if data, err = decompress(data, blockRef.Compression); err != nil {
+					return err
+				}
+			}
 			if uint64(len(data)) < offset+readSize {
 				return errors.Errorf("undersized object (this is likely a bug)")
 			}
@@ -940,10 +975,13 @@ func (s *objBlockAPIServer) compact(ctx context.Context) (retErr error) {
 				if err != nil {
 					return err
 				}
-				blockRef, err = w.Write(object)
+				compactedBlockRef, err := w.Write(object)
 				if err != nil {
 					return err
 				}
+				compactedBlockRef.Compression = blockRef.Compression
+				compactedBlockRef.SizeBytes = blockRef.SizeBytes
+				blockRef = compactedBlockRef
 				mu.Lock()
 				defer mu.Unlock()
 				objectIndex.Objects[filepath.Base(name)] = blockRef
@@ -1249,7 +1287,45 @@ func (s *objBlockAPIServer) readObj(ctx context.Context, path string, offset uin
 }
 
 func (s *objBlockAPIServer) readBlockRef(ctx context.Context, blockRef *pfsclient.BlockRef, dest groupcache.Sink) error {
-	return s.readObj(ctx, s.blockPath(blockRef.Block), blockRef.Range.Lower, blockRef.Range.Upper-blockRef.Range.Lower, dest)
+	if blockRef.Compression == pfsclient.Compression_UNCOMPRESSED {
+		return s.readObj(ctx, s.blockPath(blockRef.Block), blockRef.Range.Lower, pfsserver.ByteRangeSize(blockRef.Range), dest)
+	}
+	// Compressed objects are cached decompressed, so that reads at an offset
+	// can be served from the cache.
+	var data []byte
+	if err := s.readObj(ctx, s.blockPath(blockRef.Block), blockRef.Range.Lower, pfsserver.ByteRangeSize(blockRef.Range), groupcache.AllocatingByteSliceSink(&data)); err != nil {
+		return err
+	}
+	data, err := decompress(data, blockRef.Compression)
+	if err != nil {
+		return err
+	}
+	return dest.SetBytes(data)
+}
+
+// blockRefReader returns a reader for 'size' bytes of the data referenced by
+// 'blockRef', starting at 'offset'. 'offset' and 'size' refer to the data
+// after it's decompressed, so compressed data is decompressed from the
+// beginning of the range and the bytes before 'offset' are discarded.
+func (s *objBlockAPIServer) blockRefReader(ctx context.Context, blockRef *pfsclient.BlockRef, offset uint64, size uint64) (io.ReadCloser, error) {
+	blockPath := s.blockPath(blockRef.Block)
+	if blockRef.Compression == pfsclient.Compression_UNCOMPRESSED {
+		return s.objClient.Reader(ctx, blockPath, blockRef.Range.Lower+offset, size)
+	}
+	r, err := s.objClient.Reader(ctx, blockPath, blockRef.Range.Lower, pfsserver.ByteRangeSize(blockRef.Range))
+	if err != nil {
+		return nil, err
+	}
+	dr, err := pfsserver.NewDecompressor(r, blockRef.Compression)
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+	if _, err := io.CopyN(ioutil.Discard, dr, int64(offset)); err != nil {
+		dr.Close()
+		return nil, err
+	}
+	return &limitReadCloser{Reader: io.LimitReader(dr, int64(size)), Closer: dr}, nil
 }
 
 func (s *objBlockAPIServer) getObjectIndex(prefix string) (*pfsclient.ObjectIndex, bool) {
@@ -1363,10 +1439,23 @@ type putObjectServer interface {
 }
 
 type putObjectReader struct {
-	server    putObjectServer
-	buffer    bytes.Buffer
-	tags      []*pfsclient.Tag
-	BytesRead int
+	server      putObjectServer
+	buffer      bytes.Buffer
+	tags        []*pfsclient.Tag
+	started     bool
+	compression pfsclient.Compression
+	BytesRead   int
+}
+
+// readCompression returns the compression set in the first request of the
+// stream, receiving that request if it hasn't been received yet.
+func (r *putObjectReader) readCompression() (pfsclient.Compression, error) {
+	if !r.started {
+		if _, err := r.Read(nil); err != nil && err != io.EOF {
+			return pfsclient.Compression_UNCOMPRESSED, err
+		}
+	}
+	return r.compression, nil
 }
 
 func (r *putObjectReader) Read(p []byte) (int, error) {
@@ -1375,6 +1464,10 @@ func (r *putObjectReader) Read(p []byte) (int, error) {
 		if err != nil {
 			return 0, err
 		}
+		if !r.started {
+			r.started = true
+			r.compression = request.Compression
+		}
 		r.buffer.Reset()
 		// buffer.Write cannot error
 		n, _ := r.buffer.Write(request.Value)
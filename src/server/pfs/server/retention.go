@@ -0,0 +1,33 @@
+package server
+
+import (
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+)
+
+const (
+	retentionLockPath = "pfs-retention-lock"
+)
+
+// enforceRetentionPolicies prunes every branch in every repo. A failure to
+// prune one branch is logged and doesn't stop the others from being pruned.
+// Pruning only deletes commit metadata; the objects are reclaimed by the next
+// garbage collection.
+func (d *driver) enforceRetentionPolicies(ctx context.Context) error {
+	repoInfo := &pfs.RepoInfo{}
+	return d.repos.ReadOnly(ctx).List(repoInfo, col.DefaultOptions, func(string) error {
+		for _, branch := range repoInfo.Branches {
+			pruned, err := d.enforceRetention(ctx, branch, false)
+			if err != nil {
+				logrus.Errorf("error pruning branch %s@%s: %v", branch.Repo.Name, branch.Name, err)
+				continue
+			}
+			if len(pruned) > 0 {
+				logrus.Infof("pruned %d commits from branch %s@%s", len(pruned), branch.Repo.Name, branch.Name)
+			}
+		}
+		return nil
+	})
+}
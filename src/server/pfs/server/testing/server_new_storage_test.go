@@ -119,3 +119,46 @@ func TestCompaction(t *testing.T) {
 		return eg.Wait()
 	}, config)
 }
+
+func TestPutTarSymlinkAndMode(t *testing.T) {
+	config := &serviceenv.PachdFullConfiguration{}
+	config.NewStorageLayer = true
+	testpachd.WithRealEnv(func(env *testpachd.RealEnv) error {
+		c := env.PachClient
+		repo := "test"
+		require.NoError(t, c.CreateRepo(repo))
+		commit, err := c.StartCommit(repo, "master")
+		require.NoError(t, err)
+		buf := &bytes.Buffer{}
+		tw := tar.NewWriter(buf)
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name: "/script",
+			Mode: 0755,
+			Size: int64(len("#!/bin/sh\n")),
+		}))
+		_, err = tw.Write([]byte("#!/bin/sh\n"))
+		require.NoError(t, err)
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeSymlink,
+			Name:     "/tool",
+			Linkname: "script",
+		}))
+		require.NoError(t, tw.Close())
+		require.NoError(t, c.PutTar(repo, commit.ID, buf))
+		require.NoError(t, c.FinishCommit(repo, commit.ID))
+
+		getTarHeader := func(file string) *tar.Header {
+			r, err := c.GetTar(repo, commit.ID, file)
+			require.NoError(t, err)
+			hdr, err := tar.NewReader(r).Next()
+			require.NoError(t, err)
+			return hdr
+		}
+		hdr := getTarHeader("/script")
+		require.Equal(t, int64(0755), hdr.Mode)
+		hdr = getTarHeader("/tool")
+		require.Equal(t, byte(tar.TypeSymlink), hdr.Typeflag)
+		require.Equal(t, "script", hdr.Linkname)
+		return nil
+	}, config)
+}
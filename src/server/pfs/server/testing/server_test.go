@@ -3,6 +3,9 @@ package testing
 import (
 	"bufio"
 	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/binary"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -23,8 +26,11 @@ import (
 	"github.com/pachyderm/pachyderm/src/client/pfs"
 	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
 	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	pfsserver "github.com/pachyderm/pachyderm/src/server/pfs"
 	"github.com/pachyderm/pachyderm/src/server/pkg/ancestry"
+	"github.com/pachyderm/pachyderm/src/server/pkg/avro"
 	"github.com/pachyderm/pachyderm/src/server/pkg/backoff"
+	"github.com/pachyderm/pachyderm/src/server/pkg/errutil"
 	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
 	"github.com/pachyderm/pachyderm/src/server/pkg/obj"
 	"github.com/pachyderm/pachyderm/src/server/pkg/sql"
@@ -33,6 +39,7 @@ import (
 	tu "github.com/pachyderm/pachyderm/src/server/pkg/testutil"
 	"github.com/pachyderm/pachyderm/src/server/pkg/uuid"
 
+	pbio "github.com/gogo/protobuf/io"
 	"github.com/gogo/protobuf/types"
 	"golang.org/x/net/context"
 	"golang.org/x/sync/errgroup"
@@ -2277,6 +2284,401 @@ func TestListCommit(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestCommitAnnotations(t *testing.T) {
+	t.Parallel()
+	err := testpachd.WithRealEnv(func(env *testpachd.RealEnv) error {
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+
+		listAnnotated := func(selector map[string]string) []string {
+			var ids []string
+			require.NoError(t, env.PachClient.ListCommitByAnnotationsF(repo, "", "", 0, false, selector, func(ci *pfs.CommitInfo) error {
+				ids = append(ids, ci.Commit.ID)
+				return nil
+			}))
+			return ids
+		}
+
+		// Annotations can be set in both StartCommit and FinishCommit
+		commit1, err := env.PachClient.PfsAPIClient.StartCommit(env.PachClient.Ctx(), &pfs.StartCommitRequest{
+			Parent:      pclient.NewCommit(repo, ""),
+			Branch:      "master",
+			Annotations: map[string]string{"experiment": "1", "dataset": "v1"},
+		})
+		require.NoError(t, err)
+		_, err = env.PachClient.PfsAPIClient.FinishCommit(env.PachClient.Ctx(), &pfs.FinishCommitRequest{
+			Commit:      commit1,
+			Annotations: map[string]string{"dataset": "v2", "batch": "abc"},
+		})
+		require.NoError(t, err)
+		commitInfo, err := env.PachClient.InspectCommit(repo, commit1.ID)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"experiment": "1", "dataset": "v2", "batch": "abc"}, commitInfo.Annotations)
+
+		commit2, err := env.PachClient.PfsAPIClient.StartCommit(env.PachClient.Ctx(), &pfs.StartCommitRequest{
+			Parent:      pclient.NewCommit(repo, ""),
+			Branch:      "master",
+			Annotations: map[string]string{"experiment": "2", "dataset": "v2"},
+		})
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit2.ID))
+		commit3, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit3.ID))
+
+		// Select commits by annotation
+		require.ElementsEqual(t, []string{commit1.ID, commit2.ID, commit3.ID}, listAnnotated(nil))
+		require.ElementsEqual(t, []string{commit1.ID, commit2.ID}, listAnnotated(map[string]string{"dataset": "v2"}))
+		require.ElementsEqual(t, []string{commit2.ID}, listAnnotated(map[string]string{"dataset": "v2", "experiment": "2"}))
+		require.Equal(t, 0, len(listAnnotated(map[string]string{"dataset": "v3"})))
+
+		// Selectors also apply when listing the ancestors of a commit
+		var ids []string
+		require.NoError(t, env.PachClient.ListCommitByAnnotationsF(repo, "master", "", 1, false, map[string]string{"experiment": "1"}, func(ci *pfs.CommitInfo) error {
+			ids = append(ids, ci.Commit.ID)
+			return nil
+		}))
+		require.Equal(t, []string{commit1.ID}, ids)
+
+		// Annotations can be changed after a commit is finished
+		require.NoError(t, env.PachClient.AnnotateCommit(repo, "master", map[string]string{"experiment": "3"}))
+		require.ElementsEqual(t, []string{commit3.ID}, listAnnotated(map[string]string{"experiment": "3"}))
+		_, err = env.PachClient.PfsAPIClient.AnnotateCommit(env.PachClient.Ctx(), &pfs.AnnotateCommitRequest{
+			Commit:     commit1,
+			DeleteKeys: []string{"batch"},
+		})
+		require.NoError(t, err)
+		commitInfo, err = env.PachClient.InspectCommit(repo, commit1.ID)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"experiment": "1", "dataset": "v2"}, commitInfo.Annotations)
+
+		// Empty keys are rejected
+		require.YesError(t, env.PachClient.AnnotateCommit(repo, "master", map[string]string{"": "x"}))
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestBranchRetention(t *testing.T) {
+	t.Parallel()
+	err := testpachd.WithRealEnv(func(env *testpachd.RealEnv) error {
+		repo := "in"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		require.NoError(t, env.PachClient.CreateRepo("out"))
+
+		var commits []*pfs.Commit
+		newCommit := func() {
+			commit, err := env.PachClient.StartCommit(repo, "master")
+			require.NoError(t, err)
+			require.NoError(t, env.PachClient.FinishCommit(repo, commit.ID))
+			commits = append(commits, commit)
+		}
+		ids := func(commitInfos []*pfs.CommitInfo) []string {
+			var result []string
+			for _, ci := range commitInfos {
+				result = append(result, ci.Commit.ID)
+			}
+			return result
+		}
+
+		// The first commit is processed downstream, but its output commit
+		// isn't the head of a branch, so it doesn't protect the commit
+		newCommit()
+		require.NoError(t, env.PachClient.CreateBranch("out", "master", "", []*pfs.Branch{pclient.NewBranch(repo, "master")}))
+		require.NoError(t, env.PachClient.DeleteBranch("out", "master", false))
+		for i := 0; i < 5; i++ {
+			newCommit()
+		}
+		// The third commit is the head of another branch
+		require.NoError(t, env.PachClient.CreateBranch(repo, "pinned", commits[2].ID, nil))
+
+		// No policy, nothing to prune
+		pruned, err := env.PachClient.PruneBranch(repo, "master", true)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(pruned))
+
+		require.NoError(t, env.PachClient.SetBranchRetention(repo, "master", &pfs.RetentionPolicy{KeepLast: 2}))
+		branchInfo, err := env.PachClient.InspectBranch(repo, "master")
+		require.NoError(t, err)
+		require.Equal(t, int64(2), branchInfo.Retention.KeepLast)
+
+		// A dry run reports commits without deleting them
+		pruned, err = env.PachClient.PruneBranch(repo, "master", true)
+		require.NoError(t, err)
+		require.Equal(t, []string{commits[3].ID, commits[1].ID, commits[0].ID}, ids(pruned))
+		commitInfos, err := env.PachClient.ListCommit(repo, "master", "", 0)
+		require.NoError(t, err)
+		require.Equal(t, 6, len(commitInfos))
+
+		pruned, err = env.PachClient.PruneBranch(repo, "master", false)
+		require.NoError(t, err)
+		require.Equal(t, []string{commits[3].ID, commits[1].ID, commits[0].ID}, ids(pruned))
+		commitInfos, err = env.PachClient.ListCommit(repo, "master", "", 0)
+		require.NoError(t, err)
+		require.Equal(t, []string{commits[5].ID, commits[4].ID, commits[2].ID}, ids(commitInfos))
+		// The output commit derived from the first commit is deleted with it
+		commitInfos, err = env.PachClient.ListCommit("out", "", "", 0)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(commitInfos))
+		_, err = env.PachClient.InspectCommit(repo, commits[3].ID)
+		require.YesError(t, err)
+
+		// Every commit is younger than keep_for
+		require.NoError(t, env.PachClient.SetBranchRetention(repo, "master", &pfs.RetentionPolicy{KeepFor: types.DurationProto(time.Hour)}))
+		pruned, err = env.PachClient.PruneBranch(repo, "master", true)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(pruned))
+
+		// Output branches can't have a retention policy
+		require.NoError(t, env.PachClient.CreateBranch("out", "master", "", []*pfs.Branch{pclient.NewBranch(repo, "master")}))
+		require.YesError(t, env.PachClient.SetBranchRetention("out", "master", &pfs.RetentionPolicy{KeepLast: 1}))
+		require.YesError(t, env.PachClient.SetBranchRetention(repo, "master", &pfs.RetentionPolicy{KeepLast: -1}))
+
+		// Removing the policy
+		require.NoError(t, env.PachClient.SetBranchRetention(repo, "master", nil))
+		branchInfo, err = env.PachClient.InspectBranch(repo, "master")
+		require.NoError(t, err)
+		require.Nil(t, branchInfo.Retention)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+
+func TestBranchRetentionDownstream(t *testing.T) {
+	t.Parallel()
+	err := testpachd.WithRealEnv(func(env *testpachd.RealEnv) error {
+		c := env.PachClient
+		require.NoError(t, c.CreateRepo("in"))
+		require.NoError(t, c.CreateRepo("out"))
+		// 'out' stands in for a live pipeline that processes 'in'
+		require.NoError(t, c.CreateBranch("out", "master", "", []*pfs.Branch{pclient.NewBranch("in", "master")}))
+		require.NoError(t, c.CreateBranch("out", "staging", "", []*pfs.Branch{pclient.NewBranch("in", "staging")}))
+
+		var commits []*pfs.Commit
+		for i := 0; i < 4; i++ {
+			commit, err := c.StartCommit("in", "master")
+			require.NoError(t, err)
+			require.NoError(t, c.FinishCommit("in", commit.ID))
+			require.NoError(t, c.FinishCommit("out", "master"))
+			commits = append(commits, commit)
+		}
+		// The output branch 'staging' was last processed against the second
+		// commit, which is no longer the head of any branch of 'in'
+		require.NoError(t, c.CreateBranch("in", "staging", commits[1].ID, nil))
+		require.NoError(t, c.FinishCommit("out", "staging"))
+		require.NoError(t, c.CreateBranch("out", "staging", "staging", nil))
+		require.NoError(t, c.DeleteBranch("in", "staging", false))
+
+		require.NoError(t, c.SetBranchRetention("in", "master", &pfs.RetentionPolicy{KeepLast: 1}))
+		pruned, err := c.PruneBranch("in", "master", false)
+		require.NoError(t, err)
+		require.Equal(t, 2, len(pruned))
+		require.Equal(t, commits[2].ID, pruned[0].Commit.ID)
+		require.Equal(t, commits[0].ID, pruned[1].Commit.ID)
+
+		// The output branches' heads, and their provenance, are intact
+		commitInfos, err := c.ListCommit("in", "master", "", 0)
+		require.NoError(t, err)
+		require.Equal(t, 2, len(commitInfos))
+		headInfo, err := c.InspectCommit("out", "master")
+		require.NoError(t, err)
+		require.Equal(t, commits[3].ID, headInfo.Provenance[0].Commit.ID)
+		headInfo, err = c.InspectCommit("out", "staging")
+		require.NoError(t, err)
+		require.Equal(t, commits[1].ID, headInfo.Provenance[0].Commit.ID)
+		return nil
+	})
+	require.NoError(t, err)
+}
+func TestBranchTrigger(t *testing.T) {
+	t.Parallel()
+	err := testpachd.WithRealEnv(func(env *testpachd.RealEnv) error {
+		repo := "in"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		require.NoError(t, env.PachClient.CreateRepo("out"))
+		require.NoError(t, env.PachClient.CreateBranchTrigger(repo, "master", "", &pfs.Trigger{
+			Branch:  "staging",
+			Commits: 3,
+		}))
+		require.NoError(t, env.PachClient.CreateBranch("out", "master", "", []*pfs.Branch{pclient.NewBranch(repo, "master")}))
+
+		var commits []*pfs.Commit
+		newCommit := func(data string) {
+			commit, err := env.PachClient.StartCommit(repo, "staging")
+			require.NoError(t, err)
+			_, err = env.PachClient.PutFile(repo, commit.ID, "file", strings.NewReader(data))
+			require.NoError(t, err)
+			require.NoError(t, env.PachClient.FinishCommit(repo, commit.ID))
+			commits = append(commits, commit)
+		}
+		head := func(branch string) string {
+			branchInfo, err := env.PachClient.InspectBranch(repo, branch)
+			require.NoError(t, err)
+			if branchInfo.Head == nil {
+				return ""
+			}
+			return branchInfo.Head.ID
+		}
+
+		newCommit("a")
+		newCommit("b")
+		require.Equal(t, "", head("master"))
+		newCommit("c")
+		require.Equal(t, commits[2].ID, head("master"))
+		// Moving master propagates downstream
+		outCommits, err := env.PachClient.ListCommit("out", "master", "", 0)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(outCommits))
+
+		// The count restarts from the new head of master
+		newCommit("d")
+		newCommit("e")
+		require.Equal(t, commits[2].ID, head("master"))
+		newCommit("f")
+		require.Equal(t, commits[5].ID, head("master"))
+
+		// A size trigger only fires once enough data has been added
+		require.NoError(t, env.PachClient.CreateBranchTrigger(repo, "big", "", &pfs.Trigger{
+			Branch: "staging",
+			Size_:  "1K",
+		}))
+		require.Equal(t, "", head("big"))
+		newCommit(strings.Repeat("x", 2048))
+		require.Equal(t, commits[6].ID, head("big"))
+		// 'all' requires both conditions
+		require.NoError(t, env.PachClient.CreateBranchTrigger(repo, "both", "", &pfs.Trigger{
+			Branch:  "staging",
+			All:     true,
+			Size_:   "1M",
+			Commits: 1,
+		}))
+		require.Equal(t, "", head("both"))
+
+		// Triggers can't form cycles, and must be well-formed
+		require.YesError(t, env.PachClient.CreateBranchTrigger(repo, "staging", "", &pfs.Trigger{Branch: "master"}))
+		require.YesError(t, env.PachClient.CreateBranchTrigger(repo, "bad", "", &pfs.Trigger{Branch: "staging", Size_: "lots"}))
+		require.YesError(t, env.PachClient.CreateBranchTrigger(repo, "bad", "", &pfs.Trigger{Branch: "staging", CronSpec: "never"}))
+		require.YesError(t, env.PachClient.CreateBranchTrigger(repo, "bad", "", &pfs.Trigger{Commits: 1}))
+		require.YesError(t, env.PachClient.CreateBranchTrigger(repo, "bad", "", &pfs.Trigger{Branch: "staging", Branches: []string{"bad"}}))
+
+		// A 'branches' trigger waits for every branch it names to move
+		require.NoError(t, env.PachClient.CreateBranchTrigger(repo, "joined", "", &pfs.Trigger{
+			Branch:   "staging",
+			Branches: []string{"left", "right"},
+		}))
+		require.Equal(t, "", head("joined"))
+		_, err = env.PachClient.StartCommit(repo, "left")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit(repo, "left"))
+		require.Equal(t, "", head("joined"))
+		_, err = env.PachClient.StartCommit(repo, "right")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit(repo, "right"))
+		require.Equal(t, commits[6].ID, head("joined"))
+		// Only 'left' has moved since, so it doesn't fire again
+		_, err = env.PachClient.StartCommit(repo, "left")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit(repo, "left"))
+		newCommit("g")
+		require.Equal(t, commits[6].ID, head("joined"))
+
+		// Updating a branch without a trigger keeps it, and an empty trigger
+		// removes it
+		require.NoError(t, env.PachClient.CreateBranch(repo, "master", commits[5].ID, nil))
+		branchInfo, err := env.PachClient.InspectBranch(repo, "master")
+		require.NoError(t, err)
+		require.NotNil(t, branchInfo.Trigger)
+		require.NoError(t, env.PachClient.CreateBranchTrigger(repo, "master", "", &pfs.Trigger{}))
+		branchInfo, err = env.PachClient.InspectBranch(repo, "master")
+		require.NoError(t, err)
+		require.Nil(t, branchInfo.Trigger)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestMergeBranch(t *testing.T) {
+	t.Parallel()
+	err := testpachd.WithRealEnv(func(env *testpachd.RealEnv) error {
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+
+		putFiles := func(branch string, files map[string]string, deletes ...string) {
+			commit, err := env.PachClient.StartCommit(repo, branch)
+			require.NoError(t, err)
+			for path, content := range files {
+				require.NoError(t, env.PachClient.DeleteFile(repo, commit.ID, path))
+				_, err := env.PachClient.PutFile(repo, commit.ID, path, strings.NewReader(content))
+				require.NoError(t, err)
+			}
+			for _, path := range deletes {
+				require.NoError(t, env.PachClient.DeleteFile(repo, commit.ID, path))
+			}
+			require.NoError(t, env.PachClient.FinishCommit(repo, commit.ID))
+		}
+		checkFiles := func(branch string, files map[string]string) {
+			fileInfos, err := env.PachClient.ListFile(repo, branch, "")
+			require.NoError(t, err)
+			require.Equal(t, len(files), len(fileInfos))
+			for path, content := range files {
+				var buf bytes.Buffer
+				require.NoError(t, env.PachClient.GetFile(repo, branch, path, 0, 0, &buf))
+				require.Equal(t, content, buf.String())
+			}
+		}
+
+		putFiles("master", map[string]string{"a": "a\n", "b": "b\n", "c": "c\n"})
+		require.NoError(t, env.PachClient.CreateBranch(repo, "labels-a", "master", nil))
+		require.NoError(t, env.PachClient.CreateBranch(repo, "labels-b", "master", nil))
+		putFiles("labels-a", map[string]string{"a": "a-A\n", "d": "d-A\n"}, "c")
+		putFiles("labels-b", map[string]string{"a": "a-B\n", "b": "b-B\n", "e": "e-B\n"}, "c")
+
+		// master hasn't changed, so there are no conflicts
+		response, err := env.PachClient.MergeBranch(repo, "labels-a", "master", pfs.MergeStrategy_FAIL)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(response.Conflicts))
+		require.NotNil(t, response.Commit)
+		checkFiles("master", map[string]string{"a": "a-A\n", "b": "b\n", "d": "d-A\n"})
+
+		// Both branches changed "a", the identical deletion of "c" isn't a conflict
+		response, err = env.PachClient.MergeBranch(repo, "labels-b", "master", pfs.MergeStrategy_FAIL)
+		require.NoError(t, err)
+		require.Nil(t, response.Commit)
+		require.Equal(t, 1, len(response.Conflicts))
+		require.Equal(t, "/a", response.Conflicts[0].Path)
+		require.Equal(t, uint64(4), response.Conflicts[0].Ours.SizeBytes)
+		require.Equal(t, uint64(4), response.Conflicts[0].Theirs.SizeBytes)
+		checkFiles("master", map[string]string{"a": "a-A\n", "b": "b\n", "d": "d-A\n"})
+
+		response, err = env.PachClient.MergeBranch(repo, "labels-b", "master", pfs.MergeStrategy_OURS)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(response.Conflicts))
+		require.NotNil(t, response.Commit)
+		checkFiles("master", map[string]string{"a": "a-A\n", "b": "b-B\n", "d": "d-A\n", "e": "e-B\n"})
+
+		response, err = env.PachClient.MergeBranch(repo, "labels-b", "labels-a", pfs.MergeStrategy_THEIRS)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(response.Conflicts))
+		checkFiles("labels-a", map[string]string{"a": "a-B\n", "b": "b-B\n", "d": "d-A\n", "e": "e-B\n"})
+		commitInfo, err := env.PachClient.InspectCommit(repo, "labels-a")
+		require.NoError(t, err)
+		require.Equal(t, response.Commit.ID, commitInfo.Commit.ID)
+
+		// Merging an ancestor is a no-op
+		require.NoError(t, env.PachClient.CreateBranch(repo, "old", "master^^", nil))
+		response, err = env.PachClient.MergeBranch(repo, "old", "master", pfs.MergeStrategy_FAIL)
+		require.NoError(t, err)
+		require.Nil(t, response.Commit)
+		require.Equal(t, 0, len(response.Conflicts))
+
+		_, err = env.PachClient.MergeBranch(repo, "master", "master", pfs.MergeStrategy_FAIL)
+		require.YesError(t, err)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
 func TestOffsetRead(t *testing.T) {
 	t.Parallel()
 	err := testpachd.WithRealEnv(func(env *testpachd.RealEnv) error {
@@ -3274,6 +3676,136 @@ func TestPutFileSplitCSV(t *testing.T) {
 	require.NoError(t, err)
 }
 
+// avroFile returns an avro object container file whose data blocks contain
+// 'blocks', each holding one record. The blocks aren't valid avro records,
+// which the splitting code doesn't need.
+func avroFile(blocks ...string) []byte {
+	counts := make([]int64, len(blocks))
+	for i := range counts {
+		counts[i] = 1
+	}
+	return avroFileWithCounts(counts, blocks...)
+}
+
+// avroFileWithCounts is like avroFile, but block i holds counts[i] records.
+func avroFileWithCounts(counts []int64, blocks ...string) []byte {
+	var buf bytes.Buffer
+	long := func(n int64) {
+		b := make([]byte, binary.MaxVarintLen64)
+		buf.Write(b[:binary.PutVarint(b, n)])
+	}
+	str := func(s string) {
+		long(int64(len(s)))
+		buf.WriteString(s)
+	}
+	sync := "0123456789abcdef"
+	buf.WriteString("Obj\x01")
+	long(2) // metadata block with two entries
+	str("avro.schema")
+	str(`"string"`)
+	str("avro.codec")
+	str("null")
+	long(0)
+	buf.WriteString(sync)
+	for i, block := range blocks {
+		long(counts[i])
+		str(block)
+		buf.WriteString(sync)
+	}
+	return buf.Bytes()
+}
+
+func TestPutFileSplitAvro(t *testing.T) {
+	t.Parallel()
+	err := testpachd.WithRealEnv(func(env *testpachd.RealEnv) error {
+		repo := tu.UniqueString("TestPutFileSplitAvro")
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		_, err := env.PachClient.PutFileSplit(repo, "master", "data", pfs.Delimiter_AVRO, 2, 0, 0, false,
+			bytes.NewReader(avroFile("one", "two", "three")))
+		require.NoError(t, err)
+		fileInfos, err := env.PachClient.ListFile(repo, "master", "/data")
+		require.NoError(t, err)
+		require.Equal(t, 2, len(fileInfos))
+
+		// Each file is a valid container file, with the original header
+		var contents bytes.Buffer
+		require.NoError(t, env.PachClient.GetFile(repo, "master", "/data/0000000000000000", 0, 0, &contents))
+		require.Equal(t, avroFile("one", "two"), contents.Bytes())
+		r := avro.NewContainerReader(bufio.NewReader(&contents))
+		for i := 0; i < 2; i++ {
+			_, _, err := r.ReadBlock()
+			require.NoError(t, err)
+		}
+		_, _, err = r.ReadBlock()
+		require.Equal(t, io.EOF, err)
+		contents.Reset()
+		require.NoError(t, env.PachClient.GetFile(repo, "master", "/data/0000000000000001", 0, 0, &contents))
+		require.Equal(t, avroFile("three"), contents.Bytes())
+
+		// Records, not blocks, count towards the target, but blocks aren't split
+		_, err = env.PachClient.PutFileSplit(repo, "master", "records", pfs.Delimiter_AVRO, 2, 0, 0, false,
+			bytes.NewReader(avroFileWithCounts([]int64{3, 1, 1}, "one", "two", "three")))
+		require.NoError(t, err)
+		fileInfos, err = env.PachClient.ListFile(repo, "master", "/records")
+		require.NoError(t, err)
+		require.Equal(t, 2, len(fileInfos))
+		contents.Reset()
+		require.NoError(t, env.PachClient.GetFile(repo, "master", "/records/0000000000000001", 0, 0, &contents))
+		require.Equal(t, avroFileWithCounts([]int64{1, 1}, "two", "three"), contents.Bytes())
+
+		// header records can't be used with avro, and invalid files are rejected
+		_, err = env.PachClient.PutFileSplit(repo, "master", "data2", pfs.Delimiter_AVRO, 0, 0, 1, false,
+			bytes.NewReader(avroFile("one")))
+		require.YesError(t, err)
+		_, err = env.PachClient.PutFileSplit(repo, "master", "data3", pfs.Delimiter_AVRO, 0, 0, 0, false,
+			strings.NewReader("not avro"))
+		require.YesError(t, err)
+		truncated := avroFile("one")
+		_, err = env.PachClient.PutFileSplit(repo, "master", "data4", pfs.Delimiter_AVRO, 0, 0, 0, false,
+			bytes.NewReader(truncated[:len(truncated)-1]))
+		require.YesError(t, err)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestPutFileSplitProtobufDelimited(t *testing.T) {
+	t.Parallel()
+	err := testpachd.WithRealEnv(func(env *testpachd.RealEnv) error {
+		repo := tu.UniqueString("TestPutFileSplitProtobufDelimited")
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		var buf bytes.Buffer
+		w := pbio.NewDelimitedWriter(&buf)
+		names := []string{"one", "two", "three", strings.Repeat("four", 100)}
+		for _, name := range names {
+			require.NoError(t, w.WriteMsg(pclient.NewRepo(name)))
+		}
+		_, err := env.PachClient.PutFileSplit(repo, "master", "data", pfs.Delimiter_PROTOBUF_DELIMITED, 3, 0, 0, false, &buf)
+		require.NoError(t, err)
+		fileInfos, err := env.PachClient.ListFile(repo, "master", "/data")
+		require.NoError(t, err)
+		require.Equal(t, 2, len(fileInfos))
+
+		// Every file is a stream of delimited messages
+		var contents bytes.Buffer
+		require.NoError(t, env.PachClient.GetFile(repo, "master", "/data/*", 0, 0, &contents))
+		r := pbio.NewDelimitedReader(&contents, 1024)
+		for _, name := range names {
+			repo := &pfs.Repo{}
+			require.NoError(t, r.ReadMsg(repo))
+			require.Equal(t, name, repo.Name)
+		}
+		require.Equal(t, io.EOF, r.ReadMsg(&pfs.Repo{}))
+
+		// A stream that ends part way through a message is rejected
+		_, err = env.PachClient.PutFileSplit(repo, "master", "data2", pfs.Delimiter_PROTOBUF_DELIMITED, 0, 0, 0, false,
+			bytes.NewReader([]byte{10, 'a'}))
+		require.YesError(t, err)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
 func TestPutFileSplitSQL(t *testing.T) {
 	t.Parallel()
 	err := testpachd.WithRealEnv(func(env *testpachd.RealEnv) error {
@@ -3833,6 +4365,575 @@ func TestGetFileGlobOrder(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestGrepFile(t *testing.T) {
+	t.Parallel()
+	err := testpachd.WithRealEnv(func(env *testpachd.RealEnv) error {
+		repo := tu.UniqueString("TestGrepFile")
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		_, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		_, err = env.PachClient.PutFile(repo, "master", "logs/a", strings.NewReader("ok\nerror: disk full\nok\n"))
+		require.NoError(t, err)
+		_, err = env.PachClient.PutFile(repo, "master", "logs/b", strings.NewReader("error: timeout\n"))
+		require.NoError(t, err)
+		_, err = env.PachClient.PutFile(repo, "master", "other/c", strings.NewReader("error: ignored\n"))
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit(repo, "master"))
+
+		var matches []*pfs.GrepMatch
+		grep := func(pattern, regex string) error {
+			matches = nil
+			return env.PachClient.GrepFile(repo, "master", pattern, regex, func(match *pfs.GrepMatch) error {
+				matches = append(matches, match)
+				return nil
+			})
+		}
+
+		require.NoError(t, grep("logs/*", "^error"))
+		require.Equal(t, 2, len(matches))
+		require.Equal(t, "/logs/a", matches[0].File.Path)
+		require.Equal(t, int64(2), matches[0].LineNumber)
+		require.Equal(t, "error: disk full", matches[0].Line)
+		require.Equal(t, "/logs/b", matches[1].File.Path)
+		require.Equal(t, int64(1), matches[1].LineNumber)
+		require.Equal(t, "error: timeout", matches[1].Line)
+
+		require.NoError(t, grep("**", "error"))
+		require.Equal(t, 3, len(matches))
+
+		require.NoError(t, grep("logs/*", "warning"))
+		require.Equal(t, 0, len(matches))
+
+		require.YesError(t, grep("logs/*", "("))
+
+		// ErrBreak stops the search early
+		var n int
+		require.NoError(t, env.PachClient.GrepFile(repo, "master", "**", "error", func(*pfs.GrepMatch) error {
+			n++
+			return errutil.ErrBreak
+		}))
+		require.Equal(t, 1, n)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestRepoCompression(t *testing.T) {
+	t.Parallel()
+	err := testpachd.WithRealEnv(func(env *testpachd.RealEnv) error {
+		if testing.Short() {
+			t.Skip("Skipping integration tests in short mode")
+		}
+
+		small := strings.Repeat("compressible\n", 1000)
+		// large is big enough that it's read around the object cache
+		large := strings.Repeat("compressible\n", 3*1024*1024)
+		for _, compression := range []pfs.Compression{pfs.Compression_GZIP, pfs.Compression_ZSTD} {
+			repo := tu.UniqueString("TestRepoCompression")
+			_, err := env.PachClient.PfsAPIClient.CreateRepo(env.PachClient.Ctx(), &pfs.CreateRepoRequest{
+				Repo:        pclient.NewRepo(repo),
+				Compression: compression,
+			})
+			require.NoError(t, err)
+			repoInfo, err := env.PachClient.InspectRepo(repo)
+			require.NoError(t, err)
+			require.Equal(t, compression, repoInfo.Compression)
+
+			_, err = env.PachClient.PutFile(repo, "master", "small", strings.NewReader(small))
+			require.NoError(t, err)
+			_, err = env.PachClient.PutFile(repo, "master", "large", strings.NewReader(large))
+			require.NoError(t, err)
+			_, err = env.PachClient.PutFileSplit(repo, "master", "split", pfs.Delimiter_LINE, 0, 100, 0, false, strings.NewReader(small))
+			require.NoError(t, err)
+
+			for path, content := range map[string]string{"small": small, "large": large} {
+				var buf bytes.Buffer
+				require.NoError(t, env.PachClient.GetFile(repo, "master", path, 0, 0, &buf))
+				require.Equal(t, content, buf.String())
+				buf.Reset()
+				require.NoError(t, env.PachClient.GetFile(repo, "master", path, 13, 26, &buf))
+				require.Equal(t, content[13:39], buf.String())
+
+				fileInfo, err := env.PachClient.InspectFileStoredSize(repo, "master", path)
+				require.NoError(t, err)
+				require.Equal(t, uint64(len(content)), fileInfo.SizeBytes)
+				require.True(t, fileInfo.StoredSizeBytes > 0)
+				require.True(t, fileInfo.StoredSizeBytes < fileInfo.SizeBytes)
+			}
+			var buf bytes.Buffer
+			require.NoError(t, env.PachClient.GetFile(repo, "master", "split/*", 0, 0, &buf))
+			require.Equal(t, small, buf.String())
+		}
+
+		// Turning compression on doesn't affect data that's already in the repo
+		repo := tu.UniqueString("TestRepoCompression")
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		before := strings.Repeat("uncompressed\n", 1000)
+		_, err := env.PachClient.PutFile(repo, "master", "before", strings.NewReader(before))
+		require.NoError(t, err)
+		_, err = env.PachClient.PfsAPIClient.CreateRepo(env.PachClient.Ctx(), &pfs.CreateRepoRequest{
+			Repo:        pclient.NewRepo(repo),
+			Compression: pfs.Compression_ZSTD,
+			Update:      true,
+		})
+		require.NoError(t, err)
+		after := strings.Repeat("compressed\n", 1000)
+		_, err = env.PachClient.PutFile(repo, "master", "after", strings.NewReader(after))
+		require.NoError(t, err)
+		for path, content := range map[string]string{"before": before, "after": after} {
+			var buf bytes.Buffer
+			require.NoError(t, env.PachClient.GetFile(repo, "master", path, 0, 0, &buf))
+			require.Equal(t, content, buf.String())
+			fileInfo, err := env.PachClient.InspectFileStoredSize(repo, "master", path)
+			require.NoError(t, err)
+			require.Equal(t, path == "before", fileInfo.StoredSizeBytes == fileInfo.SizeBytes)
+			// Stored sizes are only computed when they're asked for
+			fileInfo, err = env.PachClient.InspectFile(repo, "master", path)
+			require.NoError(t, err)
+			require.Equal(t, uint64(0), fileInfo.StoredSizeBytes)
+		}
+
+		// An update that doesn't set a compression keeps the repo's, unless it
+		// clears it
+		require.NoError(t, env.PachClient.UpdateRepo(repo))
+		repoInfo, err := env.PachClient.InspectRepo(repo)
+		require.NoError(t, err)
+		require.Equal(t, pfs.Compression_ZSTD, repoInfo.Compression)
+		_, err = env.PachClient.PfsAPIClient.CreateRepo(env.PachClient.Ctx(), &pfs.CreateRepoRequest{
+			Repo:             pclient.NewRepo(repo),
+			Update:           true,
+			ClearCompression: true,
+		})
+		require.NoError(t, err)
+		repoInfo, err = env.PachClient.InspectRepo(repo)
+		require.NoError(t, err)
+		require.Equal(t, pfs.Compression_UNCOMPRESSED, repoInfo.Compression)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestFileChecksums(t *testing.T) {
+	t.Parallel()
+	err := testpachd.WithRealEnv(func(env *testpachd.RealEnv) error {
+		if testing.Short() {
+			t.Skip("Skipping integration tests in short mode")
+		}
+
+		repo := tu.UniqueString("TestFileChecksums")
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		sums := func(content string) ([]byte, []byte) {
+			sha256Sum, md5Sum := sha256.Sum256([]byte(content)), md5.Sum([]byte(content))
+			return sha256Sum[:], md5Sum[:]
+		}
+
+		// A file that's put all at once has both digests
+		_, err := env.PachClient.PutFile(repo, "master", "file", strings.NewReader("foo\n"))
+		require.NoError(t, err)
+		fileInfo, err := env.PachClient.InspectFile(repo, "master", "file")
+		require.NoError(t, err)
+		expectedSHA256, expectedMD5 := sums("foo\n")
+		require.Equal(t, expectedSHA256, fileInfo.Sha256)
+		require.Equal(t, expectedMD5, fileInfo.Md5)
+		fileInfos, err := env.PachClient.ListFile(repo, "master", "/")
+		require.NoError(t, err)
+		require.Equal(t, 1, len(fileInfos))
+		require.Equal(t, expectedSHA256, fileInfos[0].Sha256)
+
+		// Appending to the file clears them, and overwriting it sets them again
+		_, err = env.PachClient.PutFile(repo, "master", "file", strings.NewReader("bar\n"))
+		require.NoError(t, err)
+		fileInfo, err = env.PachClient.InspectFile(repo, "master", "file")
+		require.NoError(t, err)
+		require.Nil(t, fileInfo.Sha256)
+		require.Nil(t, fileInfo.Md5)
+		_, err = env.PachClient.PutFileOverwrite(repo, "master", "file", strings.NewReader("baz\n"), 0)
+		require.NoError(t, err)
+		fileInfo, err = env.PachClient.InspectFile(repo, "master", "file")
+		require.NoError(t, err)
+		expectedSHA256, _ = sums("baz\n")
+		require.Equal(t, expectedSHA256, fileInfo.Sha256)
+
+		// Copies keep the digests of the original
+		require.NoError(t, env.PachClient.CopyFile(repo, "master", "file", repo, "master", "copy", false))
+		fileInfo, err = env.PachClient.InspectFile(repo, "master", "copy")
+		require.NoError(t, err)
+		require.Equal(t, expectedSHA256, fileInfo.Sha256)
+
+		// Each file created by a split has its own digests
+		_, err = env.PachClient.PutFileSplit(repo, "master", "split", pfs.Delimiter_LINE, 1, 0, 0, false, strings.NewReader("a\nb\n"))
+		require.NoError(t, err)
+		for path, content := range map[string]string{"split/0000000000000000": "a\n", "split/0000000000000001": "b\n"} {
+			fileInfo, err = env.PachClient.InspectFile(repo, "master", path)
+			require.NoError(t, err)
+			expectedSHA256, expectedMD5 = sums(content)
+			require.Equal(t, expectedSHA256, fileInfo.Sha256)
+			require.Equal(t, expectedMD5, fileInfo.Md5)
+		}
+
+		// A put with the right expected checksum succeeds, and one with the
+		// wrong checksum fails without changing the file
+		expectedSHA256, _ = sums("verified\n")
+		_, err = env.PachClient.PutFileVerified(repo, "master", "verified", strings.NewReader("verified\n"), true, 0, expectedSHA256)
+		require.NoError(t, err)
+		_, err = env.PachClient.PutFileVerified(repo, "master", "verified", strings.NewReader("corrupted\n"), true, 0, expectedSHA256)
+		require.YesError(t, err)
+		require.True(t, pfsserver.IsChecksumMismatchErr(err))
+		var buf bytes.Buffer
+		require.NoError(t, env.PachClient.GetFile(repo, "master", "verified", 0, 0, &buf))
+		require.Equal(t, "verified\n", buf.String())
+
+		// The same holds for files put in an open commit: a file put once has
+		// its digests, appending to it in the same commit clears them, and
+		// overwriting it sets them again
+		commit, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		_, err = env.PachClient.PutFile(repo, commit.ID, "open", strings.NewReader("foo\n"))
+		require.NoError(t, err)
+		_, err = env.PachClient.PutFile(repo, commit.ID, "appended", strings.NewReader("foo\n"))
+		require.NoError(t, err)
+		_, err = env.PachClient.PutFile(repo, commit.ID, "appended", strings.NewReader("bar\n"))
+		require.NoError(t, err)
+		_, err = env.PachClient.PutFile(repo, commit.ID, "overwritten", strings.NewReader("foo\n"))
+		require.NoError(t, err)
+		_, err = env.PachClient.PutFile(repo, commit.ID, "overwritten", strings.NewReader("bar\n"))
+		require.NoError(t, err)
+		_, err = env.PachClient.PutFileOverwrite(repo, commit.ID, "overwritten", strings.NewReader("baz\n"), 0)
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit.ID))
+		for path, content := range map[string]string{"open": "foo\n", "overwritten": "baz\n"} {
+			fileInfo, err = env.PachClient.InspectFile(repo, commit.ID, path)
+			require.NoError(t, err)
+			expectedSHA256, expectedMD5 = sums(content)
+			require.Equal(t, expectedSHA256, fileInfo.Sha256)
+			require.Equal(t, expectedMD5, fileInfo.Md5)
+		}
+		fileInfo, err = env.PachClient.InspectFile(repo, commit.ID, "appended")
+		require.NoError(t, err)
+		require.Nil(t, fileInfo.Sha256)
+		require.Nil(t, fileInfo.Md5)
+
+		// Appending to a file from an earlier commit clears them too
+		commit, err = env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		_, err = env.PachClient.PutFile(repo, commit.ID, "open", strings.NewReader("bar\n"))
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit.ID))
+		fileInfo, err = env.PachClient.InspectFile(repo, commit.ID, "open")
+		require.NoError(t, err)
+		require.Nil(t, fileInfo.Sha256)
+
+		// Overwriting a file from an earlier commit replaces its contents, so it
+		// gets the digests of the new contents, whether it's overwritten on a
+		// branch or in an open commit
+		checkSums := func(commitID, path, content string) {
+			fileInfo, err := env.PachClient.InspectFile(repo, commitID, path)
+			require.NoError(t, err)
+			expectedSHA256, expectedMD5 := sums(content)
+			require.Equal(t, expectedSHA256, fileInfo.Sha256)
+			require.Equal(t, expectedMD5, fileInfo.Md5)
+		}
+		_, err = env.PachClient.PutFileOverwrite(repo, "master", "open", strings.NewReader("baz\n"), 0)
+		require.NoError(t, err)
+		checkSums("master", "open", "baz\n")
+		_, err = env.PachClient.PutFileOverwrite(repo, "master", "file", strings.NewReader("qux\n"), 0)
+		require.NoError(t, err)
+		checkSums("master", "file", "qux\n")
+		commit, err = env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		_, err = env.PachClient.PutFileOverwrite(repo, commit.ID, "file", strings.NewReader("quux\n"), 0)
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit.ID))
+		checkSums(commit.ID, "file", "quux\n")
+		// The same goes for deleting a file and putting it again
+		commit, err = env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.DeleteFile(repo, commit.ID, "file"))
+		_, err = env.PachClient.PutFile(repo, commit.ID, "file", strings.NewReader("corge\n"))
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit.ID))
+		checkSums(commit.ID, "file", "corge\n")
+
+		// Stored contents match their checksums
+		require.NoError(t, env.PachClient.FsckVerifyChecksums(false, func(resp *pfs.FsckResponse) error {
+			return errors.Errorf("unexpected fsck error: %s", resp.Error)
+		}))
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestSymlinksAndModes(t *testing.T) {
+	t.Parallel()
+	err := testpachd.WithRealEnv(func(env *testpachd.RealEnv) error {
+		if testing.Short() {
+			t.Skip("Skipping integration tests in short mode")
+		}
+
+		repo := tu.UniqueString("TestSymlinksAndModes")
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+
+		// A file's mode is recorded when it's put, and kept when it's appended to
+		_, err := env.PachClient.PutFileMode(repo, "master", "dir/script", strings.NewReader("#!/bin/sh\n"), false, 0755)
+		require.NoError(t, err)
+		_, err = env.PachClient.PutFile(repo, "master", "dir/script", strings.NewReader("echo foo\n"))
+		require.NoError(t, err)
+		fileInfo, err := env.PachClient.InspectFile(repo, "master", "dir/script")
+		require.NoError(t, err)
+		require.Equal(t, pfs.FileType_FILE, fileInfo.FileType)
+		require.Equal(t, uint32(0755), fileInfo.Mode)
+		_, err = env.PachClient.PutFileMode(repo, "master", "bad-mode", strings.NewReader("foo\n"), false, uint32(os.ModeSetuid|0755))
+		require.YesError(t, err)
+
+		// Symlinks have no contents, and can't be written to
+		require.NoError(t, env.PachClient.PutSymlink(repo, "master", "link", "dir/script"))
+		fileInfo, err = env.PachClient.InspectFile(repo, "master", "link")
+		require.NoError(t, err)
+		require.Equal(t, pfs.FileType_SYMLINK, fileInfo.FileType)
+		require.Equal(t, "dir/script", fileInfo.SymlinkTarget)
+		require.Equal(t, uint64(0), fileInfo.SizeBytes)
+		_, err = env.PachClient.PutFile(repo, "master", "link", strings.NewReader("foo\n"))
+		require.YesError(t, err)
+		fileInfos, err := env.PachClient.ListFile(repo, "master", "/")
+		require.NoError(t, err)
+		require.Equal(t, 2, len(fileInfos))
+		require.Equal(t, pfs.FileType_SYMLINK, fileInfos[1].FileType)
+
+		// Copies keep the mode and target of the original
+		require.NoError(t, env.PachClient.CopyFile(repo, "master", "/", repo, "master", "copy", false))
+		fileInfo, err = env.PachClient.InspectFile(repo, "master", "copy/dir/script")
+		require.NoError(t, err)
+		require.Equal(t, uint32(0755), fileInfo.Mode)
+		fileInfo, err = env.PachClient.InspectFile(repo, "master", "copy/link")
+		require.NoError(t, err)
+		require.Equal(t, "dir/script", fileInfo.SymlinkTarget)
+
+		// Pulling the repo restores both
+		tmpDir, err := ioutil.TempDir("/tmp", "pfs")
+		require.NoError(t, err)
+		defer os.RemoveAll(tmpDir)
+		puller := pfssync.NewPuller()
+		require.NoError(t, puller.Pull(env.PachClient, tmpDir, repo, "master", "/", false, false, 2, nil, ""))
+		info, err := os.Stat(filepath.Join(tmpDir, "dir", "script"))
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0755), info.Mode().Perm())
+		target, err := os.Readlink(filepath.Join(tmpDir, "link"))
+		require.NoError(t, err)
+		require.Equal(t, "dir/script", target)
+		data, err := ioutil.ReadFile(filepath.Join(tmpDir, "link"))
+		require.NoError(t, err)
+		require.Equal(t, "#!/bin/sh\necho foo\n", string(data))
+
+		// Modes and symlinks put in an open commit are kept when it's finished
+		commit, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		_, err = env.PachClient.PutFileMode(repo, commit.ID, "open/script", strings.NewReader("#!/bin/sh\n"), false, 0700)
+		require.NoError(t, err)
+		_, err = env.PachClient.PutFile(repo, commit.ID, "open/script", strings.NewReader("echo foo\n"))
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.PutSymlink(repo, commit.ID, "open/link", "script"))
+		_, err = env.PachClient.PutFile(repo, commit.ID, "open/link", strings.NewReader("foo\n"))
+		require.YesError(t, err)
+		_, err = env.PachClient.PutFile(repo, commit.ID, "open/replaced", strings.NewReader("foo\n"))
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.PutSymlink(repo, commit.ID, "open/replaced", "script"))
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit.ID))
+		fileInfo, err = env.PachClient.InspectFile(repo, commit.ID, "open/script")
+		require.NoError(t, err)
+		require.Equal(t, uint32(0700), fileInfo.Mode)
+		require.Equal(t, uint64(len("#!/bin/sh\necho foo\n")), fileInfo.SizeBytes)
+		for _, path := range []string{"open/link", "open/replaced"} {
+			fileInfo, err = env.PachClient.InspectFile(repo, commit.ID, path)
+			require.NoError(t, err)
+			require.Equal(t, pfs.FileType_SYMLINK, fileInfo.FileType)
+			require.Equal(t, "script", fileInfo.SymlinkTarget)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestRepoQuota(t *testing.T) {
+	t.Parallel()
+	err := testpachd.WithRealEnv(func(env *testpachd.RealEnv) error {
+		if testing.Short() {
+			t.Skip("Skipping integration tests in short mode")
+		}
+
+		repo := tu.UniqueString("TestRepoQuota")
+		_, err := env.PachClient.PfsAPIClient.CreateRepo(env.PachClient.Ctx(), &pfs.CreateRepoRequest{
+			Repo:  pclient.NewRepo(repo),
+			Quota: &pfs.Quota{SizeBytes: 10, FileCount: 2},
+		})
+		require.NoError(t, err)
+
+		// Writes under the quota succeed, and the repo's usage is tracked
+		_, err = env.PachClient.PutFile(repo, "master", "file", strings.NewReader("foo\n"))
+		require.NoError(t, err)
+		repoInfo, err := env.PachClient.InspectRepo(repo)
+		require.NoError(t, err)
+		require.Equal(t, uint64(4), repoInfo.SizeBytes)
+		require.Equal(t, uint64(1), repoInfo.FileCount)
+
+		// A put that would exceed the size quota fails
+		_, err = env.PachClient.PutFile(repo, "master", "big", strings.NewReader(strings.Repeat("a", 20)))
+		require.YesError(t, err)
+		require.True(t, pfsserver.IsQuotaExceededErr(err))
+
+		// A commit that would exceed the file quota can't be finished until
+		// the quota is raised
+		commit, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		_, err = env.PachClient.PutFile(repo, commit.ID, "a", strings.NewReader("a"))
+		require.NoError(t, err)
+		_, err = env.PachClient.PutFile(repo, commit.ID, "b", strings.NewReader("b"))
+		require.NoError(t, err)
+		err = env.PachClient.FinishCommit(repo, commit.ID)
+		require.YesError(t, err)
+		require.True(t, pfsserver.IsQuotaExceededErr(err))
+		_, err = env.PachClient.PfsAPIClient.CreateRepo(env.PachClient.Ctx(), &pfs.CreateRepoRequest{
+			Repo:   pclient.NewRepo(repo),
+			Quota:  &pfs.Quota{SizeBytes: 10, FileCount: 3},
+			Update: true,
+		})
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit.ID))
+		repoInfo, err = env.PachClient.InspectRepo(repo)
+		require.NoError(t, err)
+		require.Equal(t, uint64(6), repoInfo.SizeBytes)
+		require.Equal(t, uint64(3), repoInfo.FileCount)
+
+		// An update that doesn't set a quota keeps the repo's, and an empty
+		// quota removes it
+		require.NoError(t, env.PachClient.UpdateRepo(repo))
+		repoInfo, err = env.PachClient.InspectRepo(repo)
+		require.NoError(t, err)
+		require.Equal(t, &pfs.Quota{SizeBytes: 10, FileCount: 3}, repoInfo.Quota)
+		require.Equal(t, uint64(3), repoInfo.FileCount)
+		_, err = env.PachClient.PfsAPIClient.CreateRepo(env.PachClient.Ctx(), &pfs.CreateRepoRequest{
+			Repo:   pclient.NewRepo(repo),
+			Quota:  &pfs.Quota{},
+			Update: true,
+		})
+		require.NoError(t, err)
+		repoInfo, err = env.PachClient.InspectRepo(repo)
+		require.NoError(t, err)
+		require.Nil(t, repoInfo.Quota)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+// TestRepoQuotaReplacedData checks that a put is checked against the data
+// it'll actually be added to, which doesn't include data that's overwritten
+// or deleted, or data on other branches
+func TestRepoQuotaReplacedData(t *testing.T) {
+	t.Parallel()
+	err := testpachd.WithRealEnv(func(env *testpachd.RealEnv) error {
+		if testing.Short() {
+			t.Skip("Skipping integration tests in short mode")
+		}
+
+		repo := tu.UniqueString("TestRepoQuotaReplacedData")
+		_, err := env.PachClient.PfsAPIClient.CreateRepo(env.PachClient.Ctx(), &pfs.CreateRepoRequest{
+			Repo:  pclient.NewRepo(repo),
+			Quota: &pfs.Quota{SizeBytes: 10},
+		})
+		require.NoError(t, err)
+
+		// Overwriting a file only counts the new data
+		_, err = env.PachClient.PutFile(repo, "master", "file", strings.NewReader("123456"))
+		require.NoError(t, err)
+		_, err = env.PachClient.PutFileOverwrite(repo, "master", "file", strings.NewReader("abcdef"), 0)
+		require.NoError(t, err)
+
+		// So does putting a file after deleting another in an open commit
+		commit, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.DeleteFile(repo, commit.ID, "file"))
+		_, err = env.PachClient.PutFile(repo, commit.ID, "other", strings.NewReader("12345678"))
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit.ID))
+
+		// Data already put in an open commit counts
+		commit, err = env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		_, err = env.PachClient.PutFile(repo, commit.ID, "a", strings.NewReader("a"))
+		require.NoError(t, err)
+		_, err = env.PachClient.PutFile(repo, commit.ID, "b", strings.NewReader("b"))
+		require.NoError(t, err)
+		_, err = env.PachClient.PutFile(repo, commit.ID, "c", strings.NewReader("c"))
+		require.YesError(t, err)
+		require.True(t, pfsserver.IsQuotaExceededErr(err))
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit.ID))
+
+		// Data on master doesn't count against other branches
+		_, err = env.PachClient.PutFile(repo, "dev", "file", strings.NewReader("123456789"))
+		require.NoError(t, err)
+		repoInfo, err := env.PachClient.InspectRepo(repo)
+		require.NoError(t, err)
+		require.Equal(t, uint64(10), repoInfo.SizeBytes)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+// TestRepoQuotaFileCount checks that a commit's file count is kept up to date
+// as files are added, overwritten and deleted, including when the repo had no
+// file-count quota (and so no count) before its latest commit
+func TestRepoQuotaFileCount(t *testing.T) {
+	t.Parallel()
+	err := testpachd.WithRealEnv(func(env *testpachd.RealEnv) error {
+		if testing.Short() {
+			t.Skip("Skipping integration tests in short mode")
+		}
+		c := env.PachClient
+
+		repo := tu.UniqueString("TestRepoQuotaFileCount")
+		require.NoError(t, c.CreateRepo(repo))
+		for _, file := range []string{"a", "b", "dir/c"} {
+			_, err := c.PutFile(repo, "master", file, strings.NewReader(file))
+			require.NoError(t, err)
+		}
+		_, err := c.PfsAPIClient.CreateRepo(c.Ctx(), &pfs.CreateRepoRequest{
+			Repo:   pclient.NewRepo(repo),
+			Quota:  &pfs.Quota{FileCount: 100},
+			Update: true,
+		})
+		require.NoError(t, err)
+		repoInfo, err := c.InspectRepo(repo)
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), repoInfo.FileCount)
+		requireFileCount := func(expected uint64) {
+			commitInfo, err := c.InspectCommit(repo, "master")
+			require.NoError(t, err)
+			require.Equal(t, expected, commitInfo.FileCount)
+			repoInfo, err := c.InspectRepo(repo)
+			require.NoError(t, err)
+			require.Equal(t, expected, repoInfo.FileCount)
+		}
+
+		_, err = c.PutFile(repo, "master", "dir/d", strings.NewReader("d"))
+		require.NoError(t, err)
+		requireFileCount(4)
+
+		commit, err := c.StartCommit(repo, "master")
+		require.NoError(t, err)
+		_, err = c.PutFileOverwrite(repo, commit.ID, "a", strings.NewReader("A"), 0)
+		require.NoError(t, err)
+		_, err = c.PutFile(repo, commit.ID, "dir/sub/e", strings.NewReader("e"))
+		require.NoError(t, err)
+		require.NoError(t, c.DeleteFile(repo, commit.ID, "b"))
+		require.NoError(t, c.FinishCommit(repo, commit.ID))
+		requireFileCount(4)
+
+		require.NoError(t, c.DeleteFile(repo, "master", "dir"))
+		requireFileCount(1)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
 func TestApplyWriteOrder(t *testing.T) {
 	t.Parallel()
 	err := testpachd.WithRealEnv(func(env *testpachd.RealEnv) error {
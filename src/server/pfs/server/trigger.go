@@ -0,0 +1,375 @@
+package server
+
+import (
+	"time"
+
+	units "github.com/docker/go-units"
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+	"github.com/pachyderm/pachyderm/src/server/pkg/ancestry"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	txnenv "github.com/pachyderm/pachyderm/src/server/pkg/transactionenv"
+	"github.com/robfig/cron"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+)
+
+const (
+	triggerLockPath = "pfs-trigger-lock"
+)
+
+// validateTrigger returns an error if 'trigger' can't be set on 'branch',
+// either because it's malformed or because following it would lead back to
+// 'branch'.
+func (d *driver) validateTrigger(txnCtx *txnenv.TransactionContext, branch *pfs.Branch, trigger *pfs.Trigger) error {
+	if trigger.Branch == "" {
+		return errors.Errorf("trigger on branch %s@%s must name a branch to follow", branch.Repo.Name, branch.Name)
+	}
+	if trigger.CronSpec != "" {
+		if _, err := cron.ParseStandard(trigger.CronSpec); err != nil {
+			return errors.Wrapf(err, "invalid trigger cron spec %q", trigger.CronSpec)
+		}
+	}
+	if trigger.Size_ != "" {
+		if _, err := units.RAMInBytes(trigger.Size_); err != nil {
+			return errors.Wrapf(err, "invalid trigger size %q", trigger.Size_)
+		}
+	}
+	if trigger.Commits < 0 {
+		return errors.Errorf("trigger commits cannot be negative (got %d)", trigger.Commits)
+	}
+	for _, name := range trigger.Branches {
+		if err := ancestry.ValidateName(name); err != nil {
+			return errors.Wrapf(err, "invalid trigger branch %q", name)
+		}
+		if name == branch.Name {
+			return errors.Errorf("trigger on branch %s@%s cannot wait on the branch itself", branch.Repo.Name, branch.Name)
+		}
+	}
+	// Follow the triggers that 'trigger' depends on to make sure none of them
+	// lead back to 'branch'
+	branches := d.branches(branch.Repo.Name).ReadWrite(txnCtx.Stm)
+	seen := map[string]bool{}
+	toCheck := triggerSources(trigger)
+	for len(toCheck) > 0 {
+		next := toCheck[0]
+		toCheck = toCheck[1:]
+		if next == branch.Name {
+			return errors.Errorf("trigger on branch %s@%s would create a cycle through its own trigger", branch.Repo.Name, branch.Name)
+		}
+		if seen[next] {
+			continue
+		}
+		seen[next] = true
+		branchInfo := &pfs.BranchInfo{}
+		if err := branches.Get(next, branchInfo); err != nil {
+			if col.IsErrNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if branchInfo.Trigger != nil {
+			toCheck = append(toCheck, triggerSources(branchInfo.Trigger)...)
+		}
+	}
+	return nil
+}
+
+// triggerSources returns the branches whose heads 'trigger' depends on.
+func triggerSources(trigger *pfs.Trigger) []string {
+	return append([]string{trigger.Branch}, trigger.Branches...)
+}
+
+// triggerCommit evaluates the trigger of every branch that depends on
+// 'branch', now that its head has been finished.
+func (d *driver) triggerCommit(txnCtx *txnenv.TransactionContext, branch *pfs.Branch) error {
+	return d.evaluateTriggers(txnCtx, branch.Repo.Name, func(branchInfo *pfs.BranchInfo) bool {
+		for _, source := range triggerSources(branchInfo.Trigger) {
+			if source == branch.Name {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// evaluateTriggers moves every branch in 'repo' that has a trigger and is
+// selected by 'selected' to the head of the branch its trigger follows, if the trigger
+// fires. Branches moved this way are propagated, and the triggers that depend
+// on them are evaluated in turn.
+func (d *driver) evaluateTriggers(txnCtx *txnenv.TransactionContext, repo string, selected func(*pfs.BranchInfo) bool) error {
+	repos := d.repos.ReadWrite(txnCtx.Stm)
+	repoInfo := &pfs.RepoInfo{}
+	if err := repos.Get(repo, repoInfo); err != nil {
+		return err
+	}
+	branches := d.branches(repo).ReadWrite(txnCtx.Stm)
+	for selected != nil {
+		var moved []string
+		for _, b := range repoInfo.Branches {
+			branchInfo := &pfs.BranchInfo{}
+			if err := branches.Get(b.Name, branchInfo); err != nil {
+				return err
+			}
+			if branchInfo.Trigger == nil || !selected(branchInfo) {
+				continue
+			}
+			fired, err := d.fireTrigger(txnCtx, repoInfo, b, branchInfo)
+			if err != nil {
+				return errors.Wrapf(err, "error evaluating trigger on branch %s@%s", b.Repo.Name, b.Name)
+			}
+			if fired {
+				moved = append(moved, b.Name)
+			}
+		}
+		selected = nil
+		if len(moved) > 0 {
+			selected = func(branchInfo *pfs.BranchInfo) bool {
+				for _, source := range triggerSources(branchInfo.Trigger) {
+					for _, name := range moved {
+						if source == name {
+							return true
+						}
+					}
+				}
+				return false
+			}
+		}
+	}
+	return nil
+}
+
+// fireTrigger moves 'branch' to the finished head of the branch its trigger
+// follows, if that's a new commit and the trigger fires. It returns true if
+// 'branch' was moved.
+func (d *driver) fireTrigger(txnCtx *txnenv.TransactionContext, repoInfo *pfs.RepoInfo, branch *pfs.Branch, branchInfo *pfs.BranchInfo) (bool, error) {
+	branches := d.branches(repoInfo.Repo.Name).ReadWrite(txnCtx.Stm)
+	followed := &pfs.BranchInfo{}
+	if err := branches.Get(branchInfo.Trigger.Branch, followed); err != nil {
+		if col.IsErrNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if followed.Head == nil || (branchInfo.Head != nil && branchInfo.Head.ID == followed.Head.ID) {
+		return false, nil
+	}
+	commitInfo, err := d.resolveCommit(txnCtx.Stm, followed.Head)
+	if err != nil {
+		return false, err
+	}
+	if commitInfo.Finished == nil {
+		return false, nil
+	}
+	fires, err := d.triggerFires(txnCtx, branchInfo, commitInfo)
+	if err != nil || !fires {
+		return false, err
+	}
+	branchInfo.Head = commitInfo.Commit
+	branchInfo.LastTriggered = now()
+	branchInfo.TriggerHeads = nil
+	for _, name := range branchInfo.Trigger.Branches {
+		waitedOn := &pfs.BranchInfo{}
+		if err := branches.Get(name, waitedOn); err != nil {
+			if col.IsErrNotFound(err) {
+				continue
+			}
+			return false, err
+		}
+		if waitedOn.Head != nil {
+			branchInfo.TriggerHeads = append(branchInfo.TriggerHeads, waitedOn.Head)
+		}
+	}
+	if err := branches.Put(branch.Name, branchInfo); err != nil {
+		return false, err
+	}
+	if branch.Name == "master" {
+		repoInfo.SizeBytes = commitInfo.SizeBytes
+		repoInfo.FileCount = commitInfo.FileCount
+		if err := d.repos.ReadWrite(txnCtx.Stm).Put(repoInfo.Repo.Name, repoInfo); err != nil {
+			return false, err
+		}
+	}
+	if err := txnCtx.PropagateCommit(branch, false); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// triggerFires returns true if the trigger on 'branchInfo' fires now that
+// 'commitInfo' is the finished head of the branch it follows. Commits and
+// bytes are counted from the head of 'branchInfo' (or from the first commit,
+// if it has no head) to 'commitInfo'.
+func (d *driver) triggerFires(txnCtx *txnenv.TransactionContext, branchInfo *pfs.BranchInfo, commitInfo *pfs.CommitInfo) (bool, error) {
+	trigger := branchInfo.Trigger
+	var results []bool
+	if trigger.CronSpec != "" {
+		schedule, err := cron.ParseStandard(trigger.CronSpec)
+		if err != nil {
+			return false, err
+		}
+		fires := true
+		if branchInfo.LastTriggered != nil {
+			lastMoved, err := types.TimestampFromProto(branchInfo.LastTriggered)
+			if err != nil {
+				return false, err
+			}
+			fires = !schedule.Next(lastMoved).After(time.Now())
+		} else if branchInfo.Head != nil {
+			headInfo, err := d.resolveCommit(txnCtx.Stm, branchInfo.Head)
+			if err != nil {
+				return false, err
+			}
+			if headInfo.Finished != nil {
+				lastMoved, err := types.TimestampFromProto(headInfo.Finished)
+				if err != nil {
+					return false, err
+				}
+				fires = !schedule.Next(lastMoved).After(time.Now())
+			}
+		}
+		results = append(results, fires)
+	}
+	if trigger.Size_ != "" || trigger.Commits != 0 {
+		var size int64
+		if trigger.Size_ != "" {
+			var err error
+			size, err = units.RAMInBytes(trigger.Size_)
+			if err != nil {
+				return false, err
+			}
+		}
+		var commits, bytes int64
+		commitsDone := func() bool { return trigger.Commits != 0 && commits >= trigger.Commits }
+		bytesDone := func() bool { return trigger.Size_ != "" && bytes >= size }
+		ci := commitInfo
+		for {
+			if branchInfo.Head != nil && ci.Commit.ID == branchInfo.Head.ID {
+				break
+			}
+			commits++
+			var parentSize int64
+			var parentInfo *pfs.CommitInfo
+			if ci.ParentCommit != nil {
+				var err error
+				parentInfo, err = d.resolveCommit(txnCtx.Stm, ci.ParentCommit)
+				if err != nil {
+					return false, err
+				}
+				parentSize = int64(parentInfo.SizeBytes)
+			}
+			if delta := int64(ci.SizeBytes) - parentSize; delta >= 0 {
+				bytes += delta
+			} else {
+				bytes -= delta
+			}
+			if parentInfo == nil || ((trigger.Commits == 0 || commitsDone()) && (trigger.Size_ == "" || bytesDone())) {
+				break
+			}
+			ci = parentInfo
+		}
+		if trigger.Size_ != "" {
+			results = append(results, bytesDone())
+		}
+		if trigger.Commits != 0 {
+			results = append(results, commitsDone())
+		}
+	}
+	if len(trigger.Branches) > 0 {
+		// Every branch waited on must have a head that it didn't have when the
+		// trigger last fired
+		lastHeads := make(map[string]bool)
+		for _, head := range branchInfo.TriggerHeads {
+			lastHeads[head.ID] = true
+		}
+		branches := d.branches(commitInfo.Commit.Repo.Name).ReadWrite(txnCtx.Stm)
+		advanced := true
+		for _, name := range trigger.Branches {
+			waitedOn := &pfs.BranchInfo{}
+			if err := branches.Get(name, waitedOn); err != nil {
+				if !col.IsErrNotFound(err) {
+					return false, err
+				}
+				advanced = false
+				break
+			}
+			if waitedOn.Head == nil || lastHeads[waitedOn.Head.ID] {
+				advanced = false
+				break
+			}
+		}
+		results = append(results, advanced)
+	}
+	if len(results) == 0 {
+		return true, nil
+	}
+	for _, result := range results {
+		if result && !trigger.All {
+			return true, nil
+		}
+		if !result && trigger.All {
+			return false, nil
+		}
+	}
+	return trigger.All, nil
+}
+
+// evaluateCronTriggers evaluates the cron triggers in every repo, so that a
+// branch following an idle branch still moves when its schedule ticks. A
+// failure in one repo is logged and doesn't stop the others from being
+// evaluated. Only repos where a cron trigger could move its branch are written
+// to.
+func (d *driver) evaluateCronTriggers(ctx context.Context) error {
+	var repos []string
+	repoInfo := &pfs.RepoInfo{}
+	if err := d.repos.ReadOnly(ctx).List(repoInfo, col.DefaultOptions, func(string) error {
+		repos = append(repos, repoInfo.Repo.Name)
+		return nil
+	}); err != nil {
+		return err
+	}
+	for _, repo := range repos {
+		pending, err := d.hasPendingCronTrigger(ctx, repo)
+		if err != nil {
+			logrus.Errorf("error reading cron triggers in repo %s: %v", repo, err)
+			continue
+		}
+		if !pending {
+			continue
+		}
+		if err := d.txnEnv.WithWriteContext(ctx, func(txnCtx *txnenv.TransactionContext) error {
+			return d.evaluateTriggers(txnCtx, repo, func(branchInfo *pfs.BranchInfo) bool {
+				return branchInfo.Trigger.CronSpec != ""
+			})
+		}); err != nil {
+			logrus.Errorf("error evaluating cron triggers in repo %s: %v", repo, err)
+		}
+	}
+	return nil
+}
+
+// hasPendingCronTrigger returns true if a branch in 'repo' has a cron trigger
+// and the branch it follows has a different head, i.e. if firing the trigger
+// would move the branch.
+func (d *driver) hasPendingCronTrigger(ctx context.Context, repo string) (bool, error) {
+	heads := make(map[string]string)
+	var triggers []*pfs.BranchInfo
+	branchInfo := &pfs.BranchInfo{}
+	if err := d.branches(repo).ReadOnly(ctx).List(branchInfo, col.DefaultOptions, func(string) error {
+		heads[branchInfo.Branch.Name] = branchInfo.Head.GetID()
+		if branchInfo.Trigger.GetCronSpec() != "" {
+			triggers = append(triggers, proto.Clone(branchInfo).(*pfs.BranchInfo))
+		}
+		return nil
+	}); err != nil {
+		return false, err
+	}
+	for _, bi := range triggers {
+		if followed := heads[bi.Trigger.Branch]; followed != "" && followed != bi.Head.GetID() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,155 @@
+package avro
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+)
+
+const syncSize = 16
+
+var magic = []byte{'O', 'b', 'j', 1}
+
+// ContainerReader splits an Avro object container file into its header and
+// its data blocks. The header followed by any sequence of the blocks is a
+// valid container file.
+type ContainerReader struct {
+	Header []byte
+	sync   []byte
+	rd     *recordingReader
+}
+
+// NewContainerReader creates a new ContainerReader
+func NewContainerReader(r *bufio.Reader) *ContainerReader {
+	return &ContainerReader{
+		rd: &recordingReader{rd: r},
+	}
+}
+
+// ReadBlock returns the next data block in the file, including its trailing
+// sync marker, and the number of records in it. The header is read and
+// populated before the first block is returned. ReadBlock returns io.EOF when
+// there are no more blocks, or if the file is empty (in which case Header
+// isn't populated).
+func (r *ContainerReader) ReadBlock() ([]byte, int64, error) {
+	if r.Header == nil {
+		if err := r.readHeader(); err != nil {
+			return nil, 0, err
+		}
+	}
+	r.rd.buf = nil
+	count, err := r.readLong()
+	if err != nil {
+		if err == io.EOF && len(r.rd.buf) == 0 {
+			return nil, 0, io.EOF
+		}
+		return nil, 0, errors.Wrapf(noEOF(err), "error reading avro block")
+	}
+	if count < 0 {
+		return nil, 0, errors.Errorf("invalid avro block record count %d", count)
+	}
+	size, err := r.readLong()
+	if err != nil {
+		return nil, 0, errors.Wrapf(noEOF(err), "error reading avro block")
+	}
+	if size < 0 {
+		return nil, 0, errors.Errorf("invalid avro block size %d", size)
+	}
+	if err := r.rd.readN(size + syncSize); err != nil {
+		return nil, 0, errors.Wrapf(noEOF(err), "error reading avro block")
+	}
+	if !bytes.Equal(r.rd.buf[len(r.rd.buf)-syncSize:], r.sync) {
+		return nil, 0, errors.Errorf("invalid avro block - sync marker doesn't match the header")
+	}
+	return r.rd.buf, count, nil
+}
+
+func (r *ContainerReader) readHeader() error {
+	if err := r.rd.readN(int64(len(magic))); err != nil {
+		if err == io.EOF && len(r.rd.buf) == 0 {
+			return io.EOF // an empty file has no header and no blocks
+		}
+		return errors.Wrapf(noEOF(err), "invalid avro header")
+	}
+	if !bytes.Equal(r.rd.buf, magic) {
+		return errors.Errorf("invalid avro header - not an avro object container file")
+	}
+	// The metadata is a map, which is encoded as a series of blocks of
+	// key/value pairs, ending with an empty block.
+	for {
+		count, err := r.readLong()
+		if err != nil {
+			return errors.Wrapf(noEOF(err), "invalid avro header")
+		}
+		if count == 0 {
+			break
+		}
+		if count < 0 {
+			// A negative count is followed by the size of the block in bytes
+			count = -count
+			if _, err := r.readLong(); err != nil {
+				return errors.Wrapf(noEOF(err), "invalid avro header")
+			}
+		}
+		// Each key is a string and each value is bytes, both of which are a
+		// length followed by that many bytes
+		for i := int64(0); i < 2*count; i++ {
+			n, err := r.readLong()
+			if err != nil {
+				return errors.Wrapf(noEOF(err), "invalid avro header")
+			}
+			if n < 0 {
+				return errors.Errorf("invalid avro header - negative length %d", n)
+			}
+			if err := r.rd.readN(n); err != nil {
+				return errors.Wrapf(noEOF(err), "invalid avro header")
+			}
+		}
+	}
+	if err := r.rd.readN(syncSize); err != nil {
+		return errors.Wrapf(noEOF(err), "invalid avro header")
+	}
+	r.Header = r.rd.buf
+	r.sync = r.rd.buf[len(r.rd.buf)-syncSize:]
+	return nil
+}
+
+// readLong reads a zig-zag encoded long, which is also how encoding/binary
+// encodes signed varints.
+func (r *ContainerReader) readLong() (int64, error) {
+	return binary.ReadVarint(r.rd)
+}
+
+// recordingReader records the bytes read through it in buf.
+type recordingReader struct {
+	rd  *bufio.Reader
+	buf []byte
+}
+
+func (r *recordingReader) ReadByte() (byte, error) {
+	b, err := r.rd.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	r.buf = append(r.buf, b)
+	return b, nil
+}
+
+func (r *recordingReader) readN(n int64) error {
+	buf := bytes.NewBuffer(r.buf)
+	_, err := io.CopyN(buf, r.rd, n)
+	r.buf = buf.Bytes()
+	return err
+}
+
+// noEOF converts io.EOF, which is returned when a file ends part way through
+// a header or block, to io.ErrUnexpectedEOF.
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,184 @@
+package avro
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+)
+
+var testSync = []byte("0123456789abcdef")
+
+func long(n int64) []byte {
+	buf := make([]byte, binary.MaxVarintLen64)
+	return buf[:binary.PutVarint(buf, n)]
+}
+
+func str(s string) []byte {
+	return append(long(int64(len(s))), s...)
+}
+
+// header encodes a container file header. If negativeCount is set, the
+// metadata block is written with a negative count followed by its size.
+func header(meta map[string]string, negativeCount bool) []byte {
+	var pairs []byte
+	for k, v := range meta {
+		pairs = append(pairs, str(k)...)
+		pairs = append(pairs, str(v)...)
+	}
+	h := append([]byte(nil), magic...)
+	if len(meta) > 0 {
+		if negativeCount {
+			h = append(h, long(-int64(len(meta)))...)
+			h = append(h, long(int64(len(pairs)))...)
+		} else {
+			h = append(h, long(int64(len(meta)))...)
+		}
+		h = append(h, pairs...)
+	}
+	h = append(h, long(0)...)
+	return append(h, testSync...)
+}
+
+// block encodes a data block holding count objects.
+func block(count int64, data string) []byte {
+	b := append(long(count), str(data)...)
+	return append(b, testSync...)
+}
+
+func join(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+// readAll reads every block in file. It reads one byte at a time through the
+// smallest bufio.Reader, so that values span the reader's buffer refills.
+func readAll(file []byte) ([]byte, [][]byte, error) {
+	r := NewContainerReader(bufio.NewReaderSize(iotest.OneByteReader(bytes.NewReader(file)), 16))
+	var blocks [][]byte
+	for {
+		block, _, err := r.ReadBlock()
+		if err == io.EOF {
+			return r.Header, blocks, nil
+		}
+		if err != nil {
+			return r.Header, blocks, err
+		}
+		blocks = append(blocks, append([]byte(nil), block...))
+	}
+}
+
+var schema = map[string]string{
+	"avro.schema": `{"type": "bytes"}`,
+	"avro.codec":  "null",
+}
+
+var containerTests = []struct {
+	name   string
+	header []byte
+	blocks [][]byte
+}{
+	{"no blocks", header(schema, false), nil},
+	{"no metadata", header(nil, false), [][]byte{block(1, "a")}},
+	{"one block", header(schema, false), [][]byte{block(1, "foo")}},
+	{"many blocks", header(schema, false), [][]byte{
+		block(1, "foo"),
+		block(3, "barbazqux"),
+		block(0, ""),
+		block(2, string(bytes.Repeat([]byte{'x'}, 300))),
+	}},
+	{"negative metadata count", header(schema, true), [][]byte{block(1, "foo"), block(1, "bar")}},
+}
+
+func TestContainerReader(t *testing.T) {
+	for _, test := range containerTests {
+		header, blocks, err := readAll(join(test.header, join(test.blocks...)))
+		require.NoError(t, err, test.name)
+		require.Equal(t, test.header, header, test.name)
+		require.Equal(t, len(test.blocks), len(blocks), test.name)
+		for i := range blocks {
+			require.Equal(t, test.blocks[i], blocks[i], "%s: block %d", test.name, i)
+		}
+	}
+}
+
+func TestContainerReaderRecordCounts(t *testing.T) {
+	file := join(header(schema, false), block(1, "foo"), block(3, "barbazqux"), block(0, ""))
+	r := NewContainerReader(bufio.NewReader(bytes.NewReader(file)))
+	for _, expected := range []int64{1, 3, 0} {
+		_, count, err := r.ReadBlock()
+		require.NoError(t, err)
+		require.Equal(t, expected, count)
+	}
+	_, _, err := r.ReadBlock()
+	require.Equal(t, io.EOF, err)
+}
+
+// TestContainerReaderSplit checks that the header followed by any one of the
+// blocks, which is what each split file holds, is itself a valid container.
+func TestContainerReaderSplit(t *testing.T) {
+	for _, test := range containerTests {
+		for i, b := range test.blocks {
+			header, blocks, err := readAll(join(test.header, b))
+			require.NoError(t, err, "%s: block %d", test.name, i)
+			require.Equal(t, test.header, header, "%s: block %d", test.name, i)
+			require.Equal(t, 1, len(blocks), "%s: block %d", test.name, i)
+			require.Equal(t, b, blocks[0], "%s: block %d", test.name, i)
+		}
+	}
+}
+
+// TestContainerReaderTruncated checks that a file that ends part way through
+// its header or one of its blocks is an error, and that a file that ends
+// between blocks isn't.
+func TestContainerReaderTruncated(t *testing.T) {
+	for _, test := range containerTests {
+		file := join(test.header, join(test.blocks...))
+		boundaries := map[int]bool{0: true, len(test.header): true}
+		end := len(test.header)
+		for _, b := range test.blocks {
+			end += len(b)
+			boundaries[end] = true
+		}
+		for n := 0; n < len(file); n++ {
+			_, _, err := readAll(file[:n])
+			if boundaries[n] {
+				require.NoError(t, err, "%s: truncated to %d bytes", test.name, n)
+			} else {
+				require.YesError(t, err, "%s: truncated to %d bytes", test.name, n)
+				require.Equal(t, io.ErrUnexpectedEOF, errors.Cause(err), "%s: truncated to %d bytes", test.name, n)
+			}
+		}
+	}
+}
+
+func TestContainerReaderEmpty(t *testing.T) {
+	header, blocks, err := readAll(nil)
+	require.NoError(t, err)
+	require.Nil(t, header)
+	require.Equal(t, 0, len(blocks))
+}
+
+func TestContainerReaderInvalid(t *testing.T) {
+	badSync := block(1, "foo")
+	badSync[len(badSync)-1] = '!'
+	for _, test := range []struct {
+		name string
+		file []byte
+	}{
+		{"not avro", []byte("this isn't an avro file")},
+		{"wrong version", join([]byte{'O', 'b', 'j', 2}, header(schema, false)[len(magic):])},
+		{"negative string length", join(magic, long(1), long(-3))},
+		{"negative block size", join(header(schema, false), long(1), long(-1))},
+		{"negative record count", join(header(schema, false), long(-1), long(0))},
+		{"sync marker mismatch", join(header(schema, false), badSync)},
+	} {
+		_, _, err := readAll(test.file)
+		require.YesError(t, err, test.name)
+		require.NotEqual(t, io.ErrUnexpectedEOF, errors.Cause(err), test.name)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"strconv"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/pachyderm/pachyderm/src/client/pfs"
 	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
@@ -276,6 +277,37 @@ func ParseHistory(history string) (int64, error) {
 	return int64(result), err
 }
 
+// ParseDuration parses a duration flag argument, such as the argument of a
+// --since flag. On top of the units accepted by time.ParseDuration, it accepts
+// a number of days, e.g. "30d".
+func ParseDuration(duration string) (time.Duration, error) {
+	if days := strings.TrimSuffix(duration, "d"); days != duration {
+		n, err := strconv.ParseFloat(days, 64)
+		if err != nil {
+			return 0, errors.Errorf("invalid duration \"%s\"", duration)
+		}
+		return time.Duration(n * float64(24*time.Hour)), nil
+	}
+	return time.ParseDuration(duration)
+}
+
+// ParseKeyValues parses arguments of the form "key=value" (e.g. the values
+// of a repeated --annotation flag) into a map.
+func ParseKeyValues(args []string) (map[string]string, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+	result := make(map[string]string)
+	for _, arg := range args {
+		keyAndValue := strings.SplitN(arg, "=", 2)
+		if len(keyAndValue) < 2 || keyAndValue[0] == "" {
+			return nil, errors.Errorf("invalid format \"%s\": must be of the form key=value", arg)
+		}
+		result[keyAndValue[0]] = keyAndValue[1]
+	}
+	return result, nil
+}
+
 // RepeatedStringArg is an alias for []string
 type RepeatedStringArg []string
 
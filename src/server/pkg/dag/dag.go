@@ -1,5 +1,9 @@
 package dag
 
+import (
+	"sort"
+)
+
 // DAG represents a directected acyclic graph
 type DAG struct {
 	parents  map[string][]string
@@ -85,6 +89,51 @@ func (d *DAG) Ghosts() []string {
 	return result
 }
 
+// Cycle returns the nodes of a cycle in d, from parent to child and with the
+// first node repeated at the end, or nil if d is in fact acyclic.
+func (d *DAG) Cycle() []string {
+	// Nodes are visited in a fixed order, so that the same cycle is reported
+	// every time
+	var ids []string
+	for id := range d.parents {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	state := make(map[string]int) // 1: on the current path, 2: done
+	var path []string
+	var visit func(id string) []string
+	visit = func(id string) []string {
+		switch state[id] {
+		case 1:
+			for i, pathID := range path {
+				if pathID == id {
+					return append(append([]string{}, path[i:]...), id)
+				}
+			}
+		case 2:
+			return nil
+		}
+		state[id] = 1
+		path = append(path, id)
+		children := append([]string{}, d.children[id]...)
+		sort.Strings(children)
+		for _, childID := range children {
+			if cycle := visit(childID); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		state[id] = 2
+		return nil
+	}
+	for _, id := range ids {
+		if cycle := visit(id); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
 func dfs(id string, edges map[string][]string, seen map[string]bool) []string {
 	if seen[id] {
 		return nil
@@ -83,3 +83,25 @@ func TestGhosts(t *testing.T) {
 		d.Ghosts(),
 	)
 }
+
+func TestCycle(t *testing.T) {
+	d := NewDAG(map[string][]string{
+		"1": {},
+		"2": {"1"},
+		"3": {"2"},
+	})
+	require.Equal(t, 0, len(d.Cycle()))
+
+	d = NewDAG(map[string][]string{
+		"1": {},
+		"2": {"1", "4"},
+		"3": {"2"},
+		"4": {"3"},
+	})
+	require.Equal(t, []string{"2", "3", "4", "2"}, d.Cycle())
+
+	d = NewDAG(map[string][]string{
+		"1": {"1"},
+	})
+	require.Equal(t, []string{"1", "1"}, d.Cycle())
+}
@@ -712,6 +712,86 @@ func (h *dbHashTree) PutFileOverwriteBlockRefs(path string, brs []*pfs.BlockRef,
 	return h.putFile(path, nil, brs, overwriteIndex, sizeDelta, false)
 }
 
+// SetFileChecksum implements the hashtree.SetFileChecksum interface method
+func (h *dbHashTree) SetFileChecksum(path string, sha256, md5 []byte) error {
+	path = clean(path)
+	return h.Batch(func(tx *bolt.Tx) error {
+		node, err := get(tx, path)
+		if err != nil {
+			return err
+		}
+		if node.nodetype() != file {
+			return errorf(PathConflict, "could not set checksum of %q; a file of "+
+				"type %s is there", path, node.nodetype())
+		}
+		node.FileNode.Sha256 = sha256
+		node.FileNode.Md5 = md5
+		return put(tx, path, node)
+	})
+}
+
+// SetFileMode implements the hashtree.SetFileMode interface method
+func (h *dbHashTree) SetFileMode(path string, mode uint32) error {
+	path = clean(path)
+	return h.Batch(func(tx *bolt.Tx) error {
+		node, err := get(tx, path)
+		if err != nil {
+			return err
+		}
+		if node.nodetype() != file {
+			return errorf(PathConflict, "could not set mode of %q; a file of "+
+				"type %s is there", path, node.nodetype())
+		}
+		node.FileNode.Mode = mode
+		if err := put(tx, path, node); err != nil {
+			return err
+		}
+		// The file's mode is part of its hash, so its ancestors' hashes change
+		return visit(tx, path, func(*NodeProto, string, string) error { return nil })
+	})
+}
+
+// PutSymlink implements the hashtree.PutSymlink interface method
+func (h *dbHashTree) PutSymlink(path, target string) error {
+	path = clean(path)
+	if target == "" {
+		return errorf(Internal, "could not put symlink at %q with an empty target", path)
+	}
+	return h.Batch(func(tx *bolt.Tx) error {
+		node, err := get(tx, path)
+		if err != nil && Code(err) != PathNotFound {
+			return errorf(Internal, "could not get node at %q: %v", path, err)
+		}
+		if node != nil && node.nodetype() != file {
+			return errorf(PathConflict, "could not put symlink at %q; a file of "+
+				"type %s is already there", path, node.nodetype())
+		}
+		// A symlink replaces the contents of any file that was at 'path'
+		var sizeDelta int64
+		if node != nil {
+			sizeDelta = -node.SubtreeSize
+		}
+		node = &NodeProto{
+			Name: base(path),
+			FileNode: &FileNodeProto{
+				SymlinkTarget: target,
+			},
+		}
+		if err := put(tx, path, node); err != nil {
+			return err
+		}
+		return visit(tx, path, func(node *NodeProto, parent, child string) error {
+			if node.DirNode == nil {
+				// node created as part of this visit call, fill in the basics
+				node.Name = base(parent)
+				node.DirNode = &DirectoryNodeProto{}
+			}
+			node.SubtreeSize += sizeDelta
+			return nil
+		})
+	})
+}
+
 // PutDirHeaderFooter implements the hashtree.PutDirHeaderFooter interface
 // method
 func (h *dbHashTree) PutDirHeaderFooter(path string, header, footer *pfs.Object, headerSize, footerSize int64) error {
@@ -780,6 +860,10 @@ func (h *dbHashTree) putFile(path string, objects []*pfs.Object, brs []*pfs.Bloc
 			return errorf(PathConflict, "could not put file at %q; a file of "+
 				"type %s is already there", path, node.nodetype())
 		}
+		if node != nil && node.FileNode.SymlinkTarget != "" {
+			return errorf(PathConflict, "could not put file at %q; a symlink "+
+				"is already there", path)
+		}
 
 		// validation: 'hasHeaderFooter' can be set only if parent dir has 'Shared'
 		// field for header and footer data (indicating other children of this dir
@@ -815,6 +899,10 @@ func (h *dbHashTree) putFile(path string, objects []*pfs.Object, brs []*pfs.Bloc
 			}
 		}
 
+		// The file's contents are changing, so its digests are no longer valid
+		node.FileNode.Sha256 = nil
+		node.FileNode.Md5 = nil
+
 		// Append new objects.
 
 		// Remove existing objects if overwriting.
@@ -1018,10 +1106,12 @@ func (r *Reader) Read() (*MergeNode, error) {
 
 // Writer can write a serialized hashtree from a sequence of merge nodes.
 type Writer struct {
-	pbw    pbutil.Writer
-	size   uint64
-	idxs   []*Index
-	offset uint64
+	pbw        pbutil.Writer
+	size       uint64
+	countFiles bool
+	fileCount  uint64
+	idxs       []*Index
+	offset     uint64
 }
 
 // NewWriter creates a new hashtree writer.
@@ -1051,6 +1141,17 @@ func (w *Writer) Write(n *MergeNode) error {
 		}
 		w.size = uint64(n.nodeProto.SubtreeSize)
 	}
+	if w.countFiles {
+		if n.nodeProto == nil {
+			n.nodeProto = &NodeProto{}
+			if err := n.nodeProto.Unmarshal(n.v); err != nil {
+				return err
+			}
+		}
+		if n.nodeProto.FileNode != nil {
+			w.fileCount++
+		}
+	}
 	// Write index for every index size bytes
 	if w.offset > uint64(len(w.idxs)+1)*IndexSize {
 		w.idxs = append(w.idxs, &Index{
@@ -1093,6 +1194,18 @@ func (w *Writer) Size() uint64 {
 	return w.size
 }
 
+// CountFiles makes the writer count the files that it writes (see FileCount).
+// This requires deserializing every node, so it's off by default.
+func (w *Writer) CountFiles() {
+	w.countFiles = true
+}
+
+// FileCount returns the number of files in the written hashtree, if CountFiles
+// was called before writing it.
+func (w *Writer) FileCount() uint64 {
+	return w.fileCount
+}
+
 // Index returns the index for a hashtree writer.
 func (w *Writer) Index() ([]byte, error) {
 	buf := &bytes.Buffer{}
@@ -1256,8 +1369,23 @@ func merge(ns []*MergeNode) (*MergeNode, error) {
 		}
 		// Merge file content
 		if base.nodeProto.nodetype() == file {
+			// Symlinks have no content, so they can only be merged with identical
+			// symlinks
+			if base.nodeProto.FileNode.SymlinkTarget != n.nodeProto.FileNode.SymlinkTarget {
+				return nil, errorf(PathConflict, "could not merge path \"%s\" "+
+					"which is a symlink in some hashtrees, or points to different "+
+					"targets in different hashtrees", s(base.k))
+			}
+			if base.nodeProto.FileNode.SymlinkTarget != "" {
+				continue
+			}
+			if base.nodeProto.FileNode.Mode == 0 {
+				base.nodeProto.FileNode.Mode = n.nodeProto.FileNode.Mode
+			}
 			base.nodeProto.FileNode.BlockRefs = append(base.nodeProto.FileNode.BlockRefs, n.nodeProto.FileNode.BlockRefs...)
-
+			// The digests of the individual pieces don't apply to the merged file
+			base.nodeProto.FileNode.Sha256 = nil
+			base.nodeProto.FileNode.Md5 = nil
 		}
 		hasher := pfs.NewHash()
 		hasher.Write(append(base.nodeProto.Hash, n.nodeProto.Hash...))
@@ -1373,6 +1501,14 @@ func HashFileNode(n *FileNodeProto) []byte {
 	for _, object := range n.Objects {
 		hash.Write([]byte(object.Hash))
 	}
+	// The mode and symlink target are only hashed if they're set, so that the
+	// hashes of files that have neither are unchanged.
+	if n.Mode != 0 {
+		hash.Write([]byte(fmt.Sprintf("mode:%o", n.Mode)))
+	}
+	if n.SymlinkTarget != "" {
+		hash.Write([]byte("symlink:" + n.SymlinkTarget))
+	}
 	return hash.Sum(nil)
 }
 
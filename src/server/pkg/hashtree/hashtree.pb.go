@@ -40,7 +40,18 @@ type FileNodeProto struct {
 	// block_refs/objects. Without this signal, all calls to pfs.GetFile() would
 	// need to check the parent directory's metadata before beginning to return
 	// the file's contents, which would be slow.)
-	HasHeaderFooter      bool     `protobuf:"varint,6,opt,name=has_header_footer,json=hasHeaderFooter,proto3" json:"has_header_footer,omitempty"`
+	HasHeaderFooter bool `protobuf:"varint,6,opt,name=has_header_footer,json=hasHeaderFooter,proto3" json:"has_header_footer,omitempty"`
+	// sha256 and md5 are digests of this file's contents. They're set when the
+	// file's contents are written all at once, and cleared when the contents
+	// are modified in any other way (e.g. appended to), as the digests can't
+	// be updated without rereading the whole file.
+	Sha256 []byte `protobuf:"bytes,7,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	Md5    []byte `protobuf:"bytes,8,opt,name=md5,proto3" json:"md5,omitempty"`
+	// mode holds the file's permission bits, if they were recorded (0 if not).
+	Mode uint32 `protobuf:"varint,9,opt,name=mode,proto3" json:"mode,omitempty"`
+	// symlink_target is set iff this node is a symlink, in which case it has
+	// no contents and this is the path that it points to.
+	SymlinkTarget        string   `protobuf:"bytes,10,opt,name=symlink_target,json=symlinkTarget,proto3" json:"symlink_target,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -100,6 +111,34 @@ func (m *FileNodeProto) GetHasHeaderFooter() bool {
 	return false
 }
 
+func (m *FileNodeProto) GetSha256() []byte {
+	if m != nil {
+		return m.Sha256
+	}
+	return nil
+}
+
+func (m *FileNodeProto) GetMd5() []byte {
+	if m != nil {
+		return m.Md5
+	}
+	return nil
+}
+
+func (m *FileNodeProto) GetMode() uint32 {
+	if m != nil {
+		return m.Mode
+	}
+	return 0
+}
+
+func (m *FileNodeProto) GetSymlinkTarget() string {
+	if m != nil {
+		return m.SymlinkTarget
+	}
+	return ""
+}
+
 // Shared refers to data common to all direct children of a directory (i.e.
 // headers and footers)
 type Shared struct {
@@ -513,43 +552,47 @@ func init() {
 }
 
 var fileDescriptor_4bd44075bd9a7a70 = []byte{
-	// 576 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x53, 0xcb, 0x6e, 0xd3, 0x40,
-	0x14, 0xd5, 0xd8, 0x4e, 0xe2, 0xdc, 0xa4, 0x22, 0x0c, 0x08, 0xac, 0x0a, 0xb5, 0xc1, 0x08, 0x14,
-	0x10, 0x24, 0x52, 0x41, 0x80, 0x58, 0x56, 0xa5, 0x2a, 0x59, 0x00, 0x9a, 0xb2, 0x62, 0x13, 0xf9,
-	0x71, 0x5d, 0x1b, 0xbb, 0x9e, 0x68, 0xc6, 0xa9, 0x48, 0xbf, 0x83, 0x2f, 0x60, 0xc1, 0x9f, 0x20,
-	0xb1, 0xe4, 0x13, 0x50, 0xbf, 0x04, 0xcd, 0xa3, 0x75, 0x0b, 0x5d, 0x44, 0xba, 0xe7, 0xdc, 0x73,
-	0x8f, 0xef, 0x99, 0xcc, 0x40, 0x28, 0x51, 0x9c, 0xa0, 0x98, 0x2d, 0xcb, 0xa3, 0x59, 0x1e, 0xc9,
-	0xbc, 0x11, 0x88, 0x17, 0xc5, 0x74, 0x29, 0x78, 0xc3, 0xa9, 0x7f, 0x8e, 0x37, 0x6f, 0x27, 0x55,
-	0x81, 0x75, 0x33, 0x5b, 0x66, 0x52, 0xfd, 0x4c, 0x3f, 0xfc, 0x4e, 0x60, 0x63, 0xbf, 0xa8, 0xf0,
-	0x3d, 0x4f, 0xf1, 0xa3, 0x9e, 0x78, 0x08, 0x3d, 0x1e, 0x7f, 0xc1, 0xa4, 0x91, 0x81, 0x37, 0x76,
-	0x27, 0x83, 0x9d, 0xc1, 0x54, 0xc9, 0x3f, 0x68, 0x8e, 0x9d, 0xf7, 0xe8, 0x53, 0x80, 0xb8, 0xe2,
-	0x49, 0xb9, 0x10, 0x98, 0xc9, 0xa0, 0xa3, 0x95, 0x1b, 0x5a, 0xb9, 0xab, 0x68, 0x86, 0x19, 0xeb,
-	0xc7, 0xb6, 0x92, 0xf4, 0x09, 0xdc, 0xcc, 0x23, 0xb9, 0xc8, 0x31, 0x4a, 0x51, 0x2c, 0x32, 0xce,
-	0x1b, 0x14, 0x41, 0x77, 0x4c, 0x26, 0x3e, 0xbb, 0x91, 0x47, 0xf2, 0x40, 0xf3, 0xfb, 0x9a, 0x9e,
-	0x7b, 0x3e, 0x19, 0x39, 0x73, 0xcf, 0x77, 0x46, 0xee, 0xdc, 0xf3, 0xdd, 0x91, 0x17, 0x7e, 0x23,
-	0xd0, 0x3d, 0xcc, 0x23, 0x81, 0x29, 0x7d, 0x00, 0x5d, 0x63, 0x12, 0x90, 0x31, 0xf9, 0x77, 0x39,
-	0xdb, 0x52, 0x22, 0xfb, 0x09, 0xe7, 0x1a, 0x91, 0x69, 0xd1, 0x6d, 0x18, 0xd8, 0x75, 0x64, 0x71,
-	0x8a, 0x81, 0x3b, 0x26, 0x13, 0x97, 0x81, 0xa1, 0x0e, 0x8b, 0x53, 0x54, 0x02, 0x23, 0x35, 0x02,
-	0xcf, 0x08, 0x0c, 0xa5, 0x04, 0x61, 0x06, 0x74, 0xaf, 0x10, 0x98, 0x34, 0x5c, 0xac, 0xdb, 0xf3,
-	0xdb, 0x04, 0x3f, 0xc9, 0x8b, 0x2a, 0x15, 0x58, 0x07, 0xee, 0xd8, 0x9d, 0xf4, 0xd9, 0x05, 0xa6,
-	0x13, 0xe8, 0x4a, 0x9d, 0x43, 0xbb, 0x0d, 0x76, 0x46, 0xd3, 0x8b, 0xbf, 0xcb, 0xe4, 0x63, 0xb6,
-	0x7f, 0xf9, 0x10, 0xc2, 0x9f, 0x04, 0xfa, 0xad, 0x3f, 0x05, 0xaf, 0x8e, 0x8e, 0x51, 0xe7, 0xef,
-	0x33, 0x5d, 0x2b, 0x4e, 0x19, 0xe9, 0xb8, 0x43, 0xa6, 0x6b, 0x7a, 0x1f, 0x86, 0x72, 0x15, 0x2b,
-	0xef, 0xcb, 0x01, 0x07, 0x96, 0xd3, 0x09, 0x5f, 0x40, 0x3f, 0x2b, 0x2a, 0x5c, 0xd4, 0x3c, 0x45,
-	0xbb, 0xd1, 0xdd, 0x76, 0xa3, 0x2b, 0xd7, 0x82, 0xf9, 0x99, 0x85, 0xf4, 0x15, 0xf8, 0x69, 0x21,
-	0xcc, 0x50, 0x47, 0x0f, 0xdd, 0x6b, 0x87, 0xfe, 0x3f, 0x10, 0xd6, 0x4b, 0x0b, 0xa1, 0x50, 0xf8,
-	0x83, 0xc0, 0xc6, 0x41, 0x24, 0xf3, 0x4f, 0x02, 0x6d, 0x96, 0x00, 0x7a, 0x27, 0x28, 0x64, 0xc1,
-	0x6b, 0x1d, 0xa7, 0xc3, 0xce, 0x21, 0x9d, 0x81, 0x93, 0xc9, 0xc0, 0xd1, 0xd7, 0x6a, 0xbb, 0xb5,
-	0xbf, 0x32, 0x3e, 0xdd, 0x97, 0x6f, 0xeb, 0x46, 0xac, 0x99, 0x93, 0xc9, 0xcd, 0x39, 0xf4, 0x2c,
-	0xa4, 0x23, 0x70, 0x4b, 0x5c, 0xdb, 0x03, 0x52, 0x25, 0x7d, 0x0c, 0x9d, 0x93, 0xa8, 0x5a, 0xa1,
-	0xbd, 0x0f, 0xb7, 0x5a, 0xc3, 0x76, 0x4d, 0xa3, 0x78, 0xe3, 0xbc, 0x26, 0xe1, 0x23, 0x18, 0xee,
-	0xae, 0x92, 0x12, 0x1b, 0x73, 0x2f, 0xe9, 0x1d, 0xe8, 0xc6, 0x1a, 0x5b, 0x4f, 0x8b, 0xc2, 0x67,
-	0xd0, 0x79, 0x57, 0xa7, 0xf8, 0x95, 0x0e, 0x81, 0x94, 0xba, 0x37, 0x64, 0xa4, 0x54, 0x72, 0x9e,
-	0x65, 0x12, 0x1b, 0xfd, 0x39, 0x8f, 0x59, 0xb4, 0xbb, 0xf7, 0xeb, 0x6c, 0x8b, 0xfc, 0x3e, 0xdb,
-	0x22, 0x7f, 0xce, 0xb6, 0xc8, 0xe7, 0x97, 0x47, 0x45, 0x93, 0xaf, 0xe2, 0x69, 0xc2, 0x8f, 0x67,
-	0xcb, 0x28, 0xc9, 0xd7, 0x29, 0x8a, 0xcb, 0x95, 0x14, 0xc9, 0xec, 0x9a, 0x07, 0x1e, 0x77, 0xf5,
-	0xc3, 0x7d, 0xfe, 0x37, 0x00, 0x00, 0xff, 0xff, 0x1c, 0x5c, 0xcd, 0xd9, 0xfe, 0x03, 0x00, 0x00,
+	// 637 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x54, 0xdd, 0x6e, 0xd3, 0x4a,
+	0x10, 0xd6, 0xda, 0x4e, 0xe2, 0x4c, 0x92, 0x73, 0x72, 0xf6, 0x20, 0xb0, 0x2a, 0xd4, 0x1a, 0xa3,
+	0x22, 0x83, 0x20, 0x91, 0x0a, 0x2d, 0x88, 0xcb, 0xaa, 0x54, 0x25, 0x17, 0x80, 0xb6, 0xbd, 0xe2,
+	0x26, 0xf2, 0xcf, 0xb8, 0x36, 0x76, 0xec, 0x68, 0xd7, 0xa9, 0x48, 0x5f, 0x80, 0x17, 0xe0, 0x19,
+	0x78, 0x13, 0x24, 0x2e, 0x79, 0x04, 0xd4, 0x27, 0x41, 0xbb, 0xde, 0xd6, 0x2d, 0xf4, 0x22, 0xd2,
+	0x7c, 0xdf, 0x7c, 0x33, 0x99, 0x6f, 0x3c, 0x5a, 0xf0, 0x04, 0xf2, 0x33, 0xe4, 0xd3, 0x65, 0x7e,
+	0x3a, 0x4d, 0x03, 0x91, 0xd6, 0x1c, 0xf1, 0x2a, 0x98, 0x2c, 0x79, 0x55, 0x57, 0xd4, 0xbe, 0xc4,
+	0x1b, 0x77, 0xa2, 0x22, 0xc3, 0xb2, 0x9e, 0x2e, 0x13, 0x21, 0x7f, 0x4d, 0xde, 0xfb, 0x62, 0xc0,
+	0xe8, 0x30, 0x2b, 0xf0, 0x5d, 0x15, 0xe3, 0x07, 0x55, 0xb1, 0x0d, 0xbd, 0x2a, 0xfc, 0x84, 0x51,
+	0x2d, 0x1c, 0xcb, 0x35, 0xfd, 0xc1, 0xce, 0x60, 0x22, 0xe5, 0xef, 0x15, 0xc7, 0x2e, 0x73, 0xf4,
+	0x29, 0x40, 0x58, 0x54, 0x51, 0x3e, 0xe7, 0x98, 0x08, 0xa7, 0xa3, 0x94, 0x23, 0xa5, 0xdc, 0x97,
+	0x34, 0xc3, 0x84, 0xf5, 0x43, 0x1d, 0x09, 0xfa, 0x04, 0xfe, 0x4b, 0x03, 0x31, 0x4f, 0x31, 0x88,
+	0x91, 0xcf, 0x93, 0xaa, 0xaa, 0x91, 0x3b, 0x5d, 0x97, 0xf8, 0x36, 0xfb, 0x37, 0x0d, 0xc4, 0x91,
+	0xe2, 0x0f, 0x15, 0x4d, 0xef, 0x42, 0x57, 0xa4, 0xc1, 0xce, 0xee, 0x9e, 0xd3, 0x73, 0x89, 0x3f,
+	0x64, 0x1a, 0xd1, 0x31, 0x98, 0x8b, 0x78, 0xd7, 0xb1, 0x15, 0x29, 0x43, 0x4a, 0xc1, 0x5a, 0x54,
+	0x31, 0x3a, 0x7d, 0x97, 0xf8, 0x23, 0xa6, 0x62, 0xba, 0x0d, 0xff, 0x88, 0xf5, 0xa2, 0xc8, 0xca,
+	0x7c, 0x5e, 0x07, 0xfc, 0x14, 0x6b, 0x07, 0x5c, 0xe2, 0xf7, 0xd9, 0x48, 0xb3, 0x27, 0x8a, 0x9c,
+	0x59, 0x36, 0x19, 0x1b, 0x33, 0xcb, 0x36, 0xc6, 0xe6, 0xcc, 0xb2, 0xcd, 0xb1, 0xe5, 0x7d, 0x25,
+	0xd0, 0x3d, 0x4e, 0x03, 0x8e, 0x31, 0x7d, 0x08, 0xdd, 0x66, 0x52, 0x87, 0xb8, 0xe4, 0xcf, 0x0d,
+	0xe8, 0x94, 0x14, 0x69, 0x1f, 0xc6, 0x2d, 0xa2, 0x26, 0x45, 0xb7, 0x60, 0xa0, 0x3d, 0x8b, 0xec,
+	0x1c, 0x1d, 0xd3, 0x25, 0xbe, 0xc9, 0xa0, 0xa1, 0x8e, 0xb3, 0x73, 0x94, 0x82, 0x46, 0xda, 0x08,
+	0xac, 0x46, 0xd0, 0x50, 0x52, 0xe0, 0x25, 0x40, 0x0f, 0x32, 0x8e, 0x51, 0x5d, 0xf1, 0x75, 0xfb,
+	0x91, 0x36, 0xc0, 0x8e, 0xd2, 0xac, 0x88, 0x39, 0x96, 0x8e, 0xe9, 0x9a, 0x7e, 0x9f, 0x5d, 0x61,
+	0xea, 0xab, 0xfd, 0x71, 0x8c, 0x55, 0xb7, 0xc1, 0xce, 0x78, 0x72, 0x75, 0x13, 0x8d, 0x3f, 0xa6,
+	0xf3, 0xd7, 0x97, 0xe0, 0x7d, 0x27, 0xd0, 0x6f, 0xfb, 0x53, 0xb0, 0xca, 0x60, 0x81, 0xca, 0x7f,
+	0x9f, 0xa9, 0x58, 0x72, 0xb2, 0x91, 0xb2, 0x3b, 0x64, 0x2a, 0xa6, 0x0f, 0x60, 0x28, 0x56, 0xa1,
+	0xec, 0x7d, 0xdd, 0xe0, 0x40, 0x73, 0xca, 0xe1, 0x0b, 0xe8, 0x27, 0x59, 0x81, 0xf3, 0x52, 0x7e,
+	0xa9, 0x66, 0xa2, 0x7b, 0xed, 0x44, 0x37, 0x6e, 0x8f, 0xd9, 0x89, 0x86, 0xf4, 0x25, 0xd8, 0x71,
+	0xc6, 0x9b, 0xa2, 0x8e, 0x2a, 0xba, 0xdf, 0x16, 0xfd, 0xbd, 0x10, 0xd6, 0x8b, 0x33, 0x2e, 0x91,
+	0xf7, 0x8d, 0xc0, 0xe8, 0x28, 0x10, 0xe9, 0x09, 0x47, 0xed, 0xc5, 0x81, 0xde, 0x19, 0x72, 0x91,
+	0x55, 0xa5, 0xb2, 0xd3, 0x61, 0x97, 0x90, 0x4e, 0xc1, 0x48, 0x84, 0x63, 0xa8, 0xdb, 0xdd, 0x6a,
+	0xdb, 0xdf, 0x28, 0x9f, 0x1c, 0x8a, 0x37, 0x65, 0xcd, 0xd7, 0xcc, 0x48, 0xc4, 0xc6, 0x0c, 0x7a,
+	0x1a, 0xca, 0x6b, 0xcc, 0x71, 0xad, 0x17, 0x24, 0x43, 0xfa, 0x18, 0x3a, 0x67, 0x41, 0xb1, 0x42,
+	0x7d, 0x0f, 0xff, 0xb7, 0x0d, 0xdb, 0x31, 0x1b, 0xc5, 0x6b, 0xe3, 0x15, 0xf1, 0x1e, 0xc1, 0x70,
+	0x7f, 0x15, 0xe5, 0x58, 0x37, 0xc7, 0x2f, 0xcf, 0x3e, 0x54, 0x58, 0xf7, 0xd4, 0xc8, 0x7b, 0x06,
+	0x9d, 0xb7, 0x65, 0x8c, 0x9f, 0xe9, 0x10, 0x48, 0xae, 0x72, 0x43, 0x46, 0x72, 0x29, 0xaf, 0x92,
+	0x44, 0x60, 0xad, 0xfe, 0xce, 0x62, 0x1a, 0xed, 0x1f, 0xfc, 0xb8, 0xd8, 0x24, 0x3f, 0x2f, 0x36,
+	0xc9, 0xaf, 0x8b, 0x4d, 0xf2, 0x71, 0xef, 0x34, 0xab, 0xd3, 0x55, 0x38, 0x89, 0xaa, 0xc5, 0x74,
+	0x19, 0x44, 0xe9, 0x3a, 0x46, 0x7e, 0x3d, 0x12, 0x3c, 0x9a, 0xde, 0xf2, 0x8a, 0x84, 0x5d, 0xf5,
+	0x3a, 0x3c, 0xff, 0x3d, 0x00, 0xd9, 0x05, 0xa5, 0x91, 0x63, 0x04, 0x00, 0x00,
 }
 
 func (m *FileNodeProto) Marshal() (dAtA []byte, err error) {
@@ -576,6 +619,32 @@ func (m *FileNodeProto) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if len(m.SymlinkTarget) > 0 {
+		i -= len(m.SymlinkTarget)
+		copy(dAtA[i:], m.SymlinkTarget)
+		i = encodeVarintHashtree(dAtA, i, uint64(len(m.SymlinkTarget)))
+		i--
+		dAtA[i] = 0x52
+	}
+	if m.Mode != 0 {
+		i = encodeVarintHashtree(dAtA, i, uint64(m.Mode))
+		i--
+		dAtA[i] = 0x48
+	}
+	if len(m.Md5) > 0 {
+		i -= len(m.Md5)
+		copy(dAtA[i:], m.Md5)
+		i = encodeVarintHashtree(dAtA, i, uint64(len(m.Md5)))
+		i--
+		dAtA[i] = 0x42
+	}
+	if len(m.Sha256) > 0 {
+		i -= len(m.Sha256)
+		copy(dAtA[i:], m.Sha256)
+		i = encodeVarintHashtree(dAtA, i, uint64(len(m.Sha256)))
+		i--
+		dAtA[i] = 0x3a
+	}
 	if m.HasHeaderFooter {
 		i--
 		if m.HasHeaderFooter {
@@ -959,6 +1028,21 @@ func (m *FileNodeProto) Size() (n int) {
 	if m.HasHeaderFooter {
 		n += 2
 	}
+	l = len(m.Sha256)
+	if l > 0 {
+		n += 1 + l + sovHashtree(uint64(l))
+	}
+	l = len(m.Md5)
+	if l > 0 {
+		n += 1 + l + sovHashtree(uint64(l))
+	}
+	if m.Mode != 0 {
+		n += 1 + sovHashtree(uint64(m.Mode))
+	}
+	l = len(m.SymlinkTarget)
+	if l > 0 {
+		n += 1 + l + sovHashtree(uint64(l))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -1230,6 +1314,125 @@ func (m *FileNodeProto) Unmarshal(dAtA []byte) error {
 				}
 			}
 			m.HasHeaderFooter = bool(v != 0)
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Sha256", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHashtree
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthHashtree
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthHashtree
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Sha256 = append(m.Sha256[:0], dAtA[iNdEx:postIndex]...)
+			if m.Sha256 == nil {
+				m.Sha256 = []byte{}
+			}
+			iNdEx = postIndex
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Md5", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHashtree
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthHashtree
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthHashtree
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Md5 = append(m.Md5[:0], dAtA[iNdEx:postIndex]...)
+			if m.Md5 == nil {
+				m.Md5 = []byte{}
+			}
+			iNdEx = postIndex
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Mode", wireType)
+			}
+			m.Mode = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHashtree
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Mode |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 10:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SymlinkTarget", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHashtree
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthHashtree
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthHashtree
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.SymlinkTarget = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipHashtree(dAtA[iNdEx:])
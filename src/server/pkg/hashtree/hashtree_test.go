@@ -146,6 +146,75 @@ func TestPutFileBasic(t *testing.T) {
 	require.Equal(t, int64(2), getT(t, h2, "/foo").SubtreeSize)
 }
 
+func TestSetFileChecksum(t *testing.T) {
+	h := newHashTree(t)
+	require.NoError(t, h.PutFile("/foo", obj(`hash:"20c27"`), 1))
+	require.NoError(t, h.Hash())
+	hash := getT(t, h, "/foo").Hash
+	require.NoError(t, h.SetFileChecksum("/foo", []byte("sha256"), []byte("md5")))
+	require.NoError(t, h.Hash())
+	require.Equal(t, []byte("sha256"), getT(t, h, "/foo").FileNode.Sha256)
+	require.Equal(t, []byte("md5"), getT(t, h, "/foo").FileNode.Md5)
+	// Setting the checksum doesn't change the file's hash
+	require.Equal(t, hash, getT(t, h, "/foo").Hash)
+
+	// The checksum survives a copy, but any change to the file's contents
+	// clears it
+	h2, err := h.Copy()
+	require.NoError(t, err)
+	require.Equal(t, []byte("sha256"), getT(t, h2, "/foo").FileNode.Sha256)
+	require.NoError(t, h2.PutFile("/foo", obj(`hash:"413e7"`), 1))
+	require.NoError(t, h2.Hash())
+	require.Nil(t, getT(t, h2, "/foo").FileNode.Sha256)
+	require.Nil(t, getT(t, h2, "/foo").FileNode.Md5)
+
+	// Only files have checksums
+	require.NoError(t, h.PutDir("/dir"))
+	require.YesError(t, h.SetFileChecksum("/dir", []byte("sha256"), []byte("md5")))
+	require.YesError(t, h.SetFileChecksum("/missing", []byte("sha256"), []byte("md5")))
+}
+
+func TestSetFileMode(t *testing.T) {
+	h := newHashTree(t)
+	require.NoError(t, h.PutFile("/foo", obj(`hash:"20c27"`), 1))
+	require.NoError(t, h.Hash())
+	hash := getT(t, h, "/foo").Hash
+	require.NoError(t, h.SetFileMode("/foo", 0755))
+	require.NoError(t, h.Hash())
+	require.Equal(t, uint32(0755), getT(t, h, "/foo").FileNode.Mode)
+	require.NotEqual(t, hash, getT(t, h, "/foo").Hash)
+
+	// The mode is kept when the file is appended to
+	require.NoError(t, h.PutFile("/foo", obj(`hash:"413e7"`), 1))
+	require.NoError(t, h.Hash())
+	require.Equal(t, uint32(0755), getT(t, h, "/foo").FileNode.Mode)
+
+	// Only files have modes
+	require.NoError(t, h.PutDir("/dir"))
+	require.YesError(t, h.SetFileMode("/dir", 0755))
+	require.YesError(t, h.SetFileMode("/missing", 0755))
+}
+
+func TestPutSymlink(t *testing.T) {
+	h := newHashTree(t)
+	require.NoError(t, h.PutSymlink("/dir/link", "../foo"))
+	require.NoError(t, h.Hash())
+	require.Equal(t, "../foo", getT(t, h, "/dir/link").FileNode.SymlinkTarget)
+	require.Equal(t, int64(0), getT(t, h, "/dir/link").SubtreeSize)
+
+	// Symlinks can't be written to, but they can replace files
+	require.YesError(t, h.PutFile("/dir/link", obj(`hash:"20c27"`), 1))
+	require.NoError(t, h.PutFile("/foo", obj(`hash:"20c27"`), 1))
+	require.NoError(t, h.PutSymlink("/foo", "/dir/link"))
+	require.NoError(t, h.Hash())
+	require.Equal(t, "/dir/link", getT(t, h, "/foo").FileNode.SymlinkTarget)
+	require.Equal(t, 0, len(getT(t, h, "/foo").FileNode.Objects))
+	require.Equal(t, int64(0), getT(t, h, "").SubtreeSize)
+
+	// Symlinks can't replace directories
+	require.YesError(t, h.PutSymlink("/dir", "/foo"))
+}
+
 func TestPutDirBasic(t *testing.T) {
 	h := newHashTree(t)
 	emptySha := sha256.Sum256([]byte{})
@@ -750,7 +819,10 @@ func TestMergeFiles(t *testing.T) {
 	require.NoError(t, r.Ordered().Serialize(rBuf))
 	require.NoError(t, c.Put(0, lBuf))
 	require.NoError(t, c.Put(1, rBuf))
-	require.NoError(t, c.Merge(NewWriter(resultBuf), nil, nil))
+	resultW := NewWriter(resultBuf)
+	resultW.CountFiles()
+	require.NoError(t, c.Merge(resultW, nil, nil))
+	require.Equal(t, uint64(7), resultW.FileCount())
 
 	expectedBuf := &bytes.Buffer{}
 	w := NewWriter(expectedBuf)
@@ -161,6 +161,20 @@ type HashTree interface {
 	// uses Block Refs instead of objects.
 	PutFileOverwriteBlockRefs(path string, brs []*pfs.BlockRef, overwriteIndex *pfs.OverwriteIndex, sizeDelta int64) error
 
+	// SetFileChecksum records the SHA-256 and MD5 digests of the contents of
+	// the file at 'path'. Any subsequent modification of the file's contents
+	// clears them.
+	SetFileChecksum(path string, sha256, md5 []byte) error
+
+	// SetFileMode records 'mode' as the permission bits of the file at 'path'.
+	// Unlike its checksum, a file's mode is kept when its contents change.
+	SetFileMode(path string, mode uint32) error
+
+	// PutSymlink creates a symlink at 'path' that points to 'target',
+	// replacing any file that's already there. Symlinks have no contents, and
+	// PutFile returns an error if it's called with a path that points to one.
+	PutSymlink(path, target string) error
+
 	// PutDir creates a directory (or does nothing if one exists).
 	PutDir(path string) error
 
@@ -36,6 +36,12 @@ func (c *googleClient) Writer(ctx context.Context, name string) (io.WriteCloser,
 }
 
 func (c *googleClient) Walk(ctx context.Context, name string, fn func(name string) error) error {
+	return c.WalkInfo(ctx, name, func(info *ObjectInfo) error {
+		return fn(info.Name)
+	})
+}
+
+func (c *googleClient) WalkInfo(ctx context.Context, name string, fn func(info *ObjectInfo) error) error {
 	objectIter := c.bucket.Objects(ctx, &storage.Query{Prefix: name})
 	for {
 		objectAttrs, err := objectIter.Next()
@@ -45,7 +51,12 @@ func (c *googleClient) Walk(ctx context.Context, name string, fn func(name strin
 			}
 			return err
 		}
-		if err := fn(objectAttrs.Name); err != nil {
+		if err := fn(&ObjectInfo{
+			Name:     objectAttrs.Name,
+			ETag:     objectAttrs.Etag,
+			Modified: objectAttrs.Updated,
+			Size:     objectAttrs.Size,
+		}); err != nil {
 			return err
 		}
 	}
@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/storage"
 	"golang.org/x/sync/errgroup"
@@ -64,7 +65,13 @@ func (c *microsoftClient) Delete(_ context.Context, name string) error {
 	return err
 }
 
-func (c *microsoftClient) Walk(_ context.Context, name string, f func(name string) error) error {
+func (c *microsoftClient) Walk(ctx context.Context, name string, f func(name string) error) error {
+	return c.WalkInfo(ctx, name, func(info *ObjectInfo) error {
+		return f(info.Name)
+	})
+}
+
+func (c *microsoftClient) WalkInfo(_ context.Context, name string, f func(info *ObjectInfo) error) error {
 	var marker string
 	for {
 		blobList, err := c.container.ListBlobs(storage.ListBlobsParameters{
@@ -75,7 +82,12 @@ func (c *microsoftClient) Walk(_ context.Context, name string, f func(name strin
 			return err
 		}
 		for _, file := range blobList.Blobs {
-			if err := f(file.Name); err != nil {
+			if err := f(&ObjectInfo{
+				Name:     file.Name,
+				ETag:     file.Properties.Etag,
+				Modified: time.Time(file.Properties.LastModified),
+				Size:     file.Properties.ContentLength,
+			}); err != nil {
 				return err
 			}
 		}
@@ -212,6 +212,9 @@ type Client interface {
 	Delete(ctx context.Context, name string) error
 	// Walk calls `fn` with the names of objects which can be found under `prefix`.
 	Walk(ctx context.Context, prefix string, fn func(name string) error) error
+	// WalkInfo is like Walk, but calls `fn` with each object's info rather
+	// than just its name.
+	WalkInfo(ctx context.Context, prefix string, fn func(info *ObjectInfo) error) error
 	// Exsits checks if a given object already exists
 	Exists(ctx context.Context, name string) bool
 	// IsRetryable determines if an operation should be retried given an error
@@ -222,6 +225,17 @@ type Client interface {
 	IsIgnorable(err error) bool
 }
 
+// ObjectInfo describes an object found by WalkInfo.
+type ObjectInfo struct {
+	Name string
+	// ETag identifies the object's contents. How it's computed varies between
+	// object stores (and some, like local storage, don't set it), but it
+	// changes whenever the object's contents do.
+	ETag     string
+	Modified time.Time
+	Size     int64
+}
+
 // NewGoogleClient creates a google client with the given bucket name.
 func NewGoogleClient(bucket string, opts []option.ClientOption) (Client, error) {
 	return newGoogleClient(bucket, opts)
@@ -533,6 +547,58 @@ func NewClientFromURLAndSecret(url *ObjectStoreURL, reverse ...bool) (c Client,
 	}
 }
 
+// NewClientFromURLAndCredentials constructs a client for `URL` that uses the
+// credentials in 'creds' (e.g. the data of a Kubernetes secret), rather than
+// Pachyderm's own. 'creds' uses the same keys as the storage secret, and must
+// hold static credentials: it never falls back to the instance's identity.
+func NewClientFromURLAndCredentials(url *ObjectStoreURL, creds map[string][]byte) (c Client, err error) {
+	get := func(key string, required bool) (string, error) {
+		value := strings.TrimSpace(string(creds[key]))
+		if value == "" && required {
+			return "", errors.Errorf("credentials for %s://%s have no %q", url.Store, url.Bucket, key)
+		}
+		return value, nil
+	}
+	switch url.Store {
+	case "s3":
+		var region, endpoint string
+		var amazonCreds AmazonCreds
+		if region, err = get("amazon-region", true); err != nil {
+			return nil, err
+		}
+		if amazonCreds.ID, err = get("amazon-id", true); err != nil {
+			return nil, err
+		}
+		if amazonCreds.Secret, err = get("amazon-secret", true); err != nil {
+			return nil, err
+		}
+		amazonCreds.Token, _ = get("amazon-token", false)
+		endpoint, _ = get("custom-endpoint", false)
+		c, err = NewAmazonClient(region, url.Bucket, &amazonCreds, "", endpoint, false)
+	case "gcs", "gs":
+		var cred string
+		if cred, err = get("google-cred", true); err != nil {
+			return nil, err
+		}
+		c, err = NewGoogleClient(url.Bucket, []option.ClientOption{option.WithCredentialsJSON([]byte(cred))})
+	case "as", "wasb":
+		var id, secret string
+		if id, err = get("microsoft-id", true); err != nil {
+			return nil, err
+		}
+		if secret, err = get("microsoft-secret", true); err != nil {
+			return nil, err
+		}
+		c, err = NewMicrosoftClient(url.Bucket, id, secret)
+	default:
+		return nil, errors.Errorf("object store %q can't be used with credentials", url.Store)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return TracingObjClient(url.Store, c), nil
+}
+
 // ObjectStoreURL represents a parsed URL to an object in an object store.
 type ObjectStoreURL struct {
 	// The object store, e.g. s3, gcs, as...
@@ -21,4 +21,8 @@ const (
 
 	// SpoutMarkerBranch is the branch that spouts use for keeping track of spout marker files
 	SpoutMarkerBranch = "marker"
+
+	// ObjectStoreStateBranch is the branch of an object store input's repo that
+	// records which objects have been ingested
+	ObjectStoreStateBranch = "object_store_state"
 )
@@ -13,6 +13,7 @@ import (
 const (
 	pipelinesPrefix = "/pipelines"
 	jobsPrefix      = "/jobs"
+	notifiersPrefix = "/notifiers"
 )
 
 var (
@@ -50,3 +51,15 @@ func Jobs(etcdClient *etcd.Client, etcdPrefix string) col.Collection {
 		nil,
 	)
 }
+
+// Notifiers returns a Collection of notifiers
+func Notifiers(etcdClient *etcd.Client, etcdPrefix string) col.Collection {
+	return col.NewCollection(
+		etcdClient,
+		path.Join(etcdPrefix, notifiersPrefix),
+		nil,
+		&pps.Notifier{},
+		nil,
+		nil,
+	)
+}
@@ -67,6 +67,22 @@ func NewPipelineManifestReader(path string) (result *PipelineManifestReader, ret
 	}, nil
 }
 
+// ReadInputSpec reads a pipeline input spec (a pps.Input, as it would appear in
+// a pipeline's "input" field) from 'path', which may be a file, a URL or "-"
+// (stdin), like the path passed to NewPipelineManifestReader. It's used by
+// 'list datum --input'
+func ReadInputSpec(path string) (*ppsclient.Input, error) {
+	r, err := NewPipelineManifestReader(path)
+	if err != nil {
+		return nil, err
+	}
+	var result ppsclient.Input
+	if err := r.decoder.DecodeProto(&result); err != nil {
+		return nil, errors.Wrapf(err, "malformed input spec")
+	}
+	return &result, nil
+}
+
 // NextCreatePipelineRequest gets the next request from the manifest reader.
 func (r *PipelineManifestReader) NextCreatePipelineRequest() (*ppsclient.CreatePipelineRequest, error) {
 	var result ppsclient.CreatePipelineRequest
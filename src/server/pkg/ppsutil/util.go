@@ -110,6 +110,38 @@ func GetLimitsResourceListFromPipeline(pipelineInfo *pps.PipelineInfo) (*v1.Reso
 	return getResourceListFromSpec(pipelineInfo.ResourceLimits)
 }
 
+// ValidateResourceSpec returns an error if 'resources' can't be turned into a
+// k8s resource list. getResourceListFromSpec skips such resources (with only a
+// warning in pachd's logs), so this is how they're caught before a pipeline is
+// created with them.
+func ValidateResourceSpec(resources *pps.ResourceSpec) error {
+	if resources == nil {
+		return nil
+	}
+	if resources.Cpu < 0 {
+		return errors.Errorf("cpu must be non-negative, but was %f", resources.Cpu)
+	}
+	if resources.Memory != "" {
+		if _, err := resource.ParseQuantity(resources.Memory); err != nil {
+			return errors.Wrapf(err, "could not parse memory %q", resources.Memory)
+		}
+	}
+	if resources.Disk != "" {
+		if _, err := resource.ParseQuantity(resources.Disk); err != nil {
+			return errors.Wrapf(err, "could not parse disk %q", resources.Disk)
+		}
+	}
+	if resources.Gpu != nil {
+		if resources.Gpu.Number < 0 {
+			return errors.Errorf("gpu number must be non-negative, but was %d", resources.Gpu.Number)
+		}
+		if resources.Gpu.Number > 0 && resources.Gpu.Type == "" {
+			return errors.Errorf("gpu type must be set when gpu number is")
+		}
+	}
+	return nil
+}
+
 // getNumNodes attempts to retrieve the number of nodes in the current k8s
 // cluster
 func getNumNodes(kubeClient *kube.Clientset) (int, error) {
@@ -128,7 +160,11 @@ func getNumNodes(kubeClient *kube.Clientset) (int, error) {
 //
 // This is only exported for testing
 func GetExpectedNumWorkers(kubeClient *kube.Clientset, spec *pps.ParallelismSpec) (int, error) {
-	if spec == nil || (spec.Constant == 0 && spec.Coefficient == 0) {
+	if spec != nil && spec.Autoscaling != nil {
+		// Autoscaled pipelines are planned for their largest size, so that
+		// workers added mid-job have work to claim
+		return int(spec.Autoscaling.MaxWorkers), nil
+	} else if spec == nil || (spec.Constant == 0 && spec.Coefficient == 0) {
 		return 1, nil
 	} else if spec.Constant > 0 && spec.Coefficient == 0 {
 		return int(spec.Constant), nil
@@ -170,6 +206,7 @@ func GetPipelineInfo(pachClient *client.APIClient, ptr *pps.EtcdPipelineInfo) (*
 	result.Reason = ptr.Reason
 	result.JobCounts = ptr.JobCounts
 	result.LastJobState = ptr.LastJobState
+	result.LastEgressCommit = ptr.LastEgressCommit
 	result.SpecCommit = ptr.SpecCommit
 	return result, nil
 }
@@ -215,6 +252,16 @@ func JobInput(pipelineInfo *pps.PipelineInfo, outputCommitInfo *pfs.CommitInfo)
 				input.Git.Commit = commit.ID
 			}
 		}
+		if input.Webhook != nil {
+			if commit, ok := branchToCommit[key(input.Webhook.Repo, "master")]; ok {
+				input.Webhook.Commit = commit.ID
+			}
+		}
+		if input.ObjectStore != nil {
+			if commit, ok := branchToCommit[key(input.ObjectStore.Repo, "master")]; ok {
+				input.ObjectStore.Commit = commit.ID
+			}
+		}
 	})
 	return jobInput
 }
@@ -222,35 +269,43 @@ func JobInput(pipelineInfo *pps.PipelineInfo, outputCommitInfo *pfs.CommitInfo)
 // PipelineReqFromInfo converts a PipelineInfo into a CreatePipelineRequest.
 func PipelineReqFromInfo(pipelineInfo *pps.PipelineInfo) *pps.CreatePipelineRequest {
 	return &pps.CreatePipelineRequest{
-		Pipeline:         pipelineInfo.Pipeline,
-		Transform:        pipelineInfo.Transform,
-		ParallelismSpec:  pipelineInfo.ParallelismSpec,
-		HashtreeSpec:     pipelineInfo.HashtreeSpec,
-		Egress:           pipelineInfo.Egress,
-		OutputBranch:     pipelineInfo.OutputBranch,
-		ResourceRequests: pipelineInfo.ResourceRequests,
-		ResourceLimits:   pipelineInfo.ResourceLimits,
-		Input:            pipelineInfo.Input,
-		Description:      pipelineInfo.Description,
-		CacheSize:        pipelineInfo.CacheSize,
-		EnableStats:      pipelineInfo.EnableStats,
-		MaxQueueSize:     pipelineInfo.MaxQueueSize,
-		Service:          pipelineInfo.Service,
-		ChunkSpec:        pipelineInfo.ChunkSpec,
-		DatumTimeout:     pipelineInfo.DatumTimeout,
-		JobTimeout:       pipelineInfo.JobTimeout,
-		Salt:             pipelineInfo.Salt,
-		PodSpec:          pipelineInfo.PodSpec,
-		PodPatch:         pipelineInfo.PodPatch,
-		Spout:            pipelineInfo.Spout,
-		SchedulingSpec:   pipelineInfo.SchedulingSpec,
-		DatumTries:       pipelineInfo.DatumTries,
-		Standby:          pipelineInfo.Standby,
-		S3Out:            pipelineInfo.S3Out,
-		Metadata:         pipelineInfo.Metadata,
+		Pipeline:          pipelineInfo.Pipeline,
+		Transform:         pipelineInfo.Transform,
+		ParallelismSpec:   pipelineInfo.ParallelismSpec,
+		HashtreeSpec:      pipelineInfo.HashtreeSpec,
+		Egress:            pipelineInfo.Egress,
+		OutputBranch:      pipelineInfo.OutputBranch,
+		ResourceRequests:  pipelineInfo.ResourceRequests,
+		ResourceLimits:    pipelineInfo.ResourceLimits,
+		Input:             pipelineInfo.Input,
+		Description:       pipelineInfo.Description,
+		CacheSize:         pipelineInfo.CacheSize,
+		EnableStats:       pipelineInfo.EnableStats,
+		MaxQueueSize:      pipelineInfo.MaxQueueSize,
+		Service:           pipelineInfo.Service,
+		ChunkSpec:         pipelineInfo.ChunkSpec,
+		DatumTimeout:      pipelineInfo.DatumTimeout,
+		JobTimeout:        pipelineInfo.JobTimeout,
+		Salt:              pipelineInfo.Salt,
+		PodSpec:           pipelineInfo.PodSpec,
+		PodPatch:          pipelineInfo.PodPatch,
+		Spout:             pipelineInfo.Spout,
+		SchedulingSpec:    pipelineInfo.SchedulingSpec,
+		DatumTries:        pipelineInfo.DatumTries,
+		Standby:           pipelineInfo.Standby,
+		S3Out:             pipelineInfo.S3Out,
+		Metadata:          pipelineInfo.Metadata,
+		DeadLetter:        pipelineInfo.DeadLetter,
+		Priority:          pipelineInfo.Priority,
+		MaxConcurrentJobs: pipelineInfo.MaxConcurrentJobs,
+		DatumCache:        pipelineInfo.DatumCache,
 	}
 }
 
+// EgressErrorPrefix starts the reason of jobs that failed because their
+// output couldn't be egressed.
+const EgressErrorPrefix = "egress error: "
+
 // IsTerminal returns 'true' if 'state' indicates that the job is done (i.e.
 // the state will not change later: SUCCESS, FAILURE, KILLED) and 'false'
 // otherwise.
@@ -258,7 +313,7 @@ func IsTerminal(state pps.JobState) bool {
 	switch state {
 	case pps.JobState_JOB_SUCCESS, pps.JobState_JOB_FAILURE, pps.JobState_JOB_KILLED:
 		return true
-	case pps.JobState_JOB_STARTING, pps.JobState_JOB_RUNNING, pps.JobState_JOB_MERGING:
+	case pps.JobState_JOB_STARTING, pps.JobState_JOB_QUEUED, pps.JobState_JOB_RUNNING, pps.JobState_JOB_MERGING:
 		return false
 	default:
 		panic(fmt.Sprintf("unrecognized job state: %s", state))
@@ -318,6 +373,18 @@ func ContainsS3Inputs(in *pps.Input) bool {
 	return found
 }
 
+// ContainsOuterJoins returns 'true' if 'in' is or contains any PFS inputs with
+// 'OuterJoin' set to true.
+func ContainsOuterJoins(in *pps.Input) bool {
+	var found bool
+	pps.VisitInput(in, func(in *pps.Input) {
+		if in.Pfs != nil && in.Pfs.OuterJoin {
+			found = true
+		}
+	})
+	return found
+}
+
 // SidecarS3GatewayService returns the name of the kubernetes service created
 // for the job 'jobID' to hand sidecar s3 gateway requests. This helper is in
 // ppsutil because both PPS (which creates the service, in the s3 gateway
@@ -43,6 +43,8 @@ type PachdSpecificConfiguration struct {
 	StorageHostPath            string `env:"STORAGE_HOST_PATH,default="`
 	EtcdPrefix                 string `env:"ETCD_PREFIX,default="`
 	PFSEtcdPrefix              string `env:"PFS_ETCD_PREFIX,default=pachyderm_pfs"`
+	PFSRetentionInterval       string `env:"PFS_RETENTION_INTERVAL,default=10m"`
+	PFSTriggerInterval         string `env:"PFS_TRIGGER_INTERVAL,default=1m"`
 	AuthEtcdPrefix             string `env:"PACHYDERM_AUTH_ETCD_PREFIX,default=pachyderm_auth"`
 	EnterpriseEtcdPrefix       string `env:"PACHYDERM_ENTERPRISE_ETCD_PREFIX,default=pachyderm_enterprise"`
 	KubeAddress                string `env:"KUBERNETES_PORT_443_TCP_ADDR,required"`
@@ -60,6 +62,7 @@ type PachdSpecificConfiguration struct {
 	ExposeObjectAPI            bool   `env:"EXPOSE_OBJECT_API,default=false"`
 	MemoryRequest              string `env:"PACHD_MEMORY_REQUEST,default=1T"`
 	WorkerUsesRoot             bool   `env:"WORKER_USES_ROOT,default=true"`
+	PPSWorkerCapacity          int64  `env:"PPS_WORKER_CAPACITY,default=0"`
 	DeploymentID               string `env:"CLUSTER_DEPLOYMENT_ID,default="`
 	RequireCriticalServersOnly bool   `env:"REQUIRE_CRITICAL_SERVERS_ONLY",default=false"`
 	MetricsEndpoint            string `env:"METRICS_ENDPOINT",default="`
@@ -234,6 +234,50 @@ func TestResolveIndexes(t *testing.T) {
 	}))
 }
 
+func TestPutPreservesHeaders(t *testing.T) {
+	require.NoError(t, WithLocalStorage(func(fileSets *Storage) error {
+		// Put an executable file and a symlink to it.
+		buf := &bytes.Buffer{}
+		tw := tar.NewWriter(buf)
+		data := []byte("#!/bin/sh\n")
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name: "/script",
+			Mode: 0755,
+			Size: int64(len(data)),
+		}))
+		_, err := tw.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeSymlink,
+			Name:     "/tool",
+			Linkname: "script",
+		}))
+		require.NoError(t, tw.Close())
+		fs := fileSets.New(context.Background(), testPath, "tag")
+		require.NoError(t, fs.Put(buf))
+		require.NoError(t, fs.Close())
+		// Check that their modes and types are read back.
+		hdrs := make(map[string]*tar.Header)
+		r := fileSets.newReader(context.Background(), path.Join(testPath, SubFileSetStr(0)))
+		require.NoError(t, r.Iterate(func(fr *FileReader) error {
+			buf := &bytes.Buffer{}
+			if err := fr.Get(buf); err != nil {
+				return err
+			}
+			hdr, err := tar.NewReader(buf).Next()
+			if err != nil {
+				return err
+			}
+			hdrs[hdr.Name] = hdr
+			return nil
+		}))
+		require.Equal(t, int64(0755), hdrs["/script"].Mode)
+		require.Equal(t, byte(tar.TypeSymlink), hdrs["/tool"].Typeflag)
+		require.Equal(t, "script", hdrs["/tool"].Linkname)
+		return nil
+	}))
+}
+
 func TestCompaction(t *testing.T) {
 	require.NoError(t, WithLocalStorage(func(fileSets *Storage) error {
 		msg := seedRand()
@@ -76,6 +76,22 @@ func (p *Puller) makeFile(path string, f func(io.Writer) error) (retErr error) {
 	return nil
 }
 
+func (p *Puller) makeSymlink(path, target string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.Symlink(target, path)
+}
+
+// setMode sets the permission bits of the file at 'path' to 'mode', unless
+// it's 0 (i.e. the file's mode wasn't recorded in PFS).
+func setMode(path string, mode uint32) error {
+	if mode == 0 {
+		return nil
+	}
+	return os.Chmod(path, os.FileMode(mode)&os.ModePerm)
+}
+
 // Pull clones an entire repo at a certain commit.
 // root is the local path you want to clone to.
 // repo, commit, file specify the file/dir we are pulling.
@@ -108,27 +124,40 @@ func (p *Puller) Pull(client *pachclient.APIClient, root string, repo, commit, f
 					blockRefs = append(blockRefs, objectInfo.BlockRef)
 				}
 				blockRefs = append(blockRefs, fileInfo.BlockRefs...)
-				statsTree.PutFile(statsPath, fileInfo.Hash, int64(fileInfo.SizeBytes), &hashtree.FileNodeProto{BlockRefs: blockRefs})
+				statsTree.PutFile(statsPath, fileInfo.Hash, int64(fileInfo.SizeBytes), &hashtree.FileNodeProto{
+					BlockRefs:     blockRefs,
+					Mode:          fileInfo.Mode,
+					SymlinkTarget: fileInfo.SymlinkTarget,
+				})
 			}
 		}
 		path := filepath.Join(root, basepath)
 		if fileInfo.FileType == pfs.FileType_DIR {
 			return os.MkdirAll(path, 0700)
 		}
+		if fileInfo.FileType == pfs.FileType_SYMLINK {
+			return p.makeSymlink(path, fileInfo.SymlinkTarget)
+		}
 		if pipes {
 			return p.makePipe(path, func(w io.Writer) error {
 				return client.GetFile(repo, commit, fileInfo.File.Path, 0, 0, w)
 			})
 		}
 		if emptyFiles {
-			return p.makeFile(path, func(w io.Writer) error { return nil })
+			if err := p.makeFile(path, func(w io.Writer) error { return nil }); err != nil {
+				return err
+			}
+			return setMode(path, fileInfo.Mode)
 		}
 		eg.Go(func() (retErr error) {
 			limiter.Acquire()
 			defer limiter.Release()
-			return p.makeFile(path, func(w io.Writer) error {
+			if err := p.makeFile(path, func(w io.Writer) error {
 				return client.GetFile(repo, commit, fileInfo.File.Path, 0, 0, w)
-			})
+			}); err != nil {
+				return err
+			}
+			return setMode(path, fileInfo.Mode)
 		})
 		return nil
 	}); err != nil {
@@ -344,19 +373,10 @@ func PushObj(pachClient *pachclient.APIClient, commit *pfs.Commit, objClient obj
 		if fileInfo.FileType != pfs.FileType_FILE {
 			return nil
 		}
-		eg.Go(func() (retErr error) {
+		eg.Go(func() error {
 			sem <- struct{}{}
 			defer func() { <-sem }()
-			w, err := objClient.Writer(pachClient.Ctx(), filepath.Join(root, fileInfo.File.Path))
-			if err != nil {
-				return err
-			}
-			defer func() {
-				if err := w.Close(); err != nil && retErr == nil {
-					retErr = err
-				}
-			}()
-			return pachClient.GetFile(commit.Repo.Name, commit.ID, fileInfo.File.Path, 0, 0, w)
+			return pushObjFile(pachClient, commit, fileInfo.File.Path, objClient, root)
 		})
 		return nil
 	}); err != nil {
@@ -365,12 +385,76 @@ func PushObj(pachClient *pachclient.APIClient, commit *pfs.Commit, objClient obj
 	return eg.Wait()
 }
 
+// PushObjDiff is like PushObj, except that it assumes 'oldCommit' was already
+// pushed to the object store, and only pushes the difference between it and
+// 'commit': files that were added or changed are uploaded, and files that
+// were removed are deleted from the object store.
+func PushObjDiff(pachClient *pachclient.APIClient, commit *pfs.Commit, oldCommit *pfs.Commit, objClient obj.Client, root string) error {
+	newFiles, oldFiles, err := pachClient.DiffFile(commit.Repo.Name, commit.ID, "", oldCommit.Repo.Name, oldCommit.ID, "", false)
+	if err != nil {
+		return err
+	}
+	// Files that changed are in both lists, so only the files that aren't in
+	// 'newFiles' were removed
+	present := make(map[string]bool)
+	for _, fileInfo := range newFiles {
+		if fileInfo.FileType == pfs.FileType_FILE {
+			present[fileInfo.File.Path] = true
+		}
+	}
+	var eg errgroup.Group
+	sem := make(chan struct{}, 200)
+	for path := range present {
+		path := path
+		eg.Go(func() error {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			return pushObjFile(pachClient, commit, path, objClient, root)
+		})
+	}
+	for _, fileInfo := range oldFiles {
+		if fileInfo.FileType != pfs.FileType_FILE || present[fileInfo.File.Path] {
+			continue
+		}
+		path := fileInfo.File.Path
+		eg.Go(func() error {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if err := objClient.Delete(pachClient.Ctx(), filepath.Join(root, path)); err != nil && !objClient.IsNotExist(err) {
+				return err
+			}
+			return nil
+		})
+	}
+	return eg.Wait()
+}
+
+// pushObjFile uploads the file at 'path' in 'commit' to the object store.
+func pushObjFile(pachClient *pachclient.APIClient, commit *pfs.Commit, path string, objClient obj.Client, root string) (retErr error) {
+	w, err := objClient.Writer(pachClient.Ctx(), filepath.Join(root, path))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := w.Close(); err != nil && retErr == nil {
+			retErr = err
+		}
+	}()
+	return pachClient.GetFile(commit.Repo.Name, commit.ID, path, 0, 0, w)
+}
+
 func isNotExist(err error) bool {
 	return strings.Contains(err.Error(), "not found")
 }
 
 // PushFile makes sure that pfsFile has the same content as osFile.
 func PushFile(c *pachclient.APIClient, pfc pachclient.PutFileClient, pfsFile *pfs.File, osFile io.ReadSeeker) error {
+	return PushFileMode(c, pfc, pfsFile, osFile, 0)
+}
+
+// PushFileMode is like PushFile, except that it also records mode as the
+// permission bits of the file.
+func PushFileMode(c *pachclient.APIClient, pfc pachclient.PutFileClient, pfsFile *pfs.File, osFile io.ReadSeeker, mode uint32) error {
 	fileInfo, err := c.InspectFile(pfsFile.Commit.Repo.Name, pfsFile.Commit.ID, pfsFile.Path)
 	if err != nil && !isNotExist(err) {
 		return err
@@ -398,6 +482,6 @@ func PushFile(c *pachclient.APIClient, pfc pachclient.PutFileClient, pfsFile *pf
 		return err
 	}
 
-	_, err = pfc.PutFileOverwrite(pfsFile.Commit.Repo.Name, pfsFile.Commit.ID, pfsFile.Path, osFile, int64(i))
+	_, err = pfc.PutFileOverwriteMode(pfsFile.Commit.Repo.Name, pfsFile.Commit.ID, pfsFile.Path, osFile, int64(i), mode)
 	return err
 }
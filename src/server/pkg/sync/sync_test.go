@@ -0,0 +1,92 @@
+package sync
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	"github.com/pachyderm/pachyderm/src/server/pkg/obj"
+	"github.com/pachyderm/pachyderm/src/server/pkg/testpachd"
+)
+
+// objContents returns the contents of every object under 'root'.
+func objContents(t *testing.T, objClient obj.Client, root string) map[string]string {
+	result := make(map[string]string)
+	require.NoError(t, objClient.Walk(context.Background(), root, func(name string) error {
+		r, err := objClient.Reader(context.Background(), name, 0, 0)
+		require.NoError(t, err)
+		defer r.Close()
+		var buf bytes.Buffer
+		_, err = buf.ReadFrom(r)
+		require.NoError(t, err)
+		result[strings.TrimPrefix(name, root)] = buf.String()
+		return nil
+	}))
+	return result
+}
+
+func TestPushObjDiff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestPushObjDiff")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	objClient, err := obj.NewLocalClient(dir)
+	require.NoError(t, err)
+	root := "egress"
+
+	require.NoError(t, testpachd.WithRealEnv(func(env *testpachd.RealEnv) error {
+		c := env.PachClient
+		require.NoError(t, c.CreateRepo("repo"))
+		putFiles := func(files map[string]string, deletes ...string) *pfs.Commit {
+			commit, err := c.StartCommit("repo", "master")
+			require.NoError(t, err)
+			for _, path := range deletes {
+				require.NoError(t, c.DeleteFile("repo", commit.ID, path))
+			}
+			for path, content := range files {
+				_, err := c.PutFileOverwrite("repo", commit.ID, path, strings.NewReader(content), 0)
+				require.NoError(t, err)
+			}
+			require.NoError(t, c.FinishCommit("repo", commit.ID))
+			return commit
+		}
+
+		first := putFiles(map[string]string{
+			"unchanged": "foo",
+			"changed":   "foo",
+			"dir/gone":  "foo",
+		})
+		require.NoError(t, PushObj(c, first, objClient, root))
+		require.Equal(t, map[string]string{
+			"/unchanged": "foo",
+			"/changed":   "foo",
+			"/dir/gone":  "foo",
+		}, objContents(t, objClient, root))
+
+		// Added and changed files are uploaded, and deleted files are deleted
+		// from the object store
+		second := putFiles(map[string]string{
+			"changed":   "bar",
+			"dir/added": "bar",
+		}, "dir/gone")
+		// Rewrite the unchanged file's object, so that it's clear that it isn't
+		// uploaded again
+		w, err := objClient.Writer(context.Background(), filepath.Join(root, "unchanged"))
+		require.NoError(t, err)
+		_, err = w.Write([]byte("not uploaded"))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		require.NoError(t, PushObjDiff(c, second, first, objClient, root))
+		require.Equal(t, map[string]string{
+			"/unchanged": "not uploaded",
+			"/changed":   "bar",
+			"/dir/added": "bar",
+		}, objContents(t, objClient, root))
+		return nil
+	}))
+}
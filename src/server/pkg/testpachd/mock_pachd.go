@@ -379,10 +379,14 @@ type deleteCommitFunc func(context.Context, *pfs.DeleteCommitRequest) (*types.Em
 type flushCommitFunc func(*pfs.FlushCommitRequest, pfs.API_FlushCommitServer) error
 type subscribeCommitFunc func(*pfs.SubscribeCommitRequest, pfs.API_SubscribeCommitServer) error
 type buildCommitFunc func(context.Context, *pfs.BuildCommitRequest) (*pfs.Commit, error)
+type annotateCommitFunc func(context.Context, *pfs.AnnotateCommitRequest) (*types.Empty, error)
 type createBranchFunc func(context.Context, *pfs.CreateBranchRequest) (*types.Empty, error)
 type inspectBranchFunc func(context.Context, *pfs.InspectBranchRequest) (*pfs.BranchInfo, error)
 type listBranchFunc func(context.Context, *pfs.ListBranchRequest) (*pfs.BranchInfos, error)
 type deleteBranchFunc func(context.Context, *pfs.DeleteBranchRequest) (*types.Empty, error)
+type setBranchRetentionFunc func(context.Context, *pfs.SetBranchRetentionRequest) (*types.Empty, error)
+type pruneBranchFunc func(context.Context, *pfs.PruneBranchRequest) (*pfs.CommitInfos, error)
+type mergeBranchFunc func(context.Context, *pfs.MergeBranchRequest) (*pfs.MergeBranchResponse, error)
 type putFileFunc func(pfs.API_PutFileServer) error
 type copyFileFunc func(context.Context, *pfs.CopyFileRequest) (*types.Empty, error)
 type getFileFunc func(*pfs.GetFileRequest, pfs.API_GetFileServer) error
@@ -392,6 +396,7 @@ type listFileStreamFunc func(*pfs.ListFileRequest, pfs.API_ListFileStreamServer)
 type walkFileFunc func(*pfs.WalkFileRequest, pfs.API_WalkFileServer) error
 type globFileFunc func(context.Context, *pfs.GlobFileRequest) (*pfs.FileInfos, error)
 type globFileStreamFunc func(*pfs.GlobFileRequest, pfs.API_GlobFileStreamServer) error
+type grepFileFunc func(*pfs.GrepFileRequest, pfs.API_GrepFileServer) error
 type diffFileFunc func(context.Context, *pfs.DiffFileRequest) (*pfs.DiffFileResponse, error)
 type deleteFileFunc func(context.Context, *pfs.DeleteFileRequest) (*types.Empty, error)
 type deleteAllPFSFunc func(context.Context, *types.Empty) (*types.Empty, error)
@@ -413,10 +418,14 @@ type mockDeleteCommit struct{ handler deleteCommitFunc }
 type mockFlushCommit struct{ handler flushCommitFunc }
 type mockSubscribeCommit struct{ handler subscribeCommitFunc }
 type mockBuildCommit struct{ handler buildCommitFunc }
+type mockAnnotateCommit struct{ handler annotateCommitFunc }
 type mockCreateBranch struct{ handler createBranchFunc }
 type mockInspectBranch struct{ handler inspectBranchFunc }
 type mockListBranch struct{ handler listBranchFunc }
 type mockDeleteBranch struct{ handler deleteBranchFunc }
+type mockSetBranchRetention struct{ handler setBranchRetentionFunc }
+type mockPruneBranch struct{ handler pruneBranchFunc }
+type mockMergeBranch struct{ handler mergeBranchFunc }
 type mockPutFile struct{ handler putFileFunc }
 type mockCopyFile struct{ handler copyFileFunc }
 type mockGetFile struct{ handler getFileFunc }
@@ -426,6 +435,7 @@ type mockListFileStream struct{ handler listFileStreamFunc }
 type mockWalkFile struct{ handler walkFileFunc }
 type mockGlobFile struct{ handler globFileFunc }
 type mockGlobFileStream struct{ handler globFileStreamFunc }
+type mockGrepFile struct{ handler grepFileFunc }
 type mockDiffFile struct{ handler diffFileFunc }
 type mockDeleteFile struct{ handler deleteFileFunc }
 type mockDeleteAllPFS struct{ handler deleteAllPFSFunc }
@@ -434,79 +444,89 @@ type mockPutTar struct{ handler putTarFunc }
 type mockGetTar struct{ handler getTarFunc }
 type mockGetTarConditional struct{ handler getTarConditionalFunc }
 
-func (mock *mockCreateRepo) Use(cb createRepoFunc)               { mock.handler = cb }
-func (mock *mockInspectRepo) Use(cb inspectRepoFunc)             { mock.handler = cb }
-func (mock *mockListRepo) Use(cb listRepoFunc)                   { mock.handler = cb }
-func (mock *mockDeleteRepo) Use(cb deleteRepoFunc)               { mock.handler = cb }
-func (mock *mockStartCommit) Use(cb startCommitFunc)             { mock.handler = cb }
-func (mock *mockFinishCommit) Use(cb finishCommitFunc)           { mock.handler = cb }
-func (mock *mockInspectCommit) Use(cb inspectCommitFunc)         { mock.handler = cb }
-func (mock *mockListCommit) Use(cb listCommitFunc)               { mock.handler = cb }
-func (mock *mockListCommitStream) Use(cb listCommitStreamFunc)   { mock.handler = cb }
-func (mock *mockDeleteCommit) Use(cb deleteCommitFunc)           { mock.handler = cb }
-func (mock *mockFlushCommit) Use(cb flushCommitFunc)             { mock.handler = cb }
-func (mock *mockSubscribeCommit) Use(cb subscribeCommitFunc)     { mock.handler = cb }
-func (mock *mockBuildCommit) Use(cb buildCommitFunc)             { mock.handler = cb }
-func (mock *mockCreateBranch) Use(cb createBranchFunc)           { mock.handler = cb }
-func (mock *mockInspectBranch) Use(cb inspectBranchFunc)         { mock.handler = cb }
-func (mock *mockListBranch) Use(cb listBranchFunc)               { mock.handler = cb }
-func (mock *mockDeleteBranch) Use(cb deleteBranchFunc)           { mock.handler = cb }
-func (mock *mockPutFile) Use(cb putFileFunc)                     { mock.handler = cb }
-func (mock *mockCopyFile) Use(cb copyFileFunc)                   { mock.handler = cb }
-func (mock *mockGetFile) Use(cb getFileFunc)                     { mock.handler = cb }
-func (mock *mockInspectFile) Use(cb inspectFileFunc)             { mock.handler = cb }
-func (mock *mockListFile) Use(cb listFileFunc)                   { mock.handler = cb }
-func (mock *mockListFileStream) Use(cb listFileStreamFunc)       { mock.handler = cb }
-func (mock *mockWalkFile) Use(cb walkFileFunc)                   { mock.handler = cb }
-func (mock *mockGlobFile) Use(cb globFileFunc)                   { mock.handler = cb }
-func (mock *mockGlobFileStream) Use(cb globFileStreamFunc)       { mock.handler = cb }
-func (mock *mockDiffFile) Use(cb diffFileFunc)                   { mock.handler = cb }
-func (mock *mockDeleteFile) Use(cb deleteFileFunc)               { mock.handler = cb }
-func (mock *mockDeleteAllPFS) Use(cb deleteAllPFSFunc)           { mock.handler = cb }
-func (mock *mockFsck) Use(cb fsckFunc)                           { mock.handler = cb }
-func (mock *mockPutTar) Use(cb putTarFunc)                       { mock.handler = cb }
-func (mock *mockGetTar) Use(cb getTarFunc)                       { mock.handler = cb }
-func (mock *mockGetTarConditional) Use(cb getTarConditionalFunc) { mock.handler = cb }
+func (mock *mockCreateRepo) Use(cb createRepoFunc)                 { mock.handler = cb }
+func (mock *mockInspectRepo) Use(cb inspectRepoFunc)               { mock.handler = cb }
+func (mock *mockListRepo) Use(cb listRepoFunc)                     { mock.handler = cb }
+func (mock *mockDeleteRepo) Use(cb deleteRepoFunc)                 { mock.handler = cb }
+func (mock *mockStartCommit) Use(cb startCommitFunc)               { mock.handler = cb }
+func (mock *mockFinishCommit) Use(cb finishCommitFunc)             { mock.handler = cb }
+func (mock *mockInspectCommit) Use(cb inspectCommitFunc)           { mock.handler = cb }
+func (mock *mockListCommit) Use(cb listCommitFunc)                 { mock.handler = cb }
+func (mock *mockListCommitStream) Use(cb listCommitStreamFunc)     { mock.handler = cb }
+func (mock *mockDeleteCommit) Use(cb deleteCommitFunc)             { mock.handler = cb }
+func (mock *mockFlushCommit) Use(cb flushCommitFunc)               { mock.handler = cb }
+func (mock *mockSubscribeCommit) Use(cb subscribeCommitFunc)       { mock.handler = cb }
+func (mock *mockBuildCommit) Use(cb buildCommitFunc)               { mock.handler = cb }
+func (mock *mockAnnotateCommit) Use(cb annotateCommitFunc)         { mock.handler = cb }
+func (mock *mockCreateBranch) Use(cb createBranchFunc)             { mock.handler = cb }
+func (mock *mockInspectBranch) Use(cb inspectBranchFunc)           { mock.handler = cb }
+func (mock *mockListBranch) Use(cb listBranchFunc)                 { mock.handler = cb }
+func (mock *mockDeleteBranch) Use(cb deleteBranchFunc)             { mock.handler = cb }
+func (mock *mockSetBranchRetention) Use(cb setBranchRetentionFunc) { mock.handler = cb }
+func (mock *mockPruneBranch) Use(cb pruneBranchFunc)               { mock.handler = cb }
+func (mock *mockMergeBranch) Use(cb mergeBranchFunc)               { mock.handler = cb }
+func (mock *mockPutFile) Use(cb putFileFunc)                       { mock.handler = cb }
+func (mock *mockCopyFile) Use(cb copyFileFunc)                     { mock.handler = cb }
+func (mock *mockGetFile) Use(cb getFileFunc)                       { mock.handler = cb }
+func (mock *mockInspectFile) Use(cb inspectFileFunc)               { mock.handler = cb }
+func (mock *mockListFile) Use(cb listFileFunc)                     { mock.handler = cb }
+func (mock *mockListFileStream) Use(cb listFileStreamFunc)         { mock.handler = cb }
+func (mock *mockWalkFile) Use(cb walkFileFunc)                     { mock.handler = cb }
+func (mock *mockGlobFile) Use(cb globFileFunc)                     { mock.handler = cb }
+func (mock *mockGlobFileStream) Use(cb globFileStreamFunc)         { mock.handler = cb }
+func (mock *mockGrepFile) Use(cb grepFileFunc)                     { mock.handler = cb }
+func (mock *mockDiffFile) Use(cb diffFileFunc)                     { mock.handler = cb }
+func (mock *mockDeleteFile) Use(cb deleteFileFunc)                 { mock.handler = cb }
+func (mock *mockDeleteAllPFS) Use(cb deleteAllPFSFunc)             { mock.handler = cb }
+func (mock *mockFsck) Use(cb fsckFunc)                             { mock.handler = cb }
+func (mock *mockPutTar) Use(cb putTarFunc)                         { mock.handler = cb }
+func (mock *mockGetTar) Use(cb getTarFunc)                         { mock.handler = cb }
+func (mock *mockGetTarConditional) Use(cb getTarConditionalFunc)   { mock.handler = cb }
 
 type pfsServerAPI struct {
 	mock *mockPFSServer
 }
 
 type mockPFSServer struct {
-	api               pfsServerAPI
-	CreateRepo        mockCreateRepo
-	InspectRepo       mockInspectRepo
-	ListRepo          mockListRepo
-	DeleteRepo        mockDeleteRepo
-	StartCommit       mockStartCommit
-	FinishCommit      mockFinishCommit
-	InspectCommit     mockInspectCommit
-	ListCommit        mockListCommit
-	ListCommitStream  mockListCommitStream
-	DeleteCommit      mockDeleteCommit
-	FlushCommit       mockFlushCommit
-	SubscribeCommit   mockSubscribeCommit
-	BuildCommit       mockBuildCommit
-	CreateBranch      mockCreateBranch
-	InspectBranch     mockInspectBranch
-	ListBranch        mockListBranch
-	DeleteBranch      mockDeleteBranch
-	PutFile           mockPutFile
-	CopyFile          mockCopyFile
-	GetFile           mockGetFile
-	InspectFile       mockInspectFile
-	ListFile          mockListFile
-	ListFileStream    mockListFileStream
-	WalkFile          mockWalkFile
-	GlobFile          mockGlobFile
-	GlobFileStream    mockGlobFileStream
-	DiffFile          mockDiffFile
-	DeleteFile        mockDeleteFile
-	DeleteAll         mockDeleteAllPFS
-	Fsck              mockFsck
-	PutTar            mockPutTar
-	GetTar            mockGetTar
-	GetTarConditional mockGetTarConditional
+	api                pfsServerAPI
+	CreateRepo         mockCreateRepo
+	InspectRepo        mockInspectRepo
+	ListRepo           mockListRepo
+	DeleteRepo         mockDeleteRepo
+	StartCommit        mockStartCommit
+	FinishCommit       mockFinishCommit
+	InspectCommit      mockInspectCommit
+	ListCommit         mockListCommit
+	ListCommitStream   mockListCommitStream
+	DeleteCommit       mockDeleteCommit
+	FlushCommit        mockFlushCommit
+	SubscribeCommit    mockSubscribeCommit
+	BuildCommit        mockBuildCommit
+	AnnotateCommit     mockAnnotateCommit
+	CreateBranch       mockCreateBranch
+	InspectBranch      mockInspectBranch
+	ListBranch         mockListBranch
+	DeleteBranch       mockDeleteBranch
+	SetBranchRetention mockSetBranchRetention
+	PruneBranch        mockPruneBranch
+	MergeBranch        mockMergeBranch
+	PutFile            mockPutFile
+	CopyFile           mockCopyFile
+	GetFile            mockGetFile
+	InspectFile        mockInspectFile
+	ListFile           mockListFile
+	ListFileStream     mockListFileStream
+	WalkFile           mockWalkFile
+	GlobFile           mockGlobFile
+	GlobFileStream     mockGlobFileStream
+	GrepFile           mockGrepFile
+	DiffFile           mockDiffFile
+	DeleteFile         mockDeleteFile
+	DeleteAll          mockDeleteAllPFS
+	Fsck               mockFsck
+	PutTar             mockPutTar
+	GetTar             mockGetTar
+	GetTarConditional  mockGetTarConditional
 }
 
 func (api *pfsServerAPI) CreateRepo(ctx context.Context, req *pfs.CreateRepoRequest) (*types.Empty, error) {
@@ -587,6 +607,12 @@ func (api *pfsServerAPI) BuildCommit(ctx context.Context, req *pfs.BuildCommitRe
 	}
 	return nil, errors.Errorf("unhandled pachd mock pfs.BuildCommit")
 }
+func (api *pfsServerAPI) AnnotateCommit(ctx context.Context, req *pfs.AnnotateCommitRequest) (*types.Empty, error) {
+	if api.mock.AnnotateCommit.handler != nil {
+		return api.mock.AnnotateCommit.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.AnnotateCommit")
+}
 func (api *pfsServerAPI) CreateBranch(ctx context.Context, req *pfs.CreateBranchRequest) (*types.Empty, error) {
 	if api.mock.CreateBranch.handler != nil {
 		return api.mock.CreateBranch.handler(ctx, req)
@@ -611,6 +637,24 @@ func (api *pfsServerAPI) DeleteBranch(ctx context.Context, req *pfs.DeleteBranch
 	}
 	return nil, errors.Errorf("unhandled pachd mock pfs.DeleteBranch")
 }
+func (api *pfsServerAPI) SetBranchRetention(ctx context.Context, req *pfs.SetBranchRetentionRequest) (*types.Empty, error) {
+	if api.mock.SetBranchRetention.handler != nil {
+		return api.mock.SetBranchRetention.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.SetBranchRetention")
+}
+func (api *pfsServerAPI) PruneBranch(ctx context.Context, req *pfs.PruneBranchRequest) (*pfs.CommitInfos, error) {
+	if api.mock.PruneBranch.handler != nil {
+		return api.mock.PruneBranch.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.PruneBranch")
+}
+func (api *pfsServerAPI) MergeBranch(ctx context.Context, req *pfs.MergeBranchRequest) (*pfs.MergeBranchResponse, error) {
+	if api.mock.MergeBranch.handler != nil {
+		return api.mock.MergeBranch.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.MergeBranch")
+}
 func (api *pfsServerAPI) PutFile(serv pfs.API_PutFileServer) error {
 	if api.mock.PutFile.handler != nil {
 		return api.mock.PutFile.handler(serv)
@@ -665,6 +709,12 @@ func (api *pfsServerAPI) GlobFileStream(req *pfs.GlobFileRequest, serv pfs.API_G
 	}
 	return errors.Errorf("unhandled pachd mock pfs.GlobFileStream")
 }
+func (api *pfsServerAPI) GrepFile(req *pfs.GrepFileRequest, serv pfs.API_GrepFileServer) error {
+	if api.mock.GrepFile.handler != nil {
+		return api.mock.GrepFile.handler(req, serv)
+	}
+	return errors.Errorf("unhandled pachd mock pfs.GrepFile")
+}
 func (api *pfsServerAPI) DiffFile(ctx context.Context, req *pfs.DiffFileRequest) (*pfs.DiffFileResponse, error) {
 	if api.mock.DiffFile.handler != nil {
 		return api.mock.DiffFile.handler(ctx, req)
@@ -724,12 +774,18 @@ type listDatumStreamFunc func(*pps.ListDatumRequest, pps.API_ListDatumStreamServ
 type restartDatumFunc func(context.Context, *pps.RestartDatumRequest) (*types.Empty, error)
 type createPipelineFunc func(context.Context, *pps.CreatePipelineRequest) (*types.Empty, error)
 type inspectPipelineFunc func(context.Context, *pps.InspectPipelineRequest) (*pps.PipelineInfo, error)
+type inspectPipelineUsageFunc func(context.Context, *pps.InspectPipelineUsageRequest) (*pps.PipelineUsage, error)
+type validatePipelineFunc func(context.Context, *pps.ValidatePipelineRequest) (*pps.ValidatePipelineResponse, error)
 type listPipelineFunc func(context.Context, *pps.ListPipelineRequest) (*pps.PipelineInfos, error)
 type deletePipelineFunc func(context.Context, *pps.DeletePipelineRequest) (*types.Empty, error)
 type startPipelineFunc func(context.Context, *pps.StartPipelineRequest) (*types.Empty, error)
 type stopPipelineFunc func(context.Context, *pps.StopPipelineRequest) (*types.Empty, error)
 type runPipelineFunc func(context.Context, *pps.RunPipelineRequest) (*types.Empty, error)
 type runCronFunc func(context.Context, *pps.RunCronRequest) (*types.Empty, error)
+type rollbackPipelineFunc func(context.Context, *pps.RollbackPipelineRequest) (*types.Empty, error)
+type createNotifierFunc func(context.Context, *pps.CreateNotifierRequest) (*types.Empty, error)
+type listNotifierFunc func(context.Context, *pps.ListNotifierRequest) (*pps.NotifierInfos, error)
+type deleteNotifierFunc func(context.Context, *pps.DeleteNotifierRequest) (*types.Empty, error)
 type createSecretFunc func(context.Context, *pps.CreateSecretRequest) (*types.Empty, error)
 type deleteSecretFunc func(context.Context, *pps.DeleteSecretRequest) (*types.Empty, error)
 type inspectSecretFunc func(context.Context, *pps.InspectSecretRequest) (*pps.SecretInfo, error)
@@ -753,12 +809,18 @@ type mockListDatumStream struct{ handler listDatumStreamFunc }
 type mockRestartDatum struct{ handler restartDatumFunc }
 type mockCreatePipeline struct{ handler createPipelineFunc }
 type mockInspectPipeline struct{ handler inspectPipelineFunc }
+type mockInspectPipelineUsage struct{ handler inspectPipelineUsageFunc }
+type mockValidatePipeline struct{ handler validatePipelineFunc }
 type mockListPipeline struct{ handler listPipelineFunc }
 type mockDeletePipeline struct{ handler deletePipelineFunc }
 type mockStartPipeline struct{ handler startPipelineFunc }
 type mockStopPipeline struct{ handler stopPipelineFunc }
 type mockRunPipeline struct{ handler runPipelineFunc }
 type mockRunCron struct{ handler runCronFunc }
+type mockRollbackPipeline struct{ handler rollbackPipelineFunc }
+type mockCreateNotifier struct{ handler createNotifierFunc }
+type mockListNotifier struct{ handler listNotifierFunc }
+type mockDeleteNotifier struct{ handler deleteNotifierFunc }
 type mockCreateSecret struct{ handler createSecretFunc }
 type mockDeleteSecret struct{ handler deleteSecretFunc }
 type mockInspectSecret struct{ handler inspectSecretFunc }
@@ -768,69 +830,81 @@ type mockGetLogs struct{ handler getLogsFunc }
 type mockGarbageCollect struct{ handler garbageCollectFunc }
 type mockActivateAuthPPS struct{ handler activateAuthPPSFunc }
 
-func (mock *mockCreateJob) Use(cb createJobFunc)             { mock.handler = cb }
-func (mock *mockInspectJob) Use(cb inspectJobFunc)           { mock.handler = cb }
-func (mock *mockListJob) Use(cb listJobFunc)                 { mock.handler = cb }
-func (mock *mockListJobStream) Use(cb listJobStreamFunc)     { mock.handler = cb }
-func (mock *mockFlushJob) Use(cb flushJobFunc)               { mock.handler = cb }
-func (mock *mockDeleteJob) Use(cb deleteJobFunc)             { mock.handler = cb }
-func (mock *mockStopJob) Use(cb stopJobFunc)                 { mock.handler = cb }
-func (mock *mockUpdateJobState) Use(cb updateJobStateFunc)   { mock.handler = cb }
-func (mock *mockInspectDatum) Use(cb inspectDatumFunc)       { mock.handler = cb }
-func (mock *mockListDatum) Use(cb listDatumFunc)             { mock.handler = cb }
-func (mock *mockListDatumStream) Use(cb listDatumStreamFunc) { mock.handler = cb }
-func (mock *mockRestartDatum) Use(cb restartDatumFunc)       { mock.handler = cb }
-func (mock *mockCreatePipeline) Use(cb createPipelineFunc)   { mock.handler = cb }
-func (mock *mockInspectPipeline) Use(cb inspectPipelineFunc) { mock.handler = cb }
-func (mock *mockListPipeline) Use(cb listPipelineFunc)       { mock.handler = cb }
-func (mock *mockDeletePipeline) Use(cb deletePipelineFunc)   { mock.handler = cb }
-func (mock *mockStartPipeline) Use(cb startPipelineFunc)     { mock.handler = cb }
-func (mock *mockStopPipeline) Use(cb stopPipelineFunc)       { mock.handler = cb }
-func (mock *mockRunPipeline) Use(cb runPipelineFunc)         { mock.handler = cb }
-func (mock *mockRunCron) Use(cb runCronFunc)                 { mock.handler = cb }
-func (mock *mockCreateSecret) Use(cb createSecretFunc)       { mock.handler = cb }
-func (mock *mockDeleteSecret) Use(cb deleteSecretFunc)       { mock.handler = cb }
-func (mock *mockInspectSecret) Use(cb inspectSecretFunc)     { mock.handler = cb }
-func (mock *mockListSecret) Use(cb listSecretFunc)           { mock.handler = cb }
-func (mock *mockDeleteAllPPS) Use(cb deleteAllPPSFunc)       { mock.handler = cb }
-func (mock *mockGetLogs) Use(cb getLogsFunc)                 { mock.handler = cb }
-func (mock *mockGarbageCollect) Use(cb garbageCollectFunc)   { mock.handler = cb }
-func (mock *mockActivateAuthPPS) Use(cb activateAuthPPSFunc) { mock.handler = cb }
+func (mock *mockCreateJob) Use(cb createJobFunc)                       { mock.handler = cb }
+func (mock *mockInspectJob) Use(cb inspectJobFunc)                     { mock.handler = cb }
+func (mock *mockListJob) Use(cb listJobFunc)                           { mock.handler = cb }
+func (mock *mockListJobStream) Use(cb listJobStreamFunc)               { mock.handler = cb }
+func (mock *mockFlushJob) Use(cb flushJobFunc)                         { mock.handler = cb }
+func (mock *mockDeleteJob) Use(cb deleteJobFunc)                       { mock.handler = cb }
+func (mock *mockStopJob) Use(cb stopJobFunc)                           { mock.handler = cb }
+func (mock *mockUpdateJobState) Use(cb updateJobStateFunc)             { mock.handler = cb }
+func (mock *mockInspectDatum) Use(cb inspectDatumFunc)                 { mock.handler = cb }
+func (mock *mockListDatum) Use(cb listDatumFunc)                       { mock.handler = cb }
+func (mock *mockListDatumStream) Use(cb listDatumStreamFunc)           { mock.handler = cb }
+func (mock *mockRestartDatum) Use(cb restartDatumFunc)                 { mock.handler = cb }
+func (mock *mockCreatePipeline) Use(cb createPipelineFunc)             { mock.handler = cb }
+func (mock *mockInspectPipeline) Use(cb inspectPipelineFunc)           { mock.handler = cb }
+func (mock *mockInspectPipelineUsage) Use(cb inspectPipelineUsageFunc) { mock.handler = cb }
+func (mock *mockValidatePipeline) Use(cb validatePipelineFunc)         { mock.handler = cb }
+func (mock *mockListPipeline) Use(cb listPipelineFunc)                 { mock.handler = cb }
+func (mock *mockDeletePipeline) Use(cb deletePipelineFunc)             { mock.handler = cb }
+func (mock *mockStartPipeline) Use(cb startPipelineFunc)               { mock.handler = cb }
+func (mock *mockStopPipeline) Use(cb stopPipelineFunc)                 { mock.handler = cb }
+func (mock *mockRunPipeline) Use(cb runPipelineFunc)                   { mock.handler = cb }
+func (mock *mockRunCron) Use(cb runCronFunc)                           { mock.handler = cb }
+func (mock *mockRollbackPipeline) Use(cb rollbackPipelineFunc)         { mock.handler = cb }
+func (mock *mockCreateNotifier) Use(cb createNotifierFunc)             { mock.handler = cb }
+func (mock *mockListNotifier) Use(cb listNotifierFunc)                 { mock.handler = cb }
+func (mock *mockDeleteNotifier) Use(cb deleteNotifierFunc)             { mock.handler = cb }
+func (mock *mockCreateSecret) Use(cb createSecretFunc)                 { mock.handler = cb }
+func (mock *mockDeleteSecret) Use(cb deleteSecretFunc)                 { mock.handler = cb }
+func (mock *mockInspectSecret) Use(cb inspectSecretFunc)               { mock.handler = cb }
+func (mock *mockListSecret) Use(cb listSecretFunc)                     { mock.handler = cb }
+func (mock *mockDeleteAllPPS) Use(cb deleteAllPPSFunc)                 { mock.handler = cb }
+func (mock *mockGetLogs) Use(cb getLogsFunc)                           { mock.handler = cb }
+func (mock *mockGarbageCollect) Use(cb garbageCollectFunc)             { mock.handler = cb }
+func (mock *mockActivateAuthPPS) Use(cb activateAuthPPSFunc)           { mock.handler = cb }
 
 type ppsServerAPI struct {
 	mock *mockPPSServer
 }
 
 type mockPPSServer struct {
-	api             ppsServerAPI
-	CreateJob       mockCreateJob
-	InspectJob      mockInspectJob
-	ListJob         mockListJob
-	ListJobStream   mockListJobStream
-	FlushJob        mockFlushJob
-	DeleteJob       mockDeleteJob
-	StopJob         mockStopJob
-	UpdateJobState  mockUpdateJobState
-	InspectDatum    mockInspectDatum
-	ListDatum       mockListDatum
-	ListDatumStream mockListDatumStream
-	RestartDatum    mockRestartDatum
-	CreatePipeline  mockCreatePipeline
-	InspectPipeline mockInspectPipeline
-	ListPipeline    mockListPipeline
-	DeletePipeline  mockDeletePipeline
-	StartPipeline   mockStartPipeline
-	StopPipeline    mockStopPipeline
-	RunPipeline     mockRunPipeline
-	RunCron         mockRunCron
-	CreateSecret    mockCreateSecret
-	DeleteSecret    mockDeleteSecret
-	InspectSecret   mockInspectSecret
-	ListSecret      mockListSecret
-	DeleteAll       mockDeleteAllPPS
-	GetLogs         mockGetLogs
-	GarbageCollect  mockGarbageCollect
-	ActivateAuth    mockActivateAuthPPS
+	api                  ppsServerAPI
+	CreateJob            mockCreateJob
+	InspectJob           mockInspectJob
+	ListJob              mockListJob
+	ListJobStream        mockListJobStream
+	FlushJob             mockFlushJob
+	DeleteJob            mockDeleteJob
+	StopJob              mockStopJob
+	UpdateJobState       mockUpdateJobState
+	InspectDatum         mockInspectDatum
+	ListDatum            mockListDatum
+	ListDatumStream      mockListDatumStream
+	RestartDatum         mockRestartDatum
+	CreatePipeline       mockCreatePipeline
+	InspectPipeline      mockInspectPipeline
+	InspectPipelineUsage mockInspectPipelineUsage
+	ValidatePipeline     mockValidatePipeline
+	ListPipeline         mockListPipeline
+	DeletePipeline       mockDeletePipeline
+	StartPipeline        mockStartPipeline
+	StopPipeline         mockStopPipeline
+	RunPipeline          mockRunPipeline
+	RunCron              mockRunCron
+	RollbackPipeline     mockRollbackPipeline
+	CreateNotifier       mockCreateNotifier
+	ListNotifier         mockListNotifier
+	DeleteNotifier       mockDeleteNotifier
+	CreateSecret         mockCreateSecret
+	DeleteSecret         mockDeleteSecret
+	InspectSecret        mockInspectSecret
+	ListSecret           mockListSecret
+	DeleteAll            mockDeleteAllPPS
+	GetLogs              mockGetLogs
+	GarbageCollect       mockGarbageCollect
+	ActivateAuth         mockActivateAuthPPS
 }
 
 func (api *ppsServerAPI) CreateJob(ctx context.Context, req *pps.CreateJobRequest) (*pps.Job, error) {
@@ -917,6 +991,18 @@ func (api *ppsServerAPI) InspectPipeline(ctx context.Context, req *pps.InspectPi
 	}
 	return nil, errors.Errorf("unhandled pachd mock pps.InspectPipeline")
 }
+func (api *ppsServerAPI) InspectPipelineUsage(ctx context.Context, req *pps.InspectPipelineUsageRequest) (*pps.PipelineUsage, error) {
+	if api.mock.InspectPipelineUsage.handler != nil {
+		return api.mock.InspectPipelineUsage.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pps.InspectPipelineUsage")
+}
+func (api *ppsServerAPI) ValidatePipeline(ctx context.Context, req *pps.ValidatePipelineRequest) (*pps.ValidatePipelineResponse, error) {
+	if api.mock.ValidatePipeline.handler != nil {
+		return api.mock.ValidatePipeline.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pps.ValidatePipeline")
+}
 func (api *ppsServerAPI) ListPipeline(ctx context.Context, req *pps.ListPipelineRequest) (*pps.PipelineInfos, error) {
 	if api.mock.ListPipeline.handler != nil {
 		return api.mock.ListPipeline.handler(ctx, req)
@@ -953,6 +1039,30 @@ func (api *ppsServerAPI) RunCron(ctx context.Context, req *pps.RunCronRequest) (
 	}
 	return nil, errors.Errorf("unhandled pachd mock pps.RunCron")
 }
+func (api *ppsServerAPI) RollbackPipeline(ctx context.Context, req *pps.RollbackPipelineRequest) (*types.Empty, error) {
+	if api.mock.RollbackPipeline.handler != nil {
+		return api.mock.RollbackPipeline.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pps.RollbackPipeline")
+}
+func (api *ppsServerAPI) CreateNotifier(ctx context.Context, req *pps.CreateNotifierRequest) (*types.Empty, error) {
+	if api.mock.CreateNotifier.handler != nil {
+		return api.mock.CreateNotifier.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pps.CreateNotifier")
+}
+func (api *ppsServerAPI) ListNotifier(ctx context.Context, req *pps.ListNotifierRequest) (*pps.NotifierInfos, error) {
+	if api.mock.ListNotifier.handler != nil {
+		return api.mock.ListNotifier.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pps.ListNotifier")
+}
+func (api *ppsServerAPI) DeleteNotifier(ctx context.Context, req *pps.DeleteNotifierRequest) (*types.Empty, error) {
+	if api.mock.DeleteNotifier.handler != nil {
+		return api.mock.DeleteNotifier.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pps.DeleteNotifier")
+}
 func (api *ppsServerAPI) CreateSecret(ctx context.Context, req *pps.CreateSecretRequest) (*types.Empty, error) {
 	if api.mock.CreateSecret.handler != nil {
 		return api.mock.CreateSecret.handler(ctx, req)
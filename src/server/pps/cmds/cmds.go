@@ -12,6 +12,7 @@ import (
 	"path/filepath"
 	"strings"
 	"syscall"
+	"time"
 
 	pachdclient "github.com/pachyderm/pachyderm/src/client"
 	"github.com/pachyderm/pachyderm/src/client/pfs"
@@ -362,13 +363,95 @@ each datum.`,
 	}
 	commands = append(commands, cmdutil.CreateAlias(restartDatum, "restart datum"))
 
+	resubmitDatum := &cobra.Command{
+		Use:   "{{alias}} <pipeline>",
+		Short: "Retry a pipeline's dead-lettered datums.",
+		Long: `Retry a pipeline's dead-lettered datums.
+
+This clears the records in the pipeline's dead letter repo and starts a new
+job for the pipeline (which must have been created with "dead_letter" set).
+Jobs skip the datums that are listed by "pachctl list datum <pipeline>
+--failed", so the new job retries exactly those datums.`,
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			client, err := pachdclient.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+			return client.ResubmitDeadLetters(args[0])
+		}),
+	}
+	shell.RegisterCompletionFunc(resubmitDatum, shell.PipelineCompletion)
+	commands = append(commands, cmdutil.CreateAlias(resubmitDatum, "resubmit datum"))
+
 	var pageSize int64
 	var page int64
+	var inputSpec string
+	var failed bool
 	listDatum := &cobra.Command{
 		Use:   "{{alias}} <job>",
 		Short: "Return the datums in a job.",
-		Long:  "Return the datums in a job.",
-		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+		Long: `Return the datums in a job.
+
+If --input is passed instead of a job, return the datums that a pipeline with
+that input would process if it was created now, without creating it. This can
+be used to try out glob patterns and join or cross inputs.
+
+If --failed is passed, the argument is a pipeline rather than a job, and the
+datums that the pipeline has dead-lettered (and that haven't been resubmitted
+since) are returned along with the error that failed them.`,
+		Example: `
+# Return the datums in job "1234"
+$ {{alias}} 1234
+
+# Return the datums that a pipeline with the input in "input.json" would process
+$ {{alias}} --input input.json
+
+# Return the datums that pipeline "foo" dead-lettered
+$ {{alias}} foo --failed`,
+		Run: cmdutil.RunBoundedArgs(0, 1, func(args []string) error {
+			if failed {
+				if inputSpec != "" || len(args) != 1 {
+					return errors.Errorf("--failed must be passed a pipeline, and not --input")
+				}
+				client, err := pachdclient.NewOnUserMachine("user")
+				if err != nil {
+					return err
+				}
+				defer client.Close()
+				deadLetters, err := client.ListDeadLetters(args[0])
+				if err != nil {
+					return err
+				}
+				if raw {
+					e := encoder(output)
+					for _, deadLetter := range deadLetters {
+						if err := e.EncodeProto(deadLetter); err != nil {
+							return err
+						}
+					}
+					return nil
+				} else if output != "" {
+					cmdutil.ErrorAndExit("cannot set --output (-o) without --raw")
+				}
+				writer := tabwriter.NewWriter(os.Stdout, pretty.DeadLetterHeader)
+				for _, deadLetter := range deadLetters {
+					pretty.PrintDeadLetterDatum(writer, deadLetter)
+				}
+				return writer.Flush()
+			}
+			var input *ppsclient.Input
+			if inputSpec != "" {
+				if len(args) != 0 {
+					return errors.Errorf("cannot pass both a job and --input")
+				}
+				var err error
+				if input, err = ppsutil.ReadInputSpec(inputSpec); err != nil {
+					return err
+				}
+			} else if len(args) != 1 {
+				return errors.Errorf("must pass either a job or --input")
+			}
 			client, err := pachdclient.NewOnUserMachine("user")
 			if err != nil {
 				return err
@@ -380,16 +463,41 @@ each datum.`,
 			if page < 0 {
 				return errors.Errorf("page must be zero or positive")
 			}
+			listDatumF := func(f func(*ppsclient.DatumInfo) error) error {
+				if input != nil {
+					return client.ListDatumInputF(input, pageSize, page, f)
+				}
+				return client.ListDatumF(args[0], pageSize, page, f)
+			}
 			if raw {
 				e := encoder(output)
-				return client.ListDatumF(args[0], pageSize, page, func(di *ppsclient.DatumInfo) error {
+				return listDatumF(func(di *ppsclient.DatumInfo) error {
 					return e.EncodeProto(di)
 				})
 			} else if output != "" {
 				cmdutil.ErrorAndExit("cannot set --output (-o) without --raw")
 			}
+			if input != nil {
+				resp, err := client.ListDatumInput(input, pageSize, page)
+				if err != nil {
+					return err
+				}
+				writer := tabwriter.NewWriter(os.Stdout, pretty.DatumInputHeader)
+				for _, di := range resp.DatumInfos {
+					pretty.PrintDatumInputInfo(writer, di)
+				}
+				if err := writer.Flush(); err != nil {
+					return err
+				}
+				if pageSize > 0 {
+					fmt.Printf("%d datums (page %d of %d)\n", resp.TotalDatums, page+1, resp.TotalPages)
+				} else {
+					fmt.Printf("%d datums\n", resp.TotalDatums)
+				}
+				return nil
+			}
 			writer := tabwriter.NewWriter(os.Stdout, pretty.DatumHeader)
-			if err := client.ListDatumF(args[0], pageSize, page, func(di *ppsclient.DatumInfo) error {
+			if err := listDatumF(func(di *ppsclient.DatumInfo) error {
 				pretty.PrintDatumInfo(writer, di)
 				return nil
 			}); err != nil {
@@ -400,6 +508,8 @@ each datum.`,
 	}
 	listDatum.Flags().Int64Var(&pageSize, "pageSize", 0, "Specify the number of results sent back in a single page")
 	listDatum.Flags().Int64Var(&page, "page", 0, "Specify the page of results to send")
+	listDatum.Flags().StringVar(&inputSpec, "input", "", "A file (or URL, or - for stdin) containing a pipeline input spec, whose datums are returned instead of a job's.")
+	listDatum.Flags().BoolVar(&failed, "failed", false, "Return the datums that the pipeline passed as an argument dead-lettered, instead of a job's datums.")
 	listDatum.Flags().AddFlagSet(outputFlags)
 	shell.RegisterCompletionFunc(listDatum, shell.JobCompletion)
 	commands = append(commands, cmdutil.CreateAlias(listDatum, "list datum"))
@@ -567,6 +677,108 @@ All jobs created by a pipeline will create commits in the pipeline's output repo
 	updatePipeline.Flags().BoolVar(&reprocess, "reprocess", false, "If true, reprocess datums that were already processed by previous version of the pipeline.")
 	commands = append(commands, cmdutil.CreateAlias(updatePipeline, "update pipeline"))
 
+	lintPipeline := &cobra.Command{
+		Short: "Check pipeline specifications for problems without creating them.",
+		Long: `Check pipeline specifications for problems without creating them.
+
+Every check that 'create pipeline' makes is run, along with checks that would
+otherwise only fail once the pipeline is running (unparseable resources, or a
+pod_spec or pod_patch that doesn't apply), and checks against the cluster's
+current state: missing input repos, inputs whose glob matches no files, joins
+that produce no datums, and pipelines that would read from each other. If the
+file contains several pipelines, they're checked in order, so later pipelines
+may read from earlier ones.
+
+Problems that would stop the pipeline from being created are errors, and
+exit with a non-zero status. The others are warnings.`,
+		Example: `
+# Check the pipelines in pipelines.json
+$ {{alias}} -f pipelines.json`,
+		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
+			pipelineReader, err := ppsutil.NewPipelineManifestReader(pipelinePath)
+			if err != nil {
+				return err
+			}
+			var requests []*ppsclient.CreatePipelineRequest
+			for {
+				request, err := pipelineReader.NextCreatePipelineRequest()
+				if err == io.EOF {
+					break
+				} else if err != nil {
+					return err
+				}
+				requests = append(requests, request)
+			}
+			client, err := pachdclient.NewOnUserMachine("user")
+			if err != nil {
+				return errors.Wrapf(err, "error connecting to pachd")
+			}
+			defer client.Close()
+			problems, err := client.ValidatePipeline(requests...)
+			if err != nil {
+				return err
+			}
+			if raw {
+				e := encoder(output)
+				for _, problem := range problems {
+					if err := e.EncodeProto(problem); err != nil {
+						return err
+					}
+				}
+			} else if output != "" {
+				cmdutil.ErrorAndExit("cannot set --output (-o) without --raw")
+			}
+			errs := 0
+			for _, problem := range problems {
+				severity := "warning"
+				if !problem.Warning {
+					severity = "error"
+					errs++
+				}
+				if !raw {
+					fmt.Printf("%s: %s: %s\n", problem.Pipeline.GetName(), severity, problem.Message)
+				}
+			}
+			if errs > 0 {
+				return errors.Errorf("found %d error(s) in %d pipeline spec(s)", errs, len(requests))
+			}
+			return nil
+		}),
+	}
+	lintPipeline.Flags().StringVarP(&pipelinePath, "file", "f", "-", "The JSON file containing the pipeline, it can be a url or local file. - reads from stdin.")
+	lintPipeline.Flags().AddFlagSet(outputFlags)
+	commands = append(commands, cmdutil.CreateAlias(lintPipeline, "lint pipeline"))
+
+	var toVersion uint64
+	rollbackPipeline := &cobra.Command{
+		Use:   "{{alias}} <pipeline>",
+		Short: "Re-apply a previous version of a pipeline's spec.",
+		Long: `Re-apply a previous version of a pipeline's spec.
+
+The old spec is applied as an update, so it becomes the newest version of the
+pipeline, and the version being rolled back from stays in the pipeline's
+history. Use "pachctl list pipeline <pipeline> --history all --diff" to see
+how its versions differ.`,
+		Example: `
+# Roll pipeline "foo" back to the version before its current one
+$ {{alias}} foo
+
+# Roll pipeline "foo" back to version 2, and reprocess its input
+$ {{alias}} foo --to-version 2 --reprocess`,
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			client, err := pachdclient.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+			return client.RollbackPipeline(args[0], toVersion, reprocess)
+		}),
+	}
+	rollbackPipeline.Flags().Uint64Var(&toVersion, "to-version", 0, "The version of the pipeline to roll back to (defaults to the version before the current one).")
+	rollbackPipeline.Flags().BoolVar(&reprocess, "reprocess", false, "If true, reprocess datums that were already processed by previous versions of the pipeline.")
+	shell.RegisterCompletionFunc(rollbackPipeline, shell.PipelineCompletion)
+	commands = append(commands, cmdutil.CreateAlias(rollbackPipeline, "rollback pipeline"))
+
 	runPipeline := &cobra.Command{
 		Use:   "{{alias}} <pipeline> [<repo>@<branch>[=<commit>]...]",
 		Short: "Run an existing Pachyderm pipeline on the specified commits-branch pairs.",
@@ -600,6 +812,64 @@ All jobs created by a pipeline will create commits in the pipeline's output repo
 	runPipeline.Flags().StringVar(&jobID, "job", "", "rerun the given job")
 	commands = append(commands, cmdutil.CreateAlias(runPipeline, "run pipeline"))
 
+	var outDir string
+	var outBranch string
+	runPipelineLocalCmd := &cobra.Command{
+		Use:   "{{alias}} <pipeline-spec>",
+		Short: "Run a pipeline spec on this machine, against the data in the cluster.",
+		Long: `Run a pipeline spec on this machine, against the data in the cluster.
+
+The pipeline's datums are computed the same way a worker computes them, from
+the current head of each input branch. For each datum, its inputs are
+downloaded to pfs/<input> in a temporary directory and the transform's cmd is
+run as a local process in that directory, with the environment variables a
+worker would set (except that they point at the temporary directory rather
+than /pfs). The transform's image isn't used, so the cmd must be available
+locally. Absolute paths like /pfs/<input> in the cmd or stdin are not
+rewritten, so they don't point at the datum's inputs: write them relative to
+the working directory (pfs/<input>) or read them from the input's environment
+variable.
+
+The outputs of all datums are merged into --out-dir and/or committed to
+--branch, and the pipeline isn't created. Git inputs, S3 inputs, services,
+spouts, and transforms with secrets, a working_dir or an err_cmd can't be run
+locally.`,
+		Example: `
+# Run the pipeline in "edges.json" and write its output to ./edges-out
+$ {{alias}} edges.json --out-dir edges-out
+
+# Run the pipeline in "edges.json" and commit its output to branch "debug" of repo "scratch"
+$ {{alias}} edges.json --branch scratch@debug`,
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			if outDir == "" && outBranch == "" {
+				return errors.Errorf("must pass --out-dir, --branch or both")
+			}
+			var branch *pfs.Branch
+			if outBranch != "" {
+				var err error
+				if branch, err = cmdutil.ParseBranch(outBranch); err != nil {
+					return err
+				}
+				if branch.Name == "" {
+					return errors.Errorf("--branch must be of the form <repo>@<branch>")
+				}
+			}
+			request, err := readLocalPipeline(args[0])
+			if err != nil {
+				return err
+			}
+			client, err := pachdclient.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+			return runPipelineLocal(client, request, outDir, branch)
+		}),
+	}
+	runPipelineLocalCmd.Flags().StringVar(&outDir, "out-dir", "", "A local directory (which must be empty or not exist) to write the pipeline's output to.")
+	runPipelineLocalCmd.Flags().StringVar(&outBranch, "branch", "", "A branch, as <repo>@<branch>, to commit the pipeline's output to. The repo is created if it doesn't exist, and the new commit replaces the branch's previous contents.")
+	commands = append(commands, cmdutil.CreateAlias(runPipelineLocalCmd, "run pipeline-local"))
+
 	runCron := &cobra.Command{
 		Use:   "{{alias}} <pipeline>",
 		Short: "Run an existing Pachyderm cron pipeline now",
@@ -622,16 +892,60 @@ All jobs created by a pipeline will create commits in the pipeline's output repo
 	}
 	commands = append(commands, cmdutil.CreateAlias(runCron, "run cron"))
 
+	var usage bool
+	var since string
+	var usageCSV bool
 	inspectPipeline := &cobra.Command{
 		Use:   "{{alias}} <pipeline>",
 		Short: "Return info about a pipeline.",
-		Long:  "Return info about a pipeline.",
+		Long: `Return info about a pipeline.
+
+If --usage is passed, the resources used by the pipeline's jobs (including
+those of its previous versions) are returned instead: the time spent
+processing datums, the CPU and GPU time, the peak and average memory, and the
+data read, written, downloaded and uploaded. GPU time counts every GPU
+allocated to a worker while it processes a datum.`,
+		Example: `
+# Return info about pipeline "foo"
+$ {{alias}} foo
+
+# Return the resources used by pipeline "foo"'s jobs over the last 30 days
+$ {{alias}} foo --usage --since 30d
+
+# Export the same as CSV
+$ {{alias}} foo --usage --since 30d --csv > foo-usage.csv`,
 		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
 			client, err := pachdclient.NewOnUserMachine("user")
 			if err != nil {
 				return err
 			}
 			defer client.Close()
+			if usage {
+				var sinceTime time.Time
+				if since != "" {
+					d, err := cmdutil.ParseDuration(since)
+					if err != nil {
+						return err
+					}
+					sinceTime = time.Now().Add(-d)
+				}
+				pipelineUsage, err := client.InspectPipelineUsage(args[0], sinceTime)
+				if err != nil {
+					return err
+				}
+				if raw {
+					return encoder(output).EncodeProto(pipelineUsage)
+				} else if output != "" {
+					cmdutil.ErrorAndExit("cannot set --output (-o) without --raw")
+				}
+				if usageCSV {
+					return pretty.PrintPipelineUsageCSV(os.Stdout, pipelineUsage)
+				}
+				pretty.PrintDetailedPipelineUsage(os.Stdout, pipelineUsage, fullTimestamps)
+				return nil
+			} else if since != "" || usageCSV {
+				cmdutil.ErrorAndExit("cannot set --since or --csv without --usage")
+			}
 			pipelineInfo, err := client.InspectPipeline(args[0])
 			if err != nil {
 				return err
@@ -651,6 +965,9 @@ All jobs created by a pipeline will create commits in the pipeline's output repo
 			return pretty.PrintDetailedPipelineInfo(pi)
 		}),
 	}
+	inspectPipeline.Flags().BoolVar(&usage, "usage", false, "Return the resources used by the pipeline's jobs, instead of the pipeline's info.")
+	inspectPipeline.Flags().StringVar(&since, "since", "", "With --usage, only count jobs started within this long (e.g. \"24h\" or \"30d\"), rather than all of the pipeline's jobs.")
+	inspectPipeline.Flags().BoolVar(&usageCSV, "csv", false, "With --usage, write the resources used as CSV.")
 	inspectPipeline.Flags().AddFlagSet(outputFlags)
 	inspectPipeline.Flags().AddFlagSet(fullTimestampsFlags)
 	commands = append(commands, cmdutil.CreateAlias(inspectPipeline, "inspect pipeline"))
@@ -747,10 +1064,23 @@ All jobs created by a pipeline will create commits in the pipeline's output repo
 	commands = append(commands, cmdutil.CreateAlias(editPipeline, "edit pipeline"))
 
 	var spec bool
+	var diff bool
 	listPipeline := &cobra.Command{
 		Use:   "{{alias}} [<pipeline>]",
 		Short: "Return info about all pipelines.",
-		Long:  "Return info about all pipelines.",
+		Long: `Return info about all pipelines.
+
+If --diff is passed along with a pipeline and --history, the changes between
+each of the pipeline's versions and the one before it are printed instead.`,
+		Example: `
+# Return info about all pipelines
+$ {{alias}}
+
+# Return all of the versions of pipeline "foo"
+$ {{alias}} foo --history all
+
+# Show how the last 3 versions of pipeline "foo" differ from the ones before them
+$ {{alias}} foo --history 3 --diff`,
 		Run: cmdutil.RunBoundedArgs(0, 1, func(args []string) error {
 			// validate flags
 			if raw && spec {
@@ -758,6 +1088,9 @@ All jobs created by a pipeline will create commits in the pipeline's output repo
 			} else if !raw && !spec && output != "" {
 				cmdutil.ErrorAndExit("cannot set --output (-o) without --raw or --spec")
 			}
+			if diff && (len(args) == 0 || history == "none" || raw || spec) {
+				return errors.Errorf("--diff must be passed a pipeline and --history, and not --raw or --spec")
+			}
 			history, err := cmdutil.ParseHistory(history)
 			if err != nil {
 				return errors.Wrapf(err, "error parsing history flag")
@@ -777,6 +1110,17 @@ All jobs created by a pipeline will create commits in the pipeline's output repo
 			if err != nil {
 				return err
 			}
+			if diff {
+				// pipelineInfos are ordered from newest to oldest
+				for i := 0; i+1 < len(pipelineInfos); i++ {
+					if err := pretty.PrintPipelineSpecDiff(os.Stdout,
+						pipelineInfos[i+1].Version, ppsutil.PipelineReqFromInfo(pipelineInfos[i+1]),
+						pipelineInfos[i].Version, ppsutil.PipelineReqFromInfo(pipelineInfos[i])); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
 			if raw {
 				e := encoder(output)
 				for _, pipelineInfo := range pipelineInfos {
@@ -805,6 +1149,7 @@ All jobs created by a pipeline will create commits in the pipeline's output repo
 	listPipeline.Flags().AddFlagSet(outputFlags)
 	listPipeline.Flags().AddFlagSet(fullTimestampsFlags)
 	listPipeline.Flags().StringVar(&history, "history", "none", "Return revision history for pipelines.")
+	listPipeline.Flags().BoolVar(&diff, "diff", false, "Print the changes between each returned version of the pipeline and the one before it.")
 	commands = append(commands, cmdutil.CreateAlias(listPipeline, "list pipeline"))
 
 	var (
@@ -994,6 +1339,100 @@ All jobs created by a pipeline will create commits in the pipeline's output repo
 	}
 	commands = append(commands, cmdutil.CreateAlias(listSecret, "list secret"))
 
+	var notifierPipeline string
+	var notifierEvents []string
+	var notifierSecret string
+	var updateNotifier bool
+	createNotifier := &cobra.Command{
+		Use:   "{{alias}} <name> <url>",
+		Short: "Send job and pipeline events to a webhook.",
+		Long: `Send job and pipeline events to a webhook.
+
+Pachyderm POSTs a JSON description of each event to <url>, retrying with
+backoff until the endpoint responds with a 2xx status. The event's type is in
+the X-Pachyderm-Event header, and a unique delivery ID, which is the same for
+every retry of the same event, is in the X-Pachyderm-Delivery header. If
+--secret is set, the X-Pachyderm-Signature header holds "sha256=" followed by
+the hex-encoded HMAC-SHA256 of the request body, keyed with the secret.
+<url> can't point inside the cluster, e.g. at a service or a private address.
+
+Events: ` + notifierEventNames() + `
+By default a notifier is sent every kind of event, for every pipeline.`,
+		Example: `
+# Get told about every failed job
+$ {{alias}} failures https://example.com/hook --event job_failed
+
+# Get told about everything that happens to pipeline "foo"
+$ {{alias}} foo-events https://example.com/hook --pipeline foo --secret s3cr3t`,
+		Run: cmdutil.RunFixedArgs(2, func(args []string) error {
+			events, err := parseNotifierEvents(notifierEvents)
+			if err != nil {
+				return err
+			}
+			client, err := pachdclient.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+			return client.CreateNotifier(args[0], args[1], notifierPipeline, events, notifierSecret, updateNotifier)
+		}),
+	}
+	createNotifier.Flags().StringVarP(&notifierPipeline, "pipeline", "p", "", "Only send events for this pipeline (admins may leave this unset to get events for every pipeline).")
+	createNotifier.Flags().StringSliceVarP(&notifierEvents, "event", "e", []string{}, "Only send these events (may be repeated or comma-separated).")
+	createNotifier.Flags().StringVar(&notifierSecret, "secret", "", "Sign each request with this secret.")
+	createNotifier.Flags().BoolVar(&updateNotifier, "update", false, "Replace an existing notifier with the same name.")
+	commands = append(commands, cmdutil.CreateAlias(createNotifier, "create notifier"))
+
+	listNotifier := &cobra.Command{
+		Short: "Return info about notifiers.",
+		Long:  "Return info about notifiers. Their secrets aren't shown.",
+		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
+			client, err := pachdclient.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+			notifiers, err := client.ListNotifier(notifierPipeline)
+			if err != nil {
+				return err
+			}
+			if raw {
+				e := encoder(output)
+				for _, notifier := range notifiers {
+					if err := e.EncodeProto(notifier); err != nil {
+						return err
+					}
+				}
+				return nil
+			} else if output != "" {
+				cmdutil.ErrorAndExit("cannot set --output (-o) without --raw")
+			}
+			writer := tabwriter.NewWriter(os.Stdout, pretty.NotifierHeader)
+			for _, notifier := range notifiers {
+				pretty.PrintNotifier(writer, notifier)
+			}
+			return writer.Flush()
+		}),
+	}
+	listNotifier.Flags().StringVarP(&notifierPipeline, "pipeline", "p", "", "Only list notifiers for this pipeline.")
+	listNotifier.Flags().AddFlagSet(outputFlags)
+	commands = append(commands, cmdutil.CreateAlias(listNotifier, "list notifier"))
+
+	deleteNotifier := &cobra.Command{
+		Use:   "{{alias}} <name>",
+		Short: "Delete a notifier.",
+		Long:  "Delete a notifier.",
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			client, err := pachdclient.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+			return client.DeleteNotifier(args[0])
+		}),
+	}
+	commands = append(commands, cmdutil.CreateAlias(deleteNotifier, "delete notifier"))
+
 	var memory string
 	garbageCollect := &cobra.Command{
 		Short: "Garbage collect unused data.",
@@ -1039,6 +1478,30 @@ you can increase the amount of memory used for the bloom filters with the
 	return commands
 }
 
+// notifierEventNames returns the names of the events that notifiers may
+// subscribe to, as they're given to 'create notifier --event'.
+func notifierEventNames() string {
+	var names []string
+	for i := 0; i < len(ppsclient.NotifierEvent_name); i++ {
+		names = append(names, pretty.NotifierEventName(ppsclient.NotifierEvent(i)))
+	}
+	return strings.Join(names, ", ")
+}
+
+// parseNotifierEvents converts event names like "job_failed" into
+// NotifierEvents.
+func parseNotifierEvents(names []string) ([]ppsclient.NotifierEvent, error) {
+	var events []ppsclient.NotifierEvent
+	for _, name := range names {
+		value, ok := ppsclient.NotifierEvent_value["NOTIFY_"+strings.ToUpper(strings.TrimSpace(name))]
+		if !ok {
+			return nil, errors.Errorf("unknown event %q (must be one of: %s)", name, notifierEventNames())
+		}
+		events = append(events, ppsclient.NotifierEvent(value))
+	}
+	return events, nil
+}
+
 func pipelineHelper(reprocess bool, build bool, pushImages bool, registry string, username string, pipelinePath string, update bool) error {
 	pipelineReader, err := ppsutil.NewPipelineManifestReader(pipelinePath)
 	if err != nil {
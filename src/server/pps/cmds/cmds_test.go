@@ -25,9 +25,13 @@
 package cmds
 
 import (
+	"io/ioutil"
+	"os"
 	"testing"
 
+	pachdclient "github.com/pachyderm/pachyderm/src/client"
 	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	ppsclient "github.com/pachyderm/pachyderm/src/client/pps"
 	tu "github.com/pachyderm/pachyderm/src/server/pkg/testutil"
 )
 
@@ -483,6 +487,162 @@ func TestEditPipeline(t *testing.T) {
 		`).Run())
 }
 
+func TestRunPipelineLocal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestRunPipelineLocal")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	// The pipeline is never created, so its output only shows up locally and
+	// in the scratch branch
+	require.NoError(t, tu.BashCmd(`
+		yes | pachctl delete all
+		pachctl create repo input
+		echo foo | pachctl put file input@master:/foo
+		echo bar | pachctl put file input@master:/bar
+		cat >{{.dir}}/spec.yaml <<EOF
+		pipeline:
+		  name: local
+		input:
+		  pfs:
+		    glob: /*
+		    repo: input
+		transform:
+		  cmd: [ /bin/bash ]
+		  env:
+		    SUFFIX: "-local"
+		  stdin:
+		    - "for f in pfs/input/*; do cp \$f pfs/out/\$(basename \$f)\$SUFFIX; done"
+		    - "echo \$input >>pfs/out/inputs"
+		EOF
+		pachctl run pipeline-local {{.dir}}/spec.yaml --out-dir {{.dir}}/out --branch scratch@debug
+		cat {{.dir}}/out/foo-local | match foo
+		cat {{.dir}}/out/bar-local | match bar
+		cat {{.dir}}/out/inputs | match "pfs/input/foo"
+		cat {{.dir}}/out/inputs | match "pfs/input/bar"
+		pachctl get file scratch@debug:/foo-local | match foo
+		( pachctl inspect pipeline local 2>&1 || true ) | match "not found"
+
+		# Running into a non-empty directory fails
+		( pachctl run pipeline-local {{.dir}}/spec.yaml --out-dir {{.dir}}/out 2>&1 || true ) \
+		  | match "is not empty"
+		`,
+		"dir", dir,
+	).Run())
+}
+
+func TestValidateLocalPipeline(t *testing.T) {
+	request := func(transform *ppsclient.Transform) *ppsclient.CreatePipelineRequest {
+		transform.Cmd = []string{"bash"}
+		return &ppsclient.CreatePipelineRequest{
+			Pipeline:  pachdclient.NewPipeline("local"),
+			Transform: transform,
+			Input:     pachdclient.NewPFSInput("input", "/*"),
+		}
+	}
+	require.NoError(t, validateLocalPipeline(request(&ppsclient.Transform{})))
+
+	// Transforms that wouldn't run the way a worker runs them are refused
+	err := validateLocalPipeline(request(&ppsclient.Transform{
+		Secrets: []*ppsclient.SecretMount{{Name: "secret", EnvVar: "SECRET", Key: "key"}},
+	}))
+	require.YesError(t, err)
+	require.Matches(t, "secrets", err.Error())
+	err = validateLocalPipeline(request(&ppsclient.Transform{WorkingDir: "/app"}))
+	require.YesError(t, err)
+	require.Matches(t, "working_dir", err.Error())
+	err = validateLocalPipeline(request(&ppsclient.Transform{ErrCmd: []string{"true"}}))
+	require.YesError(t, err)
+	require.Matches(t, "err_cmd", err.Error())
+}
+
+func TestInspectPipelineUsage(t *testing.T) {
+	require.NoError(t, tu.BashCmd(`
+		yes | pachctl delete all
+		pachctl create repo data
+		echo foo | pachctl put file data@master:/foo
+		pachctl create pipeline <<EOF
+		  pipeline:
+		    name: my-pipeline
+		  input:
+		    pfs:
+		      glob: /*
+		      repo: data
+		  transform:
+		    cmd: [ /bin/bash ]
+		    stdin:
+		      - "cp /pfs/data/* /pfs/out"
+		EOF
+		pachctl flush commit data@master
+		pachctl inspect pipeline my-pipeline --usage --since 30d \
+		  | match "Jobs\s+1" \
+		  | match "Datums Processed\s+1" \
+		  | match "CPU Time"
+		pachctl inspect pipeline my-pipeline --usage --since 30d --csv \
+		  | match "^pipeline,since,jobs,datums_processed," \
+		  | match "^my-pipeline,[^,]+,1,1,"
+		( pachctl inspect pipeline my-pipeline --csv 2>&1 || true ) \
+		  | match "without --usage"
+		`).Run())
+}
+
+func TestLintPipeline(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestLintPipeline")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	// 'second' reads from 'first', which doesn't exist yet but is created by
+	// the same file, so only 'first's problems are reported
+	require.NoError(t, tu.BashCmd(`
+		yes | pachctl delete all
+		pachctl create repo data
+		echo foo | pachctl put file data@master:/foo
+		cat >{{.dir}}/pipelines.yaml <<EOF
+		pipeline:
+		  name: first
+		input:
+		  cross:
+		  - pfs:
+		      glob: /*.csv
+		      repo: data
+		  - pfs:
+		      glob: /*
+		      repo: missing
+		transform:
+		  cmd: [ /bin/bash ]
+		resource_limits:
+		  memory: lots
+		---
+		pipeline:
+		  name: second
+		input:
+		  pfs:
+		    glob: /*
+		    repo: first
+		transform:
+		  cmd: [ /bin/bash ]
+		EOF
+		( pachctl lint pipeline -f {{.dir}}/pipelines.yaml 2>&1 || true ) \
+		  | match 'first: warning: glob "/\*.csv" matches no files in data@master' \
+		  | match 'first: error: input repo "missing" does not exist' \
+		  | match 'first: error: invalid resource_limits' \
+		  | match "found 2 error" \
+		  | match -v "second:"
+		( pachctl inspect pipeline first 2>&1 || true ) | match "not found"
+
+		cat >{{.dir}}/ok.yaml <<EOF
+		pipeline:
+		  name: ok
+		input:
+		  pfs:
+		    glob: /*
+		    repo: data
+		transform:
+		  cmd: [ /bin/bash ]
+		EOF
+		pachctl lint pipeline -f {{.dir}}/ok.yaml
+		`,
+		"dir", dir,
+	).Run())
+}
+
 // func TestPushImages(t *testing.T) {
 // 	if testing.Short() {
 // 		t.Skip("Skipping integration tests in short mode")
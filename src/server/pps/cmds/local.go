@@ -0,0 +1,382 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+
+	pachdclient "github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+	ppsclient "github.com/pachyderm/pachyderm/src/client/pps"
+	pfsserver "github.com/pachyderm/pachyderm/src/server/pfs"
+	"github.com/pachyderm/pachyderm/src/server/pkg/ppsutil"
+	filesync "github.com/pachyderm/pachyderm/src/server/pkg/sync"
+	"github.com/pachyderm/pachyderm/src/server/worker"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+)
+
+// localJobID is the job ID that user code run by 'run pipeline-local' sees
+const localJobID = "local"
+
+// localPipeline runs a pipeline's transform on this machine, against the
+// data in a cluster
+type localPipeline struct {
+	client  *pachdclient.APIClient
+	request *ppsclient.CreatePipelineRequest
+	// scratch is the temporary directory that each datum is processed in. The
+	// datum's inputs are downloaded to scratch/pfs/<input> and its output is
+	// written to scratch/pfs/out.
+	scratch string
+	// outDir is the directory that the outputs of all datums are merged into
+	outDir string
+}
+
+// localInput returns a copy of 'input' with the defaults that CreatePipeline
+// would fill in, and with each input read from the current head of its
+// branch (unless a commit was given), so that every datum sees the same data.
+func localInput(pachClient *pachdclient.APIClient, pipelineName string, input *ppsclient.Input) (*ppsclient.Input, error) {
+	input = proto.Clone(input).(*ppsclient.Input)
+	var err error
+	resolve := func(repo string, commit *string) {
+		if err != nil {
+			return
+		}
+		var commitInfo *pfs.CommitInfo
+		if commitInfo, err = pachClient.InspectCommit(repo, *commit); err == nil {
+			*commit = commitInfo.Commit.ID
+		}
+	}
+	ppsclient.VisitInput(input, func(input *ppsclient.Input) {
+		switch {
+		case input.Pfs != nil:
+			if input.Pfs.Branch == "" {
+				input.Pfs.Branch = "master"
+			}
+			if input.Pfs.Name == "" {
+				input.Pfs.Name = input.Pfs.Repo
+			}
+			if input.Pfs.Commit == "" {
+				input.Pfs.Commit = input.Pfs.Branch
+			}
+			if input.Pfs.S3 {
+				err = errors.Errorf("input %q is an S3 input, which can't be run locally", input.Pfs.Name)
+			}
+			resolve(input.Pfs.Repo, &input.Pfs.Commit)
+		case input.Cron != nil:
+			if input.Cron.Repo == "" {
+				input.Cron.Repo = fmt.Sprintf("%s_%s", pipelineName, input.Cron.Name)
+			}
+			if input.Cron.Commit == "" {
+				input.Cron.Commit = "master"
+			}
+			resolve(input.Cron.Repo, &input.Cron.Commit)
+		case input.Webhook != nil:
+			if input.Webhook.Repo == "" {
+				input.Webhook.Repo = fmt.Sprintf("%s_%s", pipelineName, input.Webhook.Name)
+			}
+			if input.Webhook.Commit == "" {
+				input.Webhook.Commit = "master"
+			}
+			resolve(input.Webhook.Repo, &input.Webhook.Commit)
+		case input.ObjectStore != nil:
+			if input.ObjectStore.Repo == "" {
+				input.ObjectStore.Repo = fmt.Sprintf("%s_%s", pipelineName, input.ObjectStore.Name)
+			}
+			if input.ObjectStore.Commit == "" {
+				input.ObjectStore.Commit = "master"
+			}
+			resolve(input.ObjectStore.Repo, &input.ObjectStore.Commit)
+		case input.Git != nil:
+			err = errors.Errorf("git inputs can't be run locally")
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return input, nil
+}
+
+// validateLocalPipeline returns an error if 'request' describes a pipeline
+// that can't be run locally
+func validateLocalPipeline(request *ppsclient.CreatePipelineRequest) error {
+	switch {
+	case request.Pipeline == nil || request.Pipeline.Name == "":
+		return errors.Errorf("pipeline spec must specify a name")
+	case request.Transform == nil || len(request.Transform.Cmd) == 0:
+		return errors.Errorf("pipeline spec must specify a transform with a cmd")
+	case request.Input == nil:
+		return errors.Errorf("pipeline spec must specify an input")
+	case request.Service != nil || request.Spout != nil:
+		return errors.Errorf("services and spouts can't be run locally")
+	case request.S3Out:
+		return errors.Errorf("pipelines that output via Pachyderm's S3 gateway can't be run locally")
+	// The rest would run, but not the way a worker runs them, so the local
+	// output could silently differ from the pipeline's
+	case len(request.Transform.Secrets) > 0:
+		return errors.Errorf("pipelines with secrets can't be run locally, as the secrets aren't available outside the cluster")
+	case request.Transform.WorkingDir != "":
+		return errors.Errorf("pipelines with a working_dir can't be run locally, as the cmd runs in the directory that the inputs are downloaded to")
+	case len(request.Transform.ErrCmd) > 0 || len(request.Transform.ErrStdin) > 0:
+		return errors.Errorf("pipelines with an err_cmd can't be run locally, as a failed datum fails the run")
+	}
+	return nil
+}
+
+// userCodeEnv returns the environment that user code processing 'data' runs
+// in. It matches the environment of the pipeline's workers, except that
+// inputs are found under the scratch directory rather than /pfs.
+func (p *localPipeline) userCodeEnv(outputCommitID string, data []*worker.Input) []string {
+	result := os.Environ()
+	var keys []string
+	for key := range p.request.Transform.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		result = append(result, fmt.Sprintf("%s=%s", key, p.request.Transform.Env[key]))
+	}
+	result = append(result, fmt.Sprintf("%s=%s", pachdclient.PPSPipelineNameEnv, p.request.Pipeline.Name))
+	return append(result, worker.InputEnv(filepath.Join(p.scratch, pachdclient.PPSInputPrefix), localJobID, outputCommitID, data)...)
+}
+
+// processDatum downloads the inputs in 'data' to the scratch directory, runs
+// the transform on them, and appends the output to p.outDir.
+func (p *localPipeline) processDatum(outputCommitID string, data []*worker.Input) error {
+	pfsRoot := filepath.Join(p.scratch, pachdclient.PPSInputPrefix)
+	if err := os.RemoveAll(pfsRoot); err != nil {
+		return err
+	}
+	outPath := filepath.Join(pfsRoot, "out")
+	if err := os.MkdirAll(outPath, 0777); err != nil {
+		return err
+	}
+	puller := filesync.NewPuller()
+	for _, input := range data {
+		file := input.FileInfo.File
+		root := filepath.Join(pfsRoot, input.Name, file.Path)
+		if err := puller.Pull(p.client, root, file.Commit.Repo.Name, file.Commit.ID, file.Path, false, input.EmptyFiles, 10, nil, ""); err != nil {
+			return err
+		}
+	}
+	if _, err := puller.CleanUp(); err != nil {
+		return err
+	}
+	if err := p.runUserCode(p.userCodeEnv(outputCommitID, data)); err != nil {
+		return err
+	}
+	return appendDir(outPath, p.outDir)
+}
+
+// runUserCode runs the transform the way a worker does: with the same stdin,
+// datum timeout and accepted return codes.
+func (p *localPipeline) runUserCode(environ []string) error {
+	transform := p.request.Transform
+	ctx := context.Background()
+	if p.request.DatumTimeout != nil {
+		datumTimeout, err := types.DurationFromProto(p.request.DatumTimeout)
+		if err != nil {
+			return err
+		}
+		var cancel func()
+		ctx, cancel = context.WithTimeout(ctx, datumTimeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, transform.Cmd[0], transform.Cmd[1:]...)
+	if transform.Stdin != nil {
+		cmd.Stdin = strings.NewReader(strings.Join(transform.Stdin, "\n") + "\n")
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = environ
+	// Run in the scratch directory so that relative paths like "pfs/<input>"
+	// find the datum's inputs. Absolute paths like "/pfs/<input>" in the cmd
+	// or stdin still refer to this machine's /pfs, not to the scratch
+	// directory.
+	cmd.Dir = p.scratch
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return errors.Errorf("datum timed out after %v", p.request.DatumTimeout)
+	}
+	if err != nil && !strings.Contains(err.Error(), "broken pipe") {
+		if exiterr, ok := err.(*exec.ExitError); ok {
+			if status, ok := exiterr.Sys().(syscall.WaitStatus); ok {
+				for _, returnCode := range transform.AcceptReturnCode {
+					if int(returnCode) == status.ExitStatus() {
+						return nil
+					}
+				}
+			}
+		}
+		return errors.Wrapf(err, "error running user code")
+	}
+	return nil
+}
+
+// appendDir appends the files under 'src' to the files at the same paths
+// under 'dst', the way PFS merges the outputs of a job's datums.
+func appendDir(src string, dst string) error {
+	return filepath.Walk(src, func(filePath string, info os.FileInfo, err error) (retErr error) {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(src, filePath)
+		if err != nil {
+			return err
+		}
+		dstPath := filepath.Join(dst, relPath)
+		if info.IsDir() {
+			return os.MkdirAll(dstPath, 0777)
+		}
+		r, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := r.Close(); err != nil && retErr == nil {
+				retErr = err
+			}
+		}()
+		w, err := os.OpenFile(dstPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := w.Close(); err != nil && retErr == nil {
+				retErr = err
+			}
+		}()
+		_, err = io.Copy(w, r)
+		return err
+	})
+}
+
+// datumString describes the inputs of a datum, for progress messages
+func datumString(data []*worker.Input) string {
+	var files []string
+	for _, input := range data {
+		files = append(files, path.Join(input.Name, input.FileInfo.File.Path))
+	}
+	return strings.Join(files, ", ")
+}
+
+// runPipelineLocal runs the pipeline in 'request' on this machine. Its output
+// is written to 'outDir' if it's set, and committed to 'branch' if that's set.
+func runPipelineLocal(pachClient *pachdclient.APIClient, request *ppsclient.CreatePipelineRequest, outDir string, branch *pfs.Branch) (retErr error) {
+	if err := validateLocalPipeline(request); err != nil {
+		return err
+	}
+	input, err := localInput(pachClient, request.Pipeline.Name, request.Input)
+	if err != nil {
+		return err
+	}
+	scratch, err := ioutil.TempDir("", "pachyderm-pipeline-local")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.RemoveAll(scratch); err != nil && retErr == nil {
+			retErr = err
+		}
+	}()
+	if outDir == "" {
+		outDir = filepath.Join(scratch, "out")
+	} else if entries, err := ioutil.ReadDir(outDir); err == nil && len(entries) > 0 {
+		return errors.Errorf("output directory %q is not empty", outDir)
+	}
+	if err := os.MkdirAll(outDir, 0777); err != nil {
+		return err
+	}
+	p := &localPipeline{
+		client:  pachClient,
+		request: request,
+		scratch: filepath.Join(scratch, "root"),
+		outDir:  outDir,
+	}
+
+	var commit *pfs.Commit
+	outputCommitID := localJobID
+	if branch != nil {
+		if _, err := pachClient.InspectRepo(branch.Repo.Name); err != nil {
+			if !pfsserver.IsRepoNotFoundErr(err) {
+				return err
+			}
+			if err := pachClient.CreateRepo(branch.Repo.Name); err != nil {
+				return err
+			}
+		}
+		if commit, err = pachClient.StartCommit(branch.Repo.Name, branch.Name); err != nil {
+			return err
+		}
+		defer func() {
+			if retErr != nil {
+				if err := pachClient.DeleteCommit(commit.Repo.Name, commit.ID); err != nil {
+					fmt.Fprintf(os.Stderr, "could not delete output commit %s@%s: %v\n", commit.Repo.Name, commit.ID, err)
+				}
+			}
+		}()
+		// The branch holds the output of this run only
+		if err := pachClient.DeleteFile(commit.Repo.Name, commit.ID, ""); err != nil {
+			return err
+		}
+		outputCommitID = commit.ID
+	}
+
+	di, err := worker.NewDatumIterator(pachClient, input)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < di.Len(); i++ {
+		data := di.DatumN(i)
+		fmt.Fprintf(os.Stderr, "processing datum %d/%d (%s)\n", i+1, di.Len(), datumString(data))
+		if err := p.processDatum(outputCommitID, data); err != nil {
+			return errors.Wrapf(err, "datum %d (%s) failed", i+1, datumString(data))
+		}
+	}
+	fmt.Fprintf(os.Stderr, "processed %d datums\n", di.Len())
+	if commit != nil {
+		if err := filesync.Push(pachClient, outDir, commit, false); err != nil {
+			return err
+		}
+		if err := pachClient.FinishCommit(commit.Repo.Name, commit.ID); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "output committed to %s@%s\n", commit.Repo.Name, commit.ID)
+	}
+	if outDir != filepath.Join(scratch, "out") {
+		fmt.Fprintf(os.Stderr, "output written to %s\n", outDir)
+	}
+	return nil
+}
+
+// readLocalPipeline reads the single pipeline spec in 'pipelinePath'
+func readLocalPipeline(pipelinePath string) (*ppsclient.CreatePipelineRequest, error) {
+	pipelineReader, err := ppsutil.NewPipelineManifestReader(pipelinePath)
+	if err != nil {
+		return nil, err
+	}
+	request, err := pipelineReader.NextCreatePipelineRequest()
+	if err != nil {
+		if err == io.EOF {
+			return nil, errors.Errorf("no pipeline spec in %q", pipelinePath)
+		}
+		return nil, err
+	}
+	if _, err := pipelineReader.NextCreatePipelineRequest(); err != io.EOF {
+		if err != nil {
+			return nil, err
+		}
+		return nil, errors.Errorf("%q contains more than one pipeline spec; only one can be run locally at a time", pipelinePath)
+	}
+	return request, nil
+}
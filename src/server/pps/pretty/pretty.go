@@ -2,15 +2,19 @@ package pretty
 
 import (
 	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/docker/go-units"
 	"github.com/fatih/color"
+	"github.com/gogo/protobuf/jsonpb"
 	"github.com/gogo/protobuf/types"
 	"github.com/juju/ansiterm"
 	"github.com/pachyderm/pachyderm/src/client"
@@ -27,10 +31,22 @@ const (
 	JobHeader = "ID\tPIPELINE\tSTARTED\tDURATION\tRESTART\tPROGRESS\tDL\tUL\tSTATE\t\n"
 	// DatumHeader is the header for datums
 	DatumHeader = "ID\tSTATUS\tTIME\t\n"
+	// DatumInputHeader is the header for the datums of an input (rather than a
+	// job), which have no status yet
+	DatumInputHeader = "ID\tFILES\tSIZE\t\n"
+	// DeadLetterHeader is the header for a pipeline's dead-lettered datums
+	DeadLetterHeader = "ID\tJOB\tFAILED\tEXIT CODE\tERROR\t\n"
 	// SecretHeader is the header for secrets
 	SecretHeader = "NAME\tTYPE\tCREATED\t\n"
+	// NotifierHeader is the header for notifiers
+	NotifierHeader = "NAME\tPIPELINE\tEVENTS\tURL\t\n"
 	// jobReasonLen is the amount of the job reason that we print
 	jobReasonLen = 25
+	// datumFilesLen is the amount of a datum's list of files that we print
+	datumFilesLen = 80
+	// deadLetterErrorLen is the amount of a dead-lettered datum's error that we
+	// print
+	deadLetterErrorLen = 60
 )
 
 func safeTrim(s string, l int) string {
@@ -58,7 +74,7 @@ func PrintJobInfo(w io.Writer, jobInfo *ppsclient.JobInfo, fullTimestamps bool)
 	fmt.Fprintf(w, "%s\t", Progress(jobInfo))
 	fmt.Fprintf(w, "%s\t", pretty.Size(jobInfo.Stats.DownloadBytes))
 	fmt.Fprintf(w, "%s\t", pretty.Size(jobInfo.Stats.UploadBytes))
-	if jobInfo.State == ppsclient.JobState_JOB_FAILURE {
+	if jobInfo.State == ppsclient.JobState_JOB_FAILURE || jobInfo.State == ppsclient.JobState_JOB_QUEUED {
 		fmt.Fprintf(w, "%s: %s\t", JobState(jobInfo.State), safeTrim(jobInfo.Reason, jobReasonLen))
 	} else {
 		fmt.Fprintf(w, "%s\t", JobState(jobInfo.State))
@@ -138,6 +154,12 @@ Data Uploaded: {{prettySize .Stats.UploadBytes}}
 Download Time: {{prettyDuration .Stats.DownloadTime}}
 Process Time: {{prettyDuration .Stats.ProcessTime}}
 Upload Time: {{prettyDuration .Stats.UploadTime}}
+CPU Time: {{prettyDuration .Stats.CpuTime}}{{if .Stats.GpuTime}}
+GPU Time: {{prettyDuration .Stats.GpuTime}}{{end}}
+Peak Memory: {{prettySize .Stats.PeakMemoryBytes}}
+Average Memory: {{prettySize .Stats.AverageMemoryBytes}}
+Data Read: {{prettySize .Stats.ReadBytes}}
+Data Written: {{prettySize .Stats.WriteBytes}}
 Datum Timeout: {{.DatumTimeout}}
 Job Timeout: {{.JobTimeout}}
 Worker Status:
@@ -196,7 +218,9 @@ Created: {{prettyAgo .CreatedAt}} {{end}}
 State: {{pipelineState .State}}
 Stopped: {{ .Stopped }}
 Reason: {{.Reason}}
-Parallelism Spec: {{.ParallelismSpec}}
+Parallelism Spec: {{.ParallelismSpec}}{{if .Priority}}
+Priority: {{.Priority}}{{end}}{{if .MaxConcurrentJobs}}
+Max Concurrent Jobs: {{.MaxConcurrentJobs}}{{end}}
 {{ if .ResourceRequests }}ResourceRequests:
   CPU: {{ .ResourceRequests.Cpu }}
   Memory: {{ .ResourceRequests.Memory }} {{end}}
@@ -207,14 +231,17 @@ Parallelism Spec: {{.ParallelismSpec}}
     Type: {{ .ResourceLimits.Gpu.Type }} 
     Number: {{ .ResourceLimits.Gpu.Number }} {{end}} {{end}}
 Datum Timeout: {{.DatumTimeout}}
-Job Timeout: {{.JobTimeout}}
+Job Timeout: {{.JobTimeout}}{{if .DatumCache}}
+Datum Cache: enabled{{end}}
 Input:
 {{pipelineInput .PipelineInfo}}
 {{ if .GithookURL }}Githook URL: {{.GithookURL}} {{end}}
+{{ if .WebhookURL }}Webhook URL: {{.WebhookURL}}/<input> {{end}}
 Output Branch: {{.OutputBranch}}
 Transform:
 {{prettyTransform .Transform}}
-{{ if .Egress }}Egress: {{.Egress.URL}} {{end}}
+{{ if .Egress }}Egress: {{.Egress.URL}}{{if .Egress.Incremental}} (incremental){{end}} {{end}}{{if .LastEgressCommit}}
+Last Egress Commit: {{.LastEgressCommit.ID}} {{end}}
 {{if .RecentError}} Recent Error: {{.RecentError}} {{end}}
 Job Counts:
 {{jobCounts .JobCounts}}
@@ -229,6 +256,89 @@ Job Counts:
 	return nil
 }
 
+// PrintDetailedPipelineUsage pretty-prints the resources used by a
+// pipeline's jobs.
+func PrintDetailedPipelineUsage(w io.Writer, usage *ppsclient.PipelineUsage, fullTimestamps bool) {
+	fmt.Fprintf(w, "Pipeline\t%s\n", usage.Pipeline.Name)
+	if usage.Since == nil {
+		fmt.Fprintf(w, "Since\t-\n")
+	} else if fullTimestamps {
+		fmt.Fprintf(w, "Since\t%s\n", usage.Since.String())
+	} else {
+		fmt.Fprintf(w, "Since\t%s\n", pretty.Ago(usage.Since))
+	}
+	fmt.Fprintf(w, "Jobs\t%d\n", usage.Jobs)
+	fmt.Fprintf(w, "Datums Processed\t%d\n", usage.DataProcessed)
+	fmt.Fprintf(w, "Process Time\t%s\n", usageDuration(usage.Stats.ProcessTime))
+	fmt.Fprintf(w, "CPU Time\t%s\n", usageDuration(usage.Stats.CpuTime))
+	fmt.Fprintf(w, "GPU Time\t%s\n", usageDuration(usage.Stats.GpuTime))
+	fmt.Fprintf(w, "Peak Memory\t%s\n", pretty.Size(usage.Stats.PeakMemoryBytes))
+	fmt.Fprintf(w, "Average Memory\t%s\n", pretty.Size(usage.Stats.AverageMemoryBytes))
+	fmt.Fprintf(w, "Data Read\t%s\n", pretty.Size(usage.Stats.ReadBytes))
+	fmt.Fprintf(w, "Data Written\t%s\n", pretty.Size(usage.Stats.WriteBytes))
+	fmt.Fprintf(w, "Data Downloaded\t%s\n", pretty.Size(usage.Stats.DownloadBytes))
+	fmt.Fprintf(w, "Data Uploaded\t%s\n", pretty.Size(usage.Stats.UploadBytes))
+}
+
+// pipelineUsageCSVHeader is the header of the CSV written by
+// PrintPipelineUsageCSV
+var pipelineUsageCSVHeader = []string{
+	"pipeline", "since", "jobs", "datums_processed", "process_seconds",
+	"cpu_seconds", "gpu_seconds", "peak_memory_bytes", "average_memory_bytes",
+	"read_bytes", "write_bytes", "download_bytes", "upload_bytes",
+}
+
+// PrintPipelineUsageCSV writes the resources used by pipelines' jobs as CSV,
+// with a header row and a row per pipeline. Times are in seconds, so that
+// the CSV can be imported into a spreadsheet.
+func PrintPipelineUsageCSV(w io.Writer, usages ...*ppsclient.PipelineUsage) error {
+	csvWriter := csv.NewWriter(w)
+	if err := csvWriter.Write(pipelineUsageCSVHeader); err != nil {
+		return err
+	}
+	for _, usage := range usages {
+		var since string
+		if usage.Since != nil {
+			t, err := types.TimestampFromProto(usage.Since)
+			if err != nil {
+				return err
+			}
+			since = t.Format(time.RFC3339)
+		}
+		if err := csvWriter.Write([]string{
+			usage.Pipeline.Name,
+			since,
+			strconv.FormatInt(usage.Jobs, 10),
+			strconv.FormatInt(usage.DataProcessed, 10),
+			usageSeconds(usage.Stats.ProcessTime),
+			usageSeconds(usage.Stats.CpuTime),
+			usageSeconds(usage.Stats.GpuTime),
+			strconv.FormatUint(usage.Stats.PeakMemoryBytes, 10),
+			strconv.FormatUint(usage.Stats.AverageMemoryBytes, 10),
+			strconv.FormatUint(usage.Stats.ReadBytes, 10),
+			strconv.FormatUint(usage.Stats.WriteBytes, 10),
+			strconv.FormatUint(usage.Stats.DownloadBytes, 10),
+			strconv.FormatUint(usage.Stats.UploadBytes, 10),
+		}); err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// usageDuration prints a duration of resource usage to the second, unlike
+// pretty.Duration, which only gives its order of magnitude
+func usageDuration(d *types.Duration) string {
+	duration, _ := types.DurationFromProto(d)
+	return duration.Round(time.Second).String()
+}
+
+func usageSeconds(d *types.Duration) string {
+	duration, _ := types.DurationFromProto(d)
+	return strconv.FormatFloat(duration.Seconds(), 'f', 3, 64)
+}
+
 // PrintDatumInfo pretty-prints file info.
 // If recurse is false and directory size is 0, display "-" instead
 // If fast is true and file size is 0, display "-" instead
@@ -241,6 +351,31 @@ func PrintDatumInfo(w io.Writer, datumInfo *ppsclient.DatumInfo) {
 	fmt.Fprintln(w)
 }
 
+// PrintDatumInputInfo pretty-prints info about a datum of an input, listing
+// the files in it rather than its status.
+func PrintDatumInputInfo(w io.Writer, datumInfo *ppsclient.DatumInfo) {
+	var files []string
+	var size uint64
+	for _, fileInfo := range datumInfo.Data {
+		files = append(files, fmt.Sprintf("%s:%s", fileInfo.File.Commit.Repo.Name, fileInfo.File.Path))
+		size += fileInfo.SizeBytes
+	}
+	fmt.Fprintf(w, "%s\t%s\t%s\t", datumInfo.Datum.ID, safeTrim(strings.Join(files, ", "), datumFilesLen), pretty.Size(size))
+	fmt.Fprintln(w)
+}
+
+// PrintDeadLetterDatum pretty-prints info about a dead-lettered datum.
+func PrintDeadLetterDatum(w io.Writer, deadLetter *ppsclient.DeadLetterDatum) {
+	exitCode := "-"
+	if deadLetter.ExitCode >= 0 {
+		exitCode = fmt.Sprint(deadLetter.ExitCode)
+	}
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t", deadLetter.DatumInfo.Datum.ID,
+		deadLetter.DatumInfo.Datum.Job.ID, pretty.Ago(deadLetter.Failed), exitCode,
+		safeTrim(strings.Replace(deadLetter.Error, "\n", " ", -1), deadLetterErrorLen))
+	fmt.Fprintln(w)
+}
+
 // PrintDetailedDatumInfo pretty-prints detailed info about a datum
 func PrintDetailedDatumInfo(w io.Writer, datumInfo *ppsclient.DatumInfo) {
 	fmt.Fprintf(w, "ID\t%s\n", datumInfo.Datum.ID)
@@ -278,6 +413,14 @@ func PrintDetailedDatumInfo(w io.Writer, datumInfo *ppsclient.DatumInfo) {
 		uploadTime = ul.String()
 	}
 	fmt.Fprintf(w, "Upload Time\t%s\n", uploadTime)
+	fmt.Fprintf(w, "CPU Time\t%s\n", usageDuration(datumInfo.Stats.CpuTime))
+	if datumInfo.Stats.GpuTime != nil {
+		fmt.Fprintf(w, "GPU Time\t%s\n", usageDuration(datumInfo.Stats.GpuTime))
+	}
+	fmt.Fprintf(w, "Peak Memory\t%s\n", pretty.Size(datumInfo.Stats.PeakMemoryBytes))
+	fmt.Fprintf(w, "Average Memory\t%s\n", pretty.Size(datumInfo.Stats.AverageMemoryBytes))
+	fmt.Fprintf(w, "Data Read\t%s\n", pretty.Size(datumInfo.Stats.ReadBytes))
+	fmt.Fprintf(w, "Data Written\t%s\n", pretty.Size(datumInfo.Stats.WriteBytes))
 
 	fmt.Fprintf(w, "PFS State:\n")
 	tw := ansiterm.NewTabWriter(w, 10, 1, 3, ' ', 0)
@@ -298,6 +441,29 @@ func PrintSecretInfo(w io.Writer, secretInfo *ppsclient.SecretInfo) {
 	fmt.Fprintf(w, "%s\t%s\t%s\t\n", secretInfo.Secret.Name, secretInfo.Type, pretty.Ago(secretInfo.CreationTimestamp))
 }
 
+// PrintNotifier pretty-prints a notifier.
+func PrintNotifier(w io.Writer, notifier *ppsclient.Notifier) {
+	pipeline := "all"
+	if notifier.Pipeline != nil {
+		pipeline = notifier.Pipeline.Name
+	}
+	events := "all"
+	if len(notifier.Events) > 0 {
+		var names []string
+		for _, event := range notifier.Events {
+			names = append(names, NotifierEventName(event))
+		}
+		events = strings.Join(names, ",")
+	}
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t\n", notifier.Name, pipeline, events, notifier.URL)
+}
+
+// NotifierEventName returns the name used for 'event' on the command line,
+// e.g. "job_failed".
+func NotifierEventName(event ppsclient.NotifierEvent) string {
+	return strings.ToLower(strings.TrimPrefix(event.String(), "NOTIFY_"))
+}
+
 // PrintFileHeader prints the header for a pfs file.
 func PrintFileHeader(w io.Writer) {
 	fmt.Fprintf(w, "  REPO\tCOMMIT\tPATH\t\n")
@@ -327,6 +493,8 @@ func JobState(jobState ppsclient.JobState) string {
 	switch jobState {
 	case ppsclient.JobState_JOB_STARTING:
 		return color.New(color.FgYellow).SprintFunc()("starting")
+	case ppsclient.JobState_JOB_QUEUED:
+		return color.New(color.FgYellow).SprintFunc()("queued")
 	case ppsclient.JobState_JOB_RUNNING:
 		return color.New(color.FgYellow).SprintFunc()("running")
 	case ppsclient.JobState_JOB_MERGING:
@@ -417,6 +585,85 @@ func prettyTransform(transform *ppsclient.Transform) (string, error) {
 	return pretty.UnescapeHTML(string(result)), nil
 }
 
+// PrintPipelineSpecDiff prints the lines that changed between two versions of
+// a pipeline's spec, with the removed lines prefixed by "-" and the added ones
+// by "+".
+func PrintPipelineSpecDiff(w io.Writer, oldVersion uint64, oldSpec *ppsclient.CreatePipelineRequest, newVersion uint64, newSpec *ppsclient.CreatePipelineRequest) error {
+	marshaler := &jsonpb.Marshaler{Indent: "  "}
+	oldJSON, err := marshaler.MarshalToString(oldSpec)
+	if err != nil {
+		return err
+	}
+	newJSON, err := marshaler.MarshalToString(newSpec)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "--- version %d\n+++ version %d\n", oldVersion, newVersion)
+	changed := false
+	lastChanged := true
+	for _, line := range diffLines(strings.Split(oldJSON, "\n"), strings.Split(newJSON, "\n")) {
+		if line[0] == ' ' {
+			lastChanged = false
+			continue
+		}
+		if !lastChanged && changed {
+			fmt.Fprintln(w, "...")
+		}
+		fmt.Fprintln(w, line)
+		changed, lastChanged = true, true
+	}
+	if !changed {
+		fmt.Fprintln(w, "(no changes)")
+	}
+	return nil
+}
+
+// diffLines returns the lines of 'a' and 'b' in order, prefixed with "-" if
+// they're only in 'a', "+" if they're only in 'b' and " " if they're in both,
+// based on the longest common subsequence of the two.
+func diffLines(a, b []string) []string {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and
+	// b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	var result []string
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			result = append(result, " "+a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			result = append(result, "-"+a[i])
+			i++
+		default:
+			result = append(result, "+"+b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		result = append(result, "-"+a[i])
+	}
+	for ; j < len(b); j++ {
+		result = append(result, "+"+b[j])
+	}
+	return result
+}
+
 // ShorthandInput renders a pps.Input as a short, readable string
 func ShorthandInput(input *ppsclient.Input) string {
 	switch {
@@ -436,6 +683,12 @@ func ShorthandInput(input *ppsclient.Input) string {
 			subInput = append(subInput, ShorthandInput(input))
 		}
 		return "(" + strings.Join(subInput, " ⋈ ") + ")"
+	case input.Group != nil:
+		var subInput []string
+		for _, input := range input.Group {
+			subInput = append(subInput, ShorthandInput(input))
+		}
+		return "group(" + strings.Join(subInput, ", ") + ")"
 	case input.Union != nil:
 		var subInput []string
 		for _, input := range input.Union {
@@ -444,6 +697,10 @@ func ShorthandInput(input *ppsclient.Input) string {
 		return "(" + strings.Join(subInput, " ∪ ") + ")"
 	case input.Cron != nil:
 		return fmt.Sprintf("%s:%s", input.Cron.Name, input.Cron.Spec)
+	case input.Webhook != nil:
+		return fmt.Sprintf("%s:webhook", input.Webhook.Name)
+	case input.ObjectStore != nil:
+		return fmt.Sprintf("%s:%s", input.ObjectStore.Name, input.ObjectStore.URL)
 	}
 	return ""
 }
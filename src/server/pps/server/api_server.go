@@ -29,9 +29,11 @@ import (
 	"github.com/pachyderm/pachyderm/src/server/pkg/ancestry"
 	"github.com/pachyderm/pachyderm/src/server/pkg/backoff"
 	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	"github.com/pachyderm/pachyderm/src/server/pkg/errutil"
 	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
 	"github.com/pachyderm/pachyderm/src/server/pkg/log"
 	"github.com/pachyderm/pachyderm/src/server/pkg/metrics"
+	"github.com/pachyderm/pachyderm/src/server/pkg/obj"
 	"github.com/pachyderm/pachyderm/src/server/pkg/ppsconsts"
 	"github.com/pachyderm/pachyderm/src/server/pkg/ppsdb"
 	"github.com/pachyderm/pachyderm/src/server/pkg/ppsutil"
@@ -43,6 +45,7 @@ import (
 	workerpkg "github.com/pachyderm/pachyderm/src/server/worker"
 
 	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
 	"github.com/gogo/protobuf/types"
 	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/robfig/cron"
@@ -105,6 +108,7 @@ type apiServer struct {
 	reporter              *metrics.Reporter
 	monitorCancelsMu      sync.Mutex
 	monitorCancels        map[string]func()
+	scheduler             *scheduler
 	workerUsesRoot        bool
 	workerGrpcPort        uint16
 	port                  uint16
@@ -113,6 +117,7 @@ type apiServer struct {
 	// collections
 	pipelines col.Collection
 	jobs      col.Collection
+	notifiers col.Collection
 }
 
 func merge(from, to map[string]bool) {
@@ -160,22 +165,89 @@ func validateNames(names map[string]bool, input *pps.Input) error {
 				return err
 			}
 		}
+	case input.Group != nil:
+		for _, input := range input.Group {
+			if err := validateNames(names, input); err != nil {
+				return err
+			}
+		}
 	case input.Git != nil:
 		if names[input.Git.Name] {
 			return errors.Errorf(`name "%s" was used more than once`, input.Git.Name)
 		}
 		names[input.Git.Name] = true
+	case input.Webhook != nil:
+		if names[input.Webhook.Name] {
+			return errors.Errorf(`name "%s" was used more than once`, input.Webhook.Name)
+		}
+		names[input.Webhook.Name] = true
+	case input.ObjectStore != nil:
+		if names[input.ObjectStore.Name] {
+			return errors.Errorf(`name "%s" was used more than once`, input.ObjectStore.Name)
+		}
+		names[input.ObjectStore.Name] = true
 	}
 	return nil
 }
 
 func (a *apiServer) validateInput(pachClient *client.APIClient, pipelineName string, input *pps.Input, job bool) error {
+	if err := validateInputSpec(input, job); err != nil {
+		return err
+	}
+	return validateInputRepos(pachClient, input, job)
+}
+
+// validateInputRepos checks that the repos (or, for jobs, the commits) that
+// 'input' reads from exist.
+func validateInputRepos(pachClient *client.APIClient, input *pps.Input, job bool) error {
+	var result error
+	pps.VisitInput(input, func(input *pps.Input) {
+		if input.Pfs == nil || result != nil {
+			return
+		}
+		// Note that input.Pfs.Commit is empty if a) this is a job b) one of
+		// the job pipeline's input branches has no commits yet
+		if job && input.Pfs.Commit != "" {
+			// for jobs we check that the input commit exists
+			_, result = pachClient.InspectCommit(input.Pfs.Repo, input.Pfs.Commit)
+		} else {
+			// for pipelines we only check that the repo exists
+			_, result = pachClient.InspectRepo(input.Pfs.Repo)
+		}
+	})
+	return result
+}
+
+// validateInputSpec checks 'input' without reading anything from PFS.
+func validateInputSpec(input *pps.Input, job bool) error {
 	if err := validateNames(make(map[string]bool), input); err != nil {
 		return err
 	}
+	// outer_join may only be set on the direct inputs of a join, and group_by
+	// must be set on exactly the direct inputs of a group
+	joined := make(map[*pps.PFSInput]bool)
+	grouped := make(map[*pps.PFSInput]bool)
+	pps.VisitInput(input, func(input *pps.Input) {
+		for _, input := range input.Join {
+			if input.Pfs != nil {
+				joined[input.Pfs] = true
+			}
+		}
+		for _, input := range input.Group {
+			if input.Pfs != nil {
+				grouped[input.Pfs] = true
+			}
+		}
+	})
 	var result error
 	pps.VisitInput(input, func(input *pps.Input) {
 		if err := func() error {
+			for _, input := range input.Group {
+				if input.Pfs == nil {
+					return errors.Errorf("the inputs of a group must be PFS inputs " +
+						"that set 'group_by'")
+				}
+			}
 			set := false
 			if input.Pfs != nil {
 				set = true
@@ -207,19 +279,21 @@ func (a *apiServer) validateInput(pachClient *client.APIClient, pipelineName str
 					return errors.Errorf("input cannot specify both 's3' and " +
 						"'empty_files', as 's3' requires input data to be accessed via " +
 						"Pachyderm's S3 gateway rather than the file system")
-				}
-				// Note that input.Pfs.Commit is empty if a) this is a job b) one of
-				// the job pipeline's input branches has no commits yet
-				if job && input.Pfs.Commit != "" {
-					// for jobs we check that the input commit exists
-					if _, err := pachClient.InspectCommit(input.Pfs.Repo, input.Pfs.Commit); err != nil {
-						return err
-					}
-				} else {
-					// for pipelines we only check that the repo exists
-					if _, err := pachClient.InspectRepo(input.Pfs.Repo); err != nil {
-						return err
-					}
+				case input.Pfs.OuterJoin && !joined[input.Pfs]:
+					return errors.Errorf("input %q sets 'outer_join', but only the "+
+						"inputs of a join can be outer joined", input.Pfs.Name)
+				case input.Pfs.GroupBy != "" && !grouped[input.Pfs]:
+					return errors.Errorf("input %q sets 'group_by', but only the "+
+						"inputs of a group can be grouped", input.Pfs.Name)
+				case input.Pfs.GroupBy == "" && grouped[input.Pfs]:
+					return errors.Errorf("input %q is part of a group, so it must "+
+						"set 'group_by'", input.Pfs.Name)
+				case input.Pfs.Trigger != nil && input.Pfs.Trigger.Branch == "":
+					return errors.Errorf("input trigger must specify a branch")
+				case input.Pfs.Trigger != nil && input.Pfs.Trigger.Branch == input.Pfs.Branch:
+					return errors.Errorf("input trigger cannot follow the input's own " +
+						"branch; set 'branch' to a new branch that will follow the " +
+						"trigger's branch")
 				}
 			}
 			if input.Cross != nil {
@@ -240,6 +314,17 @@ func (a *apiServer) validateInput(pachClient *client.APIClient, pipelineName str
 					return errors.Errorf("S3 inputs in join expressions are not supported")
 				}
 			}
+			if input.Group != nil {
+				if set {
+					return errors.Errorf("multiple input types set")
+				}
+				set = true
+				if ppsutil.ContainsS3Inputs(input) {
+					// See above for "joins"; block s3 inputs in group expressions until
+					// we know how they should work
+					return errors.Errorf("S3 inputs in group expressions are not supported")
+				}
+			}
 			if input.Union != nil {
 				if set {
 					return errors.Errorf("multiple input types set")
@@ -269,6 +354,64 @@ func (a *apiServer) validateInput(pachClient *client.APIClient, pipelineName str
 					return err
 				}
 			}
+			if input.Webhook != nil {
+				if set {
+					return errors.Errorf("multiple input types set")
+				}
+				set = true
+				switch {
+				case input.Webhook.Name == "":
+					return errors.Errorf("webhook input must specify a name")
+				case input.Webhook.Name == "out":
+					return errors.Errorf("input cannot be named \"out\", as pachyderm " +
+						"already creates /pfs/out to collect job output")
+				case input.Webhook.SecretKey != "" && input.Webhook.Secret == "":
+					return errors.Errorf("webhook input %q sets 'secret_key' without "+
+						"'secret'", input.Webhook.Name)
+				}
+				if err := ancestry.ValidateName(input.Webhook.Name); err != nil {
+					return errors.Wrapf(err, "invalid webhook input name")
+				}
+			}
+			if input.ObjectStore != nil {
+				if set {
+					return errors.Errorf("multiple input types set")
+				}
+				set = true
+				switch {
+				case input.ObjectStore.Name == "":
+					return errors.Errorf("object store input must specify a name")
+				case input.ObjectStore.Name == "out":
+					return errors.Errorf("input cannot be named \"out\", as pachyderm " +
+						"already creates /pfs/out to collect job output")
+				}
+				if err := ancestry.ValidateName(input.ObjectStore.Name); err != nil {
+					return errors.Wrapf(err, "invalid object store input name")
+				}
+				url, err := obj.ParseURL(input.ObjectStore.URL)
+				if err != nil {
+					return errors.Wrapf(err, "invalid URL for object store input %q", input.ObjectStore.Name)
+				}
+				// Pachyderm's own storage (and its local disk) is never read on
+				// behalf of an object store input
+				switch {
+				case url.Store == "local":
+					return errors.Errorf("object store input %q cannot read local:// "+
+						"URLs", input.ObjectStore.Name)
+				case input.ObjectStore.Secret == "":
+					return errors.Errorf("object store input %q must set 'secret' to "+
+						"a secret holding the credentials for its URL", input.ObjectStore.Name)
+				}
+				if input.ObjectStore.Interval != nil {
+					interval, err := types.DurationFromProto(input.ObjectStore.Interval)
+					if err != nil {
+						return err
+					}
+					if interval < time.Second {
+						return errors.Errorf("the interval of object store input %q must be at least one second", input.ObjectStore.Name)
+					}
+				}
+			}
 			if !set {
 				return errors.Errorf("no input set")
 			}
@@ -415,10 +558,22 @@ func (a *apiServer) authorizePipelineOp(pachClient *client.APIClient, operation
 		done := make(map[string]struct{}) // don't double-authorize repos
 		pps.VisitInput(input, func(in *pps.Input) {
 			var repo string
-
-			if in.Pfs != nil {
+			switch {
+			case in.Pfs != nil:
 				repo = in.Pfs.Repo
-			} else {
+			case operation != pipelineOpListDatum:
+				// A pipeline creates its other input repos itself, but listing
+				// datums reads all of them
+				return
+			case in.Cron != nil:
+				repo = in.Cron.Repo
+			case in.Git != nil:
+				repo = in.Git.Name
+			case in.Webhook != nil:
+				repo = in.Webhook.Repo
+			case in.ObjectStore != nil:
+				repo = in.ObjectStore.Repo
+			default:
 				return
 			}
 
@@ -464,7 +619,11 @@ func (a *apiServer) authorizePipelineOp(pachClient *client.APIClient, operation
 			return err
 		}
 	case pipelineOpListDatum, pipelineOpGetLogs:
-		required = auth.Scope_READER
+		// 'output' is empty if the datums being listed are those of a bare input
+		// (see listDatumInput), in which case there's no output repo to check
+		if output != "" {
+			required = auth.Scope_READER
+		}
 	case pipelineOpUpdate:
 		required = auth.Scope_WRITER
 	case pipelineOpDelete:
@@ -992,10 +1151,16 @@ func (a *apiServer) RestartDatum(ctx context.Context, request *pps.RestartDatumR
 // listDatum contains our internal implementation of ListDatum, which is shared
 // between ListDatum and ListDatumStream. When ListDatum is removed, this should
 // be inlined into ListDatumStream
-func (a *apiServer) listDatum(pachClient *client.APIClient, job *pps.Job, page, pageSize int64) (response *pps.ListDatumResponse, retErr error) {
+func (a *apiServer) listDatum(pachClient *client.APIClient, job *pps.Job, input *pps.Input, page, pageSize int64) (response *pps.ListDatumResponse, retErr error) {
 	if _, err := checkLoggedIn(pachClient); err != nil {
 		return nil, err
 	}
+	if input != nil {
+		if job != nil {
+			return nil, errors.Errorf("cannot list the datums of both a job and an input")
+		}
+		return a.listDatumInput(pachClient, input, page, pageSize)
+	}
 	response = &pps.ListDatumResponse{}
 	ctx := pachClient.Ctx()
 	pfsClient := pachClient.PfsAPIClient
@@ -1019,24 +1184,6 @@ func (a *apiServer) listDatum(pachClient *client.APIClient, job *pps.Job, page,
 		return nil, err
 	}
 
-	// helper functions for pagination
-	getTotalPages := func(totalSize int) int64 {
-		return (int64(totalSize) + pageSize - 1) / pageSize // == ceil(totalSize/pageSize)
-	}
-	getPageBounds := func(totalSize int) (int, int, error) {
-		start := int(page * pageSize)
-		end := int((page + 1) * pageSize)
-		switch {
-		case totalSize <= start:
-			return 0, 0, io.EOF
-		case totalSize <= end:
-			return start, totalSize, nil
-		case end < totalSize:
-			return start, end, nil
-		}
-		return 0, 0, errors.New("getPageBounds: unreachable code")
-	}
-
 	df, err := workerpkg.NewDatumIterator(pachClient, jobInfo.Input)
 	if err != nil {
 		return nil, err
@@ -1047,13 +1194,14 @@ func (a *apiServer) listDatum(pachClient *client.APIClient, job *pps.Job, page,
 		end := df.Len()
 		if pageSize > 0 {
 			var err error
-			start, end, err = getPageBounds(df.Len())
+			start, end, err = getPageBounds(df.Len(), page, pageSize)
 			if err != nil {
 				return nil, err
 			}
 			response.Page = page
-			response.TotalPages = getTotalPages(df.Len())
+			response.TotalPages = getTotalPages(df.Len(), pageSize)
 		}
+		response.TotalDatums = int64(df.Len())
 		var datumInfos []*pps.DatumInfo
 		for i := start; i < end; i++ {
 			datum := df.DatumN(i) // flattened slice of *worker.Input to job
@@ -1142,10 +1290,11 @@ func (a *apiServer) listDatum(pachClient *client.APIClient, job *pps.Job, page,
 	sort.Slice(datumInfos, func(i, j int) bool {
 		return datumInfos[i].State < datumInfos[j].State
 	})
+	response.TotalDatums = int64(len(datumInfos))
 	if pageSize > 0 {
 		response.Page = page
-		response.TotalPages = getTotalPages(len(datumInfos))
-		start, end, err := getPageBounds(len(datumInfos))
+		response.TotalPages = getTotalPages(len(datumInfos), pageSize)
+		start, end, err := getPageBounds(len(datumInfos), page, pageSize)
 		if err != nil {
 			return nil, err
 		}
@@ -1155,6 +1304,108 @@ func (a *apiServer) listDatum(pachClient *client.APIClient, job *pps.Job, page,
 	return response, nil
 }
 
+// listDatumInput contains the implementation of ListDatum for requests that
+// set 'input' rather than 'job'. It computes the datums that a pipeline with
+// 'input' would process, using the same datum iterator as the pipeline's
+// workers, but without creating the pipeline.
+func (a *apiServer) listDatumInput(pachClient *client.APIClient, input *pps.Input, page, pageSize int64) (*pps.ListDatumResponse, error) {
+	input = proto.Clone(input).(*pps.Input)
+	// Fill in the same defaults as CreatePipeline, and read each input from the
+	// head of its branch unless a commit was given
+	pps.VisitInput(input, func(input *pps.Input) {
+		if input.Pfs != nil {
+			if input.Pfs.Branch == "" {
+				input.Pfs.Branch = "master"
+			}
+			if input.Pfs.Name == "" {
+				input.Pfs.Name = input.Pfs.Repo
+			}
+			if input.Pfs.Commit == "" {
+				input.Pfs.Commit = input.Pfs.Branch
+			}
+		}
+		if input.Cron != nil && input.Cron.Commit == "" {
+			input.Cron.Commit = "master"
+		}
+		if input.Webhook != nil && input.Webhook.Commit == "" {
+			input.Webhook.Commit = "master"
+		}
+		if input.ObjectStore != nil && input.ObjectStore.Commit == "" {
+			input.ObjectStore.Commit = "master"
+		}
+		if input.Git != nil {
+			if input.Git.Branch == "" {
+				input.Git.Branch = "master"
+			}
+			if input.Git.Commit == "" {
+				input.Git.Commit = input.Git.Branch
+			}
+		}
+	})
+	// authorize ListDatum (must have READER access to all inputs). This is
+	// checked first, so that validation errors don't reveal anything about
+	// repos the caller can't read
+	if err := a.authorizePipelineOp(pachClient, pipelineOpListDatum, input, ""); err != nil {
+		return nil, err
+	}
+	if err := a.validateInput(pachClient, "", input, false); err != nil {
+		return nil, err
+	}
+
+	df, err := workerpkg.NewDatumIterator(pachClient, input)
+	if err != nil {
+		return nil, err
+	}
+	response := &pps.ListDatumResponse{}
+	start, end := 0, df.Len()
+	if pageSize > 0 {
+		var err error
+		start, end, err = getPageBounds(df.Len(), page, pageSize)
+		if err != nil {
+			return nil, err
+		}
+		response.Page = page
+		response.TotalPages = getTotalPages(df.Len(), pageSize)
+	}
+	response.TotalDatums = int64(df.Len())
+	for i := start; i < end; i++ {
+		datum := df.DatumN(i)
+		// There's no pipeline to salt the datum's ID with, so the IDs of these
+		// datums won't match the IDs of the datums in any job
+		datumInfo := &pps.DatumInfo{
+			Datum: &pps.Datum{ID: workerpkg.HashDatum("", "", datum)},
+			State: pps.DatumState_STARTING,
+		}
+		for _, input := range datum {
+			datumInfo.Data = append(datumInfo.Data, input.FileInfo)
+		}
+		response.DatumInfos = append(response.DatumInfos, datumInfo)
+	}
+	return response, nil
+}
+
+// getTotalPages returns the number of pages of size 'pageSize' needed to hold
+// 'totalSize' results
+func getTotalPages(totalSize int, pageSize int64) int64 {
+	return (int64(totalSize) + pageSize - 1) / pageSize // == ceil(totalSize/pageSize)
+}
+
+// getPageBounds returns the bounds of the results in page 'page' (of size
+// 'pageSize') of 'totalSize' results, or io.EOF if there are no such results
+func getPageBounds(totalSize int, page, pageSize int64) (int, int, error) {
+	start := int(page * pageSize)
+	end := int((page + 1) * pageSize)
+	switch {
+	case totalSize <= start:
+		return 0, 0, io.EOF
+	case totalSize <= end:
+		return start, totalSize, nil
+	case end < totalSize:
+		return start, end, nil
+	}
+	return 0, 0, errors.New("getPageBounds: unreachable code")
+}
+
 // ListDatum implements the protobuf pps.ListDatum RPC
 func (a *apiServer) ListDatum(ctx context.Context, request *pps.ListDatumRequest) (response *pps.ListDatumResponse, retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
@@ -1162,16 +1413,17 @@ func (a *apiServer) ListDatum(ctx context.Context, request *pps.ListDatumRequest
 		if response != nil && len(response.DatumInfos) > client.MaxListItemsLog {
 			logrus.Infof("Response contains %d objects; logging the first %d", len(response.DatumInfos), client.MaxListItemsLog)
 			logResponse := &pps.ListDatumResponse{
-				TotalPages: response.TotalPages,
-				Page:       response.Page,
-				DatumInfos: response.DatumInfos[:client.MaxListItemsLog],
+				TotalPages:  response.TotalPages,
+				Page:        response.Page,
+				DatumInfos:  response.DatumInfos[:client.MaxListItemsLog],
+				TotalDatums: response.TotalDatums,
 			}
 			a.Log(request, logResponse, retErr, time.Since(start))
 		} else {
 			a.Log(request, response, retErr, time.Since(start))
 		}
 	}(time.Now())
-	return a.listDatum(a.env.GetPachClient(ctx), request.Job, request.Page, request.PageSize)
+	return a.listDatum(a.env.GetPachClient(ctx), request.Job, request.Input, request.Page, request.PageSize)
 }
 
 // ListDatumStream implements the protobuf pps.ListDatumStream RPC
@@ -1181,7 +1433,7 @@ func (a *apiServer) ListDatumStream(req *pps.ListDatumRequest, resp pps.API_List
 	defer func(start time.Time) {
 		a.Log(req, fmt.Sprintf("stream containing %d DatumInfos", sent), retErr, time.Since(start))
 	}(time.Now())
-	ldr, err := a.listDatum(a.env.GetPachClient(resp.Context()), req.Job, req.Page, req.PageSize)
+	ldr, err := a.listDatum(a.env.GetPachClient(resp.Context()), req.Job, req.Input, req.Page, req.PageSize)
 	if err != nil {
 		return err
 	}
@@ -1191,6 +1443,7 @@ func (a *apiServer) ListDatumStream(req *pps.ListDatumRequest, resp pps.API_List
 		if first {
 			r.Page = ldr.Page
 			r.TotalPages = ldr.TotalPages
+			r.TotalDatums = ldr.TotalDatums
 			first = false
 		}
 		r.DatumInfo = di
@@ -1546,101 +1799,175 @@ func (a *apiServer) getLogsFromStats(pachClient *client.APIClient, request *pps.
 }
 
 func (a *apiServer) validatePipelineRequest(request *pps.CreatePipelineRequest) error {
+	if problems := pipelineRequestProblems(request); len(problems) > 0 {
+		return problems[0]
+	}
+	return nil
+}
+
+// pipelineRequestProblems returns every problem with 'request' that can be
+// found before its defaults are set, rather than just the first. If the
+// pipeline has no name, nothing else is checked.
+func pipelineRequestProblems(request *pps.CreatePipelineRequest) []error {
 	if request.Pipeline == nil {
-		return errors.New("invalid pipeline spec: request.Pipeline cannot be nil")
+		return []error{errors.New("invalid pipeline spec: request.Pipeline cannot be nil")}
 	}
 	if request.Pipeline.Name == "" {
-		return errors.New("invalid pipeline spec: request.Pipeline.Name cannot be empty")
+		return []error{errors.New("invalid pipeline spec: request.Pipeline.Name cannot be empty")}
 	}
+	var problems []error
 	if err := ancestry.ValidateName(request.Pipeline.Name); err != nil {
-		return errors.Wrapf(err, "invalid pipeline name")
+		problems = append(problems, errors.Wrapf(err, "invalid pipeline name"))
 	}
 	if len(request.Pipeline.Name) > 63 {
-		return errors.Errorf("pipeline name is %d characters long, but must have at most 63: %q",
-			len(request.Pipeline.Name), request.Pipeline.Name)
+		problems = append(problems, errors.Errorf("pipeline name is %d characters long, but must have at most 63: %q",
+			len(request.Pipeline.Name), request.Pipeline.Name))
 	}
 	// TODO(msteffen) eventually TFJob and Transform will be alternatives, but
 	// currently TFJob isn't supported
 	if request.TFJob != nil {
-		return errors.New("embedding TFJobs in pipelines is not supported yet")
+		problems = append(problems, errors.New("embedding TFJobs in pipelines is not supported yet"))
 	}
 	if request.S3Out && ((request.Service != nil) || (request.Spout != nil)) {
-		return errors.New("s3 output is not supported in spouts or services")
+		problems = append(problems, errors.New("s3 output is not supported in spouts or services"))
 	}
 	if request.S3Out && request.EnableStats {
-		return errors.New("stats are not supported for pipelines that output via Pachyderm's S3 gateway")
+		problems = append(problems, errors.New("stats are not supported for pipelines that output via Pachyderm's S3 gateway"))
+	}
+	if request.DeadLetter && ((request.Service != nil) || (request.Spout != nil)) {
+		problems = append(problems, errors.New("dead letter repos are not supported in spouts or services"))
+	}
+	if request.MaxConcurrentJobs < 0 {
+		problems = append(problems, errors.Errorf("max_concurrent_jobs cannot be negative (got %d)", request.MaxConcurrentJobs))
+	}
+	if request.MaxConcurrentJobs > 0 && ((request.Service != nil) || (request.Spout != nil)) {
+		problems = append(problems, errors.New("max_concurrent_jobs is not supported in spouts or services"))
+	}
+	if request.DatumCache && ((request.Service != nil) || (request.Spout != nil)) {
+		problems = append(problems, errors.New("the datum cache is not supported in spouts or services"))
+	}
+	if request.Egress != nil && request.Egress.Incremental && request.Egress.URL == "" {
+		problems = append(problems, errors.New("incremental egress requires an egress URL"))
+	}
+	if request.DatumCache && request.S3Out {
+		problems = append(problems, errors.New("the datum cache is not supported for pipelines that output via Pachyderm's S3 gateway"))
 	}
 	if request.Transform == nil {
-		return errors.Errorf("pipeline must specify a transform")
+		problems = append(problems, errors.Errorf("pipeline must specify a transform"))
+	} else if request.DatumCache && len(request.Transform.Secrets) > 0 {
+		problems = append(problems, errors.New("the datum cache is not supported for pipelines with secrets, as their outputs may depend on the secrets' contents"))
 	}
-	return nil
+	return problems
 }
 
 func (a *apiServer) validatePipeline(pachClient *client.APIClient, pipelineInfo *pps.PipelineInfo) error {
+	if err := validatePipelineSpec(pipelineInfo); err != nil {
+		return err
+	}
+	return validateInputRepos(pachClient, pipelineInfo.Input, false)
+}
+
+// validatePipelineSpec checks 'pipelineInfo' without reading anything from
+// PFS.
+func validatePipelineSpec(pipelineInfo *pps.PipelineInfo) error {
+	if problems := pipelineSpecProblems(pipelineInfo); len(problems) > 0 {
+		return problems[0]
+	}
+	return nil
+}
+
+// pipelineSpecProblems returns every problem that validatePipelineSpec would
+// find in 'pipelineInfo', rather than just the first. If the pipeline has no
+// name, nothing else is checked.
+func pipelineSpecProblems(pipelineInfo *pps.PipelineInfo) []error {
 	if pipelineInfo.Pipeline == nil {
-		return errors.New("invalid pipeline spec: Pipeline field cannot be nil")
+		return []error{errors.New("invalid pipeline spec: Pipeline field cannot be nil")}
 	}
 	if pipelineInfo.Pipeline.Name == "" {
-		return errors.New("invalid pipeline spec: Pipeline.Name cannot be empty")
+		return []error{errors.New("invalid pipeline spec: Pipeline.Name cannot be empty")}
 	}
+	var problems []error
 	if err := ancestry.ValidateName(pipelineInfo.Pipeline.Name); err != nil {
-		return errors.Wrapf(err, "invalid pipeline name")
+		problems = append(problems, errors.Wrapf(err, "invalid pipeline name"))
 	}
 	first := rune(pipelineInfo.Pipeline.Name[0])
 	if !unicode.IsLetter(first) && !unicode.IsDigit(first) {
-		return errors.Errorf("pipeline names must start with an alphanumeric character")
+		problems = append(problems, errors.Errorf("pipeline names must start with an alphanumeric character"))
 	}
 	if len(pipelineInfo.Pipeline.Name) > 63 {
-		return errors.Errorf("pipeline name is %d characters long, but must have at most 63: %q",
-			len(pipelineInfo.Pipeline.Name), pipelineInfo.Pipeline.Name)
+		problems = append(problems, errors.Errorf("pipeline name is %d characters long, but must have at most 63: %q",
+			len(pipelineInfo.Pipeline.Name), pipelineInfo.Pipeline.Name))
 	}
 	if err := validateTransform(pipelineInfo.Transform); err != nil {
-		return errors.Wrapf(err, "invalid transform")
+		problems = append(problems, errors.Wrapf(err, "invalid transform"))
 	}
-	if err := a.validateInput(pachClient, pipelineInfo.Pipeline.Name, pipelineInfo.Input, false); err != nil {
-		return err
+	if err := validateInputSpec(pipelineInfo.Input, false); err != nil {
+		problems = append(problems, err)
 	}
 	if pipelineInfo.ParallelismSpec != nil {
 		if pipelineInfo.ParallelismSpec.Coefficient < 0 {
-			return errors.New("ParallelismSpec.Coefficient cannot be negative")
+			problems = append(problems, errors.New("ParallelismSpec.Coefficient cannot be negative"))
 		}
 		if pipelineInfo.ParallelismSpec.Constant != 0 &&
 			pipelineInfo.ParallelismSpec.Coefficient != 0 {
-			return errors.New("contradictory parallelism strategies: must set at " +
-				"most one of ParallelismSpec.Constant and ParallelismSpec.Coefficient")
+			problems = append(problems, errors.New("contradictory parallelism strategies: must set at "+
+				"most one of ParallelismSpec.Constant and ParallelismSpec.Coefficient"))
+		}
+		if autoscaling := pipelineInfo.ParallelismSpec.Autoscaling; autoscaling != nil {
+			if pipelineInfo.ParallelismSpec.Constant != 0 || pipelineInfo.ParallelismSpec.Coefficient != 0 {
+				problems = append(problems, errors.New("contradictory parallelism strategies: ParallelismSpec.Autoscaling "+
+					"cannot be set with ParallelismSpec.Constant or ParallelismSpec.Coefficient"))
+			}
+			if autoscaling.MinWorkers == 0 {
+				problems = append(problems, errors.New("ParallelismSpec.Autoscaling.MinWorkers must be > 0 (use standby to scale down to zero workers)"))
+			}
+			if autoscaling.MaxWorkers < autoscaling.MinWorkers {
+				problems = append(problems, errors.Errorf("ParallelismSpec.Autoscaling.MaxWorkers (%d) cannot be less than MinWorkers (%d)",
+					autoscaling.MaxWorkers, autoscaling.MinWorkers))
+			}
+			if autoscaling.TargetDuration != nil {
+				if targetDuration, err := types.DurationFromProto(autoscaling.TargetDuration); err != nil {
+					problems = append(problems, err)
+				} else if targetDuration <= 0 {
+					problems = append(problems, errors.New("ParallelismSpec.Autoscaling.TargetDuration must be positive"))
+				}
+			}
+			if pipelineInfo.Spout != nil {
+				problems = append(problems, errors.New("spouts cannot be autoscaled"))
+			}
 		}
 		if pipelineInfo.Service != nil && pipelineInfo.ParallelismSpec.Constant != 1 {
-			return errors.New("services can only be run with a constant parallelism of 1")
+			problems = append(problems, errors.New("services can only be run with a constant parallelism of 1"))
 		}
 	}
 	if pipelineInfo.HashtreeSpec != nil {
 		if pipelineInfo.HashtreeSpec.Constant == 0 {
-			return errors.New("invalid pipeline spec: HashtreeSpec.Constant must be > 0")
+			problems = append(problems, errors.New("invalid pipeline spec: HashtreeSpec.Constant must be > 0"))
 		}
 	}
 	if pipelineInfo.OutputBranch == "" {
-		return errors.New("pipeline needs to specify an output branch")
+		problems = append(problems, errors.New("pipeline needs to specify an output branch"))
 	}
 	if _, err := resource.ParseQuantity(pipelineInfo.CacheSize); err != nil {
-		return errors.Wrapf(err, "could not parse cacheSize '%s'", pipelineInfo.CacheSize)
+		problems = append(problems, errors.Wrapf(err, "could not parse cacheSize '%s'", pipelineInfo.CacheSize))
 	}
 	if pipelineInfo.JobTimeout != nil {
 		_, err := types.DurationFromProto(pipelineInfo.JobTimeout)
 		if err != nil {
-			return err
+			problems = append(problems, err)
 		}
 	}
 	if pipelineInfo.DatumTimeout != nil {
 		_, err := types.DurationFromProto(pipelineInfo.DatumTimeout)
 		if err != nil {
-			return err
+			problems = append(problems, err)
 		}
 	}
 	if pipelineInfo.PodSpec != "" && !json.Valid([]byte(pipelineInfo.PodSpec)) {
-		return errors.Errorf("malformed PodSpec")
+		problems = append(problems, errors.Errorf("malformed PodSpec"))
 	}
 	if pipelineInfo.PodPatch != "" && !json.Valid([]byte(pipelineInfo.PodPatch)) {
-		return errors.Errorf("malformed PodPatch")
+		problems = append(problems, errors.Errorf("malformed PodPatch"))
 	}
 	if pipelineInfo.Service != nil {
 		validServiceTypes := map[v1.ServiceType]bool{
@@ -1650,24 +1977,24 @@ func (a *apiServer) validatePipeline(pachClient *client.APIClient, pipelineInfo
 		}
 
 		if !validServiceTypes[v1.ServiceType(pipelineInfo.Service.Type)] {
-			return errors.Errorf("the following service type %s is not allowed", pipelineInfo.Service.Type)
+			problems = append(problems, errors.Errorf("the following service type %s is not allowed", pipelineInfo.Service.Type))
 		}
 	}
 	if pipelineInfo.Spout != nil {
 		if pipelineInfo.EnableStats {
-			return errors.Errorf("spouts are not allowed to have a stats branch")
+			problems = append(problems, errors.Errorf("spouts are not allowed to have a stats branch"))
 		}
 		if pipelineInfo.Spout.Marker != "" {
 			// we need to make sure the marker name is also a valid file name, since it is used in file names
 			if err := hashtree.ValidatePath(pipelineInfo.Spout.Marker); err != nil || pipelineInfo.Spout.Marker == "out" {
-				return errors.Errorf("the spout marker name must be a valid filename: %v", pipelineInfo.Spout.Marker)
+				problems = append(problems, errors.Errorf("the spout marker name must be a valid filename: %v", pipelineInfo.Spout.Marker))
 			}
 		}
 		if pipelineInfo.Spout.Service == nil && pipelineInfo.Input != nil {
-			return errors.Errorf("spout pipelines (without a service) must not have an input")
+			problems = append(problems, errors.Errorf("spout pipelines (without a service) must not have an input"))
 		}
 	}
-	return nil
+	return problems
 }
 
 func branchProvenance(input *pps.Input) []*pfs.Branch {
@@ -1682,6 +2009,12 @@ func branchProvenance(input *pps.Input) []*pfs.Branch {
 		if input.Git != nil {
 			result = append(result, client.NewBranch(input.Git.Name, input.Git.Branch))
 		}
+		if input.Webhook != nil {
+			result = append(result, client.NewBranch(input.Webhook.Repo, "master"))
+		}
+		if input.ObjectStore != nil {
+			result = append(result, client.NewBranch(input.ObjectStore.Repo, "master"))
+		}
 	})
 	return result
 }
@@ -1819,6 +2152,10 @@ func (a *apiServer) fixPipelineInputRepoACLs(pachClient *client.APIClient, pipel
 				repo = input.Cron.Repo
 			case input.Git != nil:
 				repo = input.Git.Name
+			case input.Webhook != nil:
+				repo = input.Webhook.Repo
+			case input.ObjectStore != nil:
+				repo = input.ObjectStore.Repo
 			default:
 				return // no scope to set: input is not a repo
 			}
@@ -1848,6 +2185,10 @@ func (a *apiServer) fixPipelineInputRepoACLs(pachClient *client.APIClient, pipel
 				repo = input.Cron.Repo
 			case input.Git != nil:
 				repo = input.Git.Name
+			case input.Webhook != nil:
+				repo = input.Webhook.Repo
+			case input.ObjectStore != nil:
+				repo = input.ObjectStore.Repo
 			default:
 				return // no scope to set: input is not a repo
 			}
@@ -1909,12 +2250,65 @@ func (a *apiServer) fixPipelineInputRepoACLs(pachClient *client.APIClient, pipel
 			})
 		})
 	}
+	// Add pipeline to its dead letter repo's ACL as a WRITER, if it has one
+	if pipelineInfo != nil && pipelineInfo.DeadLetter {
+		eg.Go(func() error {
+			return a.sudo(pachClient, func(superUserClient *client.APIClient) error {
+				_, err := superUserClient.SetScope(superUserClient.Ctx(), &auth.SetScopeRequest{
+					Repo:     pps.DeadLetterRepo(pipelineName),
+					Username: auth.PipelinePrefix + pipelineName,
+					Scope:    auth.Scope_WRITER,
+				})
+				return grpcutil.ScrubGRPC(err)
+			})
+		})
+	}
 	if err := eg.Wait(); err != nil {
 		return errors.Wrapf(grpcutil.ScrubGRPC(err), "error fixing ACLs on \"%s\"'s input repos", pipelineName)
 	}
 	return nil
 }
 
+// newPipelineInfo returns the first version of the pipeline created by
+// 'request', before defaults are set.
+func newPipelineInfo(request *pps.CreatePipelineRequest) *pps.PipelineInfo {
+	return &pps.PipelineInfo{
+		Pipeline:          request.Pipeline,
+		Version:           1,
+		Transform:         request.Transform,
+		TFJob:             request.TFJob,
+		ParallelismSpec:   request.ParallelismSpec,
+		HashtreeSpec:      request.HashtreeSpec,
+		Input:             request.Input,
+		OutputBranch:      request.OutputBranch,
+		Egress:            request.Egress,
+		CreatedAt:         now(),
+		ResourceRequests:  request.ResourceRequests,
+		ResourceLimits:    request.ResourceLimits,
+		Description:       request.Description,
+		CacheSize:         request.CacheSize,
+		EnableStats:       request.EnableStats,
+		Salt:              request.Salt,
+		MaxQueueSize:      request.MaxQueueSize,
+		Service:           request.Service,
+		Spout:             request.Spout,
+		ChunkSpec:         request.ChunkSpec,
+		DatumTimeout:      request.DatumTimeout,
+		JobTimeout:        request.JobTimeout,
+		Standby:           request.Standby,
+		DatumTries:        request.DatumTries,
+		SchedulingSpec:    request.SchedulingSpec,
+		PodSpec:           request.PodSpec,
+		PodPatch:          request.PodPatch,
+		S3Out:             request.S3Out,
+		Metadata:          request.Metadata,
+		DeadLetter:        request.DeadLetter,
+		Priority:          request.Priority,
+		MaxConcurrentJobs: request.MaxConcurrentJobs,
+		DatumCache:        request.DatumCache,
+	}
+}
+
 // CreatePipeline implements the protobuf pps.CreatePipeline RPC
 //
 // Implementation note:
@@ -1963,39 +2357,9 @@ func (a *apiServer) CreatePipeline(ctx context.Context, request *pps.CreatePipel
 	if request.Salt == "" || request.Reprocess {
 		request.Salt = uuid.NewWithoutDashes()
 	}
-	pipelineInfo := &pps.PipelineInfo{
-		Pipeline:         request.Pipeline,
-		Version:          1,
-		Transform:        request.Transform,
-		TFJob:            request.TFJob,
-		ParallelismSpec:  request.ParallelismSpec,
-		HashtreeSpec:     request.HashtreeSpec,
-		Input:            request.Input,
-		OutputBranch:     request.OutputBranch,
-		Egress:           request.Egress,
-		CreatedAt:        now(),
-		ResourceRequests: request.ResourceRequests,
-		ResourceLimits:   request.ResourceLimits,
-		Description:      request.Description,
-		CacheSize:        request.CacheSize,
-		EnableStats:      request.EnableStats,
-		Salt:             request.Salt,
-		MaxQueueSize:     request.MaxQueueSize,
-		Service:          request.Service,
-		Spout:            request.Spout,
-		ChunkSpec:        request.ChunkSpec,
-		DatumTimeout:     request.DatumTimeout,
-		JobTimeout:       request.JobTimeout,
-		Standby:          request.Standby,
-		DatumTries:       request.DatumTries,
-		SchedulingSpec:   request.SchedulingSpec,
-		PodSpec:          request.PodSpec,
-		PodPatch:         request.PodPatch,
-		S3Out:            request.S3Out,
-		Metadata:         request.Metadata,
-	}
-	if err := setPipelineDefaults(pipelineInfo); err != nil {
-		return nil, err
+	pipelineInfo := newPipelineInfo(request)
+	if problems := setPipelineDefaults(pipelineInfo); len(problems) > 0 {
+		return nil, problems[0]
 	}
 	// Validate final PipelineInfo (now that defaults have been populated)
 	if err := a.validatePipeline(pachClient, pipelineInfo); err != nil {
@@ -2022,10 +2386,43 @@ func (a *apiServer) CreatePipeline(ctx context.Context, request *pps.CreatePipel
 				visitErr = err
 			}
 		}
+		if input.Webhook != nil && visitErr == nil {
+			repo := input.Webhook.Repo
+			visitErr = a.createPipelineRepo(pachClient, request, repo,
+				fmt.Sprintf("Webhook input repo for pipeline %s.", request.Pipeline.Name),
+				func(prevPipelineInfo *pps.PipelineInfo) bool {
+					var used bool
+					pps.VisitInput(prevPipelineInfo.Input, func(input *pps.Input) {
+						used = used || (input.Webhook != nil && input.Webhook.Repo == repo)
+					})
+					return used
+				})
+		}
+		if input.ObjectStore != nil && visitErr == nil {
+			repo := input.ObjectStore.Repo
+			visitErr = a.createPipelineRepo(pachClient, request, repo,
+				fmt.Sprintf("Object store input repo for pipeline %s, ingesting %s.", request.Pipeline.Name, input.ObjectStore.URL),
+				func(prevPipelineInfo *pps.PipelineInfo) bool {
+					var used bool
+					pps.VisitInput(prevPipelineInfo.Input, func(input *pps.Input) {
+						used = used || (input.ObjectStore != nil && input.ObjectStore.Repo == repo)
+					})
+					return used
+				})
+		}
 	})
 	if visitErr != nil {
 		return nil, visitErr
 	}
+	if pipelineInfo.DeadLetter {
+		if err := a.createPipelineRepo(pachClient, request, pps.DeadLetterRepo(request.Pipeline.Name),
+			fmt.Sprintf("Dead letter repo for pipeline %s.", request.Pipeline.Name),
+			func(prevPipelineInfo *pps.PipelineInfo) bool {
+				return prevPipelineInfo.DeadLetter
+			}); err != nil {
+			return nil, err
+		}
+	}
 
 	// Authorize pipeline creation
 	operation := pipelineOpCreate
@@ -2035,6 +2432,19 @@ func (a *apiServer) CreatePipeline(ctx context.Context, request *pps.CreatePipel
 	if err := a.authorizePipelineOp(pachClient, operation, pipelineInfo.Input, pipelineInfo.Pipeline.Name); err != nil {
 		return nil, err
 	}
+	rollbackTriggers, err := a.createInputTriggers(pachClient, pipelineInfo.Input)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		// Don't leave input branches following triggers for a pipeline that
+		// wasn't created
+		if retErr != nil {
+			if err := rollbackTriggers(); err != nil {
+				logrus.Errorf("could not roll back triggers on the inputs of pipeline %s: %v", request.Pipeline.Name, err)
+			}
+		}
+	}()
 	pipelineName := pipelineInfo.Pipeline.Name
 	pps.SortInput(pipelineInfo.Input) // Makes datum hashes comparable
 	update := false
@@ -2267,12 +2677,134 @@ func (a *apiServer) CreatePipeline(ctx context.Context, request *pps.CreatePipel
 	return &types.Empty{}, nil
 }
 
-// setPipelineDefaults sets the default values for a pipeline info
-func setPipelineDefaults(pipelineInfo *pps.PipelineInfo) error {
+// createPipelineRepo creates 'repo', a repo that PPS writes to on behalf of
+// the pipeline created by 'request' (e.g. its dead letter repo). A repo that
+// already exists is only reused if 'request' updates a pipeline that already
+// used it (according to 'used'), and the caller can write to it.
+func (a *apiServer) createPipelineRepo(pachClient *client.APIClient, request *pps.CreatePipelineRequest, repo string, description string, used func(prevPipelineInfo *pps.PipelineInfo) bool) error {
+	_, err := pachClient.PfsAPIClient.CreateRepo(pachClient.Ctx(),
+		&pfs.CreateRepoRequest{
+			Repo:        client.NewRepo(repo),
+			Description: description,
+		})
+	if err == nil || !isAlreadyExistsErr(err) {
+		return err
+	}
+	alreadyExists := errors.Errorf("cannot use existing repo \"%s\" for pipeline \"%s\", as the pipeline didn't create it", repo, request.Pipeline.Name)
+	if !request.Update {
+		return alreadyExists
+	}
+	prevPipelineInfo, err := a.inspectPipeline(pachClient, request.Pipeline.Name)
+	if err != nil {
+		if isNotFoundErr(err) {
+			return alreadyExists
+		}
+		return err
+	}
+	if !used(prevPipelineInfo) {
+		return alreadyExists
+	}
+	me, err := pachClient.WhoAmI(pachClient.Ctx(), &auth.WhoAmIRequest{})
+	if auth.IsErrNotActivated(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	resp, err := pachClient.Authorize(pachClient.Ctx(), &auth.AuthorizeRequest{
+		Repo:  repo,
+		Scope: auth.Scope_WRITER,
+	})
+	if err != nil {
+		return err
+	}
+	if !resp.Authorized {
+		return &auth.ErrNotAuthorized{
+			Subject:  me.Username,
+			Repo:     repo,
+			Required: auth.Scope_WRITER,
+		}
+	}
+	return nil
+}
+
+// createInputTriggers creates (or updates) the branches of 'input's PFS inputs
+// that have triggers, so that they follow the branches named in their
+// triggers. Existing branches keep their heads. It returns a function that
+// puts the branches back the way they were, which is used if the pipeline
+// can't be created.
+func (a *apiServer) createInputTriggers(pachClient *client.APIClient, input *pps.Input) (func() error, error) {
+	var undo []func() error
+	rollback := func() error {
+		var result error
+		for i := len(undo) - 1; i >= 0; i-- {
+			if err := undo[i](); err != nil && result == nil {
+				result = err
+			}
+		}
+		return result
+	}
+	var result error
+	pps.VisitInput(input, func(input *pps.Input) {
+		if result != nil || input.Pfs == nil || input.Pfs.Trigger == nil {
+			return
+		}
+		var head string
+		branchInfo, err := pachClient.InspectBranch(input.Pfs.Repo, input.Pfs.Branch)
+		if err != nil && !isNotFoundErr(err) {
+			result = err
+			return
+		}
+		if err == nil {
+			// Setting a trigger replaces the branch's provenance, which would
+			// detach e.g. an upstream pipeline from its output branch
+			if len(branchInfo.DirectProvenance) > 0 {
+				result = errors.Errorf("cannot set a trigger on input branch %s@%s "+
+					"because it has provenance", input.Pfs.Repo, input.Pfs.Branch)
+				return
+			}
+			if branchInfo.Head != nil {
+				head = branchInfo.Head.ID
+			}
+		}
+		existed := err == nil
+		if err := pachClient.CreateBranchTrigger(input.Pfs.Repo, input.Pfs.Branch, head, input.Pfs.Trigger); err != nil {
+			result = errors.Wrapf(err, "could not create trigger on input branch %s@%s", input.Pfs.Repo, input.Pfs.Branch)
+			return
+		}
+		repo, branch := input.Pfs.Repo, input.Pfs.Branch
+		if !existed {
+			undo = append(undo, func() error {
+				return pachClient.DeleteBranch(repo, branch, false)
+			})
+			return
+		}
+		// An empty trigger removes the one that was just set
+		oldTrigger := branchInfo.Trigger
+		if oldTrigger == nil {
+			oldTrigger = &pfs.Trigger{}
+		}
+		undo = append(undo, func() error {
+			return pachClient.CreateBranchTrigger(repo, branch, head, oldTrigger)
+		})
+	})
+	if result != nil {
+		if err := rollback(); err != nil {
+			logrus.Errorf("could not roll back input triggers: %v", err)
+		}
+		return nil, result
+	}
+	return rollback, nil
+}
+
+// setPipelineDefaults sets the default values for a pipeline info. It returns
+// the problems with the fields it can't default, after setting all the
+// others.
+func setPipelineDefaults(pipelineInfo *pps.PipelineInfo) []error {
 	now := time.Now()
 	if pipelineInfo.Transform.Image == "" {
 		pipelineInfo.Transform.Image = DefaultUserImage
 	}
+	var problems []error
 	pps.VisitInput(pipelineInfo.Input, func(input *pps.Input) {
 		if input.Pfs != nil {
 			if input.Pfs.Branch == "" {
@@ -2302,6 +2834,37 @@ func setPipelineDefaults(pipelineInfo *pps.PipelineInfo) error {
 				input.Git.Name = tokens[0]
 			}
 		}
+		if input.Webhook != nil {
+			// Webhook payloads are written as PPS, so they may only be written
+			// to a repo that belongs to the pipeline
+			repo := fmt.Sprintf("%s_%s", pipelineInfo.Pipeline.Name, input.Webhook.Name)
+			if input.Webhook.Repo == "" {
+				input.Webhook.Repo = repo
+			} else if input.Webhook.Repo != repo {
+				problems = append(problems, errors.Errorf("webhook input %q cannot set 'repo': it "+
+					"always writes to %q", input.Webhook.Name, repo))
+			}
+			if input.Webhook.Secret != "" && input.Webhook.SecretKey == "" {
+				input.Webhook.SecretKey = "secret"
+			}
+		}
+		if input.ObjectStore != nil {
+			// Objects are written as PPS, so they may only be written to a repo
+			// that belongs to the pipeline
+			repo := fmt.Sprintf("%s_%s", pipelineInfo.Pipeline.Name, input.ObjectStore.Name)
+			if input.ObjectStore.Repo == "" {
+				input.ObjectStore.Repo = repo
+			} else if input.ObjectStore.Repo != repo {
+				problems = append(problems, errors.Errorf("object store input %q cannot set 'repo': "+
+					"it always writes to %q", input.ObjectStore.Name, repo))
+			}
+			if input.ObjectStore.Interval == nil {
+				input.ObjectStore.Interval = types.DurationProto(time.Minute)
+			}
+			if input.ObjectStore.Glob == "" {
+				input.ObjectStore.Glob = "/*"
+			}
+		}
 	})
 	if pipelineInfo.OutputBranch == "" {
 		// Output branches default to master
@@ -2324,7 +2887,7 @@ func setPipelineDefaults(pipelineInfo *pps.PipelineInfo) error {
 	if pipelineInfo.Spout != nil && pipelineInfo.Spout.Service != nil && pipelineInfo.Spout.Service.Type == "" {
 		pipelineInfo.Spout.Service.Type = string(v1.ServiceTypeNodePort)
 	}
-	return nil
+	return problems
 }
 
 // InspectPipeline implements the protobuf pps.InspectPipeline RPC
@@ -2335,6 +2898,54 @@ func (a *apiServer) InspectPipeline(ctx context.Context, request *pps.InspectPip
 	return a.inspectPipeline(pachClient, request.Pipeline.Name)
 }
 
+// InspectPipelineUsage implements the protobuf pps.InspectPipelineUsage RPC
+func (a *apiServer) InspectPipelineUsage(ctx context.Context, request *pps.InspectPipelineUsageRequest) (response *pps.PipelineUsage, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	pachClient := a.env.GetPachClient(ctx)
+	if request.Pipeline == nil {
+		return nil, errors.New("request.Pipeline cannot be nil")
+	}
+	var since time.Time
+	if request.Since != nil {
+		var err error
+		if since, err = types.TimestampFromProto(request.Since); err != nil {
+			return nil, err
+		}
+	}
+	// listJob doesn't tell a missing pipeline apart from one without jobs
+	if _, err := a.inspectPipeline(pachClient, request.Pipeline.Name); err != nil {
+		return nil, err
+	}
+	usage := &pps.PipelineUsage{
+		Pipeline: request.Pipeline,
+		Since:    request.Since,
+		Stats:    &pps.ProcessStats{},
+	}
+	// Jobs from the pipeline's previous versions used resources too
+	if err := a.listJob(pachClient, request.Pipeline, nil, nil, -1, false, func(jobInfo *pps.JobInfo) error {
+		if jobInfo.Started == nil {
+			return nil
+		}
+		started, err := types.TimestampFromProto(jobInfo.Started)
+		if err != nil {
+			return err
+		}
+		if started.Before(since) {
+			return nil
+		}
+		usage.Jobs++
+		usage.DataProcessed += jobInfo.DataProcessed
+		if jobInfo.Stats == nil {
+			return nil
+		}
+		return pps.MergeProcessStats(usage.Stats, jobInfo.Stats)
+	}); err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
 // inspectPipeline contains the functional implementation of InspectPipeline.
 // Many functions (GetLogs, ListPipeline, CreateJob) need to inspect a pipeline,
 // so they call this instead of making an RPC
@@ -2373,33 +2984,32 @@ func (a *apiServer) inspectPipeline(pachClient *client.APIClient, name string) (
 			pipelineInfo.Service.IP = service.Spec.ClusterIP
 		}
 	}
-	var hasGitInput bool
+	var hasGitInput, hasWebhookInput bool
 	pps.VisitInput(pipelineInfo.Input, func(input *pps.Input) {
 		if input.Git != nil {
 			hasGitInput = true
 		}
+		if input.Webhook != nil {
+			hasWebhookInput = true
+		}
 	})
-	if hasGitInput {
-		pipelineInfo.GithookURL = "pending"
-		svc, err := getGithookService(kubeClient, a.namespace)
+	if hasGitInput || hasWebhookInput {
+		// Both git and webhook inputs are served by the githook service
+		domain, err := githookDomain(kubeClient, a.namespace)
 		if err != nil {
-			return pipelineInfo, nil
-		}
-		numIPs := len(svc.Status.LoadBalancer.Ingress)
-		if numIPs == 0 {
-			// When running locally, no external IP is set
-			return pipelineInfo, nil
+			return nil, err
 		}
-		if numIPs != 1 {
-			return nil, errors.Errorf("unexpected number of external IPs set for githook service")
+		if hasGitInput {
+			pipelineInfo.GithookURL = "pending"
+			if domain != "" {
+				pipelineInfo.GithookURL = githook.URLFromDomain(domain)
+			}
 		}
-		ingress := svc.Status.LoadBalancer.Ingress[0]
-		if ingress.IP != "" {
-			// GKE load balancing
-			pipelineInfo.GithookURL = githook.URLFromDomain(ingress.IP)
-		} else if ingress.Hostname != "" {
-			// AWS load balancing
-			pipelineInfo.GithookURL = githook.URLFromDomain(ingress.Hostname)
+		if hasWebhookInput {
+			pipelineInfo.WebhookURL = "pending"
+			if domain != "" {
+				pipelineInfo.WebhookURL = githook.WebhookURLFromDomain(domain, pipelineInfo.Pipeline.Name)
+			}
 		}
 	}
 	return pipelineInfo, nil
@@ -2642,16 +3252,32 @@ func (a *apiServer) deletePipeline(pachClient *client.APIClient, request *pps.De
 			return grpcutil.ScrubGRPC(superUserClient.DeleteBranch(ppsconsts.SpecRepo, request.Pipeline.Name, request.Force))
 		})
 	})
-	// Delete EtcdPipelineInfo
+	// Delete EtcdPipelineInfo and the pipeline's notifiers
+	var notifierNames []string
+	notifier := &pps.Notifier{}
+	if err := a.notifiers.ReadOnly(ctx).List(notifier, col.DefaultOptions, func(name string) error {
+		if notifier.Pipeline != nil && notifier.Pipeline.Name == request.Pipeline.Name {
+			notifierNames = append(notifierNames, name)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
 	eg.Go(func() error {
 		if _, err := col.NewSTM(ctx, a.env.GetEtcdClient(), func(stm col.STM) error {
+			notifiers := a.notifiers.ReadWrite(stm)
+			for _, name := range notifierNames {
+				if err := notifiers.Delete(name); err != nil && !col.IsErrNotFound(err) {
+					return err
+				}
+			}
 			return a.pipelines.ReadWrite(stm).Delete(request.Pipeline.Name)
 		}); err != nil {
 			return errors.Wrapf(err, "collection.Delete")
 		}
 		return nil
 	})
-	// Delete cron input repos
+	// Delete cron, webhook and object store input repos and the dead letter repo
 	if !request.KeepRepo {
 		pps.VisitInput(pipelineInfo.Input, func(input *pps.Input) {
 			if input.Cron != nil {
@@ -2659,7 +3285,26 @@ func (a *apiServer) deletePipeline(pachClient *client.APIClient, request *pps.De
 					return pachClient.DeleteRepo(input.Cron.Repo, request.Force)
 				})
 			}
+			if input.Webhook != nil {
+				eg.Go(func() error {
+					return pachClient.DeleteRepo(input.Webhook.Repo, request.Force)
+				})
+			}
+			if input.ObjectStore != nil {
+				eg.Go(func() error {
+					return pachClient.DeleteRepo(input.ObjectStore.Repo, request.Force)
+				})
+			}
 		})
+		if pipelineInfo.DeadLetter {
+			eg.Go(func() error {
+				err := pachClient.DeleteRepo(pps.DeadLetterRepo(request.Pipeline.Name), request.Force)
+				if isNotFoundErr(err) {
+					return nil
+				}
+				return err
+			})
+		}
 	}
 	if err := eg.Wait(); err != nil {
 		return nil, err
@@ -2966,6 +3611,166 @@ func (a *apiServer) RunCron(ctx context.Context, request *pps.RunCronRequest) (r
 	return &types.Empty{}, nil
 }
 
+// RollbackPipeline implements the protobuf pps.RollbackPipeline RPC
+func (a *apiServer) RollbackPipeline(ctx context.Context, request *pps.RollbackPipelineRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	metricsFn := metrics.ReportUserAction(ctx, a.reporter, "RollbackPipeline")
+	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
+	pachClient := a.env.GetPachClient(ctx)
+
+	if request.Pipeline == nil {
+		return nil, errors.New("request.Pipeline cannot be nil")
+	}
+	// Walk back through the pipeline's spec commits to find the version to
+	// re-apply
+	var current, target *pps.PipelineInfo
+	if err := a.listPipeline(pachClient, &pps.ListPipelineRequest{
+		Pipeline: request.Pipeline,
+		History:  -1,
+	}, func(pipelineInfo *pps.PipelineInfo) error {
+		if current == nil {
+			current = pipelineInfo
+			if request.Version == current.Version {
+				return errors.Errorf("pipeline %q is already at version %d", request.Pipeline.Name, request.Version)
+			}
+			return nil
+		}
+		if (request.Version == 0 && pipelineInfo.Version < current.Version) || pipelineInfo.Version == request.Version {
+			target = pipelineInfo
+			return errutil.ErrBreak
+		}
+		return nil
+	}); err != nil && err != errutil.ErrBreak {
+		return nil, err
+	}
+	if target == nil {
+		if request.Version == 0 {
+			return nil, errors.Errorf("pipeline %q has no previous version to roll back to", request.Pipeline.Name)
+		}
+		return nil, errors.Errorf("pipeline %q has no version %d", request.Pipeline.Name, request.Version)
+	}
+
+	// Re-apply the old spec as an update, which makes it the newest version
+	createRequest := ppsutil.PipelineReqFromInfo(target)
+	createRequest.Update = true
+	createRequest.Reprocess = request.Reprocess
+	if _, err := a.CreatePipeline(ctx, createRequest); err != nil {
+		return nil, errors.Wrapf(err, "could not re-apply version %d of pipeline %q", target.Version, request.Pipeline.Name)
+	}
+	return &types.Empty{}, nil
+}
+
+// CreateNotifier implements the protobuf pps.CreateNotifier RPC
+func (a *apiServer) CreateNotifier(ctx context.Context, request *pps.CreateNotifierRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	metricsFn := metrics.ReportUserAction(ctx, a.reporter, "CreateNotifier")
+	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
+	pachClient := a.env.GetPachClient(ctx)
+
+	notifier := request.Notifier
+	if notifier == nil {
+		return nil, errors.New("request.Notifier cannot be nil")
+	}
+	if err := ancestry.ValidateName(notifier.Name); err != nil {
+		return nil, errors.Wrapf(err, "invalid notifier name")
+	}
+	if err := validateNotifierURL(notifier.URL); err != nil {
+		return nil, err
+	}
+	if notifier.Pipeline != nil {
+		if _, err := a.inspectPipeline(pachClient, notifier.Pipeline.Name); err != nil {
+			return nil, err
+		}
+	}
+	if err := a.authorizeNotifierOp(pachClient, notifier.Pipeline, "CreateNotifier", auth.Scope_WRITER); err != nil {
+		return nil, err
+	}
+	if request.Update {
+		// Updating a notifier also requires access to whatever it notified before
+		oldNotifier := &pps.Notifier{}
+		if err := a.notifiers.ReadOnly(ctx).Get(notifier.Name, oldNotifier); err != nil {
+			if col.IsErrNotFound(err) {
+				return nil, errors.Errorf("notifier %q not found", notifier.Name)
+			}
+			return nil, err
+		}
+		if err := a.authorizeNotifierOp(pachClient, oldNotifier.Pipeline, "CreateNotifier", auth.Scope_WRITER); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := col.NewSTM(ctx, a.env.GetEtcdClient(), func(stm col.STM) error {
+		notifiers := a.notifiers.ReadWrite(stm)
+		if request.Update {
+			return notifiers.Put(notifier.Name, notifier)
+		}
+		return notifiers.Create(notifier.Name, notifier)
+	}); err != nil {
+		if col.IsErrExists(err) {
+			return nil, errors.Errorf("notifier %q already exists", notifier.Name)
+		}
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+// ListNotifier implements the protobuf pps.ListNotifier RPC
+func (a *apiServer) ListNotifier(ctx context.Context, request *pps.ListNotifierRequest) (response *pps.NotifierInfos, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	metricsFn := metrics.ReportUserAction(ctx, a.reporter, "ListNotifier")
+	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
+	pachClient := a.env.GetPachClient(ctx)
+
+	if err := a.authorizeNotifierOp(pachClient, request.Pipeline, "ListNotifier", auth.Scope_READER); err != nil {
+		return nil, err
+	}
+	response = &pps.NotifierInfos{}
+	notifier := &pps.Notifier{}
+	if err := a.notifiers.ReadOnly(ctx).List(notifier, col.DefaultOptions, func(string) error {
+		if request.Pipeline != nil && (notifier.Pipeline == nil || notifier.Pipeline.Name != request.Pipeline.Name) {
+			return nil
+		}
+		result := proto.Clone(notifier).(*pps.Notifier)
+		result.Secret = ""
+		response.Notifiers = append(response.Notifiers, result)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	sort.Slice(response.Notifiers, func(i, j int) bool {
+		return response.Notifiers[i].Name < response.Notifiers[j].Name
+	})
+	return response, nil
+}
+
+// DeleteNotifier implements the protobuf pps.DeleteNotifier RPC
+func (a *apiServer) DeleteNotifier(ctx context.Context, request *pps.DeleteNotifierRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	metricsFn := metrics.ReportUserAction(ctx, a.reporter, "DeleteNotifier")
+	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
+	pachClient := a.env.GetPachClient(ctx)
+
+	notifier := &pps.Notifier{}
+	if err := a.notifiers.ReadOnly(ctx).Get(request.Name, notifier); err != nil {
+		if col.IsErrNotFound(err) {
+			return nil, errors.Errorf("notifier %q not found", request.Name)
+		}
+		return nil, err
+	}
+	if err := a.authorizeNotifierOp(pachClient, notifier.Pipeline, "DeleteNotifier", auth.Scope_WRITER); err != nil {
+		return nil, err
+	}
+	if _, err := col.NewSTM(ctx, a.env.GetEtcdClient(), func(stm col.STM) error {
+		return a.notifiers.ReadWrite(stm).Delete(request.Name)
+	}); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
 // CreateSecret implements the protobuf pps.CreateSecret RPC
 func (a *apiServer) CreateSecret(ctx context.Context, request *pps.CreateSecretRequest) (response *types.Empty, retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
@@ -3101,6 +3906,13 @@ func (a *apiServer) DeleteAll(ctx context.Context, request *types.Empty) (respon
 		return nil, err
 	}
 
+	if _, err := col.NewSTM(ctx, a.env.GetEtcdClient(), func(stm col.STM) error {
+		a.notifiers.ReadWrite(stm).DeleteAll()
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
 	// PFS doesn't delete the spec repo, so do it here
 	if err := pachClient.DeleteRepo(ppsconsts.SpecRepo, true); err != nil && !isNotFoundErr(err) {
 		return nil, err
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+)
+
+func groupByInput(name, groupBy string) *pps.Input {
+	input := client.NewPFSInputOpts(name, name, "master", "/(*)", "", false)
+	input.Pfs.GroupBy = groupBy
+	return input
+}
+
+func TestValidateGroupInputSpec(t *testing.T) {
+	// Every input of a group sets group_by
+	require.NoError(t, validateInputSpec(client.NewGroupInput(
+		groupByInput("a", "$1"),
+		groupByInput("b", "$1"),
+	), false))
+	require.YesError(t, validateInputSpec(client.NewGroupInput(
+		groupByInput("a", "$1"),
+		groupByInput("b", ""),
+	), false))
+	require.YesError(t, validateInputSpec(client.NewGroupInput(
+		groupByInput("a", "$1"),
+		client.NewCronInput("tick", "@every 1m"),
+	), false))
+
+	// group_by is only allowed on the inputs of a group
+	require.YesError(t, validateInputSpec(groupByInput("a", "$1"), false))
+	require.YesError(t, validateInputSpec(client.NewCrossInput(
+		groupByInput("a", "$1"),
+		groupByInput("b", ""),
+	), false))
+	require.YesError(t, validateInputSpec(client.NewGroupInput(
+		client.NewUnionInput(groupByInput("a", "$1")),
+	), false))
+}
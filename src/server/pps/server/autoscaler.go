@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	"github.com/pachyderm/pachyderm/src/server/pkg/errutil"
+	"github.com/pachyderm/pachyderm/src/server/pkg/ppsdb"
+	"github.com/pachyderm/pachyderm/src/server/pkg/ppsutil"
+)
+
+const (
+	// autoscaleInterval is how often the PPS master resizes autoscaled
+	// pipelines
+	autoscaleInterval = 10 * time.Second
+	// defaultAutoscalingTarget is used for autoscaled pipelines that don't set
+	// a TargetDuration
+	defaultAutoscalingTarget = time.Minute
+	// autoscaleJobHistory is the most past jobs that are read to estimate how
+	// long a pipeline's datums take
+	autoscaleJobHistory = 10
+)
+
+// autoscaledWorkers returns the number of workers that a pipeline autoscaled
+// by 'autoscaling' should have, if its running job has 'outstanding' datums
+// left, and each datum takes 'perDatum' to process (or 0 if that isn't known
+// yet).
+func autoscaledWorkers(autoscaling *pps.Autoscaling, outstanding int64, perDatum time.Duration) int {
+	minWorkers, maxWorkers := int64(autoscaling.MinWorkers), int64(autoscaling.MaxWorkers)
+	if outstanding <= 0 {
+		return int(minWorkers)
+	}
+	target := defaultAutoscalingTarget
+	if autoscaling.TargetDuration != nil {
+		if d, err := types.DurationFromProto(autoscaling.TargetDuration); err == nil && d > 0 {
+			target = d
+		}
+	}
+	// If no datums have been timed yet, give every outstanding datum its own
+	// worker (within the bounds below)
+	workers := outstanding
+	if perDatum > 0 {
+		workers = int64(math.Ceil(float64(outstanding) * float64(perDatum) / float64(target)))
+	}
+	// There's no point in having more workers than datums
+	if workers > outstanding {
+		workers = outstanding
+	}
+	if workers < minWorkers {
+		workers = minWorkers
+	}
+	if workers > maxWorkers {
+		workers = maxWorkers
+	}
+	return int(workers)
+}
+
+// datumTime returns the total time spent on the datums counted in 'stats'.
+func datumTime(stats *pps.ProcessStats) time.Duration {
+	var total time.Duration
+	for _, d := range []*types.Duration{stats.DownloadTime, stats.ProcessTime, stats.UploadTime} {
+		if d == nil {
+			continue
+		}
+		if duration, err := types.DurationFromProto(d); err == nil {
+			total += duration
+		}
+	}
+	return total
+}
+
+// pipelineWorkload returns the number of datums that 'pipeline's unfinished
+// jobs have left to process, the average time that the pipeline's most recent
+// jobs have spent per datum (or 0 if none have processed any datums), and
+// whether the pipeline has any unfinished jobs.
+func (a *apiServer) pipelineWorkload(ctx context.Context, pipeline *pps.Pipeline) (outstanding int64, perDatum time.Duration, running bool, retErr error) {
+	jobPtr := &pps.EtcdJobInfo{}
+	var seen int
+	// Jobs are listed newest first
+	if err := a.jobs.ReadOnly(ctx).GetByIndex(ppsdb.JobsPipelineIndex, pipeline, jobPtr, col.DefaultOptions, func(string) error {
+		seen++
+		if !ppsutil.IsTerminal(jobPtr.State) {
+			running = true
+			outstanding += jobPtr.DataTotal - jobPtr.DataProcessed - jobPtr.DataSkipped - jobPtr.DataFailed - jobPtr.DataRecovered
+		}
+		if perDatum == 0 && jobPtr.DataProcessed > 0 && jobPtr.Stats != nil {
+			perDatum = datumTime(jobPtr.Stats) / time.Duration(jobPtr.DataProcessed)
+		}
+		if perDatum > 0 || seen >= autoscaleJobHistory {
+			return errutil.ErrBreak
+		}
+		return nil
+	}); err != nil {
+		return 0, 0, false, err
+	}
+	return outstanding, perDatum, running, nil
+}
+
+// autoscalePipeline periodically resizes 'pipelineInfo's workers to fit its
+// outstanding work. It only chooses the number of workers, which it writes to
+// the pipeline's EtcdPipelineInfo; the resulting event causes step() to
+// resize the pipeline's RC. Workers are added while a job is running, but
+// only removed once the pipeline has no unfinished jobs, so that scaling down
+// never kills a worker that has claimed a chunk.
+func (a *apiServer) autoscalePipeline(pachClient *client.APIClient, pipelineInfo *pps.PipelineInfo) error {
+	autoscaling := pipelineInfo.ParallelismSpec.Autoscaling
+	ticker := time.NewTicker(autoscaleInterval)
+	defer ticker.Stop()
+	for {
+		outstanding, perDatum, running, err := a.pipelineWorkload(pachClient.Ctx(), pipelineInfo.Pipeline)
+		if err != nil {
+			return err
+		}
+		workers := autoscaledWorkers(autoscaling, outstanding, perDatum)
+		if _, err := col.NewSTM(pachClient.Ctx(), a.env.GetEtcdClient(), func(stm col.STM) error {
+			pipelines := a.pipelines.ReadWrite(stm)
+			pipelinePtr := &pps.EtcdPipelineInfo{}
+			if err := pipelines.Get(pipelineInfo.Pipeline.Name, pipelinePtr); err != nil {
+				return err
+			}
+			current := int(pipelinePtr.AutoscaledWorkers)
+			if workers == current || (workers < current && running) {
+				return nil
+			}
+			log.Infof("PPS master: autoscaling pipeline %q from %d to %d workers (%d datums outstanding, %v per datum)",
+				pipelineInfo.Pipeline.Name, current, workers, outstanding, perDatum)
+			pipelinePtr.AutoscaledWorkers = uint64(workers)
+			return pipelines.Put(pipelineInfo.Pipeline.Name, pipelinePtr)
+		}); err != nil {
+			return err
+		}
+		select {
+		case <-ticker.C:
+		case <-pachClient.Ctx().Done():
+			return context.DeadlineExceeded
+		}
+	}
+}
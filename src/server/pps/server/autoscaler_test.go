@@ -0,0 +1,46 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+)
+
+func TestAutoscaledWorkers(t *testing.T) {
+	autoscaling := &pps.Autoscaling{
+		MinWorkers:     2,
+		MaxWorkers:     10,
+		TargetDuration: types.DurationProto(time.Minute),
+	}
+	// No outstanding work
+	require.Equal(t, 2, autoscaledWorkers(autoscaling, 0, time.Second))
+	// 120 datums at 2s each is 4 minutes of work, so 4 workers finish in 1m
+	require.Equal(t, 4, autoscaledWorkers(autoscaling, 120, 2*time.Second))
+	// Round up
+	require.Equal(t, 5, autoscaledWorkers(autoscaling, 121, 2*time.Second))
+	// Clamp to min and max workers
+	require.Equal(t, 2, autoscaledWorkers(autoscaling, 10, time.Second))
+	require.Equal(t, 10, autoscaledWorkers(autoscaling, 10000, time.Second))
+	// Never more workers than datums (above the minimum)
+	require.Equal(t, 3, autoscaledWorkers(autoscaling, 3, time.Hour))
+	// Without timing information, each datum gets a worker
+	require.Equal(t, 7, autoscaledWorkers(autoscaling, 7, 0))
+	require.Equal(t, 10, autoscaledWorkers(autoscaling, 70, 0))
+
+	// The target defaults to one minute
+	autoscaling.TargetDuration = nil
+	require.Equal(t, 4, autoscaledWorkers(autoscaling, 120, 2*time.Second))
+}
+
+func TestDatumTime(t *testing.T) {
+	require.Equal(t, time.Duration(0), datumTime(&pps.ProcessStats{}))
+	require.Equal(t, 6*time.Second, datumTime(&pps.ProcessStats{
+		DownloadTime: types.DurationProto(time.Second),
+		ProcessTime:  types.DurationProto(2 * time.Second),
+		UploadTime:   types.DurationProto(3 * time.Second),
+	}))
+}
@@ -1,7 +1,8 @@
 // Package githook adds support for git-based sources in pipeline specs. It
 // does so by exposing an HTTP server that listens for webhook requests. This
 // works with github's webhook API, and anything else API-compatible with
-// their push events.
+// their push events. The same server also accepts arbitrary payloads for
+// pipelines' webhook inputs.
 package githook
 
 import (
@@ -11,6 +12,8 @@ import (
 	"math"
 	"net/http"
 	"path"
+	"strings"
+	"sync"
 
 	"github.com/pachyderm/pachyderm/src/client"
 	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
@@ -23,6 +26,7 @@ import (
 	logrus "github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 	"gopkg.in/go-playground/webhooks.v5/github"
+	kube "k8s.io/client-go/kubernetes"
 )
 
 // GitHookPort specifies the port the server will listen on
@@ -35,6 +39,13 @@ type gitHookServer struct {
 	client     *client.APIClient
 	etcdClient *etcd.Client
 	pipelines  col.Collection
+	kubeClient *kube.Clientset
+	namespace  string
+	// webhookLocks serializes the commits made to each webhook input repo, so
+	// that concurrent requests don't start commits on the same branch at once.
+	// It's guarded by webhookMu.
+	webhookMu    sync.Mutex
+	webhookLocks map[string]*sync.Mutex
 }
 
 func hookPath() string {
@@ -57,8 +68,9 @@ func URLFromDomain(domain string) string {
 	return fmt.Sprintf("http://%v:%v%v", domain, ExternalPort(), hookPath())
 }
 
-// RunGitHookServer starts the webhook server
-func RunGitHookServer(address string, etcdAddress string, etcdPrefix string) error {
+// RunGitHookServer starts the webhook server. 'kubeClient' and 'namespace'
+// are used to read the secrets of webhook inputs.
+func RunGitHookServer(address string, etcdAddress string, etcdPrefix string, kubeClient *kube.Clientset, namespace string) error {
 	c, err := client.NewFromAddress(address)
 	if err != nil {
 		return err
@@ -77,10 +89,13 @@ func RunGitHookServer(address string, etcdAddress string, etcdPrefix string) err
 		return err
 	}
 	s := &gitHookServer{
-		hook,
-		c,
-		etcdClient,
-		ppsdb.Pipelines(etcdClient, etcdPrefix),
+		hook:         hook,
+		client:       c,
+		etcdClient:   etcdClient,
+		pipelines:    ppsdb.Pipelines(etcdClient, etcdPrefix),
+		kubeClient:   kubeClient,
+		namespace:    namespace,
+		webhookLocks: make(map[string]*sync.Mutex),
 	}
 	return http.ListenAndServe(fmt.Sprintf(":%d", GitHookPort), s)
 }
@@ -96,6 +111,10 @@ func matchingBranch(inputBranch string, payloadBranch string) bool {
 }
 
 func (s *gitHookServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, webhookPath()) {
+		s.serveWebhook(w, r)
+		return
+	}
 	payload, err := s.hook.Parse(r, github.PushEvent)
 	if err != nil {
 		// `ErrEventNotFound` implies github sent an event we didn't ask for
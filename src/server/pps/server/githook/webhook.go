@@ -0,0 +1,249 @@
+package githook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	"github.com/pachyderm/pachyderm/src/server/pkg/ppsconsts"
+	"github.com/pachyderm/pachyderm/src/server/pkg/ppsutil"
+	"github.com/pachyderm/pachyderm/src/server/pkg/uuid"
+
+	"github.com/gogo/protobuf/types"
+	logrus "github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// WebhookSecretHeader is the header in which requests to a webhook input
+	// present the input's shared secret
+	WebhookSecretHeader = "X-Pachyderm-Webhook-Secret"
+	// maxWebhookPayloadSize is the largest request body that's accepted by a
+	// webhook input
+	maxWebhookPayloadSize = 64 * 1024 * 1024
+	// webhookFileFormat is the format of the names of the files that payloads
+	// are written to. Unlike RFC3339Nano it's fixed-width, so that payloads
+	// sort in the order in which they were received.
+	webhookFileFormat = "2006-01-02T15:04:05.000000000Z"
+)
+
+func webhookPath() string {
+	return fmt.Sprintf("/%v/handle/webhook/", apiVersion)
+}
+
+// WebhookURLFromDomain provides the URL of 'pipeline's webhook inputs given an
+// input domain. Each input's URL is this URL followed by "/<input name>".
+func WebhookURLFromDomain(domain string, pipeline string) string {
+	return fmt.Sprintf("http://%v:%v%v%v", domain, ExternalPort(), webhookPath(), pipeline)
+}
+
+// webhookError is an error with the HTTP status that it should be reported
+// with
+type webhookError struct {
+	status int
+	err    error
+}
+
+func (e *webhookError) Error() string {
+	return e.err.Error()
+}
+
+func newWebhookError(status int, format string, args ...interface{}) error {
+	return &webhookError{status: status, err: errors.Errorf(format, args...)}
+}
+
+// serveWebhook handles a request to a webhook input, which is committed to
+// the input's repo.
+func (s *gitHookServer) serveWebhook(w http.ResponseWriter, r *http.Request) {
+	commit, file, err := s.handleWebhook(r)
+	if err != nil {
+		status := http.StatusInternalServerError
+		webhookErr, isWebhookErr := err.(*webhookError)
+		switch {
+		case isWebhookErr:
+			status = webhookErr.status
+		case auth.IsErrBadToken(err):
+			status = http.StatusUnauthorized
+		case auth.IsErrNotAuthorized(err):
+			status = http.StatusForbidden
+		default:
+			logrus.Errorf("webhook failed to handle request to %v: %v", r.URL.Path, err)
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"repo":   commit.Repo.Name,
+		"commit": commit.ID,
+		"file":   file,
+	})
+}
+
+func (s *gitHookServer) handleWebhook(r *http.Request) (_ *pfs.Commit, _ string, retErr error) {
+	if r.Method != http.MethodPost {
+		return nil, "", newWebhookError(http.StatusMethodNotAllowed, "webhook inputs only accept POST requests")
+	}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, webhookPath()), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, "", newWebhookError(http.StatusNotFound, "webhook URLs look like %v<pipeline>/<input>", webhookPath())
+	}
+	pipelineName, inputName := parts[0], parts[1]
+	ctx := r.Context()
+
+	// Looking up the pipeline is done as PPS, as the request hasn't been
+	// authenticated yet
+	superUserClient, err := s.superUserClient(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	input, err := s.findWebhookInput(superUserClient, pipelineName, inputName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Requests may present the input's shared secret, in which case the payload
+	// is written as PPS, or a Pachyderm token, in which case it's written as the
+	// token's owner (and fails if they can't write to the input repo)
+	var pachClient *client.APIClient
+	if secret := r.Header.Get(WebhookSecretHeader); secret != "" {
+		if input.Secret == "" {
+			return nil, "", newWebhookError(http.StatusUnauthorized, "webhook input %q has no secret", inputName)
+		}
+		expected, err := s.webhookSecret(input)
+		if err != nil {
+			return nil, "", err
+		}
+		if !hmac.Equal([]byte(secret), expected) {
+			return nil, "", newWebhookError(http.StatusUnauthorized, "incorrect secret for webhook input %q", inputName)
+		}
+		pachClient = superUserClient
+	} else if token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "); token != "" {
+		pachClient = s.client.WithCtx(ctx)
+		pachClient.SetAuthToken(token)
+	} else if input.Secret != "" {
+		return nil, "", newWebhookError(http.StatusUnauthorized, "webhook input %q requires a secret (in the %v header) or a Pachyderm token", inputName, WebhookSecretHeader)
+	} else {
+		// Without auth, anyone may write to an input without a secret
+		pachClient = s.client.WithCtx(ctx)
+	}
+
+	// The payload is read before the repo is locked, so that a slow request
+	// doesn't hold up the others
+	payload, err := ioutil.ReadAll(io.LimitReader(r.Body, maxWebhookPayloadSize+1))
+	if err != nil {
+		return nil, "", newWebhookError(http.StatusBadRequest, "could not read request body: %v", err)
+	}
+	if len(payload) > maxWebhookPayloadSize {
+		return nil, "", newWebhookError(http.StatusRequestEntityTooLarge, "webhook payloads may be at most %d bytes", maxWebhookPayloadSize)
+	}
+
+	lock := s.webhookLock(input.Repo)
+	lock.Lock()
+	defer lock.Unlock()
+	commit, err := pachClient.StartCommit(input.Repo, "master")
+	if err != nil {
+		return nil, "", err
+	}
+	defer func() {
+		if retErr != nil {
+			if err := pachClient.DeleteCommit(input.Repo, commit.ID); err != nil {
+				logrus.Errorf("webhook failed to delete partial commit (%v) on repo (%v) with error %v", commit.ID, input.Repo, err)
+			}
+			return
+		}
+		retErr = pachClient.FinishCommit(input.Repo, commit.ID)
+	}()
+	if input.Overwrite {
+		if err := pachClient.DeleteFile(input.Repo, commit.ID, ""); err != nil {
+			return nil, "", err
+		}
+	}
+	file := fmt.Sprintf("%s-%s", time.Now().UTC().Format(webhookFileFormat), uuid.NewWithoutDashes()[:8])
+	if _, err := pachClient.PutFile(input.Repo, commit.ID, file, bytes.NewReader(payload)); err != nil {
+		return nil, "", err
+	}
+	return commit, file, nil
+}
+
+// webhookLock returns the lock that serializes commits to webhook input repo
+// 'repo'.
+func (s *gitHookServer) webhookLock(repo string) *sync.Mutex {
+	s.webhookMu.Lock()
+	defer s.webhookMu.Unlock()
+	lock, ok := s.webhookLocks[repo]
+	if !ok {
+		lock = &sync.Mutex{}
+		s.webhookLocks[repo] = lock
+	}
+	return lock
+}
+
+// findWebhookInput returns the webhook input named 'inputName' of pipeline
+// 'pipelineName'.
+func (s *gitHookServer) findWebhookInput(pachClient *client.APIClient, pipelineName string, inputName string) (*pps.WebhookInput, error) {
+	pipelinePtr := &pps.EtcdPipelineInfo{}
+	if err := s.pipelines.ReadOnly(pachClient.Ctx()).Get(pipelineName, pipelinePtr); err != nil {
+		if col.IsErrNotFound(err) {
+			return nil, newWebhookError(http.StatusNotFound, "pipeline %q not found", pipelineName)
+		}
+		return nil, err
+	}
+	pipelineInfo, err := ppsutil.GetPipelineInfo(pachClient, pipelinePtr)
+	if err != nil {
+		return nil, err
+	}
+	var result *pps.WebhookInput
+	pps.VisitInput(pipelineInfo.Input, func(input *pps.Input) {
+		if input.Webhook != nil && input.Webhook.Name == inputName {
+			result = input.Webhook
+		}
+	})
+	if result == nil {
+		return nil, newWebhookError(http.StatusNotFound, "pipeline %q has no webhook input named %q", pipelineName, inputName)
+	}
+	return result, nil
+}
+
+// webhookSecret reads the shared secret of 'input' from Kubernetes.
+func (s *gitHookServer) webhookSecret(input *pps.WebhookInput) ([]byte, error) {
+	secret, err := s.kubeClient.CoreV1().Secrets(s.namespace).Get(input.Secret, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read secret %q of webhook input %q", input.Secret, input.Name)
+	}
+	value, ok := secret.Data[input.SecretKey]
+	if !ok || len(value) == 0 {
+		return nil, errors.Errorf("secret %q of webhook input %q has no key %q", input.Secret, input.Name, input.SecretKey)
+	}
+	return value, nil
+}
+
+// superUserClient returns a client that's authenticated as PPS, if auth is
+// activated.
+func (s *gitHookServer) superUserClient(ctx context.Context) (*client.APIClient, error) {
+	pachClient := s.client.WithCtx(ctx)
+	tokens := col.NewCollection(s.etcdClient, ppsconsts.PPSTokenKey, nil, &types.StringValue{}, nil, nil).ReadOnly(ctx)
+	var token types.StringValue
+	if err := tokens.Get("", &token); err != nil {
+		if col.IsErrNotFound(err) {
+			return pachClient, nil // auth isn't activated
+		}
+		return nil, err
+	}
+	pachClient.SetAuthToken(token.Value)
+	return pachClient, nil
+}
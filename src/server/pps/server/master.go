@@ -65,6 +65,14 @@ func (a *apiServer) master() {
 
 		log.Infof("PPS master: launching master process")
 
+		// Changes made before this revision were already seen (and notified) by
+		// a previous master
+		resp, err := a.env.GetEtcdClient().Get(ctx, path.Join(a.etcdPrefix, masterLockPath))
+		if err != nil {
+			return errors.Wrapf(err, "error reading etcd revision")
+		}
+		notifications := newNotifications(resp.Header.Revision)
+
 		// TODO(msteffen) request only keys, since pipeline_controller.go reads
 		// fresh values for each event anyway
 		pipelineWatcher, err := a.pipelines.ReadOnly(ctx).Watch()
@@ -73,6 +81,14 @@ func (a *apiServer) master() {
 		}
 		defer pipelineWatcher.Close()
 
+		// jobWatcher lets the scheduler track how many jobs are running, and
+		// admit queued jobs
+		jobWatcher, err := a.jobs.ReadOnly(ctx).Watch()
+		if err != nil {
+			return errors.Wrapf(err, "error creating job watch")
+		}
+		defer jobWatcher.Close()
+
 		// watchChan will be nil if the Watch call below errors, this means
 		// that we won't receive events from k8s and won't be able to detect
 		// errors in pods. We could just return that error and retry but that
@@ -102,11 +118,47 @@ func (a *apiServer) master() {
 				}
 				switch event.Type {
 				case watch.EventPut:
-					pipeline := string(event.Key)
+					var pipeline string
+					pipelinePtr := &pps.EtcdPipelineInfo{}
+					if err := event.Unmarshal(&pipeline, pipelinePtr); err != nil {
+						return err
+					}
 					// Create/Modify/Delete pipeline resources as needed per new state
 					if err := a.step(pachClient, pipeline, event.Ver, event.Rev); err != nil {
 						log.Errorf("PPS master: %v", err)
 					}
+					if err := a.notify(ctx, notifications.pipelineEvent(pipeline, pipelinePtr, event.Rev)); err != nil {
+						log.Errorf("PPS master: error sending notifications: %v", err)
+					}
+				case watch.EventDelete:
+					a.scheduler.removePipeline(string(event.Key))
+					notifications.forgetPipeline(string(event.Key))
+				}
+				a.stepRescheduled(pachClient)
+				if err := a.admitJobs(ctx); err != nil {
+					return err
+				}
+			case event := <-jobWatcher.Watch():
+				if event.Err != nil {
+					return errors.Wrapf(event.Err, "job event err")
+				}
+				switch event.Type {
+				case watch.EventPut:
+					var jobID string
+					jobPtr := &pps.EtcdJobInfo{}
+					if err := event.Unmarshal(&jobID, jobPtr); err != nil {
+						return err
+					}
+					a.scheduler.setJob(jobID, jobPtr.Pipeline.Name, jobPtr.State)
+					if err := a.notify(ctx, notifications.jobEvent(jobPtr, event.Rev)); err != nil {
+						log.Errorf("PPS master: error sending notifications: %v", err)
+					}
+				case watch.EventDelete:
+					a.scheduler.removeJob(string(event.Key))
+					notifications.forgetJob(string(event.Key))
+				}
+				if err := a.admitJobs(ctx); err != nil {
+					return err
 				}
 			case event := <-watchChan:
 				// if we get an error we restart the watch, k8s watches seem to
@@ -179,6 +231,41 @@ func (a *apiServer) cancelMonitor(pipeline string) {
 	a.monitorCancelsMu.Unlock()
 }
 
+// stepRescheduled steps the pipelines whose worker grant has been changed by
+// other pipelines scaling up or down, so that their RCs are resized.
+func (a *apiServer) stepRescheduled(pachClient *client.APIClient) {
+	for pipelines := a.scheduler.takeRescheduled(); len(pipelines) > 0; pipelines = a.scheduler.takeRescheduled() {
+		for _, pipeline := range pipelines {
+			log.Infof("PPS master: rescheduling workers for %q", pipeline)
+			if err := a.step(pachClient, pipeline, 0, 0); err != nil {
+				log.Errorf("PPS master: %v", err)
+			}
+		}
+	}
+}
+
+// admitJobs moves the queued jobs that the scheduler admits into
+// JOB_RUNNING. The worker master of each job is waiting for this, and
+// continues processing the job once it sees the state change.
+func (a *apiServer) admitJobs(ctx context.Context) error {
+	for _, jobID := range a.scheduler.admit() {
+		log.Infof("PPS master: admitting queued job %q", jobID)
+		if _, err := col.NewSTM(ctx, a.env.GetEtcdClient(), func(stm col.STM) error {
+			jobPtr := &pps.EtcdJobInfo{}
+			if err := a.jobs.ReadWrite(stm).Get(jobID, jobPtr); err != nil {
+				return err
+			}
+			if jobPtr.State != pps.JobState_JOB_QUEUED {
+				return nil // e.g. the job was killed while it was queued
+			}
+			return ppsutil.UpdateJobState(a.pipelines.ReadWrite(stm), a.jobs.ReadWrite(stm), jobPtr, pps.JobState_JOB_RUNNING, "")
+		}); err != nil && !col.IsErrNotFound(err) {
+			return errors.Wrapf(err, "could not admit job %q", jobID)
+		}
+	}
+	return nil
+}
+
 func (a *apiServer) deletePipelineResources(ctx context.Context, pipelineName string) (retErr error) {
 	log.Infof("PPS master: deleting resources for pipeline %q", pipelineName)
 	span, ctx := tracing.AddSpanToAnyExisting(ctx, //lint:ignore SA4006 ctx is unused, but better to have the right ctx in scope so people don't use the wrong one
@@ -190,6 +277,8 @@ func (a *apiServer) deletePipelineResources(ctx context.Context, pipelineName st
 
 	// Cancel any running monitorPipeline call
 	a.cancelMonitor(pipelineName)
+	// Give the pipeline's workers to other pipelines
+	a.scheduler.scaleDown(pipelineName)
 
 	kubeClient := a.env.GetKubeClient()
 	// Delete any services associated with op.pipeline
@@ -283,7 +372,21 @@ func (a *apiServer) monitorPipeline(pachClient *client.APIClient, pipelineInfo *
 				}, backoff.NewInfiniteBackOff(), notifyCtx(pachClient.Ctx(), "cron for "+in.Cron.Name))
 			})
 		}
+		if in.ObjectStore != nil {
+			eg.Go(func() error {
+				return backoff.RetryNotify(func() error {
+					return a.pollObjectStore(pachClient, in.ObjectStore)
+				}, backoff.NewInfiniteBackOff(), notifyCtx(pachClient.Ctx(), "object store input "+in.ObjectStore.Name))
+			})
+		}
 	})
+	if pipelineInfo.ParallelismSpec.GetAutoscaling() != nil {
+		eg.Go(func() error {
+			return backoff.RetryNotify(func() error {
+				return a.autoscalePipeline(pachClient, pipelineInfo)
+			}, backoff.NewInfiniteBackOff(), notifyCtx(pachClient.Ctx(), "autoscaling"))
+		})
+	}
 	if pipelineInfo.Standby {
 		// Capacity 1 gives us a bit of buffer so we don't needlessly go into
 		// standby when SubscribeCommit takes too long to return.
@@ -0,0 +1,325 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+	"github.com/pachyderm/pachyderm/src/server/pkg/backoff"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	"github.com/pachyderm/pachyderm/src/server/pkg/ppsutil"
+)
+
+// notificationTimeout is how long a notifier has to respond to a request
+const notificationTimeout = 30 * time.Second
+
+// notificationClient is used to deliver notifications. It refuses to connect
+// to addresses inside the cluster, so that a notifier can't be used to reach
+// services that are only meant to be reachable from within it (including when
+// a public hostname resolves to such an address).
+var notificationClient = &http.Client{
+	Timeout: notificationTimeout,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: notificationTimeout,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				return checkNotifierIP(net.ParseIP(host))
+			},
+		}).DialContext,
+		TLSHandshakeTimeout: notificationTimeout,
+	},
+}
+
+// internalNetworks are the networks that notifiers may not send requests to:
+// loopback, link-local (which includes cloud metadata services) and private
+// networks, which is where cluster services and pods live.
+var internalNetworks = func() []*net.IPNet {
+	var result []*net.IPNet
+	for _, cidr := range []string{
+		"0.0.0.0/8",
+		"10.0.0.0/8",
+		"100.64.0.0/10",
+		"127.0.0.0/8",
+		"169.254.0.0/16",
+		"172.16.0.0/12",
+		"192.168.0.0/16",
+		"::/128",
+		"::1/128",
+		"fc00::/7",
+		"fe80::/10",
+	} {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		result = append(result, network)
+	}
+	return result
+}()
+
+// checkNotifierIP returns an error if notifiers may not send requests to 'ip'.
+func checkNotifierIP(ip net.IP) error {
+	if ip == nil {
+		return errors.New("notifier address is not an IP address")
+	}
+	for _, network := range internalNetworks {
+		if network.Contains(ip) {
+			return errors.Errorf("notifiers cannot send requests to %s, which is "+
+				"a loopback, link-local or private address", ip)
+		}
+	}
+	return nil
+}
+
+// validateNotifierURL returns an error if 'rawURL' isn't an http or https URL,
+// or if it names a host inside the cluster.
+func validateNotifierURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.Errorf("notifier URL must be an http or https URL (got %q)", rawURL)
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if ip := net.ParseIP(host); ip != nil {
+		return checkNotifierIP(ip)
+	}
+	// Single-label names (e.g. "pachd") and these domains are resolved by the
+	// cluster's DNS rather than public DNS
+	if !strings.Contains(host, ".") || host == "localhost" {
+		return errors.Errorf("notifier URL cannot name host %q, which is inside the cluster", host)
+	}
+	for _, suffix := range []string{".localhost", ".local", ".internal", ".svc"} {
+		if strings.HasSuffix(host, suffix) {
+			return errors.Errorf("notifier URL cannot name host %q, which is inside the cluster", host)
+		}
+	}
+	return nil
+}
+
+// notifications tracks the states of the jobs and pipelines seen by the PPS
+// master, so that it can tell notifiers about state changes. It's owned by
+// the master's event loop, and rebuilt whenever a new master takes over.
+type notifications struct {
+	// watchRev is the etcd revision at which the master started watching.
+	// Changes at or before it were already seen by a previous master.
+	watchRev       int64
+	jobStates      map[string]pps.JobState
+	pipelineStates map[string]pps.PipelineState
+}
+
+func newNotifications(watchRev int64) *notifications {
+	return &notifications{
+		watchRev:       watchRev,
+		jobStates:      make(map[string]pps.JobState),
+		pipelineStates: make(map[string]pps.PipelineState),
+	}
+}
+
+func newNotification(event pps.NotifierEvent, name string, rev int64) *pps.Notification {
+	return &pps.Notification{
+		ID:    fmt.Sprintf("%d-%s-%s", rev, name, strings.ToLower(event.String())),
+		Event: event,
+		Time:  types.TimestampNow(),
+	}
+}
+
+// jobEvent records that 'jobPtr' was written at etcd revision 'rev', and
+// returns the notifications that its state change calls for.
+func (n *notifications) jobEvent(jobPtr *pps.EtcdJobInfo, rev int64) []*pps.Notification {
+	jobID := jobPtr.Job.ID
+	prev, seen := n.jobStates[jobID]
+	n.jobStates[jobID] = jobPtr.State
+	if rev <= n.watchRev || (seen && prev == jobPtr.State) {
+		return nil
+	}
+	var events []pps.NotifierEvent
+	switch jobPtr.State {
+	case pps.JobState_JOB_RUNNING:
+		if !seen || prev != pps.JobState_JOB_MERGING {
+			events = append(events, pps.NotifierEvent_NOTIFY_JOB_STARTED)
+		}
+	case pps.JobState_JOB_SUCCESS:
+		events = append(events, pps.NotifierEvent_NOTIFY_JOB_SUCCEEDED)
+	case pps.JobState_JOB_FAILURE:
+		events = append(events, pps.NotifierEvent_NOTIFY_JOB_FAILED)
+		if strings.HasPrefix(jobPtr.Reason, ppsutil.EgressErrorPrefix) {
+			events = append(events, pps.NotifierEvent_NOTIFY_EGRESS_FAILED)
+		}
+	case pps.JobState_JOB_KILLED:
+		events = append(events, pps.NotifierEvent_NOTIFY_JOB_KILLED)
+	}
+	var result []*pps.Notification
+	for _, event := range events {
+		notification := newNotification(event, jobID, rev)
+		notification.Pipeline = jobPtr.Pipeline
+		notification.Job = jobPtr.Job
+		notification.JobState = jobPtr.State
+		notification.Reason = jobPtr.Reason
+		result = append(result, notification)
+	}
+	return result
+}
+
+// forgetJob stops tracking a deleted job.
+func (n *notifications) forgetJob(jobID string) {
+	delete(n.jobStates, jobID)
+}
+
+// pipelineEvent records that pipeline 'pipeline', as described by
+// 'pipelinePtr', was written at etcd revision 'rev', and returns the
+// notifications that its state change calls for.
+func (n *notifications) pipelineEvent(pipeline string, pipelinePtr *pps.EtcdPipelineInfo, rev int64) []*pps.Notification {
+	prev, seen := n.pipelineStates[pipeline]
+	n.pipelineStates[pipeline] = pipelinePtr.State
+	if rev <= n.watchRev || (seen && prev == pipelinePtr.State) {
+		return nil
+	}
+	notification := newNotification(pps.NotifierEvent_NOTIFY_PIPELINE_STATE_CHANGED, pipeline, rev)
+	notification.Pipeline = client.NewPipeline(pipeline)
+	notification.PipelineState = pipelinePtr.State
+	notification.Reason = pipelinePtr.Reason
+	return []*pps.Notification{notification}
+}
+
+// forgetPipeline stops tracking a deleted pipeline.
+func (n *notifications) forgetPipeline(pipeline string) {
+	delete(n.pipelineStates, pipeline)
+}
+
+// notifierWants returns true if 'notifier' should be sent 'notification'.
+func notifierWants(notifier *pps.Notifier, notification *pps.Notification) bool {
+	if notifier.Pipeline != nil && notifier.Pipeline.Name != notification.Pipeline.Name {
+		return false
+	}
+	if len(notifier.Events) == 0 {
+		return true
+	}
+	for _, event := range notifier.Events {
+		if event == notification.Event {
+			return true
+		}
+	}
+	return false
+}
+
+// notify sends 'notifications' to the notifiers that want them. Each one is
+// delivered in the background, so that slow or broken endpoints don't hold up
+// the PPS master.
+func (a *apiServer) notify(ctx context.Context, notifications []*pps.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	notifier := &pps.Notifier{}
+	return a.notifiers.ReadOnly(ctx).List(notifier, col.DefaultOptions, func(string) error {
+		for _, notification := range notifications {
+			if notifierWants(notifier, notification) {
+				go a.deliverNotification(ctx, proto.Clone(notifier).(*pps.Notifier), notification)
+			}
+		}
+		return nil
+	})
+}
+
+// deliverNotification POSTs 'notification' to 'notifier', retrying with
+// backoff until the notifier accepts it or the backoff gives up.
+func (a *apiServer) deliverNotification(ctx context.Context, notifier *pps.Notifier, notification *pps.Notification) {
+	body, err := (&jsonpb.Marshaler{}).MarshalToString(notification)
+	if err != nil {
+		log.Errorf("PPS master: could not marshal notification %s: %v", notification.ID, err)
+		return
+	}
+	if err := backoff.RetryNotify(func() error {
+		return postNotification(ctx, notificationClient, notifier, notification, []byte(body))
+	}, backoff.NewExponentialBackOff(), func(err error, d time.Duration) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		log.Errorf("PPS master: error sending notification %s to %q: %v; retrying in %v", notification.ID, notifier.Name, err, d)
+		return nil
+	}); err != nil {
+		log.Errorf("PPS master: giving up on sending notification %s to %q: %v", notification.ID, notifier.Name, err)
+	}
+}
+
+func postNotification(ctx context.Context, httpClient *http.Client, notifier *pps.Notifier, notification *pps.Notification, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, notifier.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Pachyderm-Event", notification.Event.String())
+	req.Header.Set("X-Pachyderm-Delivery", notification.ID)
+	if notifier.Secret != "" {
+		req.Header.Set("X-Pachyderm-Signature", pps.NotificationSignature(notifier.Secret, body))
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("%s responded with %s", notifier.URL, resp.Status)
+	}
+	return nil
+}
+
+// authorizeNotifierOp checks that the caller may perform 'op' on notifiers
+// for 'pipeline': that requires 'scope' access to the pipeline's output repo,
+// or being an admin for notifiers that aren't limited to one pipeline.
+func (a *apiServer) authorizeNotifierOp(pachClient *client.APIClient, pipeline *pps.Pipeline, op string, scope auth.Scope) error {
+	ctx := pachClient.Ctx()
+	me, err := pachClient.WhoAmI(ctx, &auth.WhoAmIRequest{})
+	if auth.IsErrNotActivated(err) {
+		return nil // Auth isn't activated, skip authorization completely
+	} else if err != nil {
+		return err
+	}
+	if pipeline == nil {
+		if !me.IsAdmin {
+			return &auth.ErrNotAuthorized{
+				Subject: me.Username,
+				AdminOp: op + " for all pipelines",
+			}
+		}
+		return nil
+	}
+	resp, err := pachClient.Authorize(ctx, &auth.AuthorizeRequest{
+		Repo:  pipeline.Name,
+		Scope: scope,
+	})
+	if err != nil {
+		return err
+	}
+	if !resp.Authorized {
+		return &auth.ErrNotAuthorized{
+			Subject:  me.Username,
+			Repo:     pipeline.Name,
+			Required: scope,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+	"github.com/pachyderm/pachyderm/src/server/pkg/ppsutil"
+)
+
+func notificationEvents(notifications []*pps.Notification) []pps.NotifierEvent {
+	var result []pps.NotifierEvent
+	for _, n := range notifications {
+		result = append(result, n.Event)
+	}
+	return result
+}
+
+func TestJobNotifications(t *testing.T) {
+	n := newNotifications(10)
+	jobPtr := &pps.EtcdJobInfo{
+		Job:      client.NewJob("job"),
+		Pipeline: client.NewPipeline("pipeline"),
+		State:    pps.JobState_JOB_RUNNING,
+	}
+	// Changes from before the master started watching were already notified
+	require.Equal(t, 0, len(n.jobEvent(jobPtr, 5)))
+
+	jobPtr.State = pps.JobState_JOB_MERGING
+	require.Equal(t, 0, len(n.jobEvent(jobPtr, 11)))
+	// Going back to running after merging (e.g. because the job is retried)
+	// isn't a new start
+	jobPtr.State = pps.JobState_JOB_RUNNING
+	require.Equal(t, 0, len(n.jobEvent(jobPtr, 12)))
+	// Writes that don't change the state aren't notified
+	require.Equal(t, 0, len(n.jobEvent(jobPtr, 13)))
+
+	jobPtr.State = pps.JobState_JOB_FAILURE
+	jobPtr.Reason = ppsutil.EgressErrorPrefix + "bucket not found"
+	notifications := n.jobEvent(jobPtr, 14)
+	require.Equal(t, []pps.NotifierEvent{
+		pps.NotifierEvent_NOTIFY_JOB_FAILED,
+		pps.NotifierEvent_NOTIFY_EGRESS_FAILED,
+	}, notificationEvents(notifications))
+	require.Equal(t, "14-job-notify_job_failed", notifications[0].ID)
+	require.Equal(t, "pipeline", notifications[0].Pipeline.Name)
+	require.Equal(t, jobPtr.Reason, notifications[0].Reason)
+
+	// A new job is notified when it starts, and again when it succeeds
+	other := &pps.EtcdJobInfo{
+		Job:      client.NewJob("other"),
+		Pipeline: client.NewPipeline("pipeline"),
+		State:    pps.JobState_JOB_STARTING,
+	}
+	require.Equal(t, 0, len(n.jobEvent(other, 15)))
+	other.State = pps.JobState_JOB_RUNNING
+	require.Equal(t, []pps.NotifierEvent{pps.NotifierEvent_NOTIFY_JOB_STARTED}, notificationEvents(n.jobEvent(other, 16)))
+	other.State = pps.JobState_JOB_SUCCESS
+	require.Equal(t, []pps.NotifierEvent{pps.NotifierEvent_NOTIFY_JOB_SUCCEEDED}, notificationEvents(n.jobEvent(other, 17)))
+}
+
+func TestPipelineNotifications(t *testing.T) {
+	n := newNotifications(10)
+	pipelinePtr := &pps.EtcdPipelineInfo{State: pps.PipelineState_PIPELINE_STARTING}
+	require.Equal(t, 0, len(n.pipelineEvent("pipeline", pipelinePtr, 10)))
+	require.Equal(t, 0, len(n.pipelineEvent("pipeline", pipelinePtr, 11)))
+	pipelinePtr.State = pps.PipelineState_PIPELINE_RUNNING
+	notifications := n.pipelineEvent("pipeline", pipelinePtr, 12)
+	require.Equal(t, 1, len(notifications))
+	require.Equal(t, pps.NotifierEvent_NOTIFY_PIPELINE_STATE_CHANGED, notifications[0].Event)
+	require.Equal(t, pps.PipelineState_PIPELINE_RUNNING, notifications[0].PipelineState)
+
+	// A re-created pipeline is notified again
+	n.forgetPipeline("pipeline")
+	require.Equal(t, 1, len(n.pipelineEvent("pipeline", pipelinePtr, 13)))
+}
+
+func TestNotifierWants(t *testing.T) {
+	notification := &pps.Notification{
+		Event:    pps.NotifierEvent_NOTIFY_JOB_FAILED,
+		Pipeline: client.NewPipeline("pipeline"),
+	}
+	require.True(t, notifierWants(&pps.Notifier{}, notification))
+	require.True(t, notifierWants(&pps.Notifier{Pipeline: client.NewPipeline("pipeline")}, notification))
+	require.False(t, notifierWants(&pps.Notifier{Pipeline: client.NewPipeline("other")}, notification))
+	require.True(t, notifierWants(&pps.Notifier{Events: []pps.NotifierEvent{
+		pps.NotifierEvent_NOTIFY_JOB_SUCCEEDED,
+		pps.NotifierEvent_NOTIFY_JOB_FAILED,
+	}}, notification))
+	require.False(t, notifierWants(&pps.Notifier{Events: []pps.NotifierEvent{
+		pps.NotifierEvent_NOTIFY_JOB_SUCCEEDED,
+	}}, notification))
+}
+
+func TestPostNotification(t *testing.T) {
+	var status int
+	var header http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		var err error
+		body, err = ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	notifier := &pps.Notifier{Name: "notifier", URL: server.URL, Secret: "secret"}
+	notification := &pps.Notification{ID: "1-job-notify_job_failed", Event: pps.NotifierEvent_NOTIFY_JOB_FAILED}
+	status = http.StatusOK
+	require.NoError(t, postNotification(context.Background(), server.Client(), notifier, notification, []byte(`{"id":"1"}`)))
+	require.Equal(t, `{"id":"1"}`, string(body))
+	require.Equal(t, "NOTIFY_JOB_FAILED", header.Get("X-Pachyderm-Event"))
+	require.Equal(t, notification.ID, header.Get("X-Pachyderm-Delivery"))
+	require.Equal(t, pps.NotificationSignature("secret", body), header.Get("X-Pachyderm-Signature"))
+
+	// Requests aren't signed without a secret
+	notifier.Secret = ""
+	require.NoError(t, postNotification(context.Background(), server.Client(), notifier, notification, []byte(`{}`)))
+	require.Equal(t, "", header.Get("X-Pachyderm-Signature"))
+
+	// Anything but a 2xx response is an error, so that it's retried
+	status = http.StatusInternalServerError
+	require.YesError(t, postNotification(context.Background(), server.Client(), notifier, notification, []byte(`{}`)))
+
+	// The client that delivers notifications won't connect to the server,
+	// which is listening on a loopback address
+	status = http.StatusOK
+	require.YesError(t, postNotification(context.Background(), notificationClient, notifier, notification, []byte(`{}`)))
+}
+
+func TestValidateNotifierURL(t *testing.T) {
+	require.NoError(t, validateNotifierURL("https://hooks.example.com/pachyderm"))
+	require.NoError(t, validateNotifierURL("http://203.0.113.7:8080/"))
+
+	require.YesError(t, validateNotifierURL("ftp://hooks.example.com/"))
+	require.YesError(t, validateNotifierURL("https:///no-host"))
+	// Addresses and names inside the cluster
+	for _, u := range []string{
+		"http://localhost:650/",
+		"http://127.0.0.1/",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://10.96.0.1/",
+		"http://[::1]/",
+		"http://[fe80::1]/",
+		"http://pachd:650/",
+		"http://pachd.default.svc/",
+		"http://pachd.default.svc.cluster.local./",
+		"http://metadata.google.internal/",
+	} {
+		require.YesError(t, validateNotifierURL(u), u)
+	}
+}
@@ -0,0 +1,239 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+	pfsServer "github.com/pachyderm/pachyderm/src/server/pfs"
+	"github.com/pachyderm/pachyderm/src/server/pkg/obj"
+	"github.com/pachyderm/pachyderm/src/server/pkg/ppsconsts"
+)
+
+// objectStoreStateFile is the file, in the ObjectStoreStateBranch of an object
+// store input's repo, that records the objects that have been ingested
+const objectStoreStateFile = "state"
+
+// objectState is what's recorded about an ingested object, to tell whether it
+// has changed since
+type objectState struct {
+	ETag     string    `json:"etag,omitempty"`
+	Modified time.Time `json:"modified"`
+}
+
+func (s objectState) equal(other objectState) bool {
+	return s.ETag == other.ETag && s.Modified.Equal(other.Modified)
+}
+
+// objectStoreChanges compares the objects that have been ingested with the
+// ones that are 'current'ly in the bucket, and returns the names of the
+// objects that are new or have changed, and of those that have been deleted.
+func objectStoreChanges(ingested, current map[string]objectState) (changed []string, deleted []string) {
+	for name, state := range current {
+		if old, ok := ingested[name]; !ok || !old.equal(state) {
+			changed = append(changed, name)
+		}
+	}
+	for name := range ingested {
+		if _, ok := current[name]; !ok {
+			deleted = append(deleted, name)
+		}
+	}
+	sort.Strings(changed)
+	sort.Strings(deleted)
+	return changed, deleted
+}
+
+// objectStorePath returns the path in the input repo that the object 'name'
+// is written to, which is its name relative to the input's 'prefix'.
+func objectStorePath(prefix string, name string) string {
+	rel := strings.TrimPrefix(strings.TrimPrefix(name, prefix), "/")
+	if rel == "" {
+		// The prefix is the object itself
+		rel = path.Base(name)
+	}
+	return "/" + rel
+}
+
+// pollObjectStore ingests the objects under a single object store input's URL
+// into its repo, every time the bucket changes. It's a helper function called
+// by monitorPipeline.
+func (a *apiServer) pollObjectStore(pachClient *client.APIClient, input *pps.ObjectStoreInput) error {
+	url, err := obj.ParseURL(input.URL)
+	if err != nil {
+		return err // Shouldn't happen, as the input is validated in CreatePipeline
+	}
+	interval, err := types.DurationFromProto(input.Interval)
+	if err != nil {
+		return err
+	}
+	objClient, err := a.objectStoreClient(input, url)
+	if err != nil {
+		return err
+	}
+	// make sure there isn't an unfinished commit on the branch
+	commitInfo, err := pachClient.InspectCommit(input.Repo, "master")
+	if err != nil && !pfsServer.IsNoHeadErr(err) {
+		return err
+	} else if commitInfo != nil && commitInfo.Finished == nil {
+		// and if there is, delete it
+		if err := pachClient.DeleteCommit(input.Repo, "master"); err != nil {
+			return err
+		}
+	}
+	ingested, err := readObjectStoreState(pachClient, input.Repo)
+	if err != nil {
+		return err
+	}
+	for {
+		if ingested, err = syncObjectStore(pachClient, objClient, input, url, ingested); err != nil {
+			return err
+		}
+		select {
+		case <-time.After(interval):
+		case <-pachClient.Ctx().Done():
+			return pachClient.Ctx().Err()
+		}
+	}
+}
+
+// objectStoreClient returns a client for 'url' that uses the credentials in
+// the secret named by 'input'. Only secrets created with 'pachctl create
+// secret' may be used, so that an input can't read the bucket with the
+// credentials of Pachyderm itself.
+func (a *apiServer) objectStoreClient(input *pps.ObjectStoreInput, url *obj.ObjectStoreURL) (obj.Client, error) {
+	if input.Secret == "" {
+		return nil, errors.Errorf("object store input %q has no secret", input.Name)
+	}
+	secret, err := a.env.GetKubeClient().CoreV1().Secrets(a.namespace).Get(input.Secret, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read secret %q of object store input %q", input.Secret, input.Name)
+	}
+	if secret.Labels["secret-source"] != "pachyderm-user" {
+		return nil, errors.Errorf("secret %q of object store input %q wasn't created with 'pachctl create secret'", input.Secret, input.Name)
+	}
+	return obj.NewClientFromURLAndCredentials(url, secret.Data)
+}
+
+// syncObjectStore lists the objects under 'url' and ingests the ones that
+// were added, changed or deleted since 'ingested' was recorded. It returns
+// what's been ingested.
+func syncObjectStore(pachClient *client.APIClient, objClient obj.Client, input *pps.ObjectStoreInput, url *obj.ObjectStoreURL, ingested map[string]objectState) (map[string]objectState, error) {
+	current := make(map[string]objectState)
+	if err := objClient.WalkInfo(pachClient.Ctx(), url.Object, func(info *obj.ObjectInfo) error {
+		// Skip the empty objects that some tools create to represent
+		// directories
+		if strings.HasSuffix(info.Name, "/") {
+			return nil
+		}
+		current[info.Name] = objectState{ETag: info.ETag, Modified: info.Modified}
+		return nil
+	}); err != nil {
+		return nil, errors.Wrapf(err, "could not list %s", input.URL)
+	}
+	changed, deleted := objectStoreChanges(ingested, current)
+	if len(changed) == 0 && len(deleted) == 0 {
+		return ingested, nil
+	}
+	// If nothing is known to have been ingested, the input repo is made to
+	// mirror the bucket from scratch
+	if err := ingestObjects(pachClient, objClient, input.Repo, url.Object, changed, deleted, len(ingested) == 0); err != nil {
+		return nil, err
+	}
+	if err := writeObjectStoreState(pachClient, input.Repo, current); err != nil {
+		return nil, err
+	}
+	log.Infof("object store input %s ingested %d new or changed objects and %d deletions from %s", input.Name, len(changed), len(deleted), input.URL)
+	return current, nil
+}
+
+// ingestObjects writes the 'changed' objects to, and removes the 'deleted'
+// ones from, a new commit in 'repo'. If 'reset' is set, the commit starts out
+// empty.
+func ingestObjects(pachClient *client.APIClient, objClient obj.Client, repo string, prefix string, changed, deleted []string, reset bool) (retErr error) {
+	commit, err := pachClient.StartCommit(repo, "master")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if retErr != nil {
+			if err := pachClient.DeleteCommit(repo, commit.ID); err != nil {
+				log.Errorf("failed to delete partial commit (%v) on repo (%v) with error %v", commit.ID, repo, err)
+			}
+			return
+		}
+		retErr = pachClient.FinishCommit(repo, commit.ID)
+	}()
+	if reset {
+		if err := pachClient.DeleteFile(repo, commit.ID, ""); err != nil && !isNotFoundErr(err) {
+			return errors.Wrapf(err, "delete error")
+		}
+	}
+	for _, name := range deleted {
+		if err := pachClient.DeleteFile(repo, commit.ID, objectStorePath(prefix, name)); err != nil && !isNotFoundErr(err) {
+			return errors.Wrapf(err, "delete error")
+		}
+	}
+	for _, name := range changed {
+		if err := func() (retErr error) {
+			r, err := objClient.Reader(pachClient.Ctx(), name, 0, 0)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				if err := r.Close(); err != nil && retErr == nil {
+					retErr = err
+				}
+			}()
+			_, err = pachClient.PutFileOverwrite(repo, commit.ID, objectStorePath(prefix, name), r, 0)
+			return err
+		}(); err != nil {
+			// The object may have been deleted since the bucket was listed, in
+			// which case it's removed by the next poll
+			if objClient.IsNotExist(err) {
+				continue
+			}
+			return errors.Wrapf(err, "could not ingest %s", name)
+		}
+	}
+	return nil
+}
+
+// readObjectStoreState reads the objects that have been ingested into 'repo'.
+func readObjectStoreState(pachClient *client.APIClient, repo string) (map[string]objectState, error) {
+	var buf bytes.Buffer
+	if err := pachClient.GetFile(repo, ppsconsts.ObjectStoreStateBranch, objectStoreStateFile, 0, 0, &buf); err != nil {
+		if pfsServer.IsNoHeadErr(err) || isNotFoundErr(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var state map[string]objectState
+	if err := json.Unmarshal(buf.Bytes(), &state); err != nil {
+		return nil, errors.Wrapf(err, "could not parse object store state of %s", repo)
+	}
+	return state, nil
+}
+
+// writeObjectStoreState records that 'state' is what's been ingested into
+// 'repo'.
+func writeObjectStoreState(pachClient *client.APIClient, repo string, state map[string]objectState) error {
+	b, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if _, err := pachClient.PutFileOverwrite(repo, ppsconsts.ObjectStoreStateBranch, objectStoreStateFile, bytes.NewReader(b), 0); err != nil {
+		return errors.Wrapf(err, "could not write object store state of %s", repo)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+	"github.com/pachyderm/pachyderm/src/server/pkg/obj"
+	"github.com/pachyderm/pachyderm/src/server/pkg/testpachd"
+)
+
+func TestObjectStoreChanges(t *testing.T) {
+	now := time.Now()
+	ingested := map[string]objectState{
+		"prefix/same":     {ETag: "a", Modified: now},
+		"prefix/etag":     {ETag: "a", Modified: now},
+		"prefix/modified": {Modified: now},
+		"prefix/deleted":  {ETag: "a", Modified: now},
+	}
+	current := map[string]objectState{
+		"prefix/same":     {ETag: "a", Modified: now},
+		"prefix/etag":     {ETag: "b", Modified: now},
+		"prefix/modified": {Modified: now.Add(time.Second)},
+		"prefix/new":      {ETag: "a", Modified: now},
+	}
+	changed, deleted := objectStoreChanges(ingested, current)
+	require.Equal(t, []string{"prefix/etag", "prefix/modified", "prefix/new"}, changed)
+	require.Equal(t, []string{"prefix/deleted"}, deleted)
+
+	// Nothing has changed once the current state has been ingested, even after
+	// it's been recorded and read back
+	b, err := json.Marshal(current)
+	require.NoError(t, err)
+	var recorded map[string]objectState
+	require.NoError(t, json.Unmarshal(b, &recorded))
+	changed, deleted = objectStoreChanges(recorded, current)
+	require.Equal(t, 0, len(changed))
+	require.Equal(t, 0, len(deleted))
+
+	// Everything is new if nothing has been ingested
+	changed, deleted = objectStoreChanges(nil, current)
+	require.Equal(t, 4, len(changed))
+	require.Equal(t, 0, len(deleted))
+}
+
+func TestObjectStorePath(t *testing.T) {
+	require.Equal(t, "/a", objectStorePath("prefix/", "prefix/a"))
+	require.Equal(t, "/dir/a", objectStorePath("prefix/", "prefix/dir/a"))
+	require.Equal(t, "/a", objectStorePath("prefix", "prefix/a"))
+	require.Equal(t, "/prefix/a", objectStorePath("", "prefix/a"))
+	require.Equal(t, "/file.csv", objectStorePath("data/file.csv", "data/file.csv"))
+}
+
+func TestSyncObjectStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestSyncObjectStore")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	objClient, err := obj.NewLocalClient(dir)
+	require.NoError(t, err)
+	putObject := func(name, content string) {
+		w, err := objClient.Writer(context.Background(), name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+	}
+	input := &pps.ObjectStoreInput{Name: "data", Repo: "data", URL: "s3://bucket/prefix/"}
+	url := &obj.ObjectStoreURL{Store: "s3", Bucket: "bucket", Object: "prefix/"}
+
+	require.NoError(t, testpachd.WithRealEnv(func(env *testpachd.RealEnv) error {
+		c := env.PachClient
+		require.NoError(t, c.CreateRepo(input.Repo))
+		files := func() map[string]string {
+			result := make(map[string]string)
+			fileInfos, err := c.GlobFile(input.Repo, "master", "**")
+			require.NoError(t, err)
+			for _, fileInfo := range fileInfos {
+				if fileInfo.FileType != pfs.FileType_FILE {
+					continue
+				}
+				var buf bytes.Buffer
+				require.NoError(t, c.GetFile(input.Repo, "master", fileInfo.File.Path, 0, 0, &buf))
+				result[fileInfo.File.Path] = buf.String()
+			}
+			return result
+		}
+
+		// Objects under the prefix are written to the repo at their paths
+		// relative to it
+		putObject("prefix/a", "foo")
+		putObject("prefix/dir/b", "foo")
+		putObject("other/c", "foo")
+		ingested, err := syncObjectStore(c, objClient, input, url, nil)
+		require.NoError(t, err)
+		require.Equal(t, 2, len(ingested))
+		require.Equal(t, map[string]string{"/a": "foo", "/dir/b": "foo"}, files())
+		recorded, err := readObjectStoreState(c, input.Repo)
+		require.NoError(t, err)
+		require.Equal(t, 2, len(recorded))
+
+		// Nothing is committed if nothing changed
+		commitInfos, err := c.ListCommit(input.Repo, "master", "", 0)
+		require.NoError(t, err)
+		ingested, err = syncObjectStore(c, objClient, input, url, ingested)
+		require.NoError(t, err)
+		newCommitInfos, err := c.ListCommit(input.Repo, "master", "", 0)
+		require.NoError(t, err)
+		require.Equal(t, len(commitInfos), len(newCommitInfos))
+
+		// Changed objects are rewritten, and deleted objects are removed from
+		// the repo
+		time.Sleep(10 * time.Millisecond) // make sure the modification time changes
+		putObject("prefix/dir/b", "bar")
+		putObject("prefix/d", "bar")
+		require.NoError(t, objClient.Delete(context.Background(), "prefix/a"))
+		_, err = syncObjectStore(c, objClient, input, url, ingested)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"/dir/b": "bar", "/d": "bar"}, files())
+		return nil
+	}))
+}
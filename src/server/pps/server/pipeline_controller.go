@@ -77,6 +77,7 @@ func (a *apiServer) step(pachClient *client.APIClient, pipeline string, keyVer,
 		return a.setPipelineFailure(pachClient.Ctx(), pipeline,
 			fmt.Sprintf("couldn't initialize pipeline op: %v", err))
 	}
+	a.scheduler.setPipeline(op.pipelineInfo)
 	// set op.rc
 	// TODO(msteffen) should this fail the pipeline? (currently getRC will restart
 	// the pipeline indefinitely)
@@ -534,6 +535,15 @@ func (op *pipelineOp) scaleUpPipeline() (retErr error) {
 		log.Errorf("PPS master: error getting number of workers (defaulting to 1 worker): %v", err)
 		parallelism = 1
 	}
+	if autoscaling := op.pipelineInfo.ParallelismSpec.GetAutoscaling(); autoscaling != nil {
+		// monitorPipeline chooses the size of autoscaled pipelines
+		parallelism = max(int(autoscaling.MinWorkers), int(op.ptr.AutoscaledWorkers))
+	}
+	// the cluster may not have room for all of them
+	if granted := op.apiServer.scheduler.scaleUp(op.name, parallelism); granted < parallelism {
+		log.Infof("PPS master: pipeline %q wants %d workers but was granted %d", op.name, parallelism, granted)
+		parallelism = granted
+	}
 
 	// update pipeline RC
 	return op.updateRC(func(rc *v1.ReplicationController) {
@@ -562,6 +572,7 @@ func (op *pipelineOp) scaleDownPipeline() (retErr error) {
 		tracing.FinishAnySpan(span)
 	}()
 
+	op.apiServer.scheduler.scaleDown(op.name)
 	return op.updateRC(func(rc *v1.ReplicationController) {
 		if rc.Spec.Replicas != nil && *op.rc.Spec.Replicas == 0 {
 			return // prior attempt succeeded
@@ -0,0 +1,244 @@
+package server
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/pachyderm/pachyderm/src/client/pps"
+	"github.com/pachyderm/pachyderm/src/server/pkg/ppsutil"
+)
+
+// scheduler is the PPS master's cluster-wide view of pipelines and jobs. It
+// decides how many worker replicas each running pipeline gets when the cluster
+// has a limited worker capacity (PPS_WORKER_CAPACITY), and which queued jobs
+// may start running. Its state lives only in the PPS master, and is rebuilt
+// from the pipeline and job watches whenever a new master takes over.
+type scheduler struct {
+	mu sync.Mutex
+	// capacity is the total number of worker replicas shared by all running
+	// pipelines, or 0 if it's unlimited
+	capacity  int
+	pipelines map[string]*scheduledPipeline
+	jobs      map[string]*scheduledJob
+	// rescheduled holds the pipelines whose replica grant has changed because
+	// of another pipeline, so that the master can re-step them
+	rescheduled map[string]bool
+	seq         uint64
+}
+
+type scheduledPipeline struct {
+	priority          int64
+	maxConcurrentJobs int64
+	demand            int    // replicas wanted (0 if the pipeline isn't running)
+	grant             int    // replicas most recently granted
+	since             uint64 // when the pipeline started wanting replicas, to break priority ties
+}
+
+type scheduledJob struct {
+	pipeline string
+	state    pps.JobState
+	seq      uint64
+}
+
+func newScheduler(capacity int) *scheduler {
+	return &scheduler{
+		capacity:    capacity,
+		pipelines:   make(map[string]*scheduledPipeline),
+		jobs:        make(map[string]*scheduledJob),
+		rescheduled: make(map[string]bool),
+	}
+}
+
+func (s *scheduler) pipeline(name string) *scheduledPipeline {
+	p, ok := s.pipelines[name]
+	if !ok {
+		p = &scheduledPipeline{}
+		s.pipelines[name] = p
+	}
+	return p
+}
+
+// setPipeline records the scheduling settings in 'pipelineInfo'.
+func (s *scheduler) setPipeline(pipelineInfo *pps.PipelineInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.pipeline(pipelineInfo.Pipeline.Name)
+	if p.priority != pipelineInfo.Priority {
+		p.priority = pipelineInfo.Priority
+		s.allocate("")
+	}
+	p.maxConcurrentJobs = pipelineInfo.MaxConcurrentJobs
+}
+
+// scaleUp records that pipeline 'name' wants 'want' workers, and returns the
+// number of workers that it's been granted.
+func (s *scheduler) scaleUp(name string, want int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.pipeline(name)
+	if p.demand == 0 {
+		s.seq++
+		p.since = s.seq
+	}
+	p.demand = want
+	s.allocate(name)
+	return p.grant
+}
+
+// scaleDown records that pipeline 'name' no longer wants any workers.
+func (s *scheduler) scaleDown(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.pipeline(name)
+	p.demand = 0
+	s.allocate(name)
+}
+
+// removePipeline forgets pipeline 'name', e.g. because it has been deleted.
+func (s *scheduler) removePipeline(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.pipelines[name]; !ok {
+		return
+	}
+	delete(s.pipelines, name)
+	delete(s.rescheduled, name)
+	s.allocate(name)
+}
+
+// takeRescheduled returns (and clears) the pipelines whose replica grant has
+// been changed by another pipeline scaling up or down.
+func (s *scheduler) takeRescheduled() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result []string
+	for name := range s.rescheduled {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	s.rescheduled = make(map[string]bool)
+	return result
+}
+
+// demanding returns the names of the pipelines that want workers, highest
+// priority first, and otherwise in the order in which they started wanting
+// workers.
+func (s *scheduler) demanding() []string {
+	var names []string
+	for name, p := range s.pipelines {
+		if p.demand > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		pi, pj := s.pipelines[names[i]], s.pipelines[names[j]]
+		if pi.priority != pj.priority {
+			return pi.priority > pj.priority
+		}
+		return pi.since < pj.since
+	})
+	return names
+}
+
+// allocate recomputes every pipeline's replica grant. Every pipeline that
+// wants workers gets one (so that its master can run and its jobs can be
+// queued), and the rest of the capacity goes to pipelines in priority order.
+// Pipelines other than 'caller' whose grant changes are marked as
+// rescheduled.
+func (s *scheduler) allocate(caller string) {
+	grants := make(map[string]int)
+	demanding := s.demanding()
+	remaining := s.capacity - len(demanding)
+	for _, name := range demanding {
+		p := s.pipelines[name]
+		if s.capacity <= 0 {
+			grants[name] = p.demand
+			continue
+		}
+		grants[name] = 1
+		if extra := p.demand - 1; remaining > 0 && extra > 0 {
+			if extra > remaining {
+				extra = remaining
+			}
+			grants[name] += extra
+			remaining -= extra
+		}
+	}
+	for name, p := range s.pipelines {
+		if grants[name] != p.grant {
+			p.grant = grants[name]
+			if name != caller && p.demand > 0 {
+				s.rescheduled[name] = true
+			}
+		}
+	}
+}
+
+// setJob records the latest state of job 'jobID'.
+func (s *scheduler) setJob(jobID string, pipeline string, state pps.JobState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ppsutil.IsTerminal(state) {
+		delete(s.jobs, jobID)
+		return
+	}
+	j, ok := s.jobs[jobID]
+	if !ok {
+		s.seq++
+		j = &scheduledJob{pipeline: pipeline, seq: s.seq}
+		s.jobs[jobID] = j
+	}
+	j.state = state
+}
+
+// removeJob forgets job 'jobID', e.g. because it has been deleted.
+func (s *scheduler) removeJob(jobID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.jobs, jobID)
+}
+
+// admit returns the queued jobs that may start running, in the order in which
+// they should be started. A queued job may start if fewer of its pipeline's
+// jobs are running than the pipeline's max_concurrent_jobs. Jobs of
+// higher-priority pipelines are considered first, and otherwise jobs are
+// started in the order in which they were queued. The returned jobs are
+// counted as running from then on.
+func (s *scheduler) admit() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	running := make(map[string]int64)
+	var queued []string
+	for jobID, j := range s.jobs {
+		switch j.state {
+		case pps.JobState_JOB_RUNNING, pps.JobState_JOB_MERGING:
+			running[j.pipeline]++
+		case pps.JobState_JOB_QUEUED:
+			// Jobs whose pipeline hasn't been seen yet wait for it, so that its
+			// limit is known
+			if _, ok := s.pipelines[j.pipeline]; ok {
+				queued = append(queued, jobID)
+			}
+		}
+	}
+	sort.Slice(queued, func(i, j int) bool {
+		ji, jj := s.jobs[queued[i]], s.jobs[queued[j]]
+		pi, pj := s.pipelines[ji.pipeline], s.pipelines[jj.pipeline]
+		if pi.priority != pj.priority {
+			return pi.priority > pj.priority
+		}
+		return ji.seq < jj.seq
+	})
+	var result []string
+	for _, jobID := range queued {
+		j := s.jobs[jobID]
+		limit := s.pipelines[j.pipeline].maxConcurrentJobs
+		if limit > 0 && running[j.pipeline] >= limit {
+			continue
+		}
+		j.state = pps.JobState_JOB_RUNNING
+		running[j.pipeline]++
+		result = append(result, jobID)
+	}
+	return result
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+)
+
+func schedulerPipeline(name string, priority, maxConcurrentJobs int64) *pps.PipelineInfo {
+	return &pps.PipelineInfo{
+		Pipeline:          client.NewPipeline(name),
+		Priority:          priority,
+		MaxConcurrentJobs: maxConcurrentJobs,
+	}
+}
+
+func TestSchedulerUnlimitedCapacity(t *testing.T) {
+	s := newScheduler(0)
+	s.setPipeline(schedulerPipeline("a", 0, 0))
+	s.setPipeline(schedulerPipeline("b", 10, 0))
+	require.Equal(t, 8, s.scaleUp("a", 8))
+	require.Equal(t, 8, s.scaleUp("b", 8))
+	require.Equal(t, 0, len(s.takeRescheduled()))
+}
+
+func TestSchedulerPriority(t *testing.T) {
+	s := newScheduler(10)
+	s.setPipeline(schedulerPipeline("backfill", 0, 0))
+	s.setPipeline(schedulerPipeline("latency", 10, 0))
+
+	// With the cluster to itself, the backfill gets all of its workers
+	require.Equal(t, 8, s.scaleUp("backfill", 8))
+
+	// The higher-priority pipeline takes what it needs, and the backfill keeps
+	// one worker plus whatever is left over
+	require.Equal(t, 6, s.scaleUp("latency", 6))
+	require.Equal(t, []string{"backfill"}, s.takeRescheduled())
+	require.Equal(t, 4, s.scaleUp("backfill", 8))
+	require.Equal(t, 0, len(s.takeRescheduled()))
+
+	// Once the higher-priority pipeline goes into standby, the backfill gets its
+	// workers back
+	s.scaleDown("latency")
+	require.Equal(t, []string{"backfill"}, s.takeRescheduled())
+	require.Equal(t, 8, s.scaleUp("backfill", 8))
+
+	// Deleting a pipeline also frees its workers
+	require.Equal(t, 6, s.scaleUp("latency", 6))
+	require.Equal(t, []string{"backfill"}, s.takeRescheduled())
+	require.Equal(t, 4, s.scaleUp("backfill", 8))
+	s.removePipeline("latency")
+	require.Equal(t, []string{"backfill"}, s.takeRescheduled())
+	require.Equal(t, 8, s.scaleUp("backfill", 8))
+}
+
+func TestSchedulerMinimumOneWorker(t *testing.T) {
+	s := newScheduler(2)
+	require.Equal(t, 2, s.scaleUp("a", 4))
+	require.Equal(t, 1, s.scaleUp("b", 4))
+	require.Equal(t, []string{"a"}, s.takeRescheduled())
+	require.Equal(t, 1, s.scaleUp("a", 4))
+	// Even past capacity, every running pipeline gets a worker
+	require.Equal(t, 1, s.scaleUp("c", 4))
+}
+
+func TestSchedulerAdmit(t *testing.T) {
+	s := newScheduler(0)
+	s.setPipeline(schedulerPipeline("unlimited", 0, 0))
+	s.setPipeline(schedulerPipeline("backfill", 0, 1))
+	s.setPipeline(schedulerPipeline("latency", 10, 2))
+
+	// Other pipelines' running jobs don't count against a pipeline's limit
+	s.setJob("j1", "unlimited", pps.JobState_JOB_RUNNING)
+	s.setJob("j2", "unlimited", pps.JobState_JOB_RUNNING)
+	s.setJob("j3", "backfill", pps.JobState_JOB_QUEUED)
+	s.setJob("j4", "latency", pps.JobState_JOB_QUEUED)
+	s.setJob("j5", "unknown", pps.JobState_JOB_QUEUED)
+	// Higher-priority pipelines' jobs go first. Jobs of unknown pipelines wait.
+	require.Equal(t, []string{"j4", "j3"}, s.admit())
+	require.Equal(t, 0, len(s.admit()))
+
+	// A pipeline's own running jobs do count
+	s.setJob("j6", "backfill", pps.JobState_JOB_QUEUED)
+	s.setJob("j7", "latency", pps.JobState_JOB_QUEUED)
+	s.setJob("j8", "latency", pps.JobState_JOB_QUEUED)
+	require.Equal(t, []string{"j7"}, s.admit())
+	require.Equal(t, 0, len(s.admit()))
+
+	// Once a job finishes, the next job of the same pipeline may start
+	s.setJob("j3", "backfill", pps.JobState_JOB_SUCCESS)
+	require.Equal(t, []string{"j6"}, s.admit())
+
+	// Pipelines without a limit are always admitted
+	s.setJob("j9", "unlimited", pps.JobState_JOB_QUEUED)
+	require.Equal(t, []string{"j9"}, s.admit())
+
+	// Deleted jobs no longer count as running
+	s.setPipeline(schedulerPipeline("unknown", 0, 1))
+	s.setJob("j10", "unknown", pps.JobState_JOB_RUNNING)
+	require.Equal(t, 0, len(s.admit()))
+	s.removeJob("j10")
+	require.Equal(t, []string{"j5"}, s.admit())
+}
@@ -56,7 +56,9 @@ func NewAPIServer(
 		workerUsesRoot:        workerUsesRoot,
 		pipelines:             ppsdb.Pipelines(env.GetEtcdClient(), etcdPrefix),
 		jobs:                  ppsdb.Jobs(env.GetEtcdClient(), etcdPrefix),
+		notifiers:             ppsdb.Notifiers(env.GetEtcdClient(), etcdPrefix),
 		monitorCancels:        make(map[string]func()),
+		scheduler:             newScheduler(int(env.PPSWorkerCapacity)),
 		workerGrpcPort:        workerGrpcPort,
 		port:                  port,
 		httpPort:              httpPort,
@@ -0,0 +1,263 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"golang.org/x/net/context"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+	pfsServer "github.com/pachyderm/pachyderm/src/server/pfs"
+	"github.com/pachyderm/pachyderm/src/server/pkg/dag"
+	"github.com/pachyderm/pachyderm/src/server/pkg/errutil"
+	"github.com/pachyderm/pachyderm/src/server/pkg/ppsutil"
+	workerpkg "github.com/pachyderm/pachyderm/src/server/worker"
+)
+
+// ValidatePipeline implements the protobuf pps.ValidatePipeline RPC
+func (a *apiServer) ValidatePipeline(ctx context.Context, request *pps.ValidatePipelineRequest) (response *pps.ValidatePipelineResponse, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	pachClient := a.env.GetPachClient(ctx)
+
+	// inputs maps each pipeline (existing or in the request) to the repos it
+	// reads from, to find cycles
+	inputs := make(map[string][]string)
+	if err := a.listPipeline(pachClient, &pps.ListPipelineRequest{}, func(pipelineInfo *pps.PipelineInfo) error {
+		inputs[pipelineInfo.Pipeline.Name] = inputRepos(pipelineInfo.Input)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	response = &pps.ValidatePipelineResponse{}
+	// created is the set of repos that the earlier pipelines in the request
+	// would create, which later pipelines may read from
+	created := make(map[string]bool)
+	for _, pipelineRequest := range request.Pipelines {
+		pipelineRequest = proto.Clone(pipelineRequest).(*pps.CreatePipelineRequest)
+		problems, err := a.lintPipeline(pachClient, pipelineRequest, created)
+		if err != nil {
+			return nil, err
+		}
+		response.Problems = append(response.Problems, problems...)
+		if pipelineRequest.Pipeline != nil && pipelineRequest.Pipeline.Name != "" {
+			created[pipelineRequest.Pipeline.Name] = true
+			inputs[pipelineRequest.Pipeline.Name] = inputRepos(pipelineRequest.Input)
+		}
+	}
+
+	// Pipelines that read from each other would never run. Only the pipelines
+	// in the request are blamed for a cycle, as the existing ones were fine
+	// before it.
+	if cycle := dag.NewDAG(inputs).Cycle(); cycle != nil {
+		onCycle := make(map[string]bool)
+		for _, name := range cycle {
+			onCycle[name] = true
+		}
+		for _, pipelineRequest := range request.Pipelines {
+			if pipelineRequest.Pipeline == nil || !onCycle[pipelineRequest.Pipeline.Name] {
+				continue
+			}
+			response.Problems = append(response.Problems, &pps.PipelineProblem{
+				Pipeline: pipelineRequest.Pipeline,
+				Message:  fmt.Sprintf("pipeline is part of a cycle: %s", strings.Join(cycle, " -> ")),
+			})
+			onCycle[pipelineRequest.Pipeline.Name] = false // report each pipeline once
+		}
+	}
+	return response, nil
+}
+
+// lintPipeline returns the problems with the pipeline that 'request' would
+// create, given that the repos in 'created' will exist by the time it's
+// created. An error is only returned if the validation itself fails.
+func (a *apiServer) lintPipeline(pachClient *client.APIClient, request *pps.CreatePipelineRequest, created map[string]bool) ([]*pps.PipelineProblem, error) {
+	var problems []*pps.PipelineProblem
+	reported := make(map[string]bool)
+	report := func(warning bool, format string, args ...interface{}) {
+		message := fmt.Sprintf(format, args...)
+		if reported[message] {
+			return // the request and spec checks overlap
+		}
+		reported[message] = true
+		problems = append(problems, &pps.PipelineProblem{
+			Pipeline: request.Pipeline,
+			Warning:  warning,
+			Message:  message,
+		})
+	}
+
+	// The checks that CreatePipeline makes without reading PFS. Each is
+	// reported, not just the first, but the rest can't be made without a
+	// pipeline name and transform.
+	for _, err := range pipelineRequestProblems(request) {
+		report(false, "%v", err)
+	}
+	if request.Pipeline == nil || request.Pipeline.Name == "" || request.Transform == nil {
+		return problems, nil
+	}
+	pipelineInfo := newPipelineInfo(request)
+	for _, err := range setPipelineDefaults(pipelineInfo) {
+		report(false, "%v", err)
+	}
+	for _, err := range pipelineSpecProblems(pipelineInfo) {
+		report(false, "%v", err)
+	}
+
+	// CreatePipeline only updates pipelines that exist, and only creates ones
+	// that don't
+	exists := created[request.Pipeline.Name]
+	if !exists {
+		if _, err := a.inspectPipeline(pachClient, request.Pipeline.Name); err == nil {
+			exists = true
+		} else if !isNotFoundErr(err) {
+			return nil, err
+		}
+	}
+	switch {
+	case exists && !request.Update:
+		report(false, "pipeline %q already exists; set 'update' to update it", request.Pipeline.Name)
+	case !exists && request.Update:
+		report(false, "pipeline %q does not exist, so it can't be updated", request.Pipeline.Name)
+	}
+
+	// CreatePipeline skips resources it can't parse, so they're only caught
+	// here
+	if err := ppsutil.ValidateResourceSpec(pipelineInfo.ResourceRequests); err != nil {
+		report(false, "invalid resource_requests: %v", err)
+	}
+	if err := ppsutil.ValidateResourceSpec(pipelineInfo.ResourceLimits); err != nil {
+		report(false, "invalid resource_limits: %v", err)
+	}
+
+	// pod_spec and pod_patch are applied to the worker pod spec by the PPS
+	// master, after the pipeline has been created
+	if pipelineInfo.PodSpec != "" || pipelineInfo.PodPatch != "" {
+		if err := a.validatePodSpec(pipelineInfo); err != nil {
+			report(false, "invalid pod_spec or pod_patch: %v", err)
+		}
+	}
+
+	// Inputs that would produce no datums
+	hasFiles := make(map[*pps.PFSInput]bool)
+	var visitErr error
+	pps.VisitInput(pipelineInfo.Input, func(input *pps.Input) {
+		if input.Pfs == nil || visitErr != nil || created[input.Pfs.Repo] {
+			return
+		}
+		if _, err := pachClient.InspectRepo(input.Pfs.Repo); err != nil {
+			if !isNotFoundErr(err) {
+				visitErr = err
+				return
+			}
+			report(false, "input repo %q does not exist", input.Pfs.Repo)
+			return
+		}
+		branchInfo, err := pachClient.InspectBranch(input.Pfs.Repo, input.Pfs.Branch)
+		if err != nil {
+			if !pfsServer.IsBranchNotFoundErr(err) && !isNotFoundErr(err) {
+				visitErr = err
+				return
+			}
+			report(true, "input branch %s@%s does not exist yet (it will be created with the pipeline)", input.Pfs.Repo, input.Pfs.Branch)
+			return
+		}
+		if branchInfo.Head == nil {
+			report(true, "input branch %s@%s has no commits yet", input.Pfs.Repo, input.Pfs.Branch)
+			return
+		}
+		if input.Pfs.S3 {
+			hasFiles[input.Pfs] = true
+			return // s3 inputs are exposed as a whole, whatever's in them
+		}
+		matched := false
+		if err := pachClient.GlobFileF(input.Pfs.Repo, input.Pfs.Branch, input.Pfs.Glob, func(*pfs.FileInfo) error {
+			matched = true
+			return errutil.ErrBreak
+		}); err != nil && err != errutil.ErrBreak {
+			visitErr = err
+			return
+		}
+		if !matched {
+			report(true, "glob %q matches no files in %s@%s", input.Pfs.Glob, input.Pfs.Repo, input.Pfs.Branch)
+			return
+		}
+		hasFiles[input.Pfs] = true
+	})
+	if visitErr != nil {
+		return nil, visitErr
+	}
+
+	// Joins whose inputs all have files, but whose join_on keys never match
+	pps.VisitInput(pipelineInfo.Input, func(input *pps.Input) {
+		if input.Join == nil || visitErr != nil {
+			return
+		}
+		for _, joined := range input.Join {
+			if joined.Pfs == nil || !hasFiles[joined.Pfs] {
+				return // there's nothing to compare
+			}
+			if joined.Pfs.OuterJoin {
+				return // every file produces a datum anyway
+			}
+		}
+		join := proto.Clone(input).(*pps.Input)
+		for _, joined := range join.Join {
+			joined.Pfs.Commit = joined.Pfs.Branch
+		}
+		di, err := workerpkg.NewDatumIterator(pachClient, join)
+		if err != nil {
+			visitErr = err
+			return
+		}
+		if di.Len() == 0 {
+			var names []string
+			for _, joined := range join.Join {
+				names = append(names, joined.Pfs.Name)
+			}
+			report(true, "join of %s produces no datums, as the files matched by its inputs have no join_on values in common", strings.Join(names, ", "))
+		}
+	})
+	if visitErr != nil {
+		return nil, visitErr
+	}
+	return problems, nil
+}
+
+// validatePodSpec checks that 'pipelineInfo's pod_spec and pod_patch apply to
+// its worker pod spec.
+func (a *apiServer) validatePodSpec(pipelineInfo *pps.PipelineInfo) error {
+	if pipelineInfo.PodSpec != "" && !json.Valid([]byte(pipelineInfo.PodSpec)) {
+		return errors.Errorf("pod_spec is not valid JSON")
+	}
+	if pipelineInfo.PodPatch != "" && !json.Valid([]byte(pipelineInfo.PodPatch)) {
+		return errors.Errorf("pod_patch is not valid JSON")
+	}
+	// The pipeline doesn't have a spec commit or auth token yet, but neither
+	// affects whether the patches apply
+	options, err := a.getWorkerOptions(&pps.EtcdPipelineInfo{SpecCommit: &pfs.Commit{}}, pipelineInfo)
+	if err != nil {
+		return err
+	}
+	_, err = a.workerPodSpec(options)
+	return err
+}
+
+// inputRepos returns the names of the PFS repos that 'input' reads from. The
+// repos of cron, git, webhook and object store inputs are left out, as only
+// their own pipeline writes to them.
+func inputRepos(input *pps.Input) []string {
+	var result []string
+	pps.VisitInput(input, func(input *pps.Input) {
+		if input.Pfs != nil {
+			result = append(result, input.Pfs.Repo)
+		}
+	})
+	return result
+}
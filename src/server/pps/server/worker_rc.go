@@ -688,10 +688,11 @@ func (a *apiServer) createWorkerSvcAndRc(ctx context.Context, ptr *pps.EtcdPipel
 		}
 	}
 
-	// True if the pipeline has a git input
+	// True if the pipeline has a git or webhook input, which are both served by
+	// the githook service
 	var hasGitInput bool
 	pps.VisitInput(pipelineInfo.Input, func(input *pps.Input) {
-		if input.Git != nil {
+		if input.Git != nil || input.Webhook != nil {
 			hasGitInput = true
 		}
 	})
@@ -740,3 +741,27 @@ func getGithookService(kubeClient *kube.Clientset, namespace string) (*v1.Servic
 	}
 	return &serviceList.Items[0], nil
 }
+
+// githookDomain returns the external IP or hostname of the githook service,
+// or "" if it isn't known (yet).
+func githookDomain(kubeClient *kube.Clientset, namespace string) (string, error) {
+	svc, err := getGithookService(kubeClient, namespace)
+	if err != nil {
+		return "", nil
+	}
+	numIPs := len(svc.Status.LoadBalancer.Ingress)
+	if numIPs == 0 {
+		// When running locally, no external IP is set
+		return "", nil
+	}
+	if numIPs != 1 {
+		return "", errors.Errorf("unexpected number of external IPs set for githook service")
+	}
+	ingress := svc.Status.LoadBalancer.Ingress[0]
+	if ingress.IP != "" {
+		// GKE load balancing
+		return ingress.IP, nil
+	}
+	// AWS load balancing (or "" if neither is set)
+	return ingress.Hostname, nil
+}
@@ -3,6 +3,7 @@ package worker
 import (
 	"bufio"
 	"bytes"
+	"crypto/md5"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
@@ -135,6 +136,14 @@ type APIServer struct {
 	// We only export application statistics if enterprise is enabled
 	exportStats bool
 
+	// egressMu serializes incremental egress, which diffs each output commit
+	// against the last one egressed
+	egressMu sync.Mutex
+
+	// datumCacheKey identifies this pipeline's transform in the datum cache
+	// (see DatumCacheKey). It's empty if the datum cache isn't used.
+	datumCacheKey string
+
 	uid *uint32
 	gid *uint32
 
@@ -166,6 +175,9 @@ type taggedLogger struct {
 	objSize      int64
 	msgCh        chan string
 	eg           errgroup.Group
+	// tail is only set if the pipeline dead-letters datums, in which case it
+	// keeps the end of the user code's output
+	tail *outputTail
 }
 
 // DatumID computes the id for a datum, this value is used in ListDatum and
@@ -204,6 +216,9 @@ func (a *APIServer) getTaggedLogger(pachClient *client.APIClient, jobID string,
 	// InputFileID is a single string id for the data from this input, it's used in logs and in
 	// the statsTree
 	result.template.DatumID = a.DatumID(data)
+	if a.pipelineInfo.DeadLetter {
+		result.tail = &outputTail{}
+	}
 	if enableStats {
 		putObjClient, err := pachClient.ObjectAPIClient.PutObject(pachClient.Ctx())
 		if err != nil {
@@ -282,6 +297,9 @@ func (logger *taggedLogger) Write(p []byte) (_ int, retErr error) {
 		// because if the message has format characters like %s in it those
 		// will result in errors being logged.
 		logger.Logf("%s", strings.TrimSuffix(message, "\n"))
+		if logger.tail != nil {
+			logger.tail.writeLine(strings.TrimSuffix(message, "\n"))
+		}
 	}
 }
 
@@ -307,6 +325,7 @@ func (logger *taggedLogger) clone() *taggedLogger {
 		marshaler:    &jsonpb.Marshaler{},
 		putObjClient: logger.putObjClient,
 		msgCh:        logger.msgCh,
+		tail:         logger.tail,
 	}
 }
 
@@ -316,8 +335,10 @@ func (logger *taggedLogger) userLogger() *taggedLogger {
 	return result
 }
 
-// NewAPIServer creates an APIServer for a given pipeline
-func NewAPIServer(pachClient *client.APIClient, etcdClient *etcd.Client, etcdPrefix string, pipelineInfo *pps.PipelineInfo, workerName string, namespace string, hashtreeStorage string) (*APIServer, error) {
+// NewAPIServer creates an APIServer for a given pipeline. 'userImageID' is the
+// ID of the image running the pipeline's user code, and is only needed if the
+// pipeline uses the datum cache.
+func NewAPIServer(pachClient *client.APIClient, etcdClient *etcd.Client, etcdPrefix string, pipelineInfo *pps.PipelineInfo, workerName string, namespace string, hashtreeStorage string, userImageID string) (*APIServer, error) {
 	initPrometheus()
 
 	span, ctx := extended.AddPipelineSpanToAnyTrace(pachClient.Ctx(),
@@ -358,6 +379,15 @@ func NewAPIServer(pachClient *client.APIClient, etcdClient *etcd.Client, etcdPre
 	} else {
 		server.exportStats = resp.State == enterprise.State_ACTIVE
 	}
+	// Pipelines with secrets can't use the datum cache, as the secrets'
+	// contents (which may affect the output) aren't part of the cache key.
+	// CreatePipeline refuses them, but older pipelines may still set both.
+	if pipelineInfo.DatumCache && userImageID != "" && len(pipelineInfo.Transform.Secrets) == 0 {
+		server.datumCacheKey, err = DatumCacheKey(userImageID, pipelineInfo.Transform)
+		if err != nil {
+			return nil, err
+		}
+	}
 	numShards, err := ppsutil.GetExpectedNumHashtrees(pipelineInfo.HashtreeSpec)
 	if err != nil {
 		logger.Logf("error getting number of shards, default to 1 shard: %v", err)
@@ -590,16 +620,42 @@ func (a *APIServer) linkData(inputs []*Input, dir string) error {
 	if err != nil {
 		return err
 	}
+	// Inputs in a group datum may share a name, in which case they share a
+	// directory and are only linked once
+	linked := make(map[string]bool)
 	for _, input := range inputs {
-		if input.S3 {
+		if input.S3 || linked[input.Name] {
 			continue
 		}
+		linked[input.Name] = true
 		src := filepath.Join(dir, input.Name)
 		dst := filepath.Join(client.PPSInputPrefix, input.Name)
 		if err := os.Symlink(src, dst); err != nil {
 			return err
 		}
 	}
+	// Inputs of an outer join may have no files in a datum, in which case
+	// they get an empty directory
+	if ppsutil.ContainsOuterJoins(a.pipelineInfo.Input) {
+		var linkErr error
+		pps.VisitInput(a.pipelineInfo.Input, func(input *pps.Input) {
+			for _, input := range input.Join {
+				if linkErr != nil || input.Pfs == nil || input.Pfs.S3 || linked[input.Pfs.Name] {
+					continue
+				}
+				linked[input.Pfs.Name] = true
+				src := filepath.Join(dir, input.Pfs.Name)
+				if err := os.MkdirAll(src, 0777); err != nil {
+					linkErr = err
+					return
+				}
+				linkErr = os.Symlink(src, filepath.Join(client.PPSInputPrefix, input.Pfs.Name))
+			}
+		})
+		if linkErr != nil {
+			return linkErr
+		}
+	}
 
 	if a.pipelineInfo.Spout != nil && a.pipelineInfo.Spout.Marker != "" {
 		err = os.Symlink(filepath.Join(dir, a.pipelineInfo.Spout.Marker),
@@ -644,6 +700,7 @@ func (a *APIServer) reportUserCodeStats(logger *taggedLogger) {
 func (a *APIServer) reportDeferredUserCodeStats(err error, start time.Time, stats *pps.ProcessStats, logger *taggedLogger) {
 	duration := time.Since(start)
 	stats.ProcessTime = types.DurationProto(duration)
+	stats.GpuTime = gpuTime(a.pipelineInfo, duration)
 	if a.exportStats {
 		state := "errored"
 		if err == nil {
@@ -705,12 +762,15 @@ func (a *APIServer) runUserCode(ctx context.Context, logger *taggedLogger, envir
 	if err != nil {
 		return errors.Wrapf(err, "error cmd.Start")
 	}
+	sampler := startMemorySampler(cmd.Process.Pid)
 	// A context w a deadline will successfully cancel/kill
 	// the running process (minus zombies)
 	state, err := cmd.Process.Wait()
 	if err != nil {
+		sampler.stop()
 		return errors.Wrapf(err, "error cmd.Wait")
 	}
+	reportResourceUsage(state, sampler, stats)
 	if isDone(ctx) {
 		if err = ctx.Err(); err != nil {
 			return err
@@ -833,7 +893,58 @@ func (a *APIServer) reportUploadStats(start time.Time, stats *pps.ProcessStats,
 	}
 }
 
-func (a *APIServer) uploadOutput(pachClient *client.APIClient, dir string, tag string, logger *taggedLogger, inputs []*Input, stats *pps.ProcessStats, statsTree *hashtree.Ordered, datumIdx int64) (retErr error) {
+// outputQuota tracks the data that a worker has uploaded to a job's output
+// commit, so that uploadOutput can fail if the output repo's quota would be
+// exceeded. A nil outputQuota doesn't limit anything.
+type outputQuota struct {
+	repo      *pfs.Repo
+	quota     *pfs.Quota
+	mu        sync.Mutex
+	sizeBytes uint64
+	fileCount uint64
+}
+
+// check returns ErrQuotaExceeded if uploading another 'sizeBytes' bytes in
+// 'fileCount' files would exceed the quota.
+func (q *outputQuota) check(sizeBytes, fileCount uint64) error {
+	if q == nil {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return pfsserver.CheckQuota(q.repo, q.quota, q.sizeBytes+sizeBytes, q.fileCount+fileCount)
+}
+
+// add records that 'sizeBytes' bytes in 'fileCount' files have been uploaded.
+func (q *outputQuota) add(sizeBytes, fileCount uint64) {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.sizeBytes += sizeBytes
+	q.fileCount += fileCount
+}
+
+// putObjectsWriter sends the data written to it on a PutObjects stream, and
+// counts the bytes sent.
+type putObjectsWriter struct {
+	client pfs.ObjectAPI_PutObjectsClient
+	size   uint64
+}
+
+func (w *putObjectsWriter) Write(p []byte) (int, error) {
+	if err := w.client.Send(&pfs.PutObjectRequest{Value: p}); err != nil {
+		return 0, err
+	}
+	w.size += uint64(len(p))
+	return len(p), nil
+}
+
+// uploadOutput uploads the files in the datum's output directory, and the
+// datum's hashtree, compressed with 'compression'. Each file is compressed
+// separately, so that it can be read from the shared block on its own.
+func (a *APIServer) uploadOutput(pachClient *client.APIClient, dir string, tag string, cacheTag string, logger *taggedLogger, inputs []*Input, stats *pps.ProcessStats, statsTree *hashtree.Ordered, datumIdx int64, quota *outputQuota, compression pfs.Compression) (retErr error) {
 	defer a.reportUploadStats(time.Now(), stats, logger)
 	logger.Logf("starting to upload output")
 	defer func(start time.Time) {
@@ -859,6 +970,9 @@ func (a *APIServer) uploadOutput(pachClient *client.APIClient, dir string, tag s
 	defer grpcutil.PutBuffer(buf)
 	var offset uint64
 	var tree *hashtree.Ordered
+	// The data in this datum's output, which only counts against 'quota' once
+	// the datum has been uploaded successfully
+	var datumSize, datumFiles uint64
 	// Upload all files in output directory
 	if err := filepath.Walk(outputPath, func(filePath string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -951,6 +1065,11 @@ func (a *APIServer) uploadOutput(pachClient *client.APIClient, dir string, tag s
 							if err != nil {
 								return err
 							}
+							datumSize += fileInfo.SizeBytes
+							datumFiles++
+							if err := quota.check(datumSize, datumFiles); err != nil {
+								return err
+							}
 							var blockRefs []*pfs.BlockRef
 							for _, object := range fileInfo.Objects {
 								objectInfo, err := pachClient.InspectObject(object.Hash)
@@ -960,7 +1079,13 @@ func (a *APIServer) uploadOutput(pachClient *client.APIClient, dir string, tag s
 								blockRefs = append(blockRefs, objectInfo.BlockRef)
 							}
 							blockRefs = append(blockRefs, fileInfo.BlockRefs...)
-							n := &hashtree.FileNodeProto{BlockRefs: blockRefs}
+							n := &hashtree.FileNodeProto{
+								BlockRefs:     blockRefs,
+								Sha256:        fileInfo.Sha256,
+								Md5:           fileInfo.Md5,
+								Mode:          fileInfo.Mode,
+								SymlinkTarget: fileInfo.SymlinkTarget,
+							}
 							tree.PutFile(subRelPath, fileInfo.Hash, int64(fileInfo.SizeBytes), n)
 							if statsTree != nil {
 								statsTree.PutFile(subRelPath, fileInfo.Hash, int64(fileInfo.SizeBytes), n)
@@ -971,6 +1096,11 @@ func (a *APIServer) uploadOutput(pachClient *client.APIClient, dir string, tag s
 				}
 			}
 		}
+		datumSize += uint64(info.Size())
+		datumFiles++
+		if err := quota.check(datumSize, datumFiles); err != nil {
+			return err
+		}
 		// Open local file that is being uploaded
 		f, err := os.Open(filePath)
 		if err != nil {
@@ -987,42 +1117,56 @@ func (a *APIServer) uploadOutput(pachClient *client.APIClient, dir string, tag s
 				retErr = err
 			}
 		}()
-		var size int64
+		// 'info' describes the symlink if this is one, so the mode comes from
+		// the file that was opened
+		fileInfo, err := f.Stat()
+		if err != nil {
+			return err
+		}
 		h := pfs.NewHash()
-		r := io.TeeReader(f, h)
+		sha256Hash, md5Hash := sha256.New(), md5.New()
+		r := io.TeeReader(f, io.MultiWriter(h, sha256Hash, md5Hash))
 		// Write local file to object storage block
-		for {
-			n, err := r.Read(buf)
-			if n == 0 && err != nil {
-				if err == io.EOF {
-					break
-				}
+		objW := &putObjectsWriter{client: putObjsClient}
+		var w io.Writer = objW
+		var compressor io.WriteCloser
+		if compression != pfs.Compression_UNCOMPRESSED {
+			if compressor, err = pfsserver.NewCompressor(objW, compression); err != nil {
 				return err
 			}
-			if err := putObjsClient.Send(&pfs.PutObjectRequest{
-				Value: buf[:n],
-			}); err != nil {
+			w = compressor
+		}
+		size, err := io.CopyBuffer(w, r, buf)
+		if err != nil {
+			return err
+		}
+		blockRef := &pfs.BlockRef{
+			Block: block,
+			Range: &pfs.ByteRange{
+				Lower: offset,
+				Upper: offset + uint64(size),
+			},
+		}
+		if compressor != nil {
+			if err := compressor.Close(); err != nil {
 				return err
 			}
-			size += int64(n)
+			blockRef.Range.Upper = offset + objW.size
+			blockRef.Compression = compression
+			blockRef.SizeBytes = uint64(size)
 		}
 		n := &hashtree.FileNodeProto{
-			BlockRefs: []*pfs.BlockRef{
-				&pfs.BlockRef{
-					Block: block,
-					Range: &pfs.ByteRange{
-						Lower: offset,
-						Upper: offset + uint64(size),
-					},
-				},
-			},
+			BlockRefs: []*pfs.BlockRef{blockRef},
+			Sha256:    sha256Hash.Sum(nil),
+			Md5:       md5Hash.Sum(nil),
+			Mode:      uint32(fileInfo.Mode().Perm()),
 		}
 		hash := h.Sum(nil)
 		tree.PutFile(relPath, hash, size, n)
 		if statsTree != nil {
 			statsTree.PutFile(relPath, hash, size, n)
 		}
-		offset += uint64(size)
+		offset = blockRef.Range.Upper
 		stats.UploadBytes += uint64(size)
 		return nil
 	}); err != nil {
@@ -1037,7 +1181,11 @@ func (a *APIServer) uploadOutput(pachClient *client.APIClient, dir string, tag s
 		return err
 	}
 	// Write datum hashtree to object storage
-	w, err := pachClient.PutObjectAsync([]*pfs.Tag{client.NewTag(tag)})
+	tags := []*pfs.Tag{client.NewTag(tag)}
+	if cacheTag != "" {
+		tags = append(tags, client.NewTag(cacheTag))
+	}
+	w, err := pachClient.PutObjectAsyncCompressed(tags, compression)
 	if err != nil {
 		return err
 	}
@@ -1049,6 +1197,7 @@ func (a *APIServer) uploadOutput(pachClient *client.APIClient, dir string, tag s
 	if _, err := w.Write(b.Bytes()); err != nil {
 		return err
 	}
+	quota.add(datumSize, datumFiles)
 	// Cache datum hashtree locally
 	return a.datumCache.Put(datumIdx, bytes.NewReader(b.Bytes()))
 }
@@ -1163,13 +1312,7 @@ func (a *APIServer) datum() []*pps.InputFile {
 }
 
 func (a *APIServer) userCodeEnv(jobID string, outputCommitID string, data []*Input) []string {
-	result := os.Environ()
-	for _, input := range data {
-		result = append(result, fmt.Sprintf("%s=%s", input.Name, filepath.Join(client.PPSInputPrefix, input.Name, input.FileInfo.File.Path)))
-		result = append(result, fmt.Sprintf("%s_COMMIT=%s", input.Name, input.FileInfo.File.Commit.ID))
-	}
-	result = append(result, fmt.Sprintf("%s=%s", client.JobIDEnv, jobID))
-	result = append(result, fmt.Sprintf("%s=%s", client.OutputCommitIDEnv, outputCommitID))
+	result := append(os.Environ(), InputEnv(client.PPSInputPrefix, jobID, outputCommitID, data)...)
 	if ppsutil.ContainsS3Inputs(a.pipelineInfo.Input) || a.pipelineInfo.S3Out {
 		// TODO(msteffen) Instead of reading S3GATEWAY_PORT directly, worker/main.go
 		// should pass its ServiceEnv to worker.NewAPIServer, which should store it
@@ -1186,13 +1329,28 @@ func (a *APIServer) userCodeEnv(jobID string, outputCommitID string, data []*Inp
 	return result
 }
 
+// InputEnv returns the environment variables that tell user code processing
+// 'data' where its inputs are, given that they're downloaded under 'pfsRoot'.
+func InputEnv(pfsRoot string, jobID string, outputCommitID string, data []*Input) []string {
+	var result []string
+	for _, input := range data {
+		result = append(result, fmt.Sprintf("%s=%s", input.Name, filepath.Join(pfsRoot, input.Name, input.FileInfo.File.Path)))
+		result = append(result, fmt.Sprintf("%s_COMMIT=%s", input.Name, input.FileInfo.File.Commit.ID))
+	}
+	result = append(result, fmt.Sprintf("%s=%s", client.JobIDEnv, jobID))
+	result = append(result, fmt.Sprintf("%s=%s", client.OutputCommitIDEnv, outputCommitID))
+	return result
+}
+
 type processResult struct {
 	failedDatumID   string
+	failedReason    string
 	datumsProcessed int64
 	datumsSkipped   int64
 	datumsRecovered int64
 	datumsFailed    int64
 	recoveredDatums *pfs.Object
+	deadLetters     *pfs.Object
 }
 
 type processFunc func(low, high int64) (*processResult, error)
@@ -1259,12 +1417,14 @@ func (a *APIServer) processChunk(ctx context.Context, jobID string, low, high in
 				State:   State_FAILED,
 				DatumID: processResult.failedDatumID,
 				Address: os.Getenv(client.PPSWorkerIPEnv),
+				Reason:  processResult.failedReason,
 			})
 		}
 		return chunks.Put(fmt.Sprint(high), &ChunkState{
 			State:           State_COMPLETE,
 			Address:         os.Getenv(client.PPSWorkerIPEnv),
 			RecoveredDatums: processResult.recoveredDatums,
+			DeadLetters:     processResult.deadLetters,
 		})
 	}); err != nil {
 		return err
@@ -1273,7 +1433,7 @@ func (a *APIServer) processChunk(ctx context.Context, jobID string, low, high in
 }
 
 func (a *APIServer) mergeDatums(jobCtx context.Context, pachClient *client.APIClient, jobInfo *pps.JobInfo, jobID string,
-	plan *Plan, logger *taggedLogger, df DatumIterator, skip map[string]bool, useParentHashTree bool) (retErr error) {
+	plan *Plan, logger *taggedLogger, df DatumIterator, skip map[string]bool, deadLettered map[string]bool, useParentHashTree bool) (retErr error) {
 	for {
 		if err := func() error {
 			// if this worker is not responsible for a shard, it waits to be assigned one or for the job to finish
@@ -1316,7 +1476,7 @@ func (a *APIServer) mergeDatums(jobCtx context.Context, pachClient *client.APICl
 						case State_COMPLETE:
 							if err := a.getChunk(ctx, high, chunkState.Address, failed); err != nil {
 								logger.Logf("error downloading chunk %v from worker at %v (%v), falling back on object storage", high, chunkState.Address, err)
-								tags := a.computeTags(df, low, high, skip, useParentHashTree)
+								tags := a.computeTags(df, low, high, skip, deadLettered, useParentHashTree)
 								// Download datum hashtrees from object storage if we run into an error getting them from the worker
 								if err := a.getChunkFromObjectStorage(ctx, pachClient, objClient, tags, high, failed); err != nil {
 									return err
@@ -1369,18 +1529,26 @@ func (a *APIServer) mergeDatums(jobCtx context.Context, pachClient *client.APICl
 					parentStatsHashtree = bufio.NewReaderSize(r, parentTreeBufSize)
 				}
 			}
+			// count the output's files while merging it if the output repo
+			// has a file-count quota, so that pachd doesn't have to walk the
+			// output commit's trees to check it
+			repoInfo, err := pachClient.InspectRepo(jobInfo.OutputCommit.Repo.Name)
+			if err != nil {
+				return err
+			}
+			countFiles := repoInfo.Quota.GetFileCount() != 0
 			// merging output tree(s)
 			var tree, statsTree *pfs.Object
-			var size, statsSize uint64
+			var size, statsSize, fileCount uint64
 			if err := logger.LogStep("merging output", func() error {
 				if a.pipelineInfo.EnableStats {
-					statsTree, statsSize, err = a.merge(pachClient, objClient, true, parentStatsHashtree)
+					statsTree, statsSize, _, err = a.merge(pachClient, objClient, true, false, parentStatsHashtree)
 					if err != nil {
 						return err
 					}
 				}
 				if !failed {
-					tree, size, err = a.merge(pachClient, objClient, false, parentHashtree)
+					tree, size, fileCount, err = a.merge(pachClient, objClient, false, countFiles, parentHashtree)
 					if err != nil {
 						return err
 					}
@@ -1392,7 +1560,7 @@ func (a *APIServer) mergeDatums(jobCtx context.Context, pachClient *client.APICl
 			// mark merge as complete
 			_, err = col.NewSTM(ctx, a.etcdClient, func(stm col.STM) error {
 				merges := a.merges(jobID).ReadWrite(stm)
-				return merges.Put(fmt.Sprint(a.shard), &MergeState{State: State_COMPLETE, Tree: tree, SizeBytes: size, StatsTree: statsTree, StatsSizeBytes: statsSize})
+				return merges.Put(fmt.Sprint(a.shard), &MergeState{State: State_COMPLETE, Tree: tree, SizeBytes: size, StatsTree: statsTree, StatsSizeBytes: statsSize, FileCount: fileCount})
 			})
 			return err
 		}(); err != nil {
@@ -1462,10 +1630,14 @@ func (a *APIServer) getChunk(ctx context.Context, id int64, address string, fail
 	return nil
 }
 
-func (a *APIServer) computeTags(df DatumIterator, low, high int64, skip map[string]bool, useParentHashTree bool) []*pfs.Tag {
+func (a *APIServer) computeTags(df DatumIterator, low, high int64, skip map[string]bool, deadLettered map[string]bool, useParentHashTree bool) []*pfs.Tag {
 	var tags []*pfs.Tag
 	for i := low; i < high; i++ {
 		files := df.DatumN(int(i))
+		// Skipped dead-lettered datums have no output
+		if deadLettered[a.DatumID(files)] {
+			continue
+		}
 		datumHash := HashDatum(a.pipelineInfo.Pipeline.Name, a.pipelineInfo.Salt, files)
 		// Skip datum if it is in the parent hashtree and the parent hashtree is being used in the merge
 		if skip[datumHash] && useParentHashTree {
@@ -1512,15 +1684,21 @@ func (a *APIServer) getChunkFromObjectStorage(ctx context.Context, pachClient *c
 	return nil
 }
 
-func (a *APIServer) merge(pachClient *client.APIClient, objClient obj.Client, stats bool, parent io.Reader) (*pfs.Object, uint64, error) {
+func (a *APIServer) merge(pachClient *client.APIClient, objClient obj.Client, stats bool, countFiles bool, parent io.Reader) (*pfs.Object, uint64, uint64, error) {
 	var tree *pfs.Object
-	var size uint64
+	var size, fileCount uint64
 	if err := func() (retErr error) {
+		// Chunk hashtrees aren't compressed with the output repo's
+		// compression, because pachd reads them by byte range through their
+		// index
 		objW, err := pachClient.PutObjectAsync(nil)
 		if err != nil {
 			return err
 		}
 		w := hashtree.NewWriter(objW)
+		if countFiles {
+			w.CountFiles()
+		}
 		filter := hashtree.NewFilter(a.numShards, a.shard)
 		if stats {
 			err = a.chunkStatsCache.Merge(w, parent, filter)
@@ -1528,6 +1706,7 @@ func (a *APIServer) merge(pachClient *client.APIClient, objClient obj.Client, st
 			err = a.chunkCache.Merge(w, parent, filter)
 		}
 		size = w.Size()
+		fileCount = w.FileCount()
 		if err != nil {
 			objW.Close()
 			return err
@@ -1547,9 +1726,9 @@ func (a *APIServer) merge(pachClient *client.APIClient, objClient obj.Client, st
 		}
 		return writeIndex(pachClient, objClient, tree, idx)
 	}(); err != nil {
-		return nil, 0, err
+		return nil, 0, 0, err
 	}
-	return tree, size, nil
+	return tree, size, fileCount, nil
 }
 
 func (a *APIServer) getParentCommitInfo(ctx context.Context, pachClient *client.APIClient, commit *pfs.Commit) (*pfs.CommitInfo, error) {
@@ -1609,6 +1788,14 @@ func (a *APIServer) getHashtrees(ctx context.Context, pachClient *client.APIClie
 			if err != nil {
 				return err
 			}
+			if info.BlockRef.Compression != pfs.Compression_UNCOMPRESSED {
+				dr, err := pfsserver.NewDecompressor(objR, info.BlockRef.Compression)
+				if err != nil {
+					objR.Close()
+					return err
+				}
+				objR = dr
+			}
 			defer func() {
 				if err := objR.Close(); err != nil && retErr == nil {
 					retErr = err
@@ -1873,6 +2060,10 @@ func (a *APIServer) worker() {
 				}
 
 				// Compute the datums to skip
+				deadLettered, err := a.deadLetteredDatums(pachClient, plan.DeadLetterCommit)
+				if err != nil {
+					return err
+				}
 				skip := make(map[string]bool)
 				var useParentHashTree bool
 				if err := logger.LogStep("computing datums to skip", func() error {
@@ -1947,7 +2138,7 @@ func (a *APIServer) worker() {
 						return a.acquireDatums(
 							ctx, jobID, plan, logger,
 							func(low, high int64) (*processResult, error) {
-								processResult, err := a.processDatums(pachClient, logger, jobInfo, df, low, high, skip, useParentHashTree)
+								processResult, err := a.processDatums(pachClient, logger, jobInfo, df, low, high, skip, deadLettered, useParentHashTree)
 								if err != nil {
 									return nil, err
 								}
@@ -1959,7 +2150,7 @@ func (a *APIServer) worker() {
 				if !a.pipelineInfo.S3Out {
 					eg.Go(func() error {
 						return logger.LogStep("merge worker", func() error {
-							return a.mergeDatums(ctx, pachClient, jobInfo, jobID, plan, logger, df, skip, useParentHashTree)
+							return a.mergeDatums(ctx, pachClient, jobInfo, jobID, plan, logger, df, skip, deadLettered, useParentHashTree)
 						})
 					})
 				}
@@ -2019,7 +2210,7 @@ func (a *APIServer) claimShard(ctx context.Context) {
 // returns the id of the failed datum it also may return a variety of errors
 // such as network errors.
 func (a *APIServer) processDatums(pachClient *client.APIClient, logger *taggedLogger, jobInfo *pps.JobInfo,
-	df DatumIterator, low, high int64, skip map[string]bool, useParentHashTree bool) (result *processResult, retErr error) {
+	df DatumIterator, low, high int64, skip map[string]bool, deadLettered map[string]bool, useParentHashTree bool) (result *processResult, retErr error) {
 	defer func() {
 		if err := a.datumCache.Clear(); err != nil && retErr == nil {
 			logger.Logf("error clearing datum cache: %v", err)
@@ -2033,12 +2224,25 @@ func (a *APIServer) processDatums(pachClient *client.APIClient, logger *taggedLo
 	if err != nil {
 		return nil, err
 	}
+	var quota *outputQuota
+	var compression pfs.Compression
+	if !a.pipelineInfo.S3Out {
+		repoInfo, err := pachClient.InspectRepo(jobInfo.OutputCommit.Repo.Name)
+		if err != nil {
+			return nil, err
+		}
+		compression = repoInfo.Compression
+		if repoInfo.Quota != nil {
+			quota = &outputQuota{repo: repoInfo.Repo, quota: repoInfo.Quota}
+		}
+	}
 	stats := &pps.ProcessStats{}
 	var statsMu sync.Mutex
 	result = &processResult{}
 	var eg errgroup.Group
 	limiter := limit.New(int(a.pipelineInfo.MaxQueueSize))
 	var recoveredDatums []string
+	var deadLetters []*pps.DeadLetterDatum
 	var recoverMu sync.Mutex
 	for i := low; i < high; i++ {
 		datumIdx := i
@@ -2054,6 +2258,11 @@ func (a *APIServer) processDatums(pachClient *client.APIClient, logger *taggedLo
 			if err != nil {
 				return err
 			}
+			if deadLettered[a.DatumID(data)] {
+				atomic.AddInt64(&result.datumsSkipped, 1)
+				logger.Logf("skipping dead-lettered datum")
+				return nil
+			}
 			// Hash inputs
 			tag := HashDatum(a.pipelineInfo.Pipeline.Name, a.pipelineInfo.Salt, data)
 			if skip[tag] {
@@ -2074,6 +2283,35 @@ func (a *APIServer) processDatums(pachClient *client.APIClient, logger *taggedLo
 				logger.Logf("skipping datum")
 				return nil
 			}
+			var cacheTag string
+			if a.datumCacheKey != "" {
+				cacheTag = HashDatumCache(a.datumCacheKey, data)
+				if objectInfo, err := pachClient.InspectTag(ctx, client.NewTag(cacheTag)); err == nil {
+					// Another pipeline (or an earlier version of this one) already
+					// processed this datum with the same transform, so tag its
+					// output as this pipeline's. The output counts against the
+					// output repo's quota as if it had been uploaded.
+					if err := a.chargeCachedOutput(pachClient, cacheTag, quota); err != nil {
+						if !pfsserver.IsQuotaExceededErr(err) {
+							return err
+						}
+						logger.Logf("failed to process datum with error: %+v", err)
+						result.failedDatumID = a.DatumID(data)
+						result.failedReason = err.Error()
+						atomic.AddInt64(&result.datumsFailed, 1)
+						return nil
+					}
+					if err := pachClient.TagObject(objectInfo.Object.Hash, tag); err != nil {
+						return err
+					}
+					if err := a.cacheHashtree(pachClient, tag, datumIdx); err != nil {
+						return err
+					}
+					atomic.AddInt64(&result.datumsSkipped, 1)
+					logger.Logf("reusing cached output of datum")
+					return nil
+				}
+			}
 			subStats := &pps.ProcessStats{}
 			var inputTree, outputTree *hashtree.Ordered
 			var statsTree *hashtree.Unordered
@@ -2112,6 +2350,9 @@ func (a *APIServer) processDatums(pachClient *client.APIClient, logger *taggedLo
 				if isDone(ctx) {
 					return ctx.Err() // timeout or cancelled job--don't run datum
 				}
+				if logger.tail != nil {
+					logger.tail.reset() // only the last try's output is recorded
+				}
 				// Download input data
 				puller := filesync.NewPuller()
 				// TODO parent tag shouldn't be nil
@@ -2194,7 +2435,7 @@ func (a *APIServer) processDatums(pachClient *client.APIClient, logger *taggedLo
 				a.reportDownloadSizeStats(float64(downSize), logger)
 				if !a.pipelineInfo.S3Out {
 					// Only upload output for jobs not writing via the S3 gateway
-					return a.uploadOutput(pachClient, dir, tag, logger, data, subStats, outputTree, datumIdx)
+					return a.uploadOutput(pachClient, dir, tag, cacheTag, logger, data, subStats, outputTree, datumIdx, quota, compression)
 				}
 				return nil
 			}, &backoff.ZeroBackOff{}, func(err error, d time.Duration) error {
@@ -2202,7 +2443,8 @@ func (a *APIServer) processDatums(pachClient *client.APIClient, logger *taggedLo
 					return ctx.Err() // timeout or cancelled job, err out and don't retry
 				}
 				failures++
-				if failures >= jobInfo.DatumTries {
+				// Retrying won't help if the output repo's quota is exceeded
+				if failures >= jobInfo.DatumTries || pfsserver.IsQuotaExceededErr(err) {
 					logger.Logf("failed to process datum with error: %+v", err)
 					if statsTree != nil {
 						object, size, err := pachClient.PutObject(strings.NewReader(err.Error()))
@@ -2231,14 +2473,29 @@ func (a *APIServer) processDatums(pachClient *client.APIClient, logger *taggedLo
 				recoveredDatums = append(recoveredDatums, a.DatumID(data))
 				atomic.AddInt64(&result.datumsRecovered, 1)
 				return nil
+			} else if err != nil && a.pipelineInfo.DeadLetter && !pfsserver.IsQuotaExceededErr(err) && !isDone(ctx) {
+				// dead-letter the datum instead of failing the job. Like a
+				// recovered datum, it's left out of the job's processed datums.
+				// Later jobs skip it while it has a record in the dead letter
+				// repo, and retry it once it's resubmitted.
+				deadLetter := a.deadLetterDatum(jobInfo, data, logger, subStats, err)
+				recoverMu.Lock()
+				defer recoverMu.Unlock()
+				recoveredDatums = append(recoveredDatums, a.DatumID(data))
+				deadLetters = append(deadLetters, deadLetter)
+				atomic.AddInt64(&result.datumsFailed, 1)
+				return nil
 			} else if err != nil {
 				result.failedDatumID = a.DatumID(data)
+				if pfsserver.IsQuotaExceededErr(err) {
+					result.failedReason = err.Error()
+				}
 				atomic.AddInt64(&result.datumsFailed, 1)
 				return nil
 			}
 			statsMu.Lock()
 			defer statsMu.Unlock()
-			if err := mergeStats(stats, subStats); err != nil {
+			if err := pps.MergeProcessStats(stats, subStats); err != nil {
 				logger.Logf("failed to merge Stats: %v", err)
 			}
 			return nil
@@ -2264,6 +2521,13 @@ func (a *APIServer) processDatums(pachClient *client.APIClient, logger *taggedLo
 
 		result.recoveredDatums = recoveredDatumsObj
 	}
+	if len(deadLetters) > 0 {
+		deadLettersObj, err := putDeadLetters(pachClient, deadLetters)
+		if err != nil {
+			return nil, err
+		}
+		result.deadLetters = deadLettersObj
+	}
 
 	if _, err := col.NewSTM(ctx, a.etcdClient, func(stm col.STM) error {
 		jobs := a.jobs.ReadWrite(stm)
@@ -2275,7 +2539,7 @@ func (a *APIServer) processDatums(pachClient *client.APIClient, logger *taggedLo
 		if jobPtr.Stats == nil {
 			jobPtr.Stats = &pps.ProcessStats{}
 		}
-		if err := mergeStats(jobPtr.Stats, stats); err != nil {
+		if err := pps.MergeProcessStats(jobPtr.Stats, stats); err != nil {
 			logger.Logf("failed to merge Stats: %v", err)
 		}
 		return jobs.Put(jobID, jobPtr)
@@ -2292,6 +2556,39 @@ func (a *APIServer) processDatums(pachClient *client.APIClient, logger *taggedLo
 	return result, nil
 }
 
+// chargeCachedOutput adds the output that was stored in the datum cache under
+// 'cacheTag' to 'quota', or returns ErrQuotaExceeded if it doesn't fit, like
+// uploadOutput does for output that it uploads.
+func (a *APIServer) chargeCachedOutput(pachClient *client.APIClient, cacheTag string, quota *outputQuota) (retErr error) {
+	if quota == nil {
+		return nil
+	}
+	r, err := pachClient.GetTagReader(cacheTag)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := r.Close(); err != nil && retErr == nil {
+			retErr = err
+		}
+	}()
+	var sizeBytes, fileCount uint64
+	if err := hashtree.Walk([]io.ReadCloser{r}, "/", func(_ string, node *hashtree.NodeProto) error {
+		if node.FileNode != nil {
+			sizeBytes += uint64(node.SubtreeSize)
+			fileCount++
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	if err := quota.check(sizeBytes, fileCount); err != nil {
+		return err
+	}
+	quota.add(sizeBytes, fileCount)
+	return nil
+}
+
 func (a *APIServer) cacheHashtree(pachClient *client.APIClient, tag string, datumIdx int64) (retErr error) {
 	buf := &bytes.Buffer{}
 	if err := pachClient.GetTag(tag, buf); err != nil {
@@ -2384,23 +2681,6 @@ func (a *APIServer) writeStats(pachClient *client.APIClient, objClient obj.Clien
 	return a.datumStatsCache.Put(datumIdx, bytes.NewReader(buf.Bytes()))
 }
 
-// mergeStats merges y into x
-func mergeStats(x, y *pps.ProcessStats) error {
-	var err error
-	if x.DownloadTime, err = plusDuration(x.DownloadTime, y.DownloadTime); err != nil {
-		return err
-	}
-	if x.ProcessTime, err = plusDuration(x.ProcessTime, y.ProcessTime); err != nil {
-		return err
-	}
-	if x.UploadTime, err = plusDuration(x.UploadTime, y.UploadTime); err != nil {
-		return err
-	}
-	x.DownloadBytes += y.DownloadBytes
-	x.UploadBytes += y.UploadBytes
-	return nil
-}
-
 // mergeChunk merges the datum hashtrees into a chunk hashtree and stores it.
 func (a *APIServer) mergeChunk(logger *taggedLogger, high int64, result *processResult) (retErr error) {
 	logger.Logf("starting to merge chunk")
@@ -2412,7 +2692,7 @@ func (a *APIServer) mergeChunk(logger *taggedLogger, high int64, result *process
 		}
 	}(time.Now())
 	buf := &bytes.Buffer{}
-	if result.datumsFailed <= 0 {
+	if result.failedDatumID == "" {
 		if err := a.datumCache.Merge(hashtree.NewWriter(buf), nil, nil); err != nil {
 			return err
 		}
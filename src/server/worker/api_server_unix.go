@@ -3,7 +3,13 @@
 package worker
 
 import (
+	"os"
 	"syscall"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+
+	"github.com/pachyderm/pachyderm/src/client/pps"
 )
 
 // Mkfifo does not exist on Windows, so this is left unimplemented there, except for tests
@@ -19,3 +25,21 @@ func makeCmdCredentials(uid uint32, gid uint32) *syscall.SysProcAttr {
 		},
 	}
 }
+
+// rusageStats records the CPU time, peak memory and disk I/O of user code
+// that exited with 'state' in 'stats'. These include the resources used by
+// any child processes that the user code waited for.
+func rusageStats(state *os.ProcessState, stats *pps.ProcessStats) {
+	rusage, ok := state.SysUsage().(*syscall.Rusage)
+	if !ok {
+		return
+	}
+	stats.CpuTime = types.DurationProto(time.Duration(rusage.Utime.Nano() + rusage.Stime.Nano()))
+	// Workers run on Linux, where Maxrss is in kilobytes and I/O is counted in
+	// 512 byte blocks
+	if peak := uint64(rusage.Maxrss) * 1024; peak > stats.PeakMemoryBytes {
+		stats.PeakMemoryBytes = peak
+	}
+	stats.ReadBytes = uint64(rusage.Inblock) * 512
+	stats.WriteBytes = uint64(rusage.Oublock) * 512
+}
@@ -3,9 +3,11 @@
 package worker
 
 import (
+	"os"
 	"syscall"
 
 	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+	"github.com/pachyderm/pachyderm/src/client/pps"
 )
 
 // Note that these functions are stubs for windows and they are not meant to be used outside of tests
@@ -17,3 +19,5 @@ func createSpoutFifo(path string) error {
 func makeCmdCredentials(uid uint32, gid uint32) *syscall.SysProcAttr {
 	return nil
 }
+
+func rusageStats(state *os.ProcessState, stats *pps.ProcessStats) {}
@@ -0,0 +1,89 @@
+package worker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kube "k8s.io/client-go/kubernetes"
+)
+
+// datumCacheTagPrefix is the prefix of the tags under which the outputs of
+// datums processed by pipelines with 'datum_cache' set are stored. Unlike the
+// tags created by HashDatum, these aren't specific to a pipeline, so any
+// pipeline with the same transform and inputs can reuse them.
+const datumCacheTagPrefix = "datum_cache_"
+
+// DatumCacheKey returns the part of a datum cache tag that identifies
+// 'transform' running in the image 'imageID'. 'imageID' must include the
+// image's digest, as an image name may refer to different images over time.
+// Secrets are only identified by name, so transforms with secrets mustn't use
+// the datum cache.
+func DatumCacheKey(imageID string, transform *pps.Transform) (string, error) {
+	// Env is a map, which encoding/json serializes with sorted keys, so the
+	// key doesn't depend on the order in which variables were declared
+	b, err := json.Marshal(struct {
+		ImageID    string
+		Cmd        []string
+		Stdin      []string
+		ErrCmd     []string
+		ErrStdin   []string
+		Env        map[string]string
+		Secrets    []*pps.SecretMount
+		User       string
+		WorkingDir string
+	}{
+		ImageID:    imageID,
+		Cmd:        transform.Cmd,
+		Stdin:      transform.Stdin,
+		ErrCmd:     transform.ErrCmd,
+		ErrStdin:   transform.ErrStdin,
+		Env:        transform.Env,
+		Secrets:    transform.Secrets,
+		User:       transform.User,
+		WorkingDir: transform.WorkingDir,
+	})
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(b)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// HashDatumCache computes the datum cache tag of 'data' processed by the
+// transform identified by 'cacheKey' (see DatumCacheKey).
+func HashDatumCache(cacheKey string, data []*Input) string {
+	hash := sha256.New()
+	for _, datum := range data {
+		hash.Write([]byte(datum.Name))
+		hash.Write([]byte(datum.FileInfo.File.Path))
+		hash.Write(datum.FileInfo.Hash)
+	}
+	hash.Write([]byte(cacheKey))
+	return datumCacheTagPrefix + hex.EncodeToString(hash.Sum(nil))
+}
+
+// UserImageID returns the ID (including the digest) of the image that the
+// user container of the worker pod 'podName' is running.
+func UserImageID(kubeClient kube.Interface, namespace string, podName string) (string, error) {
+	pod, err := kubeClient.CoreV1().Pods(namespace).Get(podName, metav1.GetOptions{})
+	if err != nil {
+		return "", errors.Wrapf(err, "could not inspect worker pod %q", podName)
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name != client.PPSWorkerUserContainerName {
+			continue
+		}
+		if !strings.Contains(status.ImageID, "@sha256:") {
+			return "", errors.Errorf("image ID %q of worker pod %q has no digest", status.ImageID, podName)
+		}
+		return status.ImageID, nil
+	}
+	return "", errors.Errorf("worker pod %q has no %q container", podName, client.PPSWorkerUserContainerName)
+}
@@ -0,0 +1,72 @@
+package worker
+
+import (
+	"testing"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+)
+
+const testImageID = "docker-pullable://ubuntu@sha256:0123456789abcdef"
+
+func datumCacheKey(t *testing.T, imageID string, transform *pps.Transform) string {
+	key, err := DatumCacheKey(imageID, transform)
+	require.NoError(t, err)
+	return key
+}
+
+func TestDatumCacheKey(t *testing.T) {
+	transform := &pps.Transform{
+		Image: "ubuntu",
+		Cmd:   []string{"bash"},
+		Stdin: []string{"cp /pfs/in/* /pfs/out/"},
+		Env:   map[string]string{"A": "1", "B": "2", "C": "3"},
+	}
+	key := datumCacheKey(t, testImageID, transform)
+
+	// The key only depends on what the user code runs, not on how the image
+	// is named or the order in which env vars are declared
+	require.Equal(t, key, datumCacheKey(t, testImageID, &pps.Transform{
+		Image: "ubuntu:latest",
+		Cmd:   []string{"bash"},
+		Stdin: []string{"cp /pfs/in/* /pfs/out/"},
+		Env:   map[string]string{"C": "3", "B": "2", "A": "1"},
+	}))
+
+	require.NotEqual(t, key, datumCacheKey(t, "docker-pullable://ubuntu@sha256:fedcba9876543210", transform))
+	changed := *transform
+	changed.Cmd = []string{"sh"}
+	require.NotEqual(t, key, datumCacheKey(t, testImageID, &changed))
+	changed = *transform
+	changed.Stdin = []string{"mv /pfs/in/* /pfs/out/"}
+	require.NotEqual(t, key, datumCacheKey(t, testImageID, &changed))
+	changed = *transform
+	changed.Env = map[string]string{"A": "1", "B": "2", "C": "4"}
+	require.NotEqual(t, key, datumCacheKey(t, testImageID, &changed))
+}
+
+func TestHashDatumCache(t *testing.T) {
+	input := func(name, path, hash string) *Input {
+		return &Input{
+			Name: name,
+			FileInfo: &pfs.FileInfo{
+				File: client.NewFile("repo", "master", path),
+				Hash: []byte(hash),
+			},
+		}
+	}
+	key := datumCacheKey(t, testImageID, &pps.Transform{Cmd: []string{"true"}})
+	data := []*Input{input("in", "/a", "hash")}
+	tag := HashDatumCache(key, data)
+	require.Equal(t, datumCacheTagPrefix, tag[:len(datumCacheTagPrefix)])
+
+	// Unlike HashDatum, the tag doesn't depend on the pipeline, so datums with
+	// the same contents map to the same tag
+	require.Equal(t, tag, HashDatumCache(key, []*Input{input("in", "/a", "hash")}))
+	require.NotEqual(t, tag, HashDatumCache(key, []*Input{input("in", "/a", "other")}))
+	require.NotEqual(t, tag, HashDatumCache(key, []*Input{input("in", "/b", "hash")}))
+	require.NotEqual(t, tag, HashDatumCache(key, []*Input{input("other", "/a", "hash")}))
+	require.NotEqual(t, tag, HashDatumCache(datumCacheKey(t, testImageID, &pps.Transform{Cmd: []string{"false"}}), data))
+}
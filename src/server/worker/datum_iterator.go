@@ -56,9 +56,11 @@ func newPFSDatumIterator(pachClient *client.APIClient, input *pps.PFSInput) (Dat
 		}
 		g := glob.MustCompile(input.Glob, '/')
 		joinOn := g.Replace(fileInfo.File.Path, input.JoinOn)
+		groupBy := g.Replace(fileInfo.File.Path, input.GroupBy)
 		result.inputs = append(result.inputs, &Input{
 			FileInfo:   fileInfo,
 			JoinOn:     joinOn,
+			GroupBy:    groupBy,
 			Name:       input.Name,
 			Lazy:       input.Lazy,
 			Branch:     input.Branch,
@@ -320,6 +322,10 @@ func newJoinDatumIterator(pachClient *client.APIClient, join []*pps.Input) (Datu
 	result := &joinDatumIterator{}
 	om := ordered_map.NewOrderedMap()
 
+	outerJoin := make([]bool, len(join))
+	for i, input := range join {
+		outerJoin[i] = input.Pfs != nil && input.Pfs.OuterJoin
+	}
 	for i, input := range join {
 		datumIterator, err := NewDatumIterator(pachClient, input)
 		if err != nil {
@@ -344,7 +350,22 @@ func newJoinDatumIterator(pachClient *client.APIClient, join []*pps.Input) (Datu
 	iter := om.IterFunc()
 	for kv, ok := iter(); ok; kv, ok = iter() {
 		tuple := kv.Value.([][]*Input)
-		cross, err := newCrossListDatumIterator(pachClient, tuple)
+		// Inputs with no files for this key are left out of the datum, which is
+		// only emitted if one of the inputs that do have files is outer joined
+		var matched [][]*Input
+		var missing, outer bool
+		for i, inputs := range tuple {
+			if len(inputs) == 0 {
+				missing = true
+				continue
+			}
+			matched = append(matched, inputs)
+			outer = outer || outerJoin[i]
+		}
+		if missing && !outer {
+			continue
+		}
+		cross, err := newCrossListDatumIterator(pachClient, matched)
 		if err != nil {
 			return nil, err
 		}
@@ -383,6 +404,75 @@ func (d *joinDatumIterator) DatumN(n int) []*Input {
 	return d.Datum()
 }
 
+type groupDatumIterator struct {
+	datums   [][]*Input
+	location int
+}
+
+func newGroupDatumIterator(pachClient *client.APIClient, group []*pps.Input) (DatumIterator, error) {
+	result := &groupDatumIterator{}
+	om := ordered_map.NewOrderedMap()
+
+	for _, input := range group {
+		datumIterator, err := NewDatumIterator(pachClient, input)
+		if err != nil {
+			return nil, err
+		}
+		for datumIterator.Next() {
+			x := datumIterator.Datum()
+			for _, k := range x {
+				var datum []*Input
+				if datumI, ok := om.Get(k.GroupBy); ok {
+					datum = datumI.([]*Input)
+				}
+				om.Set(k.GroupBy, append(datum, k))
+			}
+		}
+	}
+
+	iter := om.IterFunc()
+	for kv, ok := iter(); ok; kv, ok = iter() {
+		result.datums = append(result.datums, kv.Value.([]*Input))
+	}
+	result.location = -1
+	return result, nil
+}
+
+func (d *groupDatumIterator) Reset() {
+	d.location = -1
+}
+
+func (d *groupDatumIterator) Len() int {
+	return len(d.datums)
+}
+
+func (d *groupDatumIterator) Next() bool {
+	if d.location < len(d.datums) {
+		d.location++
+	}
+	return d.location < len(d.datums)
+}
+
+func (d *groupDatumIterator) Datum() []*Input {
+	var result []*Input
+	result = append(result, d.datums[d.location]...)
+	// Unlike other datums, a group datum may have many inputs with the same
+	// name, so they're also sorted by path to keep the datum's order (and
+	// thus its hash) deterministic
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].FileInfo.File.Path < result[j].FileInfo.File.Path
+	})
+	return result
+}
+
+func (d *groupDatumIterator) DatumN(n int) []*Input {
+	d.location = n
+	return d.Datum()
+}
+
 type gitDatumIterator struct {
 	inputs   []*Input
 	location int
@@ -451,6 +541,34 @@ func newCronDatumIterator(pachClient *client.APIClient, input *pps.CronInput) (D
 	})
 }
 
+// newWebhookDatumIterator exposes each payload in a webhook input's repo as a
+// datum, like a cron input's ticks.
+func newWebhookDatumIterator(pachClient *client.APIClient, input *pps.WebhookInput) (DatumIterator, error) {
+	return newPFSDatumIterator(pachClient, &pps.PFSInput{
+		Name:   input.Name,
+		Repo:   input.Repo,
+		Branch: "master",
+		Commit: input.Commit,
+		Glob:   "/*",
+	})
+}
+
+// newObjectStoreDatumIterator splits the objects ingested into an object store
+// input's repo into datums with the input's glob pattern.
+func newObjectStoreDatumIterator(pachClient *client.APIClient, input *pps.ObjectStoreInput) (DatumIterator, error) {
+	glob := input.Glob
+	if glob == "" {
+		glob = "/*"
+	}
+	return newPFSDatumIterator(pachClient, &pps.PFSInput{
+		Name:   input.Name,
+		Repo:   input.Repo,
+		Branch: "master",
+		Commit: input.Commit,
+		Glob:   glob,
+	})
+}
+
 // NewDatumIterator creates a datumIterator for an input.
 func NewDatumIterator(pachClient *client.APIClient, input *pps.Input) (DatumIterator, error) {
 	switch {
@@ -462,10 +580,16 @@ func NewDatumIterator(pachClient *client.APIClient, input *pps.Input) (DatumIter
 		return newCrossDatumIterator(pachClient, input.Cross)
 	case input.Join != nil:
 		return newJoinDatumIterator(pachClient, input.Join)
+	case input.Group != nil:
+		return newGroupDatumIterator(pachClient, input.Group)
 	case input.Cron != nil:
 		return newCronDatumIterator(pachClient, input.Cron)
 	case input.Git != nil:
 		return newGitDatumIterator(pachClient, input.Git)
+	case input.Webhook != nil:
+		return newWebhookDatumIterator(pachClient, input.Webhook)
+	case input.ObjectStore != nil:
+		return newObjectStoreDatumIterator(pachClient, input.ObjectStore)
 	}
 	return nil, errors.Errorf("unrecognized input type: %v", input)
 }
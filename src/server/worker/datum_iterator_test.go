@@ -218,6 +218,67 @@ func TestDatumIterators(t *testing.T) {
 		require.True(t, checked > 0 && 3*checked == s3Count,
 			"checked: %v, s3Count: %v", checked, s3Count)
 	})
+
+	// in[14-15] are elements of in16, which is a group input
+	in14 := client.NewPFSInputOpts("", dataRepo, "", "/foo(?)(?)", "", false)
+	in14.Pfs.GroupBy = "$1"
+	in14.Pfs.Commit = commit.ID
+	in15 := client.NewPFSInputOpts("", dataRepo, "", "/foo(?)", "", false)
+	in15.Pfs.GroupBy = "$1"
+	in15.Pfs.Commit = commit.ID
+	in16 := client.NewGroupInput(in14, in15)
+	t.Run("Group", func(t *testing.T) {
+		group1, err := NewDatumIterator(c, in16)
+		require.NoError(t, err)
+		var expected []string
+		for _, key := range []int{1, 2, 3, 4} {
+			datum := fmt.Sprintf("/foo%d", key)
+			for j := 0; j < 10; j++ {
+				datum += fmt.Sprintf("/foo%d%d", key, j)
+			}
+			expected = append(expected, datum)
+		}
+		expected = append(expected, "/foo0", "/foo5", "/foo6", "/foo7", "/foo8", "/foo9")
+		validateDI(t, group1, expected...)
+	})
+
+	// in[17-18] are in8 and in9, outer joined
+	in17 := client.NewPFSInputOpts("", dataRepo, "", "/foo(?)(?)", "$1$2", false)
+	in17.Pfs.OuterJoin = true
+	in17.Pfs.Commit = commit.ID
+	in18 := client.NewPFSInputOpts("", dataRepo, "", "/foo(?)(?)", "$2$1", false)
+	in18.Pfs.OuterJoin = true
+	in18.Pfs.Commit = commit.ID
+	t.Run("OuterJoin", func(t *testing.T) {
+		// A left outer join includes all of in17's files, with their matches
+		// from in9 if there are any
+		var left []string
+		for i := 1; i <= 4; i++ {
+			for j := 0; j < 10; j++ {
+				datum := fmt.Sprintf("/foo%d%d", i, j)
+				if j >= 1 && j <= 4 {
+					datum += fmt.Sprintf("/foo%d%d", j, i)
+				}
+				left = append(left, datum)
+			}
+		}
+		leftJoin, err := NewDatumIterator(c, client.NewJoinInput(in17, in9))
+		require.NoError(t, err)
+		validateDI(t, leftJoin, left...)
+
+		// A full outer join also includes in18's unmatched files
+		full := left
+		for i := 1; i <= 4; i++ {
+			for j := 0; j < 10; j++ {
+				if j < 1 || j > 4 {
+					full = append(full, fmt.Sprintf("/foo%d%d", i, j))
+				}
+			}
+		}
+		fullJoin, err := NewDatumIterator(c, client.NewJoinInput(in17, in18))
+		require.NoError(t, err)
+		validateDI(t, fullJoin, full...)
+	})
 }
 
 func benchmarkDatumIterators(j int, b *testing.B) {
@@ -0,0 +1,213 @@
+package worker
+
+import (
+	"bytes"
+	"io"
+	"path"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/types"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+	"github.com/pachyderm/pachyderm/src/client/pkg/pbutil"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+	"github.com/pachyderm/pachyderm/src/server/pkg/errutil"
+	"github.com/pachyderm/pachyderm/src/server/pkg/exec"
+)
+
+// maxOutputTail is the most user code output that's kept for a dead-lettered
+// datum.
+const maxOutputTail = 64 * 1024
+
+// outputTail keeps the last maxOutputTail bytes of what a datum's user code
+// wrote to stdout and stderr, so it can be recorded if the datum is
+// dead-lettered.
+type outputTail struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (t *outputTail) writeLine(line string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, line...)
+	t.buf = append(t.buf, '\n')
+	if len(t.buf) > maxOutputTail {
+		t.buf = t.buf[len(t.buf)-maxOutputTail:]
+	}
+}
+
+func (t *outputTail) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = nil
+}
+
+func (t *outputTail) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return string(t.buf)
+}
+
+// exitCode returns the exit code of the user code that produced 'err', or -1
+// if 'err' wasn't caused by the user code exiting.
+func exitCode(err error) int64 {
+	if exitErr, ok := errors.Cause(err).(*exec.ExitError); ok {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+			return int64(status.ExitStatus())
+		}
+	}
+	return -1
+}
+
+// deadLetterDatum builds the record of a datum that failed with 'err' after
+// exhausting its tries.
+func (a *APIServer) deadLetterDatum(jobInfo *pps.JobInfo, data []*Input, logger *taggedLogger, stats *pps.ProcessStats, err error) *pps.DeadLetterDatum {
+	datumInfo := &pps.DatumInfo{
+		Datum: &pps.Datum{
+			ID:  a.DatumID(data),
+			Job: jobInfo.Job,
+		},
+		State: pps.DatumState_FAILED,
+		Stats: stats,
+	}
+	for _, d := range data {
+		datumInfo.Data = append(datumInfo.Data, d.FileInfo)
+	}
+	result := &pps.DeadLetterDatum{
+		DatumInfo: datumInfo,
+		Error:     err.Error(),
+		ExitCode:  exitCode(err),
+	}
+	if logger.tail != nil {
+		result.Output = logger.tail.String()
+	}
+	if failed, err := types.TimestampProto(time.Now()); err == nil {
+		result.Failed = failed
+	}
+	return result
+}
+
+// putDeadLetters writes 'deadLetters' to an object, so that they can be passed
+// from a worker to the master in a ChunkState.
+func putDeadLetters(pachClient *client.APIClient, deadLetters []*pps.DeadLetterDatum) (*pfs.Object, error) {
+	buf := &bytes.Buffer{}
+	pbw := pbutil.NewWriter(buf)
+	for _, deadLetter := range deadLetters {
+		if _, err := pbw.Write(deadLetter); err != nil {
+			return nil, err
+		}
+	}
+	object, _, err := pachClient.PutObject(buf)
+	return object, err
+}
+
+// getDeadLetters reads the dead-lettered datums written by putDeadLetters.
+func getDeadLetters(pachClient *client.APIClient, object *pfs.Object) (_ []*pps.DeadLetterDatum, retErr error) {
+	r, err := pachClient.GetObjectReader(object.Hash)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := r.Close(); err != nil && retErr == nil {
+			retErr = err
+		}
+	}()
+	pbr := pbutil.NewReader(r)
+	var result []*pps.DeadLetterDatum
+	for {
+		deadLetter := &pps.DeadLetterDatum{}
+		if err := pbr.Read(deadLetter); err != nil {
+			if err == io.EOF {
+				return result, nil
+			}
+			return nil, err
+		}
+		result = append(result, deadLetter)
+	}
+}
+
+// writeDeadLetters adds the datums dead-lettered by 'jobInfo' to the
+// pipeline's dead letter repo, one JSON file per datum. The records of datums
+// dead-lettered by earlier jobs are kept: later jobs skip those datums, until
+// they're resubmitted and their records are cleared.
+func (a *APIServer) writeDeadLetters(pachClient *client.APIClient, logger *taggedLogger, jobInfo *pps.JobInfo, objects []*pfs.Object) (retErr error) {
+	repo := pps.DeadLetterRepo(a.pipelineInfo.Pipeline.Name)
+	var deadLetters []*pps.DeadLetterDatum
+	for _, object := range objects {
+		chunkDeadLetters, err := getDeadLetters(pachClient, object)
+		if err != nil {
+			return err
+		}
+		deadLetters = append(deadLetters, chunkDeadLetters...)
+	}
+	if len(deadLetters) == 0 {
+		return nil
+	}
+	logger.Logf("writing %d dead-lettered datums to %s", len(deadLetters), repo)
+	commit, err := pachClient.PfsAPIClient.StartCommit(pachClient.Ctx(), &pfs.StartCommitRequest{
+		Branch:      "master",
+		Parent:      client.NewCommit(repo, ""),
+		Description: "dead-lettered datums of job " + jobInfo.Job.ID,
+	})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := pachClient.FinishCommit(repo, commit.ID); err != nil && retErr == nil {
+			retErr = err
+		}
+	}()
+	marshaler := &jsonpb.Marshaler{Indent: "  "}
+	for _, deadLetter := range deadLetters {
+		deadLetterJSON, err := marshaler.MarshalToString(deadLetter)
+		if err != nil {
+			return err
+		}
+		if _, err := pachClient.PutFileOverwrite(repo, commit.ID, "/"+deadLetter.DatumInfo.Datum.ID+".json", strings.NewReader(deadLetterJSON), 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deadLetterHead returns the ID of the head commit of the pipeline's dead
+// letter repo, or "" if nothing has been dead-lettered yet.
+func (a *APIServer) deadLetterHead(pachClient *client.APIClient) (string, error) {
+	branchInfo, err := pachClient.InspectBranch(pps.DeadLetterRepo(a.pipelineInfo.Pipeline.Name), "master")
+	if err != nil {
+		if errutil.IsNotFoundError(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	if branchInfo.Head == nil {
+		return "", nil
+	}
+	return branchInfo.Head.ID, nil
+}
+
+// deadLetteredDatums returns the IDs of the datums that have a record in
+// commit 'commitID' of the pipeline's dead letter repo (see Plan).
+func (a *APIServer) deadLetteredDatums(pachClient *client.APIClient, commitID string) (map[string]bool, error) {
+	result := make(map[string]bool)
+	if commitID == "" {
+		return result, nil
+	}
+	fileInfos, err := pachClient.ListFile(pps.DeadLetterRepo(a.pipelineInfo.Pipeline.Name), commitID, "/")
+	if err != nil {
+		return nil, err
+	}
+	for _, fileInfo := range fileInfos {
+		if fileInfo.FileType == pfs.FileType_FILE && strings.HasSuffix(fileInfo.File.Path, ".json") {
+			result[strings.TrimSuffix(path.Base(fileInfo.File.Path), ".json")] = true
+		}
+	}
+	return result, nil
+}
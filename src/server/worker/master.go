@@ -19,6 +19,7 @@ import (
 	"github.com/pachyderm/pachyderm/src/client/auth"
 	"github.com/pachyderm/pachyderm/src/client/pfs"
 	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
 	"github.com/pachyderm/pachyderm/src/client/pkg/pbutil"
 	"github.com/pachyderm/pachyderm/src/client/pps"
 	pfsserver "github.com/pachyderm/pachyderm/src/server/pfs"
@@ -386,25 +387,6 @@ func (a *APIServer) serviceSpawner(pachClient *client.APIClient) error {
 	}
 }
 
-func plusDuration(x *types.Duration, y *types.Duration) (*types.Duration, error) {
-	var xd time.Duration
-	var yd time.Duration
-	var err error
-	if x != nil {
-		xd, err = types.DurationFromProto(x)
-		if err != nil {
-			return nil, err
-		}
-	}
-	if y != nil {
-		yd, err = types.DurationFromProto(y)
-		if err != nil {
-			return nil, err
-		}
-	}
-	return types.DurationProto(xd + yd), nil
-}
-
 func (a *APIServer) chunks(jobID string) col.Collection {
 	return col.NewCollection(a.etcdClient, path.Join(a.etcdPrefix, chunkPrefix, jobID), nil, &ChunkState{}, nil, nil)
 }
@@ -474,6 +456,12 @@ func (a *APIServer) failedInputs(ctx context.Context, jobInfo *pps.JobInfo) ([]s
 		if input.Git != nil && input.Git.Commit != "" {
 			blockCommit(input.Git.Name, client.NewCommit(input.Git.Name, input.Git.Commit))
 		}
+		if input.Webhook != nil && input.Webhook.Commit != "" {
+			blockCommit(input.Webhook.Name, client.NewCommit(input.Webhook.Repo, input.Webhook.Commit))
+		}
+		if input.ObjectStore != nil && input.ObjectStore.Commit != "" {
+			blockCommit(input.ObjectStore.Name, client.NewCommit(input.ObjectStore.Repo, input.ObjectStore.Commit))
+		}
 	})
 	return failedInputs, vistErr
 }
@@ -605,6 +593,13 @@ func (a *APIServer) waitJob(pachClient *client.APIClient, jobInfo *pps.JobInfo,
 			}
 			return nil
 		}
+		// Wait for the PPS master to admit the job, if the pipeline limits how
+		// many of its jobs may be running at once
+		if a.pipelineInfo.MaxConcurrentJobs > 0 {
+			if err := a.queueJob(ctx, jobInfo, logger); err != nil {
+				return err
+			}
+		}
 		// Create a datum factory pointing at the job's inputs and split up the
 		// input data into chunks
 		df, err := NewDatumIterator(pachClient, jobInfo.Input)
@@ -620,6 +615,14 @@ func (a *APIServer) waitJob(pachClient *client.APIClient, jobInfo *pps.JobInfo,
 		if err != nil {
 			return errors.Wrapf(err, "error from GetExpectedNumHashtrees")
 		}
+		// Datums that are dead-lettered as of now are skipped by this job (see
+		// Plan.DeadLetterCommit)
+		var deadLetterCommit string
+		if a.pipelineInfo.DeadLetter {
+			if deadLetterCommit, err = a.deadLetterHead(pachClient); err != nil {
+				return err
+			}
+		}
 		plan := &Plan{}
 		// Read the job document, and either resume (if we're recovering from a
 		// crash) or mark it running. Also write the input chunks calculated above
@@ -643,10 +646,15 @@ func (a *APIServer) waitJob(pachClient *client.APIClient, jobInfo *pps.JobInfo,
 				return nil
 			}
 			plan = newPlan(df, jobInfo.ChunkSpec, parallelism, numHashtrees)
+			plan.DeadLetterCommit = deadLetterCommit
 			return plansCol.Put(jobID, plan)
 		}); err != nil {
 			return err
 		}
+		deadLettered, err := a.deadLetteredDatums(pachClient, plan.DeadLetterCommit)
+		if err != nil {
+			return err
+		}
 		defer func() {
 			if retErr == nil {
 				if _, err := col.NewSTM(ctx, a.etcdClient, func(stm col.STM) error {
@@ -663,8 +671,9 @@ func (a *APIServer) waitJob(pachClient *client.APIClient, jobInfo *pps.JobInfo,
 		}()
 		// Watch the chunks in order
 		chunks := a.chunks(jobInfo.Job.ID).ReadOnly(ctx)
-		var failedDatumID string
+		var failedDatumID, failedReason string
 		recoveredDatums := make(map[string]uint64)
+		var deadLetters []*pfs.Object
 		for _, high := range plan.Chunks {
 			chunkState := &ChunkState{}
 			if err := chunks.WatchOneF(fmt.Sprint(high), func(e *watch.Event) error {
@@ -678,6 +687,7 @@ func (a *APIServer) waitJob(pachClient *client.APIClient, jobInfo *pps.JobInfo,
 				if chunkState.State != State_RUNNING {
 					if chunkState.State == State_FAILED {
 						failedDatumID = chunkState.DatumID
+						failedReason = chunkState.Reason
 					} else if chunkState.State == State_COMPLETE {
 						// if the chunk has been completed, grab the recovered datums from the chunk
 						chunkRecoveredDatums, err := a.getDatumMap(ctx, pachClient, chunkState.RecoveredDatums)
@@ -687,6 +697,9 @@ func (a *APIServer) waitJob(pachClient *client.APIClient, jobInfo *pps.JobInfo,
 						for k, count := range chunkRecoveredDatums {
 							recoveredDatums[k] += count
 						}
+						if chunkState.DeadLetters != nil {
+							deadLetters = append(deadLetters, chunkState.DeadLetters)
+						}
 					}
 					return errutil.ErrBreak
 				}
@@ -695,10 +708,15 @@ func (a *APIServer) waitJob(pachClient *client.APIClient, jobInfo *pps.JobInfo,
 				return err
 			}
 		}
+		if a.pipelineInfo.DeadLetter && failedDatumID == "" {
+			if err := a.writeDeadLetters(pachClient, logger, jobInfo, deadLetters); err != nil {
+				return err
+			}
+		}
 		oc := jobInfo.OutputCommit
 		// trees and size are only set if !a.pipelineInfo.S3out
 		var trees []*pfs.Object
-		var size uint64
+		var size, fileCount uint64
 		logger.Logf("finishing output commit %v@%v", oc.Repo.Name, oc.ID)
 		if !a.pipelineInfo.S3Out {
 			// only merge & write out stats for non-s3-out jobs. S3 jobs use
@@ -726,6 +744,7 @@ func (a *APIServer) waitJob(pachClient *client.APIClient, jobInfo *pps.JobInfo,
 						if mergeState.State != State_RUNNING {
 							trees = append(trees, mergeState.Tree)
 							size += mergeState.SizeBytes
+							fileCount += mergeState.FileCount
 							statsTrees = append(statsTrees, mergeState.StatsTree)
 							statsSize += mergeState.StatsSizeBytes
 							return errutil.ErrBreak
@@ -753,6 +772,9 @@ func (a *APIServer) waitJob(pachClient *client.APIClient, jobInfo *pps.JobInfo,
 		// killed.
 		if failedDatumID != "" {
 			reason := fmt.Sprintf("failed to process datum: %v", failedDatumID)
+			if failedReason != "" {
+				reason = fmt.Sprintf("failed to process datum %v: %v", failedDatumID, failedReason)
+			}
 			if err := a.updateJobState(ctx, jobInfo, pps.JobState_JOB_FAILURE, reason); err != nil {
 				return err
 			}
@@ -778,6 +800,11 @@ func (a *APIServer) waitJob(pachClient *client.APIClient, jobInfo *pps.JobInfo,
 					recoveredDatums[a.DatumID(files)]--
 					continue // so we won't write them to the processed datums object
 				}
+				// neither were dead-lettered datums, which are skipped until
+				// they're resubmitted
+				if deadLettered[a.DatumID(files)] {
+					continue
+				}
 				if _, err := pbw.WriteBytes([]byte(datumHash)); err != nil {
 					return err
 				}
@@ -793,9 +820,25 @@ func (a *APIServer) waitJob(pachClient *client.APIClient, jobInfo *pps.JobInfo,
 				Commit:    oc,
 				Trees:     trees,
 				SizeBytes: size,
+				FileCount: fileCount,
 				Datums:    datums,
 			})
 		}
+		if pfsserver.IsQuotaExceededErr(err) {
+			// The job's output would take the output repo over its quota, which
+			// retrying won't fix, so fail the job and finish its output commit
+			// with an empty tree (after setting the state, as above)
+			if err := a.updateJobState(ctx, jobInfo, pps.JobState_JOB_FAILURE, grpcutil.ScrubGRPC(err).Error()); err != nil {
+				return err
+			}
+			if _, err = pachClient.PfsAPIClient.FinishCommit(ctx, &pfs.FinishCommitRequest{
+				Commit: jobInfo.OutputCommit,
+				Empty:  true,
+			}); err != nil && !pfsserver.IsCommitFinishedErr(err) {
+				return err
+			}
+			return nil
+		}
 		if err != nil && !pfsserver.IsCommitFinishedErr(err) {
 			if pfsserver.IsCommitNotFoundErr(err) || pfsserver.IsCommitDeletedErr(err) {
 				// output commit was deleted during e.g. FinishCommit, which means this job
@@ -808,7 +851,7 @@ func (a *APIServer) waitJob(pachClient *client.APIClient, jobInfo *pps.JobInfo,
 		}
 		// Handle egress
 		if err := a.egress(pachClient, logger, jobInfo); err != nil {
-			reason := fmt.Sprintf("egress error: %v", err)
+			reason := ppsutil.EgressErrorPrefix + err.Error()
 			return a.updateJobState(ctx, jobInfo, pps.JobState_JOB_FAILURE, reason)
 		}
 		return a.updateJobState(ctx, jobInfo, pps.JobState_JOB_SUCCESS, "")
@@ -851,6 +894,41 @@ func (a *APIServer) updateJobState(ctx context.Context, info *pps.JobInfo, state
 	return err
 }
 
+// queueJob puts 'jobInfo' in JOB_QUEUED, if it hasn't started running yet, and
+// blocks until the PPS master admits it by moving it to JOB_RUNNING (or until
+// the job is finished some other way, e.g. by being killed).
+func (a *APIServer) queueJob(ctx context.Context, jobInfo *pps.JobInfo, logger *taggedLogger) error {
+	jobID := jobInfo.Job.ID
+	if _, err := col.NewSTM(ctx, a.etcdClient, func(stm col.STM) error {
+		jobPtr := &pps.EtcdJobInfo{}
+		if err := a.jobs.ReadWrite(stm).Get(jobID, jobPtr); err != nil {
+			return err
+		}
+		if jobPtr.State != pps.JobState_JOB_STARTING {
+			return nil // already queued, or admitted before this master restarted
+		}
+		return ppsutil.UpdateJobState(a.pipelines.ReadWrite(stm), a.jobs.ReadWrite(stm), jobPtr, pps.JobState_JOB_QUEUED,
+			fmt.Sprintf("waiting for one of the pipeline's running jobs to finish (max_concurrent_jobs: %d)", a.pipelineInfo.MaxConcurrentJobs))
+	}); err != nil {
+		return err
+	}
+	logger.Logf("waiting for job %q to be admitted", jobID)
+	return a.jobs.ReadOnly(ctx).WatchOneF(jobID, func(e *watch.Event) error {
+		if e.Type == watch.EventDelete {
+			return errutil.ErrBreak // the job's output commit was deleted
+		}
+		var key string
+		jobPtr := &pps.EtcdJobInfo{}
+		if err := e.Unmarshal(&key, jobPtr); err != nil {
+			return err
+		}
+		if jobPtr.State != pps.JobState_JOB_QUEUED {
+			return errutil.ErrBreak
+		}
+		return nil
+	})
+}
+
 // deleteJob is identical to updateJobState, except that jobPtr points to a job
 // that should be deleted rather than marked failed. Jobs may be deleted if
 // their output commit is deleted.
@@ -888,7 +966,11 @@ func (a *APIServer) egress(pachClient *client.APIClient, logger *taggedLogger, j
 			if err != nil {
 				return err
 			}
-			if err := filesync.PushObj(pachClient, jobInfo.OutputCommit, objClient, url.Object); err != nil {
+			if jobInfo.Egress.Incremental {
+				if err := a.egressIncremental(pachClient, logger, jobInfo, objClient, url.Object); err != nil {
+					return err
+				}
+			} else if err := filesync.PushObj(pachClient, jobInfo.OutputCommit, objClient, url.Object); err != nil {
 				return err
 			}
 			logger.Logf("Completed egress upload for job (%v), duration (%v)", jobInfo, time.Since(start))
@@ -904,6 +986,51 @@ func (a *APIServer) egress(pachClient *client.APIClient, logger *taggedLogger, j
 	})
 }
 
+// egressIncremental uploads the diff between jobInfo's output commit and the
+// last commit egressed to the same URL, and then records the output commit as
+// the last one egressed. If nothing was egressed to the URL yet (or the last
+// egressed commit was deleted), the whole output commit is uploaded.
+func (a *APIServer) egressIncremental(pachClient *client.APIClient, logger *taggedLogger, jobInfo *pps.JobInfo, objClient obj.Client, root string) error {
+	// Concurrent jobs would otherwise diff against the same commit, and could
+	// record their output commits out of order
+	a.egressMu.Lock()
+	defer a.egressMu.Unlock()
+	pipelineName := jobInfo.Pipeline.Name
+	pipelinePtr := &pps.EtcdPipelineInfo{}
+	if err := a.pipelines.ReadOnly(pachClient.Ctx()).Get(pipelineName, pipelinePtr); err != nil {
+		return err
+	}
+	lastCommit := pipelinePtr.LastEgressCommit
+	if lastCommit != nil && pipelinePtr.LastEgressURL == jobInfo.Egress.URL {
+		if _, err := pachClient.InspectCommit(lastCommit.Repo.Name, lastCommit.ID); err != nil {
+			if !pfsserver.IsCommitNotFoundErr(err) && !pfsserver.IsCommitDeletedErr(err) {
+				return err
+			}
+			logger.Logf("last egressed commit (%v) was deleted, egressing the whole output commit", lastCommit.ID)
+			lastCommit = nil
+		}
+	} else {
+		lastCommit = nil
+	}
+	if lastCommit != nil {
+		logger.Logf("egressing the diff against the last egressed commit (%v)", lastCommit.ID)
+		if err := filesync.PushObjDiff(pachClient, jobInfo.OutputCommit, lastCommit, objClient, root); err != nil {
+			return err
+		}
+	} else if err := filesync.PushObj(pachClient, jobInfo.OutputCommit, objClient, root); err != nil {
+		return err
+	}
+	_, err := col.NewSTM(pachClient.Ctx(), a.etcdClient, func(stm col.STM) error {
+		pipelinePtr := &pps.EtcdPipelineInfo{}
+		return a.pipelines.ReadWrite(stm).Update(pipelineName, pipelinePtr, func() error {
+			pipelinePtr.LastEgressCommit = jobInfo.OutputCommit
+			pipelinePtr.LastEgressURL = jobInfo.Egress.URL
+			return nil
+		})
+	})
+	return err
+}
+
 func (a *APIServer) receiveSpout(ctx context.Context, logger *taggedLogger) error {
 	return backoff.RetryNotify(func() error {
 		repo := a.pipelineInfo.Pipeline.Name
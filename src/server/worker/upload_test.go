@@ -0,0 +1,107 @@
+package worker
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+	pfsserver "github.com/pachyderm/pachyderm/src/server/pfs"
+	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
+	"github.com/pachyderm/pachyderm/src/server/pkg/testpachd"
+)
+
+func TestUploadOutputCompression(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestUploadOutputCompression")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"a": strings.Repeat("compressible\n", 1000),
+		"b": strings.Repeat("also compressible\n", 1000),
+	}
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "out"), 0777))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "cache"), 0777))
+	for name, content := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "out", name), []byte(content), 0644))
+	}
+
+	require.NoError(t, testpachd.WithRealEnv(func(env *testpachd.RealEnv) error {
+		c := env.PachClient
+		a := &APIServer{
+			pipelineInfo: &pps.PipelineInfo{},
+			datumCache:   hashtree.NewMergeCache(filepath.Join(dir, "cache")),
+		}
+		logger := &taggedLogger{marshaler: &jsonpb.Marshaler{}}
+		require.NoError(t, a.uploadOutput(c, dir, "datum", "", logger, nil, &pps.ProcessStats{}, nil, 0, nil, pfs.Compression_ZSTD))
+
+		// The datum's hashtree is compressed
+		objectInfo, err := c.InspectTag(c.Ctx(), client.NewTag("datum"))
+		require.NoError(t, err)
+		require.Equal(t, pfs.Compression_ZSTD, objectInfo.BlockRef.Compression)
+
+		// Each file is compressed separately, and can be read back on its own
+		for name, content := range files {
+			r, err := c.GetTagReader("datum")
+			require.NoError(t, err)
+			node, err := hashtree.Get([]io.ReadCloser{r}, name)
+			require.NoError(t, err)
+			require.Equal(t, 1, len(node.FileNode.BlockRefs))
+			blockRef := node.FileNode.BlockRefs[0]
+			require.Equal(t, pfs.Compression_ZSTD, blockRef.Compression)
+			require.Equal(t, uint64(len(content)), blockRef.SizeBytes)
+			require.True(t, blockRef.Range.Upper-blockRef.Range.Lower < blockRef.SizeBytes)
+
+			getBlocksClient, err := c.ObjectAPIClient.GetBlocks(c.Ctx(), &pfs.GetBlocksRequest{
+				BlockRefs: []*pfs.BlockRef{blockRef},
+			})
+			require.NoError(t, err)
+			var buf bytes.Buffer
+			require.NoError(t, grpcutil.WriteFromStreamingBytesClient(getBlocksClient, &buf))
+			require.Equal(t, content, buf.String())
+		}
+		return nil
+	}))
+}
+
+func TestChargeCachedOutput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestChargeCachedOutput")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "out"), 0777))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "cache"), 0777))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "out", "a"), []byte("foo"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "out", "b"), []byte("barbaz"), 0644))
+
+	require.NoError(t, testpachd.WithRealEnv(func(env *testpachd.RealEnv) error {
+		c := env.PachClient
+		a := &APIServer{
+			pipelineInfo: &pps.PipelineInfo{},
+			datumCache:   hashtree.NewMergeCache(filepath.Join(dir, "cache")),
+		}
+		logger := &taggedLogger{marshaler: &jsonpb.Marshaler{}}
+		require.NoError(t, a.uploadOutput(c, dir, "datum", "cached", logger, nil, &pps.ProcessStats{}, nil, 0, nil, pfs.Compression_UNCOMPRESSED))
+
+		// Without a quota, nothing is charged
+		require.NoError(t, a.chargeCachedOutput(c, "cached", nil))
+
+		// The cached output's files and bytes count against the quota
+		quota := &outputQuota{repo: client.NewRepo("out"), quota: &pfs.Quota{SizeBytes: 12, FileCount: 3}}
+		require.NoError(t, a.chargeCachedOutput(c, "cached", quota))
+		require.Equal(t, uint64(9), quota.sizeBytes)
+		require.Equal(t, uint64(2), quota.fileCount)
+		err := a.chargeCachedOutput(c, "cached", quota)
+		require.YesError(t, err)
+		require.True(t, pfsserver.IsQuotaExceededErr(err))
+		require.Equal(t, uint64(9), quota.sizeBytes)
+		return nil
+	}))
+}
@@ -0,0 +1,165 @@
+package worker
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/errors"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+)
+
+// memorySampleInterval is how often the memory used by user code is sampled
+const memorySampleInterval = time.Second
+
+// memorySampler periodically samples the resident memory of a process and
+// its descendants, while user code runs
+type memorySampler struct {
+	pid     int
+	stopC   chan struct{}
+	doneC   chan struct{}
+	samples uint64
+	total   uint64
+	peak    uint64
+}
+
+func startMemorySampler(pid int) *memorySampler {
+	s := &memorySampler{
+		pid:   pid,
+		stopC: make(chan struct{}),
+		doneC: make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+func (s *memorySampler) run() {
+	defer close(s.doneC)
+	ticker := time.NewTicker(memorySampleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			rss, err := processTreeRSS(s.pid)
+			if err != nil || rss == 0 {
+				continue // the process has exited, or there's no /proc
+			}
+			s.samples++
+			s.total += rss
+			if rss > s.peak {
+				s.peak = rss
+			}
+		case <-s.stopC:
+			return
+		}
+	}
+}
+
+// stop stops sampling, and returns the peak and average of the samples (or 0
+// if none were taken).
+func (s *memorySampler) stop() (peak uint64, average uint64) {
+	close(s.stopC)
+	<-s.doneC
+	if s.samples == 0 {
+		return 0, 0
+	}
+	return s.peak, s.total / s.samples
+}
+
+// processTreeRSS returns the resident memory (in bytes) of the process 'pid'
+// and all of its descendants.
+func processTreeRSS(pid int) (uint64, error) {
+	proc, err := os.Open("/proc")
+	if err != nil {
+		return 0, err
+	}
+	names, err := proc.Readdirnames(-1)
+	proc.Close()
+	if err != nil {
+		return 0, err
+	}
+	children := make(map[int][]int)
+	rss := make(map[int]uint64)
+	for _, name := range names {
+		p, err := strconv.Atoi(name)
+		if err != nil {
+			continue // not a process
+		}
+		stat, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/stat", p))
+		if err != nil {
+			continue // the process has exited
+		}
+		ppid, pages, err := parseProcStat(stat)
+		if err != nil {
+			return 0, err
+		}
+		children[ppid] = append(children[ppid], p)
+		rss[p] = pages * uint64(os.Getpagesize())
+	}
+	if _, ok := rss[pid]; !ok {
+		return 0, errors.Errorf("process %d not found", pid)
+	}
+	var total uint64
+	for queue := []int{pid}; len(queue) > 0; queue = queue[1:] {
+		total += rss[queue[0]]
+		queue = append(queue, children[queue[0]]...)
+	}
+	return total, nil
+}
+
+// parseProcStat returns the parent PID and the resident set size (in pages)
+// in the contents of a /proc/<pid>/stat file.
+func parseProcStat(stat []byte) (ppid int, rssPages uint64, retErr error) {
+	// The process's name (the 2nd field) may contain spaces and parentheses,
+	// so fields are counted from the parenthesis that ends it
+	end := bytes.LastIndexByte(stat, ')')
+	if end < 0 {
+		return 0, 0, errors.Errorf("malformed /proc stat %q", stat)
+	}
+	// These are fields 4 (ppid) and 24 (rss) in proc(5)
+	fields := strings.Fields(string(stat[end+1:]))
+	if len(fields) < 22 {
+		return 0, 0, errors.Errorf("malformed /proc stat %q", stat)
+	}
+	ppid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, 0, errors.Wrapf(err, "malformed /proc stat %q", stat)
+	}
+	rssPages, err = strconv.ParseUint(fields[21], 10, 64)
+	if err != nil {
+		return 0, 0, errors.Wrapf(err, "malformed /proc stat %q", stat)
+	}
+	return ppid, rssPages, nil
+}
+
+// reportResourceUsage records the resources used by user code that exited
+// with 'state', and whose memory was sampled by 'sampler', in 'stats'.
+func reportResourceUsage(state *os.ProcessState, sampler *memorySampler, stats *pps.ProcessStats) {
+	stats.PeakMemoryBytes, stats.AverageMemoryBytes = sampler.stop()
+	rusageStats(state, stats)
+	if stats.AverageMemoryBytes == 0 {
+		// The user code finished before it could be sampled, so its peak is the
+		// best estimate
+		stats.AverageMemoryBytes = stats.PeakMemoryBytes
+	}
+}
+
+// gpuTime returns the GPU time used by a worker of the pipeline 'pipelineInfo'
+// that processed a datum for 'processTime', which is counted for every GPU
+// allocated to the worker whether or not the user code used it.
+func gpuTime(pipelineInfo *pps.PipelineInfo, processTime time.Duration) *types.Duration {
+	gpus := pipelineInfo.ResourceLimits.GetGpu().GetNumber()
+	if gpus == 0 {
+		gpus = pipelineInfo.ResourceRequests.GetGpu().GetNumber()
+	}
+	if gpus == 0 {
+		return nil
+	}
+	return types.DurationProto(processTime * time.Duration(gpus))
+}
@@ -0,0 +1,42 @@
+package worker
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+)
+
+func TestParseProcStat(t *testing.T) {
+	// The process name may contain spaces and parentheses
+	ppid, rss, err := parseProcStat([]byte("1234 (my (odd) cmd) S 42 1234 1234 0 -1 4194560 1024 0 0 0 10 5 0 0 20 0 1 0 12345 123456789 2048 18446744073709551615 1 1 0 0 0 0 0 0 0 0 0 0 17 0 0 0 0 0 0\n"))
+	require.NoError(t, err)
+	require.Equal(t, 42, ppid)
+	require.Equal(t, uint64(2048), rss)
+
+	_, _, err = parseProcStat([]byte("1234 (cmd) S 42"))
+	require.YesError(t, err)
+}
+
+func TestReportResourceUsage(t *testing.T) {
+	if _, err := os.Stat("/proc/self/stat"); err != nil {
+		t.Skip("no /proc")
+	}
+	rss, err := processTreeRSS(os.Getpid())
+	require.NoError(t, err)
+	require.True(t, rss > 0)
+
+	cmd := exec.Command("sh", "-c", "head -c 10000000 /dev/zero | tail -c 1 >/dev/null")
+	require.NoError(t, cmd.Start())
+	sampler := startMemorySampler(cmd.Process.Pid)
+	state, err := cmd.Process.Wait()
+	require.NoError(t, err)
+	stats := &pps.ProcessStats{}
+	reportResourceUsage(state, sampler, stats)
+	require.NotNil(t, stats.CpuTime)
+	require.True(t, stats.PeakMemoryBytes > 0)
+	// The command is too quick to be sampled, so its peak is the estimate
+	require.Equal(t, stats.PeakMemoryBytes, stats.AverageMemoryBytes)
+}
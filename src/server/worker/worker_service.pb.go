@@ -63,6 +63,7 @@ type Input struct {
 	ParentCommit         *pfs.Commit   `protobuf:"bytes,5,opt,name=parent_commit,json=parentCommit,proto3" json:"parent_commit,omitempty"`
 	Name                 string        `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
 	JoinOn               string        `protobuf:"bytes,8,opt,name=join_on,json=joinOn,proto3" json:"join_on,omitempty"`
+	GroupBy              string        `protobuf:"bytes,10,opt,name=group_by,json=groupBy,proto3" json:"group_by,omitempty"`
 	Lazy                 bool          `protobuf:"varint,3,opt,name=lazy,proto3" json:"lazy,omitempty"`
 	Branch               string        `protobuf:"bytes,4,opt,name=branch,proto3" json:"branch,omitempty"`
 	GitURL               string        `protobuf:"bytes,6,opt,name=git_url,json=gitUrl,proto3" json:"git_url,omitempty"`
@@ -134,6 +135,13 @@ func (m *Input) GetJoinOn() string {
 	return ""
 }
 
+func (m *Input) GetGroupBy() string {
+	if m != nil {
+		return m.GroupBy
+	}
+	return ""
+}
+
 func (m *Input) GetLazy() bool {
 	if m != nil {
 		return m.Lazy
@@ -338,8 +346,14 @@ type ChunkState struct {
 	State   State  `protobuf:"varint,1,opt,name=state,proto3,enum=worker.State" json:"state,omitempty"`
 	DatumID string `protobuf:"bytes,2,opt,name=datum_id,json=datumId,proto3" json:"datum_id,omitempty"`
 	// The IP address of the worker who processed this chunk
-	Address              string      `protobuf:"bytes,3,opt,name=address,proto3" json:"address,omitempty"`
-	RecoveredDatums      *pfs.Object `protobuf:"bytes,4,opt,name=recovered_datums,json=recoveredDatums,proto3" json:"recovered_datums,omitempty"`
+	Address         string      `protobuf:"bytes,3,opt,name=address,proto3" json:"address,omitempty"`
+	RecoveredDatums *pfs.Object `protobuf:"bytes,4,opt,name=recovered_datums,json=recoveredDatums,proto3" json:"recovered_datums,omitempty"`
+	// reason is why the chunk failed, if it failed for a reason other than an
+	// error in the user's code (e.g. the output repo's quota was exceeded).
+	Reason string `protobuf:"bytes,5,opt,name=reason,proto3" json:"reason,omitempty"`
+	// dead_letters holds the pps.DeadLetterDatums of the datums in this chunk
+	// that failed, if the pipeline has 'dead_letter' set.
+	DeadLetters          *pfs.Object `protobuf:"bytes,6,opt,name=dead_letters,json=deadLetters,proto3" json:"dead_letters,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
 	XXX_unrecognized     []byte      `json:"-"`
 	XXX_sizecache        int32       `json:"-"`
@@ -406,15 +420,32 @@ func (m *ChunkState) GetRecoveredDatums() *pfs.Object {
 	return nil
 }
 
+func (m *ChunkState) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+func (m *ChunkState) GetDeadLetters() *pfs.Object {
+	if m != nil {
+		return m.DeadLetters
+	}
+	return nil
+}
+
 type MergeState struct {
-	State                State       `protobuf:"varint,1,opt,name=state,proto3,enum=worker.State" json:"state,omitempty"`
-	Tree                 *pfs.Object `protobuf:"bytes,2,opt,name=tree,proto3" json:"tree,omitempty"`
-	SizeBytes            uint64      `protobuf:"varint,3,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"`
-	StatsTree            *pfs.Object `protobuf:"bytes,4,opt,name=stats_tree,json=statsTree,proto3" json:"stats_tree,omitempty"`
-	StatsSizeBytes       uint64      `protobuf:"varint,5,opt,name=stats_size_bytes,json=statsSizeBytes,proto3" json:"stats_size_bytes,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
-	XXX_unrecognized     []byte      `json:"-"`
-	XXX_sizecache        int32       `json:"-"`
+	State          State       `protobuf:"varint,1,opt,name=state,proto3,enum=worker.State" json:"state,omitempty"`
+	Tree           *pfs.Object `protobuf:"bytes,2,opt,name=tree,proto3" json:"tree,omitempty"`
+	SizeBytes      uint64      `protobuf:"varint,3,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"`
+	StatsTree      *pfs.Object `protobuf:"bytes,4,opt,name=stats_tree,json=statsTree,proto3" json:"stats_tree,omitempty"`
+	StatsSizeBytes uint64      `protobuf:"varint,5,opt,name=stats_size_bytes,json=statsSizeBytes,proto3" json:"stats_size_bytes,omitempty"`
+	// file_count is the number of files in 'tree'. It's only set if the output
+	// repo has a file-count quota.
+	FileCount            uint64   `protobuf:"varint,6,opt,name=file_count,json=fileCount,proto3" json:"file_count,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *MergeState) Reset()         { *m = MergeState{} }
@@ -485,6 +516,13 @@ func (m *MergeState) GetStatsSizeBytes() uint64 {
 	return 0
 }
 
+func (m *MergeState) GetFileCount() uint64 {
+	if m != nil {
+		return m.FileCount
+	}
+	return 0
+}
+
 type ShardInfo struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
@@ -525,8 +563,13 @@ func (m *ShardInfo) XXX_DiscardUnknown() {
 var xxx_messageInfo_ShardInfo proto.InternalMessageInfo
 
 type Plan struct {
-	Chunks               []int64  `protobuf:"varint,1,rep,packed,name=chunks,proto3" json:"chunks,omitempty"`
-	Merges               int64    `protobuf:"varint,2,opt,name=merges,proto3" json:"merges,omitempty"`
+	Chunks []int64 `protobuf:"varint,1,rep,packed,name=chunks,proto3" json:"chunks,omitempty"`
+	Merges int64   `protobuf:"varint,2,opt,name=merges,proto3" json:"merges,omitempty"`
+	// dead_letter_commit is the commit of the pipeline's dead letter repo that
+	// the job's datums are checked against. Datums with a record in it were
+	// dead-lettered by an earlier job and haven't been resubmitted, so the job
+	// skips them.
+	DeadLetterCommit     string   `protobuf:"bytes,3,opt,name=dead_letter_commit,json=deadLetterCommit,proto3" json:"dead_letter_commit,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -579,6 +622,13 @@ func (m *Plan) GetMerges() int64 {
 	return 0
 }
 
+func (m *Plan) GetDeadLetterCommit() string {
+	if m != nil {
+		return m.DeadLetterCommit
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterEnum("worker.State", State_name, State_value)
 	proto.RegisterType((*Input)(nil), "worker.Input")
@@ -596,58 +646,63 @@ func init() {
 }
 
 var fileDescriptor_23ff4b5163b7daa7 = []byte{
-	// 813 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x54, 0xcd, 0x6e, 0xdb, 0x46,
-	0x10, 0x36, 0xf5, 0x43, 0x49, 0x23, 0x5b, 0x51, 0x17, 0xa9, 0x43, 0x38, 0xa8, 0xa5, 0x32, 0x40,
-	0x21, 0xf8, 0x40, 0x19, 0x36, 0x1a, 0xa0, 0x97, 0x02, 0xb5, 0x65, 0x1b, 0x2a, 0xfc, 0x13, 0xac,
-	0xed, 0x16, 0xe8, 0x85, 0x58, 0x92, 0x2b, 0x89, 0x0e, 0xc5, 0x65, 0x77, 0x97, 0x09, 0x9c, 0xe7,
-	0xe8, 0x5b, 0xf4, 0x25, 0x7a, 0x6b, 0x8f, 0x7d, 0x02, 0xa3, 0xd0, 0x93, 0x14, 0x3b, 0x2b, 0xe6,
-	0xc7, 0xed, 0x25, 0x07, 0x42, 0x33, 0xdf, 0x7c, 0xfc, 0x34, 0x33, 0xfb, 0x71, 0xc1, 0x57, 0x5c,
-	0xbe, 0xe1, 0x72, 0xfc, 0x56, 0xc8, 0xd7, 0xef, 0x7f, 0x42, 0x03, 0xa6, 0x31, 0x0f, 0x0a, 0x29,
-	0xb4, 0x20, 0xae, 0x45, 0x77, 0x9e, 0xc6, 0x59, 0xca, 0x73, 0x3d, 0x2e, 0x66, 0xca, 0x3c, 0xb6,
-	0xfa, 0x01, 0x2d, 0x94, 0x79, 0x2a, 0x74, 0x2e, 0xe6, 0x02, 0xc3, 0xb1, 0x89, 0xd6, 0xe8, 0xf3,
-	0xb9, 0x10, 0xf3, 0x8c, 0x8f, 0x31, 0x8b, 0xca, 0xd9, 0x98, 0x2f, 0x0b, 0x7d, 0xbf, 0x2e, 0xee,
-	0x3e, 0x2e, 0xbe, 0x95, 0xac, 0x28, 0xb8, 0x5c, 0x4b, 0xfa, 0xbf, 0xd5, 0xa0, 0x39, 0xcd, 0x8b,
-	0x52, 0x93, 0x3d, 0xe8, 0xcc, 0xd2, 0x8c, 0x87, 0x69, 0x3e, 0x13, 0x9e, 0x33, 0x74, 0x46, 0xdd,
-	0x83, 0xad, 0xc0, 0x74, 0x74, 0x9a, 0x66, 0x7c, 0x9a, 0xcf, 0x04, 0x6d, 0xcf, 0xd6, 0x11, 0xd9,
-	0x87, 0xad, 0x82, 0x49, 0x9e, 0xeb, 0x30, 0x16, 0xcb, 0x65, 0xaa, 0xbd, 0x26, 0xf2, 0xbb, 0xc8,
-	0x3f, 0x46, 0x88, 0x6e, 0x5a, 0x86, 0xcd, 0x08, 0x81, 0x46, 0xce, 0x96, 0xdc, 0xab, 0x0d, 0x9d,
-	0x51, 0x87, 0x62, 0x4c, 0x9e, 0x41, 0xeb, 0x4e, 0xa4, 0x79, 0x28, 0x72, 0xaf, 0x8d, 0xb0, 0x6b,
-	0xd2, 0xab, 0xdc, 0x90, 0x33, 0xf6, 0xee, 0xde, 0xab, 0x0f, 0x9d, 0x51, 0x9b, 0x62, 0x4c, 0xb6,
-	0xc1, 0x8d, 0x24, 0xcb, 0xe3, 0x85, 0xd7, 0xb0, 0x5c, 0x9b, 0x91, 0x17, 0xd0, 0x9a, 0xa7, 0x3a,
-	0x2c, 0x65, 0xe6, 0xb9, 0xa6, 0x70, 0x04, 0xab, 0x87, 0x81, 0x7b, 0x96, 0xea, 0x5b, 0x7a, 0x4e,
-	0xdd, 0x79, 0xaa, 0x6f, 0x65, 0x46, 0x06, 0xd0, 0xc5, 0xa5, 0x84, 0x66, 0x02, 0xe5, 0xb5, 0x50,
-	0x17, 0x10, 0x32, 0xd3, 0x29, 0xd2, 0x83, 0x9a, 0x3a, 0xf4, 0x3a, 0x88, 0xd7, 0xd4, 0xa1, 0x7f,
-	0x03, 0x5b, 0xc7, 0x2c, 0x8f, 0x79, 0x46, 0xf9, 0xaf, 0x25, 0x57, 0x9a, 0x0c, 0xc1, 0xbd, 0x13,
-	0x51, 0x98, 0x26, 0x76, 0x82, 0xa3, 0xce, 0xea, 0x61, 0xd0, 0xfc, 0x51, 0x44, 0xd3, 0x09, 0x6d,
-	0xde, 0x89, 0x68, 0x9a, 0x90, 0xaf, 0x61, 0x33, 0x61, 0x9a, 0x99, 0xbf, 0xd0, 0x5c, 0x2a, 0xcf,
-	0x19, 0xd6, 0x47, 0x1d, 0xda, 0x35, 0xd8, 0xa9, 0x85, 0xfc, 0x3d, 0xe8, 0x55, 0xaa, 0xaa, 0x10,
-	0xb9, 0xe2, 0xc4, 0x83, 0x96, 0x2a, 0xe3, 0x98, 0x2b, 0x85, 0x2b, 0x6f, 0xd3, 0x2a, 0xf5, 0x2f,
-	0xe0, 0xc9, 0x19, 0xd7, 0xc7, 0x8b, 0x32, 0x7f, 0x5d, 0xf5, 0xd0, 0x83, 0x5a, 0x9a, 0x20, 0xaf,
-	0x4e, 0x6b, 0x69, 0x42, 0x9e, 0x42, 0x53, 0x2d, 0x98, 0xb4, 0x2d, 0xd5, 0xa9, 0x4d, 0x10, 0xd5,
-	0x4c, 0xab, 0xf5, 0xf6, 0x6c, 0xe2, 0xff, 0xee, 0x00, 0xa0, 0xd8, 0xb5, 0x66, 0x9a, 0x93, 0x17,
-	0x96, 0xc4, 0x51, 0xad, 0x77, 0xb0, 0x15, 0x58, 0x37, 0x06, 0x58, 0xb5, 0xef, 0x70, 0xf2, 0x0d,
-	0xb4, 0x13, 0xa6, 0xcb, 0xe5, 0x87, 0xa9, 0xbb, 0xab, 0x87, 0x41, 0x6b, 0x62, 0xb0, 0xe9, 0x84,
-	0xb6, 0xb0, 0x38, 0x4d, 0xcc, 0x10, 0x2c, 0x49, 0xa4, 0x19, 0xa2, 0x8e, 0x67, 0x53, 0xa5, 0xe4,
-	0x25, 0xf4, 0x25, 0x8f, 0xc5, 0x1b, 0x2e, 0x79, 0x12, 0x22, 0x5d, 0xe1, 0xf1, 0x55, 0x56, 0xb9,
-	0x8a, 0xee, 0x78, 0xac, 0xe9, 0x93, 0xf7, 0x24, 0xd4, 0x56, 0xfe, 0x9f, 0x0e, 0xc0, 0x05, 0x97,
-	0x73, 0xfe, 0x19, 0xdd, 0x0e, 0xa0, 0xa1, 0x25, 0xb7, 0x0e, 0x7b, 0xa4, 0x8f, 0x05, 0xf2, 0x15,
-	0x80, 0x4a, 0xdf, 0xf1, 0x30, 0xba, 0xd7, 0xdc, 0x76, 0xda, 0xa0, 0x1d, 0x83, 0x1c, 0x19, 0x80,
-	0xec, 0x01, 0xe0, 0xaa, 0x42, 0x54, 0xf9, 0x9f, 0x2e, 0x3b, 0x58, 0xbe, 0x31, 0x52, 0x23, 0xe8,
-	0x5b, 0xee, 0x47, 0x82, 0x4d, 0x14, 0xec, 0x21, 0x7e, 0x5d, 0xa9, 0xfa, 0x5d, 0xe8, 0x5c, 0x9b,
-	0x63, 0x31, 0x9f, 0x8d, 0xff, 0x12, 0x1a, 0xaf, 0x32, 0x96, 0x1b, 0x2f, 0xc7, 0xe6, 0x2c, 0xac,
-	0x49, 0xea, 0x74, 0x9d, 0x19, 0x7c, 0x69, 0xa6, 0x56, 0xeb, 0x13, 0x5d, 0x67, 0x7b, 0x01, 0x34,
-	0xed, 0x22, 0xba, 0xd0, 0xa2, 0xb7, 0x97, 0x97, 0xd3, 0xcb, 0xb3, 0xfe, 0x06, 0xd9, 0x84, 0xf6,
-	0xf1, 0xd5, 0xc5, 0xab, 0xf3, 0x93, 0x9b, 0x93, 0xbe, 0x43, 0x00, 0xdc, 0xd3, 0x1f, 0xa6, 0xe7,
-	0x27, 0x93, 0x7e, 0xfd, 0xe0, 0x0f, 0x07, 0xdc, 0x9f, 0x71, 0x45, 0xe4, 0x5b, 0x70, 0xcd, 0xab,
-	0xa5, 0x22, 0xdb, 0x81, 0xbd, 0x0a, 0x82, 0xea, 0x2a, 0x08, 0x4e, 0x8c, 0xff, 0x77, 0xbe, 0x08,
-	0xcc, 0x05, 0x63, 0xe9, 0x96, 0xea, 0x6f, 0x90, 0xef, 0xc0, 0xb5, 0x4e, 0x25, 0x5f, 0x56, 0xcb,
-	0xfe, 0xe4, 0x7b, 0xd8, 0xd9, 0x7e, 0x0c, 0x5b, 0x43, 0xfb, 0x1b, 0x64, 0x02, 0xed, 0xca, 0xb8,
-	0xe4, 0x59, 0xc5, 0x7a, 0x64, 0xe5, 0x9d, 0xe7, 0xff, 0x69, 0x06, 0xd7, 0xf5, 0x13, 0xcb, 0x4a,
-	0xee, 0x6f, 0xec, 0x3b, 0x47, 0xdf, 0xff, 0xb5, 0xda, 0x75, 0xfe, 0x5e, 0xed, 0x3a, 0xff, 0xac,
-	0x76, 0x9d, 0x5f, 0xf6, 0xe7, 0xa9, 0x5e, 0x94, 0x51, 0x10, 0x8b, 0xe5, 0xb8, 0x60, 0xf1, 0xe2,
-	0x3e, 0xe1, 0xf2, 0xe3, 0x48, 0xc9, 0x78, 0xfc, 0xc9, 0x9d, 0x1b, 0xb9, 0x28, 0x7c, 0xf8, 0x6f,
-	0x00, 0x00, 0x00, 0xff, 0xff, 0x5c, 0x6b, 0x2b, 0x01, 0x8b, 0x05, 0x00, 0x00,
+	// 888 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x55, 0xcd, 0x6e, 0xdb, 0x46,
+	0x10, 0x36, 0xf5, 0x43, 0x89, 0x23, 0xdb, 0x51, 0x17, 0xa9, 0xc3, 0x3a, 0x88, 0xed, 0x32, 0x40,
+	0x21, 0x18, 0x05, 0x65, 0xd8, 0x68, 0x81, 0x5e, 0x0a, 0x54, 0x92, 0x6d, 0xa8, 0xf0, 0x4f, 0xb0,
+	0xb6, 0x5b, 0xa0, 0x17, 0x82, 0x3f, 0x2b, 0x89, 0x0e, 0xc5, 0x65, 0x77, 0x97, 0x09, 0x94, 0x97,
+	0xea, 0x2b, 0xf4, 0xd8, 0x63, 0x9f, 0xc0, 0x28, 0x74, 0xe8, 0x0b, 0xf4, 0x05, 0x8a, 0x9d, 0x15,
+	0x6d, 0xc7, 0xe9, 0xa5, 0x07, 0x42, 0xfb, 0x7d, 0xf3, 0xf1, 0xe3, 0xce, 0xec, 0xcc, 0x0a, 0x3c,
+	0xc9, 0xc4, 0x3b, 0x26, 0xfa, 0xef, 0xb9, 0x78, 0x7b, 0xff, 0x13, 0x68, 0x32, 0x8d, 0x99, 0x5f,
+	0x08, 0xae, 0x38, 0xb1, 0x0d, 0xbb, 0xfd, 0x3c, 0xce, 0x52, 0x96, 0xab, 0x7e, 0x31, 0x91, 0xfa,
+	0x31, 0xd1, 0x07, 0xb6, 0x90, 0xfa, 0xa9, 0xd8, 0x29, 0x9f, 0x72, 0x5c, 0xf6, 0xf5, 0x6a, 0xc5,
+	0xbe, 0x9c, 0x72, 0x3e, 0xcd, 0x58, 0x1f, 0x51, 0x54, 0x4e, 0xfa, 0x6c, 0x5e, 0xa8, 0xc5, 0x2a,
+	0xb8, 0xf3, 0x34, 0xf8, 0x5e, 0x84, 0x45, 0xc1, 0xc4, 0xca, 0xd2, 0xfb, 0xad, 0x06, 0xcd, 0x71,
+	0x5e, 0x94, 0x8a, 0xec, 0x83, 0x33, 0x49, 0x33, 0x16, 0xa4, 0xf9, 0x84, 0xbb, 0xd6, 0x9e, 0xd5,
+	0xeb, 0x1c, 0x6e, 0xf8, 0x7a, 0x47, 0x27, 0x69, 0xc6, 0xc6, 0xf9, 0x84, 0xd3, 0xf6, 0x64, 0xb5,
+	0x22, 0x07, 0xb0, 0x51, 0x84, 0x82, 0xe5, 0x2a, 0x88, 0xf9, 0x7c, 0x9e, 0x2a, 0xb7, 0x89, 0xfa,
+	0x0e, 0xea, 0x87, 0x48, 0xd1, 0x75, 0xa3, 0x30, 0x88, 0x10, 0x68, 0xe4, 0xe1, 0x9c, 0xb9, 0xb5,
+	0x3d, 0xab, 0xe7, 0x50, 0x5c, 0x93, 0x17, 0xd0, 0xba, 0xe5, 0x69, 0x1e, 0xf0, 0xdc, 0x6d, 0x23,
+	0x6d, 0x6b, 0x78, 0x99, 0x93, 0x2f, 0xa0, 0x3d, 0x15, 0xbc, 0x2c, 0x82, 0x68, 0xe1, 0x02, 0x46,
+	0x5a, 0x88, 0x07, 0x0b, 0xed, 0x93, 0x85, 0x1f, 0x16, 0x6e, 0x7d, 0xcf, 0xea, 0xb5, 0x29, 0xae,
+	0xc9, 0x16, 0xd8, 0x91, 0x08, 0xf3, 0x78, 0xe6, 0x36, 0x8c, 0x8d, 0x41, 0xe4, 0x35, 0xb4, 0xa6,
+	0xa9, 0x0a, 0x4a, 0x91, 0xb9, 0xb6, 0x0e, 0x0c, 0x60, 0x79, 0xb7, 0x6b, 0x9f, 0xa6, 0xea, 0x86,
+	0x9e, 0x51, 0x7b, 0x9a, 0xaa, 0x1b, 0x91, 0x91, 0x5d, 0xe8, 0x60, 0xbd, 0x02, 0x9d, 0x9c, 0x74,
+	0x5b, 0xe8, 0x0b, 0x48, 0xe9, 0xc4, 0x25, 0xd9, 0x84, 0x9a, 0x3c, 0x72, 0x1d, 0xe4, 0x6b, 0xf2,
+	0xc8, 0xbb, 0x86, 0x8d, 0x61, 0x98, 0xc7, 0x2c, 0xa3, 0xec, 0xd7, 0x92, 0x49, 0x45, 0xf6, 0xc0,
+	0xbe, 0xe5, 0x51, 0x90, 0x26, 0x26, 0xb9, 0x81, 0xb3, 0xbc, 0xdb, 0x6d, 0xfe, 0xc8, 0xa3, 0xf1,
+	0x88, 0x36, 0x6f, 0x79, 0x34, 0x4e, 0xc8, 0x97, 0xb0, 0x9e, 0x84, 0x2a, 0xd4, 0x9f, 0x50, 0x4c,
+	0x48, 0xd7, 0xda, 0xab, 0xf7, 0x1c, 0xda, 0xd1, 0xdc, 0x89, 0xa1, 0xbc, 0x7d, 0xd8, 0xac, 0x5c,
+	0x65, 0xc1, 0x73, 0xc9, 0x88, 0x0b, 0x2d, 0x59, 0xc6, 0x31, 0x93, 0x12, 0x4f, 0xa3, 0x4d, 0x2b,
+	0xe8, 0x9d, 0xc3, 0xb3, 0x53, 0xa6, 0x86, 0xb3, 0x32, 0x7f, 0x5b, 0xed, 0x61, 0x13, 0x6a, 0x69,
+	0x82, 0xba, 0x3a, 0xad, 0xa5, 0x09, 0x79, 0x0e, 0x4d, 0x39, 0x0b, 0x85, 0xd9, 0x52, 0x9d, 0x1a,
+	0x80, 0xac, 0x0a, 0x95, 0x5c, 0x55, 0xcf, 0x00, 0xef, 0x1f, 0x0b, 0x00, 0xcd, 0xae, 0x54, 0xa8,
+	0x18, 0x79, 0x6d, 0x44, 0x0c, 0xdd, 0x36, 0x0f, 0x37, 0x7c, 0xd3, 0xa8, 0x3e, 0x46, 0xcd, 0x3b,
+	0x8c, 0x7c, 0x05, 0xed, 0x24, 0x54, 0xe5, 0xfc, 0x21, 0xeb, 0xce, 0xf2, 0x6e, 0xb7, 0x35, 0xd2,
+	0xdc, 0x78, 0x44, 0x5b, 0x18, 0x1c, 0x27, 0x3a, 0x89, 0x30, 0x49, 0x04, 0x93, 0xe6, 0x9b, 0x0e,
+	0xad, 0x20, 0xf9, 0x16, 0xba, 0x82, 0xc5, 0xfc, 0x1d, 0x13, 0x2c, 0x09, 0x50, 0x2e, 0xdd, 0xc6,
+	0xa3, 0x2e, 0xba, 0x8c, 0x6e, 0x59, 0xac, 0xe8, 0xb3, 0x7b, 0x11, 0x7a, 0x4b, 0x7d, 0xd8, 0x82,
+	0x85, 0x92, 0xe7, 0xd8, 0x73, 0x0e, 0x5d, 0x21, 0xe2, 0xc3, 0x7a, 0xc2, 0xc2, 0x24, 0xc8, 0x98,
+	0xc2, 0x1a, 0xdb, 0x9f, 0x7a, 0x75, 0xb4, 0xe0, 0xcc, 0xc4, 0xbd, 0xbf, 0x2d, 0x80, 0x73, 0x26,
+	0xa6, 0xec, 0x7f, 0x64, 0xbd, 0x0b, 0x0d, 0x25, 0x98, 0x69, 0xe2, 0x27, 0xde, 0x18, 0x20, 0xaf,
+	0x00, 0x64, 0xfa, 0x81, 0x05, 0xd1, 0x42, 0x31, 0x93, 0x71, 0x83, 0x3a, 0x9a, 0x19, 0x68, 0x82,
+	0xec, 0x03, 0x60, 0xc9, 0x03, 0x74, 0xf9, 0x8f, 0x6c, 0x1d, 0x0c, 0x5f, 0x6b, 0xab, 0x1e, 0x74,
+	0x8d, 0xf6, 0x91, 0x61, 0x13, 0x0d, 0x37, 0x91, 0xbf, 0xba, 0x77, 0x7d, 0x05, 0x80, 0x83, 0x1b,
+	0xf3, 0x32, 0x57, 0x98, 0x77, 0x83, 0xe2, 0x28, 0x0f, 0x35, 0xe1, 0x75, 0xc0, 0xb9, 0xd2, 0xa7,
+	0xaf, 0x07, 0xd7, 0x4b, 0xa0, 0xf1, 0x26, 0x0b, 0x73, 0x5d, 0xc5, 0x58, 0x1f, 0xb9, 0xe9, 0xc5,
+	0x3a, 0x5d, 0x21, 0xcd, 0xcf, 0x75, 0x51, 0xe4, 0xaa, 0x71, 0x56, 0x88, 0x7c, 0x0d, 0xe4, 0x51,
+	0x75, 0xab, 0xa9, 0x37, 0x47, 0xda, 0x7d, 0x28, 0xab, 0x19, 0xf6, 0x7d, 0x1f, 0x9a, 0xa6, 0xaa,
+	0x1d, 0x68, 0xd1, 0x9b, 0x8b, 0x8b, 0xf1, 0xc5, 0x69, 0x77, 0x8d, 0xac, 0x43, 0x7b, 0x78, 0x79,
+	0xfe, 0xe6, 0xec, 0xf8, 0xfa, 0xb8, 0x6b, 0x11, 0x00, 0xfb, 0xe4, 0x87, 0xf1, 0xd9, 0xf1, 0xa8,
+	0x5b, 0x3f, 0xfc, 0xdd, 0x02, 0xfb, 0x67, 0xac, 0x37, 0xf9, 0x06, 0x6c, 0xfd, 0x6a, 0x29, 0xc9,
+	0x96, 0x6f, 0xae, 0x2e, 0xbf, 0xba, 0xba, 0xfc, 0x63, 0x3d, 0x94, 0xdb, 0x9f, 0xf9, 0xfa, 0x42,
+	0x34, 0x72, 0x23, 0xf5, 0xd6, 0xc8, 0x77, 0x60, 0x9b, 0xf1, 0x21, 0x9f, 0x57, 0x27, 0xf7, 0xd1,
+	0x90, 0x6e, 0x6f, 0x3d, 0xa5, 0xcd, 0x94, 0x79, 0x6b, 0x64, 0x04, 0xed, 0x6a, 0x9a, 0xc8, 0x8b,
+	0x4a, 0xf5, 0x64, 0xbe, 0xb6, 0x5f, 0x7e, 0xb2, 0x19, 0xac, 0xfd, 0x4f, 0x61, 0x56, 0x32, 0x6f,
+	0xed, 0xc0, 0x1a, 0x7c, 0xff, 0xc7, 0x72, 0xc7, 0xfa, 0x73, 0xb9, 0x63, 0xfd, 0xb5, 0xdc, 0xb1,
+	0x7e, 0x39, 0x98, 0xa6, 0x6a, 0x56, 0x46, 0x7e, 0xcc, 0xe7, 0xfd, 0x22, 0x8c, 0x67, 0x8b, 0x84,
+	0x89, 0xc7, 0x2b, 0x29, 0xe2, 0xfe, 0x47, 0xff, 0x11, 0x91, 0x8d, 0xc6, 0x47, 0xff, 0x0e, 0x00,
+	0x2f, 0x9a, 0xac, 0xd8, 0x3b, 0x06, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -854,6 +909,13 @@ func (m *Input) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if len(m.GroupBy) > 0 {
+		i -= len(m.GroupBy)
+		copy(dAtA[i:], m.GroupBy)
+		i = encodeVarintWorkerService(dAtA, i, uint64(len(m.GroupBy)))
+		i--
+		dAtA[i] = 0x52
+	}
 	if m.S3 {
 		i--
 		if m.S3 {
@@ -1090,6 +1152,25 @@ func (m *ChunkState) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if m.DeadLetters != nil {
+		{
+			size, err := m.DeadLetters.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintWorkerService(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x32
+	}
+	if len(m.Reason) > 0 {
+		i -= len(m.Reason)
+		copy(dAtA[i:], m.Reason)
+		i = encodeVarintWorkerService(dAtA, i, uint64(len(m.Reason)))
+		i--
+		dAtA[i] = 0x2a
+	}
 	if m.RecoveredDatums != nil {
 		{
 			size, err := m.RecoveredDatums.MarshalToSizedBuffer(dAtA[:i])
@@ -1148,6 +1229,11 @@ func (m *MergeState) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if m.FileCount != 0 {
+		i = encodeVarintWorkerService(dAtA, i, uint64(m.FileCount))
+		i--
+		dAtA[i] = 0x30
+	}
 	if m.StatsSizeBytes != 0 {
 		i = encodeVarintWorkerService(dAtA, i, uint64(m.StatsSizeBytes))
 		i--
@@ -1241,27 +1327,34 @@ func (m *Plan) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if len(m.DeadLetterCommit) > 0 {
+		i -= len(m.DeadLetterCommit)
+		copy(dAtA[i:], m.DeadLetterCommit)
+		i = encodeVarintWorkerService(dAtA, i, uint64(len(m.DeadLetterCommit)))
+		i--
+		dAtA[i] = 0x1a
+	}
 	if m.Merges != 0 {
 		i = encodeVarintWorkerService(dAtA, i, uint64(m.Merges))
 		i--
 		dAtA[i] = 0x10
 	}
 	if len(m.Chunks) > 0 {
-		dAtA7 := make([]byte, len(m.Chunks)*10)
-		var j6 int
+		dAtA8 := make([]byte, len(m.Chunks)*10)
+		var j7 int
 		for _, num1 := range m.Chunks {
 			num := uint64(num1)
 			for num >= 1<<7 {
-				dAtA7[j6] = uint8(uint64(num)&0x7f | 0x80)
+				dAtA8[j7] = uint8(uint64(num)&0x7f | 0x80)
 				num >>= 7
-				j6++
+				j7++
 			}
-			dAtA7[j6] = uint8(num)
-			j6++
+			dAtA8[j7] = uint8(num)
+			j7++
 		}
-		i -= j6
-		copy(dAtA[i:], dAtA7[:j6])
-		i = encodeVarintWorkerService(dAtA, i, uint64(j6))
+		i -= j7
+		copy(dAtA[i:], dAtA8[:j7])
+		i = encodeVarintWorkerService(dAtA, i, uint64(j7))
 		i--
 		dAtA[i] = 0xa
 	}
@@ -1318,6 +1411,10 @@ func (m *Input) Size() (n int) {
 	if m.S3 {
 		n += 2
 	}
+	l = len(m.GroupBy)
+	if l > 0 {
+		n += 1 + l + sovWorkerService(uint64(l))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -1403,6 +1500,14 @@ func (m *ChunkState) Size() (n int) {
 		l = m.RecoveredDatums.Size()
 		n += 1 + l + sovWorkerService(uint64(l))
 	}
+	l = len(m.Reason)
+	if l > 0 {
+		n += 1 + l + sovWorkerService(uint64(l))
+	}
+	if m.DeadLetters != nil {
+		l = m.DeadLetters.Size()
+		n += 1 + l + sovWorkerService(uint64(l))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -1432,6 +1537,9 @@ func (m *MergeState) Size() (n int) {
 	if m.StatsSizeBytes != 0 {
 		n += 1 + sovWorkerService(uint64(m.StatsSizeBytes))
 	}
+	if m.FileCount != 0 {
+		n += 1 + sovWorkerService(uint64(m.FileCount))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -1466,6 +1574,10 @@ func (m *Plan) Size() (n int) {
 	if m.Merges != 0 {
 		n += 1 + sovWorkerService(uint64(m.Merges))
 	}
+	l = len(m.DeadLetterCommit)
+	if l > 0 {
+		n += 1 + l + sovWorkerService(uint64(l))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -1767,6 +1879,38 @@ func (m *Input) Unmarshal(dAtA []byte) error {
 				}
 			}
 			m.S3 = bool(v != 0)
+		case 10:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field GroupBy", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWorkerService
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthWorkerService
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthWorkerService
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.GroupBy = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipWorkerService(dAtA[iNdEx:])
@@ -2244,6 +2388,74 @@ func (m *ChunkState) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Reason", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWorkerService
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthWorkerService
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthWorkerService
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Reason = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DeadLetters", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWorkerService
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthWorkerService
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthWorkerService
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.DeadLetters == nil {
+				m.DeadLetters = &pfs.Object{}
+			}
+			if err := m.DeadLetters.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipWorkerService(dAtA[iNdEx:])
@@ -2427,6 +2639,25 @@ func (m *MergeState) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FileCount", wireType)
+			}
+			m.FileCount = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWorkerService
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.FileCount |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipWorkerService(dAtA[iNdEx:])
@@ -2630,6 +2861,38 @@ func (m *Plan) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DeadLetterCommit", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWorkerService
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthWorkerService
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthWorkerService
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.DeadLetterCommit = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipWorkerService(dAtA[iNdEx:])